@@ -0,0 +1,12 @@
+package cli
+
+// ExitError is returned by a command which finished successfully, but should
+// exit with a specific status code (e.g. because no matches were found).
+type ExitError struct {
+	Code    int
+	Message string
+}
+
+func (e *ExitError) Error() string {
+	return e.Message
+}
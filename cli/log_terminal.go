@@ -4,13 +4,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
-
-	"github.com/fd0/termstatus"
 )
 
 // LogTerminal writes data to a second writer in addition to the terminal.
 type LogTerminal struct {
-	*termstatus.Terminal
+	Terminal
 	io.Writer
 }
 
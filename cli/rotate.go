@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/RedTeamPentesting/monsoon/recorder"
+)
+
+// RotatingFile is a file which is continued in a new file when it would grow
+// larger than MaxSize. The first file is created with the original name, the
+// following ones are numbered (e.g. foo.1.log, foo.2.log for foo.log). Each
+// call to Write is written completely to one file, so a message is never
+// split between two files.
+type RotatingFile struct {
+	filename string
+	maxSize  int64
+
+	file *os.File
+	size int64
+	part int
+}
+
+// NewRotatingFile creates the file. If maxSize is zero, the file is never
+// rotated.
+func NewRotatingFile(filename string, maxSize int64) (*RotatingFile, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rf := &RotatingFile{
+		filename: filename,
+		maxSize:  maxSize,
+		file:     f,
+	}
+
+	return rf, nil
+}
+
+// rotate closes the current file and creates the next one.
+func (rf *RotatingFile) rotate() error {
+	err := rf.file.Close()
+	if err != nil {
+		return err
+	}
+
+	rf.part++
+	rf.file, err = os.Create(recorder.PartFilename(rf.filename, rf.part))
+	if err != nil {
+		return err
+	}
+	rf.size = 0
+
+	return nil
+}
+
+// Write writes p to the current file, a new file is started before if the
+// current file would grow larger than the maximum size.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		err := rf.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file.
+func (rf *RotatingFile) Close() error {
+	return rf.file.Close()
+}
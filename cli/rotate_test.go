@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRotatingFile(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-cli-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	rf, err := NewRotatingFile(filepath.Join(tempdir, "foo.log"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"first\n", "abc\n", "message too long\n", "x\n", "yyyyyyy\n"} {
+		_, err = rf.Write([]byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = rf.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"foo.log":   "first\nabc\n",
+		"foo.1.log": "message too long\n",
+		"foo.2.log": "x\nyyyyyyy\n",
+	}
+
+	got := make(map[string]string)
+	entries, err := ioutil.ReadDir(tempdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fi := range entries {
+		buf, err := ioutil.ReadFile(filepath.Join(tempdir, fi.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[fi.Name()] = string(buf)
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
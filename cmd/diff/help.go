@@ -0,0 +1,30 @@
+package diff
+
+import "strings"
+
+const helpShort = "Compare the results of two runs of 'fuzz'"
+
+var helpLong = strings.TrimSpace(`
+The 'diff' command reads the JSON data written by two runs of the 'fuzz'
+command (with --logfile or --logdir) and displays the values for which the
+response has changed: values which only appear in the new run are marked with
+'+', values which only appear in the old run with '-', and values for which the
+status code, the body size or the extracted data changed with '~'.
+
+Only the responses which were not hidden are saved in the JSON data, so a value
+which was hidden in one of the runs is displayed as added or removed. The
+header size is not compared since it changes for most responses (e.g. because
+of the Date header). When the JSON data was split with --log-max-size, each
+file contains only a part of the responses.
+`)
+
+const helpExamples = `
+Compare two runs saved in the log directory:
+
+    monsoon diff logs/monsoon_example.com_20200101_120000.json \
+      logs/monsoon_example.com_20200201_120000.json
+
+Write the changes as JSON:
+
+    monsoon diff --json old.json new.json
+`
@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/RedTeamPentesting/monsoon/recorder"
+	"github.com/spf13/cobra"
+)
+
+// Options collect options for the command.
+type Options struct {
+	JSON bool
+}
+
+var opts Options
+
+// AddCommand adds the command to c.
+func AddCommand(c *cobra.Command) {
+	c.AddCommand(cmd)
+
+	fs := cmd.Flags()
+	fs.SortFlags = false
+
+	fs.BoolVar(&opts.JSON, "json", false, "print the changes as JSON")
+}
+
+var cmd = &cobra.Command{
+	Use:                   "diff [options] OLD NEW",
+	DisableFlagsInUseLine: true,
+
+	Short:   helpShort,
+	Long:    helpLong,
+	Example: helpExamples,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("need exactly two files with JSON data to compare")
+		}
+
+		return run(opts, args[0], args[1])
+	},
+}
+
+func run(opts Options, oldFile, newFile string) error {
+	oldRun, err := recorder.Load(oldFile)
+	if err != nil {
+		return err
+	}
+
+	newRun, err := recorder.Load(newFile)
+	if err != nil {
+		return err
+	}
+
+	changes := recorder.Diff(oldRun, newRun)
+
+	if opts.JSON {
+		if changes == nil {
+			changes = []recorder.Change{}
+		}
+
+		buf, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Printf("%s\n", buf)
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+		fmt.Println(formatChange(change))
+	}
+
+	if len(changes) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%d added, %d removed, %d changed\n", counts[recorder.Added], counts[recorder.Removed], counts[recorder.Changed])
+
+	return nil
+}
+
+// formatResponse returns the status and the body size of res.
+func formatResponse(res *recorder.Response) string {
+	if res.Error != "" {
+		return "error: " + res.Error
+	}
+
+	s := fmt.Sprintf("status %d, body %d bytes", res.StatusCode, res.Body.Bytes)
+	if len(res.ExtractedData) > 0 {
+		s += fmt.Sprintf(", data: %q", res.ExtractedData)
+	}
+	return s
+}
+
+func formatChange(change recorder.Change) string {
+	switch change.Kind {
+	case recorder.Added:
+		return fmt.Sprintf("+ %-20v %v", change.Item, formatResponse(change.New))
+	case recorder.Removed:
+		return fmt.Sprintf("- %-20v %v", change.Item, formatResponse(change.Old))
+	default:
+		return fmt.Sprintf("~ %-20v %v -> %v", change.Item, formatResponse(change.Old), formatResponse(change.New))
+	}
+}
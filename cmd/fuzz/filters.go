@@ -0,0 +1,99 @@
+package fuzz
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// filterOptions returns functions which add the value for a filter option
+// to opts, indexed by the name of the option.
+func (opts *Options) filterOptions() map[string]func(string) error {
+	list := func(target *[]string) func(string) error {
+		return func(s string) error {
+			*target = append(*target, strings.Split(s, ",")...)
+			return nil
+		}
+	}
+
+	pattern := func(target *[]string) func(string) error {
+		return func(s string) error {
+			*target = append(*target, s)
+			return nil
+		}
+	}
+
+	return map[string]func(string) error{
+		"hide-status":      list(&opts.HideStatusCodes),
+		"show-status":      list(&opts.ShowStatusCodes),
+		"hide-header-size": list(&opts.HideHeaderSize),
+		"hide-body-size":   list(&opts.HideBodySize),
+		"hide-ttfb":        list(&opts.HideTTFB),
+		"show-ttfb":        list(&opts.ShowTTFB),
+		"hide-pattern":     pattern(&opts.HidePattern),
+		"show-pattern":     pattern(&opts.ShowPattern),
+		"match-expr": func(s string) error {
+			if opts.MatchExpression != "" {
+				return errors.New("match-expr is already set")
+			}
+			opts.MatchExpression = s
+			return nil
+		},
+	}
+}
+
+// readFiltersFile reads filter options from the file, one per line in the
+// form "name: value". Empty lines and lines starting with # are ignored. The
+// filters are added to the ones which are already configured.
+func (opts *Options) readFiltersFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	filters := opts.filterOptions()
+
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+
+		data := strings.SplitN(s, ":", 2)
+		if len(data) != 2 {
+			_ = f.Close()
+			return fmt.Errorf("%v:%d: invalid filter %q, must be \"name: value\"", filename, line, s)
+		}
+
+		name, value := strings.TrimSpace(data[0]), strings.TrimSpace(data[1])
+		set, ok := filters[name]
+		if !ok {
+			_ = f.Close()
+			return fmt.Errorf("%v:%d: unknown filter %q", filename, line, name)
+		}
+
+		if value == "" {
+			_ = f.Close()
+			return fmt.Errorf("%v:%d: no value for filter %q", filename, line, name)
+		}
+
+		err = set(value)
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("%v:%d: %v", filename, line, err)
+		}
+	}
+
+	if sc.Err() != nil {
+		_ = f.Close()
+		return sc.Err()
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,189 @@
+package fuzz
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// filters are the options set by readFiltersFile.
+type filters struct {
+	HideStatusCodes []string
+	ShowStatusCodes []string
+	HideHeaderSize  []string
+	HideBodySize    []string
+	HideTTFB        []string
+	ShowTTFB        []string
+	HidePattern     []string
+	ShowPattern     []string
+	MatchExpression string
+}
+
+func getFilters(opts *Options) filters {
+	return filters{
+		HideStatusCodes: opts.HideStatusCodes,
+		ShowStatusCodes: opts.ShowStatusCodes,
+		HideHeaderSize:  opts.HideHeaderSize,
+		HideBodySize:    opts.HideBodySize,
+		HideTTFB:        opts.HideTTFB,
+		ShowTTFB:        opts.ShowTTFB,
+		HidePattern:     opts.HidePattern,
+		ShowPattern:     opts.ShowPattern,
+		MatchExpression: opts.MatchExpression,
+	}
+}
+
+func TestReadFiltersFile(t *testing.T) {
+	var tests = []struct {
+		// filters set on the command line
+		flags filters
+		data  string
+		want  filters
+		err   string
+	}{
+		{
+			data: "hide-status: 404,500\n" +
+				"show-status: 200\n" +
+				"hide-header-size: 100-200\n" +
+				"hide-body-size: 0,10-\n" +
+				"hide-pattern: foo, bar: baz\n" +
+				"show-pattern: ^admin$\n" +
+				"match-expr: status == 200 && body contains \"x\"\n",
+			want: filters{
+				HideStatusCodes: []string{"404", "500"},
+				ShowStatusCodes: []string{"200"},
+				HideHeaderSize:  []string{"100-200"},
+				HideBodySize:    []string{"0", "10-"},
+				HidePattern:     []string{"foo, bar: baz"},
+				ShowPattern:     []string{"^admin$"},
+				MatchExpression: "status == 200 && body contains \"x\"",
+			},
+		},
+		{
+			// comments, empty lines and spaces are ignored
+			data: "# hide the boring stuff\n" +
+				"\n" +
+				"   \n" +
+				"  hide-status :  404  \n" +
+				"\t# indented comment\n" +
+				"hide-status: 403\n",
+			want: filters{
+				HideStatusCodes: []string{"404", "403"},
+			},
+		},
+		{
+			data: "",
+			want: filters{},
+		},
+		{
+			data: "hide-ttfb: -100ms,5s-\n" +
+				"show-ttfb: 1s-2s\n" +
+				"hide-ttfb: 3s-4s\n",
+			want: filters{
+				HideTTFB: []string{"-100ms", "5s-", "3s-4s"},
+				ShowTTFB: []string{"1s-2s"},
+			},
+		},
+		{
+			// the values from the file are added to the ones from the command line
+			flags: filters{
+				HideStatusCodes: []string{"404"},
+				HidePattern:     []string{"flag"},
+			},
+			data: "hide-status: 500\n" +
+				"hide-pattern: file\n" +
+				"match-expr: size > 10\n",
+			want: filters{
+				HideStatusCodes: []string{"404", "500"},
+				HidePattern:     []string{"flag", "file"},
+				MatchExpression: "size > 10",
+			},
+		},
+		{
+			// a match expression from the command line is not replaced
+			flags: filters{MatchExpression: "status == 200"},
+			data:  "# comment\nmatch-expr: status == 404\n",
+			err:   "filters.txt:2: match-expr is already set",
+		},
+		{
+			data: "match-expr: status == 200\nmatch-expr: status == 404\n",
+			err:  "filters.txt:2: match-expr is already set",
+		},
+		{
+			data: "hide-status: 404\n\nhide-stats: 500\n",
+			err:  `filters.txt:3: unknown filter "hide-stats"`,
+		},
+		{
+			// filters which are not in the list can't be set
+			data: "filters-file: other.txt\n",
+			err:  `filters.txt:1: unknown filter "filters-file"`,
+		},
+		{
+			data: "hide-status: 404\nhide-pattern:\n",
+			err:  `filters.txt:2: no value for filter "hide-pattern"`,
+		},
+		{
+			data: "hide-status: 404\nhide-status:   \n",
+			err:  `filters.txt:2: no value for filter "hide-status"`,
+		},
+		{
+			data: "# comment\nhide-status 404\n",
+			err:  `filters.txt:2: invalid filter "hide-status 404", must be "name: value"`,
+		},
+	}
+
+	tempdir, err := ioutil.TempDir("", "monsoon-fuzz-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			filename := filepath.Join(tempdir, "filters.txt")
+			err := ioutil.WriteFile(filename, []byte(test.data), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := &Options{
+				HideStatusCodes: test.flags.HideStatusCodes,
+				HidePattern:     test.flags.HidePattern,
+				MatchExpression: test.flags.MatchExpression,
+			}
+
+			err = opts.readFiltersFile(filename)
+			if test.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q not found", test.err)
+				}
+
+				if !strings.HasSuffix(err.Error(), test.err) {
+					t.Fatalf("wrong error, want %q, got %q", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := getFilters(opts)
+			if !cmp.Equal(test.want, got) {
+				t.Error(cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func TestReadFiltersFileMissing(t *testing.T) {
+	opts := &Options{}
+	err := opts.readFiltersFile(filepath.Join("testdata", "does-not-exist.txt"))
+	if err == nil {
+		t.Fatal("expected error not found")
+	}
+}
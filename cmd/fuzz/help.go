@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
 )
 
 const helpShort = "Execute and filter HTTP requests"
@@ -14,13 +15,63 @@ filters the returned results. The 'show' command can be used to try out and
 improve generating the HTTP requests.
 ` + request.LongHelp)
 
-const helpExamples = `
+var helpExamples = `
 Use the file filenames.txt as input, hide all 200 and 404 responses:
 
     monsoon fuzz --file filenames.txt \
       --hide-status 200,404 \
       https://example.com/FUZZ
 
+Download the list of values from a URL (the proxy configuration and --insecure
+are used for the download, the total number of requests is not known in
+advance):
+
+    monsoon fuzz --file https://wordlists.example.com/filenames.txt \
+      https://example.com/FUZZ
+
+Read values from a FIFO which another process writes to and keep waiting for
+more values (like 'tail -f', this also works for regular files) until the run
+is stopped with Ctrl+C or --max-duration:
+
+    mkfifo /tmp/values
+    monsoon fuzz --follow --file /tmp/values https://example.com/FUZZ
+
+Start with the last line of passwords.txt and send at most 1000 requests (with
+--reverse, all values are read into memory before the first request is sent,
+which needs roughly as much memory as the size of the file plus about 16
+bytes per value):
+
+    monsoon fuzz --file passwords.txt \
+      --reverse \
+      --limit 1000 \
+      --data 'user=admin&pass=FUZZ' \
+      https://example.com/login
+
+Append the values to the path of the URL (the values may contain a query
+string, see --append-path in the help for details):
+
+    monsoon fuzz --file paths.txt \
+      --append-path \
+      https://example.com/app/
+
+Save bandwidth while brute forcing directories by sending HEAD requests, the
+body of the responses which are not hidden is requested with GET only when
+it is needed:
+
+    monsoon fuzz --file dirs.txt \
+      --head-first \
+      --hide-status 404 \
+      --hide-pattern 'Not Found' \
+      https://example.com/FUZZ
+
+With --head-first, a GET request is sent after the HEAD request if the server
+does not support HEAD (status 405 or 501), or if an option needs the body
+(e.g. --hide-pattern, --extract, --extract-pipe-stream, --show-trailers,
+--hide-body-size or --hide-similar-to) and the status code is not hidden by
+--hide-status or --show-status. Otherwise the response to HEAD is displayed,
+so the body size is zero. The method used for the response is recorded in the
+JSON log (field "method").
+
 Only show redirect responses with status codes between 300 and 399:
 
     monsoon fuzz --file filenames.txt \
@@ -35,6 +86,25 @@ Skip the first 23 entries in filenames.txt and send at most 2000 requests:
       --hide-status 404 \
       https://example.com/FUZZ
 
+The option --limit counts values, so retries (--on-status) and redirects
+(--follow-redirect) cause more requests to be sent. For a metered API, send at
+most 5000 HTTP requests in total instead, including retries and redirects
+(a redirect which would exceed the limit is not followed and the response is
+not shown). The requests sent before the scan for --warmup-url and
+--hide-similar-to are not counted:
+
+    monsoon fuzz --file filenames.txt \
+      --max-requests 5000 \
+      --on-status 429=retry \
+      https://api.example.com/FUZZ
+
+Send requests for at most ten minutes, then stop and print the summary:
+
+    monsoon fuzz --file filenames.txt \
+      --max-duration 10m \
+      --hide-status 404 \
+      https://example.com/FUZZ
+
 Hide responses with body size between 100 and 200 bytes (inclusive), exactly
 533 bytes or more than 10000 bytes:
 
@@ -51,6 +121,75 @@ HTTP POST request:
       --hide-status 403 \
       https://example.com/login
 
+Try the user names from users.txt with the password in the same line of
+passwords.txt for HTTP basic authentication (e.g. "admin:secret"):
+
+    monsoon fuzz --zip users.txt,passwords.txt --zip-sep : \
+      --user FUZZ \
+      --hide-status 401 \
+      https://example.com/admin
+
+Interleave a short list of likely file names with a large generic list: each
+value is taken from top.txt with a probability of 3/4 and from all.txt with
+1/4 (as long as both have values left), so the likely names are tried early
+while the long tail is still covered. The random seed is printed and can be
+set with --file-weighted-seed to repeat the order:
+
+    monsoon fuzz --file-weighted top.txt:3 \
+      --file-weighted all.txt:1 \
+      --hide-status 404 \
+      https://example.com/FUZZ
+
+Use the values from the column "username" of users.csv (the first line of the
+file contains the names of the columns):
+
+    monsoon fuzz --csv users.csv --csv-header --csv-column username \
+      --hide-status 404 \
+      https://example.com/profile/FUZZ
+
+Split each line of login.txt at the tab into a value and a password, which is
+inserted for the placeholder PASS (the names of the fields are used as
+placeholders, the field "value" replaces FUZZ):
+
+    monsoon fuzz --file login.txt --fields value,PASS \
+      --data 'username=FUZZ&password=PASS' \
+      --hide-status 403 \
+      https://example.com/login
+
+A field named "timeout" is not inserted but used as the timeout for the
+request for that line, either as a duration (e.g. "1.5s") or as a number of
+seconds. Requests which take longer are reported as timeouts (status 1).
+Missing fields are empty, the last field contains the rest of the line. Use
+--fields-sep to split at another separator than a tab. The names must not
+contain each other or the placeholder. The value displayed in the output is
+the whole line. With --expand-templates, the fields are also available as
+'{{ .Fields.name }}'.
+
+Try each parameter name from params.txt with all prefixes from prefixes.txt
+(e.g. "admin_" and "debug_") and all suffixes from suffixes.txt (e.g. "_id"),
+so that prefix + name + suffix is used for every combination:
+
+    monsoon fuzz --file params.txt \
+      --prefix-file prefixes.txt --suffix-file suffixes.txt \
+      --hide-status 404 \
+      'https://example.com/api?FUZZ=1'
+
+Send 5000 random mutations of the value "id=1234" (bit flips, insertions,
+deletions and known-bad strings) in the request body, use the same values again
+for the next run by passing the same seed:
+
+    monsoon fuzz --mutate 'id=1234' --mutate-count 5000 --mutate-seed 42 \
+      --method POST --data FUZZ \
+      --hide-status 200 \
+      https://example.com/api
+
+Slow down automatically when more than 20% of the last 50 responses are server
+errors (5xx), and speed up again when the server recovers:
+
+    monsoon fuzz --file filenames.txt \
+      --backoff-on-5xx --backoff-threshold 0.2 \
+      https://example.com/FUZZ
+
 Run requests with a range from 100 to 500 with the request value inserted into
 the cookie "sessionid":
 
@@ -64,6 +203,32 @@ You can also request multiple ranges:
       --header 'Cookie: sessionid=FUZZ' \
       --hide-status 500 https://example.com/login/session
 
+Request every tenth value of the ranges, so 100, 110, ..., 500, 999, 1009, ...:
+
+    monsoon fuzz --range 100-500,999-2000 --range-step 10 \
+      --header 'Cookie: sessionid=FUZZ' \
+      --hide-status 500 https://example.com/login/session
+
+Request the powers of two between 1 and 65536 (1, 2, 4, ..., 65536) as the page
+size, e.g. to find the limit for pagination:
+
+    monsoon fuzz --range 1-65536 --range-mode geometric --range-factor 2 \
+      'https://example.com/api/items?limit=FUZZ'
+
+Probe a large space of IDs by requesting 1000 values chosen randomly from the
+range, each at most once. The random seed is printed (and saved in the JSON
+log), pass it to --range-seed to request the same values again:
+
+    monsoon fuzz --range 1-1000000000 --range-random 1000 --range-unique \
+      --hide-status 404 \
+      https://example.com/invoice/FUZZ
+
+The value can also be inserted as the port or the scheme of the URL, e.g. to
+find web servers on the ports 8000 to 8100 (hiding failed connections):
+
+    monsoon fuzz --range 8000-8100 --hide-status 2 \
+      http://example.com:FUZZ/
+
 Request 500 session IDs and extract the cookie values (matching case insensitive):
 
     monsoon fuzz --range 1-500 \
@@ -89,6 +254,13 @@ Only show responses which contain the pattern "The secret is: " in the response:
       --show-pattern 'The secret is: ' \
       https://example.com/FUZZ
 
+Only show responses with status 200 which contain the string "admin" in the
+body, or which were sent by an nginx server:
+
+    monsoon fuzz --file filenames.txt \
+      --match-expr 'status == 200 and (body contains "admin" or header["Server"] matches "(?i)nginx")' \
+      https://example.com/FUZZ
+
 Load a request from the file 'template.txt', setting the 'User-Agent' header
 and replacing the string FUZZ from the file:
 
@@ -97,6 +269,33 @@ and replacing the string FUZZ from the file:
       --header 'user-agent: foobar' \
       https://example.com
 
+Use the method, URL, headers and body of the first request in the file
+'login.http' (in the format of the VS Code REST Client), which contains the
+string FUZZ, no URL argument is needed:
+
+    monsoon fuzz --file passwords.txt \
+      --request-file login.http
+
+Paste a request copied from Burp (after replacing the password with FUZZ) and
+finish with Ctrl-D, the URL is taken from the Host header:
+
+    monsoon fuzz --file passwords.txt --raw-request-stdin
+
+Send the request in 'smuggle.txt' exactly as it is (including the HTTP version
+in the request line and all line endings) to example.com via TLS, inserting the
+values from the range into the file:
+
+    monsoon fuzz --range 1-100 \
+      --raw-request smuggle.txt \
+      https://example.com
+
+Replay captured requests: each file in the directory 'requests' is sent
+exactly as it is (like --raw-request, nothing is replaced) to example.com, the
+file name is used as the value. Hidden files and subdirectories are ignored:
+
+    monsoon fuzz --raw-request-dir requests \
+      https://example.com
+
 Try different passwords for the user admin with HTTP Basic authentication:
 
     monsoon fuzz --file passwords.txt \
@@ -104,6 +303,177 @@ Try different passwords for the user admin with HTTP Basic authentication:
 	  --user admin:FUZZ \
       http://example.com
 
+Visit the start page first to obtain a session cookie, which is then sent with
+all requests (like a browser, cookies set in later responses are ignored):
+
+    monsoon fuzz --file filenames.txt \
+      --warmup-url / \
+      --hide-status 404 \
+      https://example.com/app/FUZZ
+
+Write all responses which are not hidden (together with the requests) to
+results.xml, which can be imported into Burp Suite:
+
+    monsoon fuzz --file filenames.txt \
+      --hide-status 404 \
+      --output-burp results.xml \
+      https://example.com/FUZZ
+
+Publish all responses which are not hidden as JSON messages (in the same format
+as in the JSON log file) to the subject monsoon.results on a NATS server. If
+the server cannot keep up, at most --publish-queue messages are queued before
+the scan is slowed down, with --publish-drop messages are dropped instead. TLS
+is used if the server requires it, with the scheme tls:// it is always used:
+
+    monsoon fuzz --file filenames.txt \
+      --hide-status 404 \
+      --publish nats://nats.example.com:4222/monsoon.results \
+      https://example.com/FUZZ
+
+Save the header and body of all responses which are not hidden to files in
+the directory responses/, named after the value (e.g. responses/admin.http).
+Characters other than letters, digits, '.', '-' and '_' are replaced in the
+file names, the body is saved up to --max-body-size:
+
+    monsoon fuzz --file filenames.txt \
+      --hide-status 404 \
+      --save-responses responses/ \
+      https://example.com/FUZZ
+
+Write a shell script with a curl command for each response which is not
+hidden, which sends the request again with the value inserted (like the
+'repro' command does for the JSON log). The values are quoted for a POSIX
+shell, options which transform the value (like --data-urlencode or
+--expand-templates) or which are not part of the request (like --insecure)
+are not reproduced, and raw requests and --fields are not supported:
+
+    monsoon fuzz --file filenames.txt \
+      --hide-status 404 \
+      --curl-file repro.sh \
+      https://example.com/FUZZ
+
+Send a notification for each response which is not hidden, at most two
+commands are run at the same time:
+
+    monsoon fuzz --file filenames.txt \
+      --hide-status 404 \
+      --on-match 'sh -c "notify-send \"found $MONSOON_VALUE: $MONSOON_STATUS\""' \
+      --on-match-concurrency 2 \
+      https://example.com/FUZZ
+
+Expose Prometheus metrics (completed requests, responses by status code,
+errors, rate and queue depth) on port 9090 while the scan is running:
+
+    monsoon fuzz --file filenames.txt \
+      --metrics-addr :9090 \
+      https://example.com/FUZZ
+
+Write the duration of every response (including hidden and failed ones) in
+seconds to durations.txt, one per line, e.g. to plot a histogram of the
+latency with another tool:
+
+    monsoon fuzz --file filenames.txt \
+      --timing-dump durations.txt \
+      https://example.com/FUZZ
+
+For unattended runs, write a line with the progress (requests done, rate,
+number of shown responses and errors) to the logfile every minute and for
+every 10% of the requests:
+
+    monsoon fuzz --file filenames.txt \
+      --logfile scan --checkpoint-interval 60s --checkpoint-percent 10 \
+      https://example.com/FUZZ
+
+
+Several Targets
+###############
+
+With --targets-file, the URLs are read from a file (one per line, empty lines
+and lines starting with # are ignored) instead of the URL argument, and the
+request for each value is sent to every target. Each target has its own
+--threads runners, so the targets are scanned at the same time, and a value is
+only passed on when all targets have accepted the previous one. The other
+options (e.g. the headers and the filters) apply to all targets, and each
+response is shown and recorded with its target.
+
+The rate set with --requests-per-second is shared by the requests to all
+targets. With --requests-per-second-per-host, the requests to each host
+(including the port) are limited in addition, a request is sent when both
+limits allow it:
+
+    monsoon fuzz --file filenames.txt \
+      --targets-file targets.txt \
+      --requests-per-second 50 --requests-per-second-per-host 5 \
+      --hide-status 404
+
+The JSON log contains the list of targets, the template is recorded for the
+first target (so the 'repro' command only works for it). The options
+--warmup-url, --hide-similar-to and --curl-file cannot be used with several
+targets.
+
+
+Sharding
+########
+
+With --shard i/n, the values are split into n contiguous parts of (nearly) the
+same size and only the part i (starting at 1) is used, e.g. --shard 2/4 runs
+the second quarter of the values. This can be used to split a large scan across
+several machines. The parts can only be computed when the number of values is
+known: the input file is read twice (once to count the lines). When the values
+are read from stdin, they are kept in memory until all have been read.
+
+With --shard-mod i/n, every n-th value starting with value i is used instead,
+e.g. --shard-mod 2/4 runs the values 2, 6, 10, and so on. Each shard receives
+an interleaved part of the values, which is useful when the input is sorted by
+likelihood. The values do not need to be counted beforehand.
+
+The shard is selected first, --skip and --limit apply to the values of the
+shard afterwards. A run for a shard can therefore be resumed with --skip.
+
+
+Value Processing Order
+######################
+
+The values from the producer (e.g. --file or --range) are processed in the
+following order before a request is sent:
+
+ * Duplicates are counted (--warn-duplicates), but not removed
+ * Only values matching --value-match and not matching --value-reject are used
+ * The order is reversed (--reverse)
+ * The part for the shard is selected (--shard, --shard-mod)
+ * Each value is combined with the prefixes and suffixes (--prefix-file, --suffix-file)
+ * The first values are skipped (--skip)
+ * At most n values are passed on (--limit)
+ * The values are delayed to limit the throughput (--requests-per-second)
+
+So --skip and --limit count the values which are actually used for requests,
+including duplicates, and skipped values are not delayed by the rate limit.
+
+The rate limit works like a token bucket: with --burst n, up to n requests are
+sent without delay when the rate has been lower before (e.g. at the start),
+but the average over a longer time is still at most --requests-per-second. As
+many rate limits of servers work like this, a larger burst may increase the
+throughput without triggering them. The default of 1 sends the requests at
+even intervals.
+
+The options --value-match and --value-reject filter the input itself (the
+whole line, also for --fields) before any request is sent, in contrast to the
+filters for the responses. For example, only send requests for the values
+starting with "a" which do not end in ".bak":
+
+    monsoon fuzz --file filenames.txt \
+      --value-match '^a' --value-reject '\.bak$' \
+      https://example.com/FUZZ
+
+For a regular file passed to --file, the matching lines are counted beforehand,
+so the progress is shown. For other inputs the number of values is unknown.
+
+For --warn-duplicates, all distinct values are kept in memory. For very large
+inputs, --dedup-max-entries n limits this to the n most recently seen values
+(the least recently seen one is forgotten when a new value is added). Memory
+use is then bounded, but the count is a lower bound: a value is only counted
+as a duplicate if it appears again before n other distinct values have been
+seen, there are no false positives.
 
 Filter Evaluation Order
 #######################
@@ -115,6 +485,423 @@ The filters are evaluated in the following order. A response is displayed if:
  * The header and body size are not hidden (--header-size, --body-size)
  * The header and body does not contain a hide pattern (--hide-pattern)
  * The header or body contain all show pattern (--show-pattern, if specified)
+ * The expression matches (--match-expr, if specified)
+ * The value is contained in the response (--only-reflected, if specified)
+ * The body is not similar to a baseline (--hide-similar-to)
+ * No response with the same headers has been shown before (--hide-duplicate-headers)
+
+
+Filters File
+############
+
+Filters can also be read from a file with --filters-file, so that a set of
+filters can be reused. Each line contains the name of an option (without the
+leading dashes) and the value, separated by a colon. Empty lines and lines
+starting with # are ignored. The filters are added to the ones specified on the
+command line. The options hide-status, show-status, hide-header-size,
+hide-body-size, hide-ttfb, show-ttfb, hide-pattern, show-pattern and
+match-expr are supported:
+
+    # hide not found pages, empty responses and cached pages
+    hide-status: 404,400-403
+    hide-body-size: 0-10
+    hide-ttfb: -20ms
+    hide-pattern: (?i)page not found
+    match-expr: header["Server"] matches "nginx"
+
+
+Failed Requests
+###############
+
+Requests which failed without an HTTP response are assigned a synthetic status
+code, so they can be filtered with --hide-status and --show-status (and the
+field status in --match-expr) like any other response:
+
+ * 0: any other error
+ * 1: timeout (e.g. while waiting for the response)
+ * 2: connection refused
+ * 3: TLS error (e.g. handshake failure, invalid certificate)
+ * 4: DNS error
+ * 5: blocked host (see --allowed-hosts)
+ * 6: connect timeout, establishing the connection or the TLS handshake took
+   longer than --connect-timeout or --tls-handshake-timeout
+
+For example, --hide-status 0-9 hides all failed requests, --hide-status 1 only
+hides timeouts. Note that --show-status hides failed requests unless their
+code is included.
+
+
+Status Actions
+##############
+
+With --on-status, an action can be configured for status codes (or ranges of
+status codes like for --hide-status, including the synthetic codes for failed
+requests). The first matching rule is used:
+
+ * continue: process the response as usual (the default for all status codes)
+ * backoff: count the response as an error for slowing down, in the same way
+   as --backoff-on-5xx does for server errors (see --backoff-threshold)
+ * stop: stop the run gracefully after the response, like --max-duration
+ * retry: send the request again, at most --on-status-retries times (default
+   2), only the last response is displayed and filtered
+
+Without --on-status, all status codes use continue: nothing is retried, the
+run is not stopped, and only --backoff-on-5xx slows down. Responses for
+failed requests (timeouts, connection errors) are only counted for backoff
+if their synthetic status code has a backoff rule. The defaults for the
+related options are --on-status-retries 2 and --backoff-threshold 0.5 (slow
+down when more than half of the last 50 responses are counted).
+
+For a target behind a WAF, a reasonable policy is to slow down when too many
+requests are rejected with 429 (Too Many Requests), to stop as soon as the
+scanner is blocked (often 403), and to retry timeouts:
+
+    monsoon fuzz --file filenames.txt \
+      --on-status 429=backoff,403=stop,1=retry \
+      --hide-status 404 \
+      https://example.com/FUZZ
+
+The retry action sends the request again immediately. For transient server
+states, --retry-status sends the request again after a delay for responses
+with one of the listed status codes, at most --retry-status-max times (default
+3). If the response contains a Retry-After header, the delay is taken from it
+(at most one minute), otherwise it starts at --retry-status-delay (default 1s)
+and is doubled for each retry. Only the last response is displayed and
+filtered, the number of retries is recorded in the logfile:
+
+    monsoon fuzz --file filenames.txt \
+      --retry-status 429,503 \
+      --retry-status-max 3 \
+      --hide-status 404 \
+      https://example.com/FUZZ
+
+In order to not send the whole list of values to a target which went down,
+--max-errors aborts the run after the given number of failed requests, and
+--max-consecutive-errors after the given number of failed requests in a row.
+Only network errors (the synthetic status codes 0-4 and 6) are counted, with
+--max-errors-5xx responses with a status code of 500 or above are counted as
+well. When the run is aborted, monsoon exits with status 3 (see "Exit Codes"):
+
+    monsoon fuzz --file filenames.txt \
+      --max-consecutive-errors 50 \
+      https://example.com/FUZZ
+
+
+Commands For Matches
+####################
+
+With --on-match, a command is run for each response which is not hidden (after
+data has been extracted). The command is not run by a shell, use e.g. 'sh -c'
+for that. The output is discarded, it is only printed if the command fails. The
+following environment variables are set:
+
+ * MONSOON_VALUE: the value inserted into the request
+ * MONSOON_URL: the URL of the request
+ * MONSOON_STATUS: the status code (or the synthetic status code for errors)
+ * MONSOON_ERROR: the error, if the request failed
+ * MONSOON_HEADER_SIZE: the size of the response header
+ * MONSOON_BODY_SIZE, MONSOON_BODY_WORDS, MONSOON_BODY_LINES: the size of the body
+ * MONSOON_EXTRACT: the extracted data, separated by newlines
+
+At most --on-match-concurrency commands run at the same time, afterwards the
+next response waits until a command has finished. Running commands are killed
+when the run is stopped (e.g. with --max-duration).
+
+The commands for --extract-pipe receive the response body on stdin and the
+same environment variables (with the data extracted from the header so far)
+for each response which is not hidden. In addition, the response headers are
+available as MONSOON_HTTP_NAME, with the name in upper case and characters
+other than letters and digits replaced by '_' (e.g. MONSOON_HTTP_CONTENT_TYPE),
+several values are separated by newlines. Everything the command writes to
+stdout is added to the extracted data.
+
+The body is kept in memory (up to --max-body-size) to run the commands for
+--extract-pipe after the filters. With --extract-pipe-stream, the commands are
+started for every response (including hidden ones) as soon as the header has
+been received instead, and the body is copied to stdin while it is read, so it
+does not need to be kept in memory. This allows extracting data from very large
+responses with a high --max-body-size. Only MONSOON_VALUE, MONSOON_URL,
+MONSOON_STATUS and the MONSOON_HTTP_ variables are set in this case. A command
+may exit before reading the whole body (e.g. "head -n 10").
+
+
+Header Size
+###########
+
+The header size (used for --hide-header-size) includes the status line, all
+header lines and the empty line which terminates the header. By default, the
+header is reconstructed from the parsed response: every header is formatted as
+"Name: value" with the canonical name and CRLF line terminators, so the size
+may differ from what the server sent. With --wire-header-size, the header is
+recorded exactly as it was received on the connection instead. This only works
+for HTTP/1.x, so HTTP/2 is disabled, and it is not available for HTTPS
+requests sent through an HTTP proxy.
+
+
+Compressed Responses
+####################
+
+Response bodies sent with the Content-Encoding gzip, deflate or br (Brotli)
+are decompressed before the sizes are computed and the filters, patterns and
+extraction are applied, so they work on the decoded data. This also applies to
+--raw-request and when the Accept-Encoding header is set explicitly. At most
+--max-body-size bytes are decompressed. The size of the body as received is
+recorded in the logfile as compressed_body_size. Other encodings (e.g. zstd)
+and invalid data are left as they are. With --no-decompress, the bodies are
+used exactly as they were received.
+
+By default, the header 'Accept-Encoding: gzip' is sent. Use --accept-encoding
+to request other encodings, e.g. '--accept-encoding "gzip, deflate, br"' like
+a browser, or '--accept-encoding identity' for uncompressed responses.
+
+
+Response Bodies
+###############
+
+The response bodies (up to --max-body-size) are only kept in memory when they
+are needed, which is the case for --extract (unless --extract-target headers
+is used), --extract-pipe (unless --extract-pipe-stream is used),
+--hide-pattern, --show-pattern, --match-expr, --assert, --save-responses,
+--output-burp, --show-reflected, --only-reflected, --normalize-reflection,
+--show-waf, --follow-meta-refresh and --tui. Otherwise, --no-body is implied:
+the bodies are still received to compute the sizes (and so that the connection
+can be reused), but they are not buffered. Compressed bodies are decompressed
+in memory and discarded afterwards. Use --no-body=false to keep the bodies
+anyway, or --no-body to never keep them.
+
+
+Reflected Values
+################
+
+With --show-reflected, the number of times the value is contained in the
+response header and body (up to --max-body-size) is printed in an additional
+column. Apart from the value itself, the URL-encoded (query and path) and
+HTML-encoded forms of the value are searched for, so that values reflected in
+links or HTML pages are found, e.g. as a first step for discovering cross-site
+scripting. With --only-reflected, only responses which contain the value are
+displayed. Short values (like numbers) may be found in a response by
+coincidence, so this works best with unique values:
+
+    monsoon fuzz --show-reflected --only-reflected --file xss.txt \
+      'https://example.com/search?q=FUZZ'
+
+If the value is reflected, the sizes of the responses differ with the length of
+the value, so filters like --hide-body-size don't work. With
+--normalize-reflection, all occurrences of the value (in the same forms as
+above) are replaced by the placeholder (e.g. FUZZ) before the sizes of the
+header and body are computed, so that they only depend on the rest of the
+response. The displayed sizes are the normalized ones, the body itself (e.g.
+for --extract or --save-responses) is not modified:
+
+    monsoon fuzz --normalize-reflection --hide-body-size 1234 --file xss.txt \
+      'https://example.com/search?q=FUZZ'
+
+
+Similar Responses
+#################
+
+Many applications return the same page (e.g. a custom error page with status
+200) for all values which don't exist, but the size differs slightly, e.g.
+because the value or a timestamp is included. With --hide-similar-to, a
+request for the value is sent before the scan (with all other options as
+usual) and a fingerprint (simhash) of the body is computed. Responses with a
+similar body are hidden, which is the case if the fingerprints differ in at
+most --similar-distance of the 64 bits (default: 3). Use a value which surely
+doesn't exist, the option can be specified several times for different kinds
+of error pages:
+
+    monsoon fuzz --hide-similar-to doesnotexist1234 --file files.txt \
+      https://example.com/FUZZ
+
+The fingerprint is computed from the words (letters and digits) of the body
+(after decompression, up to --max-body-size), HTML tags and attributes are
+words as well. Small changes only change a few bits, so a larger distance also
+hides pages with more differences, a distance of 0 only hides bodies with the
+same words. The shorter the page, the more bits change for the same difference,
+so short error pages may need a larger distance (e.g. 6). Empty responses are
+never hidden by this filter, use --hide-body-size for them. The fingerprint is
+computed while the body is received, so it does not need to be kept in memory.
+It is recorded in the JSON log (as "simhash") for all responses.
+
+With --cluster-report, the displayed responses are grouped by status code and
+fingerprint when the run is complete: a response belongs to a cluster if the
+fingerprint differs from the one of the first response in the cluster in at
+most --similar-distance bits. The clusters are printed with the number of
+responses, the range of body sizes and the first value as an example (at most
+20, the largest first), so a large number of results can be reduced to a few
+kinds of responses. The example can then be inspected further, e.g. with
+--hide-similar-to to hide the whole cluster in the next run:
+
+    monsoon fuzz --cluster-report --file files.txt https://example.com/FUZZ
+
+The headers often tell apart the backend which sent a response (e.g. different
+servers behind a load balancer, or versions of an API) even when the bodies
+differ. With --hide-duplicate-headers, only the first response for each
+fingerprint of the header is shown, the body is ignored. By default the
+fingerprint consists of the sorted header names, with --header-fingerprint
+values the values are included as well, except for headers which usually
+change for each response (like Date, Set-Cookie, Content-Length and ETag). This
+filter is evaluated last, so only responses which are not hidden otherwise
+count. The fingerprint is recorded in the JSON log (as "header_fingerprint"):
+
+    monsoon fuzz --hide-duplicate-headers --header-fingerprint values \
+      --range 1-500 https://example.com/api/vFUZZ/status
+
+
+Web Application Firewalls
+#########################
+
+With --show-waf, responses which look like the block pages of common web
+application firewalls and CDNs (Cloudflare, Akamai, AWS WAF, Imperva, Sucuri,
+F5 BIG-IP ASM, ModSecurity, Barracuda and FortiWeb) are marked with the name
+of the firewall at the end of the line (like "waf: Cloudflare"), which is also
+recorded in the logfile. This is a heuristic: characteristic texts in the body
+are recognized for all status codes, headers set by the firewall or CDN (e.g.
+Cf-Ray) only for the status codes 403, 406, 429 and 503. After 10 such
+responses, a warning suggesting to reduce the request rate is printed once.
+Block pages are not hidden, the filters work as before.
+
+
+Time to First Byte
+##################
+
+The time to first byte (TTFB) is the time between sending the request (after
+the connection has been established and the TLS handshake is done) and
+receiving the first byte of the response. Unlike the total duration, it does
+not depend on the time needed to connect or to transfer the response, so it is
+more precise for detecting delays on the server, e.g. for blind timing attacks.
+For redirects, the time of the last request is used. With --print-ttfb, the
+time is printed in an additional column. With --hide-ttfb and --show-ttfb,
+responses are hidden if the time is within one of the ranges, or shown only if
+it is within at least one of them. Ranges are written as from-to, from- or -to
+with units, failed requests are hidden by --show-ttfb. Show only responses for
+which the server took at least two seconds:
+
+    monsoon fuzz --print-ttfb --show-ttfb 2s- --file sqli.txt \
+      'https://example.com/item?id=FUZZ'
+
+The time to first byte is also available as ttfb in match expressions and is
+recorded in the logfile.
+
+
+Trailers
+########
+
+Trailers are header fields sent after the body of a response, either with
+chunked encoding (HTTP/1.1) or over HTTP/2, e.g. the status of gRPC calls in
+grpc-status and grpc-message. They are only received when the body has been
+read completely, so they are missing for bodies larger than --max-body-size.
+With --show-trailers, the trailers are printed at the end of the line. They are
+available as trailer["Name"] in match expressions and are recorded in the
+logfile. Show only failed gRPC calls:
+
+    monsoon fuzz --show-trailers --file methods.txt \
+      --header 'Content-Type: application/grpc' \
+      --match-expr 'trailer["Grpc-Status"] != "0"' \
+      https://example.com/service.Name/FUZZ
+
+
+Interactive Interface
+#####################
+
+With --tui, the responses are shown in a full-screen interface instead of
+being printed. The first line shows the progress, the second one the filters
+which have hidden responses so far and how many. All responses are kept in
+memory, but the bodies only for the responses which are not hidden.
+
+    up/down, j/k     select a response (the newest one is selected until
+                     the selection is moved, End starts following again)
+    PgUp/PgDn        move the selection by half a screen, or scroll the
+                     detail pane when it is open (J/K scroll by one line)
+    Home/End, g/G    select the first or the last response
+    enter            show the header and body of the selected response
+    esc              close the detail pane
+    1-9              show or hide the responses hidden by the filter
+    a                show or hide all hidden responses
+    q, ctrl-c        quit, stopping the run if it is not done yet
+
+A response is shown again by a filter toggle when the filter was the first one
+which hid it. Messages are printed again after the interface is closed, and
+--logfile and --exit-on-match work as usual. The options --collapse and
+--explain cannot be used with --tui.
+
+    monsoon fuzz --tui --file filenames.txt \
+      --hide-status 404 --hide-pattern 'Access denied' \
+      https://example.com/FUZZ
+
+
+Match Expressions
+#################
+` + response.ExpressionHelp + `
+
+Redirects
+#########
+
+Redirects are only followed with --follow-redirect. By default, redirects with
+the status codes 301, 302 and 303 are followed with a GET request without a
+body, and the Authorization header is removed if the redirect points to a
+different host. The option --redirect-keep-method sends the original method
+and body instead. With --redirect-keep-auth, the Authorization header (which
+includes credentials set with --user) is sent to every host the server
+redirects to. This means that the credentials are disclosed to arbitrary third
+parties if the server (or an attacker controlling the redirect target) chooses
+to redirect there, so only use it with targets you trust.
+
+Some applications redirect with a meta refresh tag (e.g. '<meta
+http-equiv="refresh" content="0; url=/login">'), a Refresh header or JavaScript
+(e.g. "window.location = '/login'") in a page with status 200 instead, so a
+login wall looks like a regular page. With --follow-meta-refresh, such a
+target is found heuristically in successful (2xx) responses and requested with
+GET like a redirect (without Authorization and Cookie headers for other hosts),
+at most as many times as set with --follow-redirect. The response for the last
+target is displayed. All targets found are recorded in the JSON log (field
+"meta_refresh"), including one which was not followed because of the limit.
+
+With --allowed-hosts, requests are only sent to the listed hosts (an entry like
+'*.example.com' allows all subdomains of example.com). This also applies to
+redirects and to values inserted into the host name of the URL. Requests to
+other hosts are not sent, they fail with the synthetic status code 5 (see
+"Failed Requests") and a warning is printed for the first request blocked for
+each host. The port is not checked.
+
+
+Exit Codes
+##########
+
+When the run completes, monsoon exits with status 0 regardless of the
+responses, errors (e.g. invalid options or an unreachable log directory) exit
+with status 1. For scheduled scans, the exit code can depend on the responses
+shown (i.e. not hidden by a filter, failed requests count as well unless they
+are hidden, e.g. with --hide-status 0-6):
+
+ * with --exit-on-match, monsoon exits with status 0 only if at least one
+   response was shown, and with status 2 otherwise
+ * with --exit-on-no-match, monsoon exits with status 0 only if no response
+   was shown, and with status 2 otherwise
+
+When the run is aborted by --max-errors or --max-consecutive-errors, monsoon
+exits with status 3, so that an incomplete scan can be told apart from one
+which completed.
+
+For example, fail a CI job when a backup file is found:
+
+    monsoon fuzz --file backups.txt \
+      --hide-status 404 \
+      --exit-on-no-match \
+      https://example.com/FUZZ
+
+With --assert, an expression (see "Match Expressions") is evaluated for every
+response, regardless of the filters. With --assert-mode all (the default) all
+responses must match, with --assert-mode any at least one. At the end, the
+number of responses which passed and failed is printed, and monsoon exits with
+status 2 if the assertion failed (or no response was received). Failed
+requests have a synthetic status code, so they don't match "status == 200".
+For example, check that all endpoints of a service are available:
+
+    monsoon fuzz --file endpoints.txt \
+      --assert 'status == 200 and body contains "OK"' \
+      --hide-status 200 \
+      https://example.com/FUZZ
 
 
 Proxy Configuration
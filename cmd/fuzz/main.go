@@ -1,19 +1,25 @@
 package fuzz
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/RedTeamPentesting/monsoon/cli"
+	"github.com/RedTeamPentesting/monsoon/metrics"
 	"github.com/RedTeamPentesting/monsoon/producer"
 	"github.com/RedTeamPentesting/monsoon/recorder"
 	"github.com/RedTeamPentesting/monsoon/reporter"
@@ -21,6 +27,7 @@ import (
 	"github.com/RedTeamPentesting/monsoon/response"
 	"github.com/RedTeamPentesting/monsoon/shell"
 	"github.com/fd0/termstatus"
+	"github.com/gdamore/tcell"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
 )
@@ -29,38 +36,186 @@ import (
 type Options struct {
 	Range       []string
 	RangeFormat string
+	RangeStep   int
+	RangeMode   string
+	RangeFactor int
+	RangeRandom int
+	RangeUnique bool
+	RangeSeed   int64
 	Filename    string
+	Follow      bool
+	Reverse     bool
+	Encoding    string
+	Delimiter   string
+	delimiter   byte
+	Zip         []string
+	ZipSep      string
+
+	FileWeighted     []string
+	FileWeightedSeed int64
+	weightedFiles    []string
+	weights          []int
+
+	RawRequestDir string
+
+	TargetsFile string
+	targets     []string
+
+	Fields   string
+	FieldSep string
+
+	CSV         string
+	CSVColumn   string
+	CSVHeader   bool
+	Mutate      string
+	MutateCount int
+	MutateSeed  int64
 	Logfile     string
 	Logdir      string
+	LogMaxSize  string
+	logMaxSize  int64
+	NoBanner    bool
 	Threads     int
 
+	ExitOnMatch   bool
+	ExitOnNoMatch bool
+
+	Assert     string
+	AssertMode string
+	assertion  *response.Assertion
+
+	CheckpointInterval time.Duration
+	CheckpointPercent  int
+
 	RequestsPerSecond float64
+	Burst             int
+	MaxDuration       time.Duration
+	BackoffOn5xx      bool
+	BackoffThreshold  float64
+	OnStatus          []string
+	OnStatusRetries   int
+	statusPolicy      response.StatusPolicy
+
+	RequestsPerSecondPerHost float64
+
+	RetryStatus      []int
+	RetryStatusMax   int
+	RetryStatusDelay time.Duration
+
+	MaxErrors            int
+	MaxConsecutiveErrors int
+	MaxErrors5xx         bool
 
 	BufferSize int
 	Skip       int
 	Limit      int
 
-	Request        *request.Request // the template for the HTTP request
-	FollowRedirect int
+	MaxRequests int
+	budget      *response.Budget
+
+	Shard       string
+	ShardMod    string
+	PrefixFile  string
+	SuffixFile  string
+	prefixes    []string
+	suffixes    []string
+	shard       int
+	shards      int
+	shardValues int
+
+	WarnDuplicates  bool
+	DedupMaxEntries int
+
+	ValueMatch  string
+	ValueReject string
+	valueMatch  *regexp.Regexp
+	valueReject *regexp.Regexp
+	matchValues int
+
+	Request            *request.Request // the template for the HTTP request
+	FollowRedirect     int
+	RedirectKeepAuth   bool
+	RedirectKeepMethod bool
+	FollowMetaRefresh  bool
+	HeadFirst          bool
+	AllowedHosts       []string
+	WarmupURL          string
 
 	HideStatusCodes []string
 	ShowStatusCodes []string
 	HideHeaderSize  []string
 	HideBodySize    []string
+	HideTTFB        []string
+	ShowTTFB        []string
 	HidePattern     []string
 	hidePattern     []*regexp.Regexp
 	ShowPattern     []string
 	showPattern     []*regexp.Regexp
-
-	Extract     []string
-	extract     []*regexp.Regexp
-	ExtractPipe []string
-	extractPipe [][]string
-	MaxBodySize int
+	MatchExpression string
+	FiltersFile     string
+	Explain         bool
+	Collapse        bool
+	TUI             bool
+	ShowReflected   bool
+	OnlyReflected   bool
+	PrintTTFB       bool
+	ShowWAF         bool
+	ShowTrailers    bool
+
+	NormalizeReflection bool
+
+	HideSimilarTo   []string
+	SimilarDistance int
+	ClusterReport   bool
+
+	HideDuplicateHeaders bool
+	HeaderFingerprint    string
+
+	Extract       []string
+	extract       []*regexp.Regexp
+	ExtractTarget string
+	ExtractPipe   []string
+	ExtractStream bool
+	extractPipe   [][]string
+	RecordHeader  []string
+	MaxBodySize   int
+	NoDecompress  bool
+	NoBody        bool
+	noBodySet     bool // --no-body has been specified explicitly
+
+	OnMatch            string
+	onMatch            []string
+	OnMatchConcurrency int
+
+	MetricsAddr   string
+	OutputBurp    string
+	SaveResponses string
+	TimingDump    string
+	CurlFile      string
+
+	Publish      string
+	PublishQueue int
+	PublishDrop  bool
 }
 
 var opts Options
 
+// exitCodeMatch is the exit code used for --exit-on-match,
+// --exit-on-no-match and --assert, errors exit with status 1.
+const exitCodeMatch = 2
+
+// exitCodeAborted is the exit code used when the run was aborted by
+// --max-errors or --max-consecutive-errors.
+const exitCodeAborted = 3
+
+// followInterval is the time to wait before checking for new data at the end
+// of the file for --follow.
+const followInterval = 500 * time.Millisecond
+
+// wafWarnResponses is the number of responses which look like block pages of
+// a web application firewall after which a warning is printed for --show-waf.
+const wafWarnResponses = 10
+
 func compileRegexps(pattern []string) (res []*regexp.Regexp, err error) {
 	for _, pat := range pattern {
 		r, err := regexp.Compile(pat)
@@ -89,356 +244,1739 @@ func splitShell(cmds []string) ([][]string, error) {
 	return data, nil
 }
 
-// valid validates the options and returns an error if something is invalid.
-func (opts *Options) valid() (err error) {
-	if opts.Threads <= 0 {
-		return errors.New("invalid number of threads")
+// sources returns the names of all sources for values which are configured.
+func (opts *Options) sources() (names []string) {
+	if len(opts.Range) > 0 {
+		names = append(names, "range")
 	}
 
-	if len(opts.Range) > 0 && opts.Filename != "" {
-		return errors.New("only one source allowed but both range and filename specified")
+	if opts.Filename != "" {
+		names = append(names, "filename")
 	}
 
-	if len(opts.Range) == 0 && opts.Filename == "" {
-		return errors.New("neither file nor range specified, nothing to do")
+	if len(opts.Zip) > 0 {
+		names = append(names, "zip")
 	}
 
-	opts.extract, err = compileRegexps(opts.Extract)
-	if err != nil {
-		return err
+	if len(opts.FileWeighted) > 0 {
+		names = append(names, "file-weighted")
 	}
 
-	opts.extractPipe, err = splitShell(opts.ExtractPipe)
-	if err != nil {
-		return err
+	if opts.CSV != "" {
+		names = append(names, "csv")
 	}
 
-	opts.hidePattern, err = compileRegexps(opts.HidePattern)
-	if err != nil {
-		return err
+	if opts.Mutate != "" {
+		names = append(names, "mutate")
 	}
 
-	opts.showPattern, err = compileRegexps(opts.ShowPattern)
-	if err != nil {
-		return err
+	if opts.RawRequestDir != "" {
+		names = append(names, "raw-request-dir")
 	}
 
-	return nil
+	return names
 }
 
-var cmd = &cobra.Command{
-	Use:                   "fuzz [options] URL",
-	DisableFlagsInUseLine: true,
+// valid validates the options and returns an error if something is invalid.
+func (opts *Options) valid() (err error) {
+	if opts.Threads <= 0 {
+		return errors.New("invalid number of threads")
+	}
 
-	Short:   helpShort,
-	Long:    helpLong,
-	Example: helpExamples,
+	sources := opts.sources()
+	if len(sources) > 1 {
+		return fmt.Errorf("only one source allowed but %v specified", strings.Join(sources, " and "))
+	}
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		return cli.WithContext(func(ctx context.Context, g *errgroup.Group) error {
-			return run(ctx, g, &opts, args)
-		})
-	},
-}
+	if len(sources) == 0 {
+		return errors.New("no source for values specified (file, range, zip, file-weighted, csv, mutate or raw-request-dir), nothing to do")
+	}
 
-// AddCommand adds the 'run' command to cmd.
-func AddCommand(c *cobra.Command) {
-	c.AddCommand(cmd)
+	if opts.Follow && (opts.Filename == "" || opts.Filename == "-" || isURL(opts.Filename)) {
+		return errors.New("--follow requires --file with the name of a file or FIFO")
+	}
 
-	fs := cmd.Flags()
-	fs.SortFlags = false
+	if opts.Follow && opts.Reverse {
+		return errors.New("--reverse cannot be used with --follow, the last value is never read")
+	}
 
-	fs.StringSliceVarP(&opts.Range, "range", "r", nil, "set range `from-to`")
-	fs.StringVar(&opts.RangeFormat, "range-format", "%d", "set `format` for range")
+	if opts.Follow && opts.Shard != "" {
+		return errors.New("--follow cannot be used with --shard because the number of values is unknown, use --shard-mod")
+	}
 
-	fs.StringVarP(&opts.Filename, "file", "f", "", "read values from `filename`")
-	fs.StringVar(&opts.Logfile, "logfile", "", "write copy of printed messages to `filename`.log")
-	fs.StringVar(&opts.Logdir, "logdir", os.Getenv("MONSOON_LOG_DIR"), "automatically log all output to files in `dir`")
+	if opts.SimilarDistance < 0 || opts.SimilarDistance > 64 {
+		return errors.New("--similar-distance must be between 0 and 64")
+	}
 
-	fs.IntVarP(&opts.Threads, "threads", "t", 5, "make as many as `n` parallel requests")
-	fs.IntVar(&opts.BufferSize, "buffer-size", 100000, "set number of buffered items to `n`")
-	fs.IntVar(&opts.Skip, "skip", 0, "skip the first `n` requests")
-	fs.IntVar(&opts.Limit, "limit", 0, "only run `n` requests, then exit")
-	fs.Float64Var(&opts.RequestsPerSecond, "requests-per-second", 0, "do at most `n` requests per second (e.g. 0.5)")
+	switch opts.HeaderFingerprint {
+	case "names", "values":
+	default:
+		return fmt.Errorf("invalid --header-fingerprint %q, supported: names, values", opts.HeaderFingerprint)
+	}
 
-	// add all options to define a request
-	opts.Request = request.New("")
-	request.AddFlags(opts.Request, fs)
+	if opts.DedupMaxEntries < 0 {
+		return errors.New("--dedup-max-entries must not be negative")
+	}
 
-	fs.IntVar(&opts.FollowRedirect, "follow-redirect", 0, "follow `n` redirects")
+	if opts.DedupMaxEntries > 0 && !opts.WarnDuplicates {
+		return errors.New("--dedup-max-entries requires --warn-duplicates")
+	}
 
-	fs.StringSliceVar(&opts.HideStatusCodes, "hide-status", nil, "hide responses with this status `code,[code-code],[-code],[...]`")
-	fs.StringSliceVar(&opts.ShowStatusCodes, "show-status", nil, "show only responses with this status `code,[code-code],[code-],[...]`")
-	fs.StringSliceVar(&opts.HideHeaderSize, "hide-header-size", nil, "hide responses with this header size (`size,from-to,from-,-to`)")
-	fs.StringSliceVar(&opts.HideBodySize, "hide-body-size", nil, "hide responses with this body size (`size,from-to,from-,-to`)")
-	fs.StringArrayVar(&opts.HidePattern, "hide-pattern", nil, "hide responses containing `regex` in response header or body (can be specified multiple times)")
-	fs.StringArrayVar(&opts.ShowPattern, "show-pattern", nil, "show only responses containing `regex` in response header or body (can be specified multiple times)")
+	if len(opts.Zip) > 0 && len(opts.Zip) != 2 {
+		return errors.New("--zip needs exactly two files")
+	}
 
-	fs.StringArrayVar(&opts.Extract, "extract", nil, "extract `regex` from response body (can be specified multiple times)")
-	fs.StringArrayVar(&opts.ExtractPipe, "extract-pipe", nil, "pipe response body to `cmd` to extract data (can be specified multiple times)")
-	fs.IntVar(&opts.MaxBodySize, "max-body-size", 5, "read at most `n` MiB from a returned response body (used for extracting data from the body)")
-}
+	for _, spec := range opts.FileWeighted {
+		filename, weight, err := producer.ParseWeight(spec)
+		if err != nil {
+			return err
+		}
+		opts.weightedFiles = append(opts.weightedFiles, filename)
+		opts.weights = append(opts.weights, weight)
+	}
 
-// logfilePath returns the prefix for the logfiles, if any.
-func logfilePath(opts *Options, inputURL string) (prefix string, err error) {
-	if opts.Logdir != "" && opts.Logfile == "" {
-		url, err := url.Parse(inputURL)
+	if len(opts.FileWeighted) > 0 && opts.FileWeightedSeed == 0 {
+		opts.FileWeightedSeed = time.Now().UnixNano()
+	}
+
+	if opts.LogMaxSize != "" {
+		opts.logMaxSize, err = parseSize(opts.LogMaxSize)
 		if err != nil {
-			return "", err
+			return fmt.Errorf("invalid --log-max-size: %v", err)
 		}
+	}
 
-		ts := time.Now().Format("20060102_150405")
-		fn := fmt.Sprintf("monsoon_%s_%s", url.Host, ts)
-		p := filepath.Join(opts.Logdir, fn)
-		return p, nil
+	if opts.CheckpointInterval < 0 || opts.CheckpointPercent < 0 || opts.CheckpointPercent > 100 {
+		return errors.New("invalid checkpoint interval or percentage")
 	}
 
-	return opts.Logfile, nil
-}
+	if (opts.CheckpointInterval > 0 || opts.CheckpointPercent > 0) && opts.Logfile == "" && opts.Logdir == "" {
+		return errors.New("--checkpoint-interval and --checkpoint-percent require --logfile or --logdir")
+	}
 
-func setupProducer(ctx context.Context, g *errgroup.Group, opts *Options, ch chan<- string, count chan<- int) error {
-	switch {
-	case len(opts.Range) > 0:
-		var ranges []producer.Range
-		for _, r := range opts.Range {
-			rng, err := producer.ParseRange(r)
-			if err != nil {
-				return err
-			}
+	if len(opts.RecordHeader) > 0 && opts.Logfile == "" && opts.Logdir == "" {
+		return errors.New("--record-header requires --logfile or --logdir")
+	}
 
-			ranges = append(ranges, rng)
+	opts.delimiter, err = producer.ParseDelimiter(opts.Delimiter)
+	if err != nil {
+		return err
+	}
+
+	if opts.Fields != "" {
+		if opts.FieldSep == "" {
+			return errors.New("--fields-sep must not be empty")
 		}
 
-		g.Go(func() error {
-			return producer.Ranges(ctx, ranges, opts.RangeFormat, ch, count)
-		})
-		return nil
+		if string(opts.delimiter) == opts.FieldSep {
+			return errors.New("--fields-sep must be different from --input-delimiter")
+		}
 
-	case opts.Filename == "-":
-		g.Go(func() error {
-			return producer.Reader(ctx, os.Stdin, ch, count)
-		})
-		return nil
+		if opts.RawRequestDir != "" {
+			return errors.New("--fields cannot be used with --raw-request-dir, the value is the file name")
+		}
 
-	case opts.Filename != "":
-		file, err := os.Open(opts.Filename)
+		opts.Request.Fields, err = request.ParseFields(opts.Fields, opts.Request.Replace)
 		if err != nil {
 			return err
 		}
+		opts.Request.FieldSep = opts.FieldSep
+	}
 
-		g.Go(func() error {
-			return producer.Reader(ctx, file, ch, count)
-		})
-		return nil
+	if opts.Burst < 1 {
+		return errors.New("invalid --burst, must be at least 1")
+	}
 
-	default:
-		return errors.New("neither file nor range specified, nothing to do")
+	if opts.Burst > 1 && opts.RequestsPerSecond <= 0 && opts.RequestsPerSecondPerHost <= 0 {
+		return errors.New("--burst needs a rate set with --requests-per-second or --requests-per-second-per-host")
 	}
-}
 
-func setupTerminal(ctx context.Context, g *errgroup.Group, logfilePrefix string) (term cli.Terminal, cleanup func(), err error) {
-	ctx, cancel := context.WithCancel(context.Background())
+	if opts.RequestsPerSecondPerHost < 0 {
+		return errors.New("invalid --requests-per-second-per-host, must not be negative")
+	}
 
-	if logfilePrefix != "" {
-		fmt.Printf("logfile is %s.log\n", logfilePrefix)
+	if opts.RequestsPerSecondPerHost > 0 && opts.TargetsFile == "" {
+		return errors.New("--requests-per-second-per-host needs several targets from --targets-file, use --requests-per-second otherwise")
+	}
 
-		logfile, err := os.Create(logfilePrefix + ".log")
-		if err != nil {
-			return nil, cancel, err
+	if opts.TargetsFile != "" {
+		r := opts.Request
+		if r.RequestFile != "" || r.RawFile != "" || r.RawStdin || opts.RawRequestDir != "" {
+			return errors.New("--targets-file cannot be used with --request-file, --raw-request, --raw-request-stdin and --raw-request-dir")
 		}
 
-		fmt.Fprintln(logfile, shell.Join(os.Args))
-
-		// write copies of messages to logfile
-		term = &cli.LogTerminal{
-			Terminal: termstatus.New(os.Stdout, os.Stderr, false),
-			Writer:   logfile,
+		if opts.WarmupURL != "" || len(opts.HideSimilarTo) > 0 || opts.CurlFile != "" {
+			return errors.New("--targets-file cannot be used with --warmup-url, --hide-similar-to and --curl-file, they need a single target")
 		}
-	} else {
-		term = termstatus.New(os.Stdout, os.Stderr, false)
 	}
 
-	// make sure error messages logged via the log package are printed nicely
-	w := cli.NewStdioWrapper(term)
-	log.SetOutput(w.Stderr())
+	if opts.MaxDuration < 0 {
+		return errors.New("invalid maximum duration, must not be negative")
+	}
 
-	g.Go(func() error {
-		term.Run(ctx)
-		return nil
-	})
+	if opts.MaxRequests < 0 {
+		return errors.New("invalid maximum number of requests, must not be negative")
+	}
 
-	return term, cancel, nil
-}
+	if opts.MaxErrors < 0 || opts.MaxConsecutiveErrors < 0 {
+		return errors.New("invalid maximum number of errors, must not be negative")
+	}
 
-func setupResponseFilters(opts *Options) ([]response.Filter, error) {
-	var filters []response.Filter
+	if opts.MaxErrors5xx && opts.MaxErrors == 0 && opts.MaxConsecutiveErrors == 0 {
+		return errors.New("--max-errors-5xx requires --max-errors or --max-consecutive-errors")
+	}
 
-	filter, err := response.NewFilterStatusCode(opts.HideStatusCodes, opts.ShowStatusCodes)
-	if err != nil {
-		return nil, err
+	if opts.RangeStep <= 0 {
+		return errors.New("invalid range step, must be positive")
 	}
 
-	filters = append(filters, filter)
+	switch opts.RangeMode {
+	case "linear":
+	case "geometric":
+		if opts.RangeStep != 1 {
+			return errors.New("--range-step cannot be used with --range-mode geometric")
+		}
 
-	if len(opts.HideHeaderSize) > 0 || len(opts.HideBodySize) > 0 {
-		f, err := response.NewFilterSize(opts.HideHeaderSize, opts.HideBodySize)
-		if err != nil {
-			return nil, err
+		if opts.RangeFactor < 2 {
+			return errors.New("invalid range factor, must be at least 2")
 		}
-		filters = append(filters, f)
+	default:
+		return fmt.Errorf("invalid range mode %q, must be linear or geometric", opts.RangeMode)
 	}
 
-	if len(opts.hidePattern) > 0 {
-		filters = append(filters, response.FilterRejectPattern{Pattern: opts.hidePattern})
+	if opts.BackoffThreshold <= 0 || opts.BackoffThreshold > 1 {
+		return errors.New("invalid backoff threshold, must be larger than 0 and at most 1")
 	}
 
-	if len(opts.showPattern) > 0 {
-		filters = append(filters, response.FilterAcceptPattern{Pattern: opts.showPattern})
+	if opts.Request.MaxIdleConns < 0 || opts.Request.MaxConnsPerHost < 0 {
+		return errors.New("invalid number of connections, must not be negative")
 	}
 
-	return filters, nil
-}
+	if opts.RawRequestDir != "" {
+		if opts.Request.RawFile != "" || opts.Request.TemplateFile != "" || opts.Request.RequestFile != "" {
+			return errors.New("--raw-request-dir cannot be used with --raw-request, --template-file and --request-file")
+		}
 
-func setupValueFilters(ctx context.Context, opts *Options, valueCh <-chan string, countCh <-chan int) (<-chan string, <-chan int) {
-	if opts.Skip > 0 {
-		f := &producer.FilterSkip{Skip: opts.Skip}
-		countCh = f.Count(ctx, countCh)
-		valueCh = f.Select(ctx, valueCh)
-	}
+		if opts.Request.ContentLength != "" || opts.Request.ExpandTemplates || opts.Request.AWSAccessKey != "" || opts.Request.AppendPath || opts.Request.RandomizeHeaders {
+			return errors.New("--raw-request-dir cannot be used with --content-length, --expand-templates, --aws-access-key, --append-path and --randomize-headers, the requests are sent as they are in the files")
+		}
 
-	if opts.Limit > 0 {
-		f := &producer.FilterLimit{Max: opts.Limit}
-		countCh = f.Count(ctx, countCh)
-		valueCh = f.Select(ctx, valueCh)
-	}
+		r := opts.Request
+		if r.Header.Changed() || r.Body != "" || len(r.FormFields) > 0 || r.Method != "" {
+			return errors.New("--raw-request-dir cannot be used with --header, --data, --data-urlencode and --method, the requests are sent as they are in the files")
+		}
 
-	return valueCh, countCh
-}
+		opts.Request.RawDir = opts.RawRequestDir
+	}
 
-func startRunners(ctx context.Context, opts *Options, in <-chan string) (<-chan response.Response, error) {
-	out := make(chan response.Response)
+	if opts.Request.AWSAccessKey != "" && opts.Request.RawFile != "" {
+		return errors.New("--aws-access-key cannot be used with --raw-request")
+	}
 
-	var wg sync.WaitGroup
-	transport, err := response.NewTransport(opts.Request.Insecure, opts.Request.TLSClientKeyCertFile,
-		opts.Request.DisableHTTP2, opts.Threads)
-	if err != nil {
-		return nil, err
+	if opts.Request.MethodOverride != "" && opts.Request.Method != "" {
+		return errors.New("--method-override cannot be used with --method, the request is sent with POST")
 	}
 
-	for i := 0; i < opts.Threads; i++ {
-		runner := response.NewRunner(transport, opts.Request, in, out)
-		runner.MaxBodySize = opts.MaxBodySize * 1024 * 1024
-		runner.Extract = opts.extract
+	if opts.Request.MethodOverride != "" && (opts.Request.RawFile != "" || opts.RawRequestDir != "") {
+		return errors.New("--method-override cannot be used with --raw-request and --raw-request-dir")
+	}
 
-		runner.Client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			if len(via) <= opts.FollowRedirect {
-				return nil
-			}
-			return http.ErrUseLastResponse
+	if opts.Request.Connect != "" {
+		if _, _, err := net.SplitHostPort(opts.Request.Connect); err != nil {
+			return fmt.Errorf("invalid --connect %q: %v", opts.Request.Connect, err)
 		}
-		wg.Add(1)
-		go func() {
-			runner.Run(ctx)
-			wg.Done()
-		}()
 	}
 
-	go func() {
-		// wait until the runners are done, then close the output channel
-		wg.Wait()
-		close(out)
-	}()
-
-	return out, nil
-}
-
-func run(ctx context.Context, g *errgroup.Group, opts *Options, args []string) error {
-	// make sure the options and arguments are valid
-	if len(args) == 0 {
-		return errors.New("last argument needs to be the URL")
+	if opts.ExitOnMatch && opts.ExitOnNoMatch {
+		return errors.New("--exit-on-match and --exit-on-no-match cannot be used together")
 	}
 
-	if len(args) > 1 {
-		return errors.New("more than one target URL specified")
+	if opts.TUI && (opts.Collapse || opts.Explain) {
+		return errors.New("--tui cannot be used with --collapse and --explain, hidden responses can be shown in the interface")
 	}
 
-	err := opts.valid()
-	if err != nil {
-		return err
+	if opts.AssertMode != "all" && opts.AssertMode != "any" {
+		return fmt.Errorf("invalid --assert-mode %q, must be all or any", opts.AssertMode)
 	}
 
-	inputURL := args[0]
-	opts.Request.URL = inputURL
+	if opts.Assert != "" {
+		if opts.ExitOnMatch || opts.ExitOnNoMatch {
+			return errors.New("--assert cannot be used with --exit-on-match and --exit-on-no-match")
+		}
 
-	// setup logging and the terminal
-	logfilePrefix, err := logfilePath(opts, inputURL)
-	if err != nil {
-		return err
+		opts.assertion, err = response.NewAssertion(opts.Assert, opts.AssertMode == "any")
+		if err != nil {
+			return fmt.Errorf("invalid --assert: %v", err)
+		}
 	}
 
-	term, cleanup, err := setupTerminal(ctx, g, logfilePrefix)
-	defer cleanup()
-	if err != nil {
-		return err
+	if opts.HeadFirst {
+		r := opts.Request
+		if r.Method != "" || r.MethodOverride != "" || r.Body != "" || len(r.FormFields) > 0 {
+			return errors.New("--head-first cannot be used with --method, --method-override, --data and --data-urlencode, the requests are sent with HEAD and GET")
+		}
+
+		if r.TemplateFile != "" || r.RequestFile != "" || r.RawStdin || r.RawMode() {
+			return errors.New("--head-first cannot be used with requests read from a file or sent raw")
+		}
+
+		// the request is signed before the method is changed to HEAD
+		if r.AWSAccessKey != "" {
+			return errors.New("--head-first cannot be used with --aws-access-key")
+		}
 	}
 
-	// collect the filters for the responses
-	responseFilters, err := setupResponseFilters(opts)
-	if err != nil {
-		return err
+	if opts.FollowMetaRefresh {
+		if opts.FollowRedirect <= 0 {
+			return errors.New("--follow-meta-refresh needs the maximum number of redirects set with --follow-redirect")
+		}
+
+		if opts.Request.RawMode() {
+			return errors.New("--follow-meta-refresh cannot be used for requests sent raw (e.g. --raw-request, --randomize-headers and --content-length)")
+		}
 	}
 
-	// setup the pipeline for the values
-	vch := make(chan string, opts.BufferSize)
-	var valueCh <-chan string = vch
-	cch := make(chan int, 1)
-	var countCh <-chan int = cch
+	if opts.CurlFile != "" {
+		if opts.Request.RawFile != "" || opts.RawRequestDir != "" || opts.Request.RawStdin {
+			return errors.New("--curl-file cannot be used with --raw-request, --raw-request-stdin and --raw-request-dir")
+		}
 
-	// start a producer from the options
-	err = setupProducer(ctx, g, opts, vch, cch)
-	if err != nil {
-		return err
+		if opts.Fields != "" {
+			return errors.New("--curl-file cannot be used with --fields")
+		}
 	}
 
-	// filter values (skip, limit)
-	valueCh, countCh = setupValueFilters(ctx, opts, valueCh, countCh)
+	if opts.Request.CacheBust && (opts.Request.RawFile != "" || opts.RawRequestDir != "") {
+		return errors.New("--cache-bust cannot be used with --raw-request and --raw-request-dir")
+	}
 
-	// limit the throughput (if requested)
-	if opts.RequestsPerSecond > 0 {
-		valueCh = producer.Limit(ctx, opts.RequestsPerSecond, valueCh)
+	if opts.Request.AppendPath && opts.Request.RawFile != "" {
+		return errors.New("--append-path cannot be used with --raw-request")
 	}
 
-	// start the runners
-	responseCh, err := startRunners(ctx, opts, valueCh)
-	if err != nil {
-		return err
+	if opts.Request.Insecure && len(opts.Request.InsecureHosts) > 0 {
+		return errors.New("--insecure and --insecure-hosts cannot be used together")
 	}
 
-	// filter the responses
-	responseCh = response.Mark(responseCh, responseFilters)
+	if opts.Request.ContentLength != "" && opts.Request.RawFile != "" {
+		return errors.New("--content-length cannot be used with --raw-request, the header is sent as it is in the file")
+	}
 
-	// extract data from all interesting (non-hidden) responses
-	extracter := &response.Extracter{
-		Pattern:  opts.extract,
-		Commands: opts.extractPipe,
-		Error: func(err error) {
-			term.Printf("%v", err)
-		},
+	if opts.Request.ExpandTemplates && opts.Request.RawFile != "" {
+		return errors.New("--expand-templates cannot be used with --raw-request")
 	}
-	responseCh = extracter.Run(responseCh)
 
-	if logfilePrefix != "" {
-		rec, err := recorder.New(logfilePrefix+".json", opts.Request)
+	if opts.Request.RequestFile != "" {
+		if opts.Request.TemplateFile != "" || opts.Request.RawFile != "" {
+			return errors.New("--request-file cannot be used with --template-file and --raw-request")
+		}
+
+		err = opts.Request.LoadRequestFile(opts.Request.RequestFile)
 		if err != nil {
 			return err
 		}
+	}
+
+	if opts.Request.RawStdin {
+		if opts.Request.RequestFile != "" || opts.Request.TemplateFile != "" || opts.Request.RawFile != "" || opts.RawRequestDir != "" {
+			return errors.New("--raw-request-stdin cannot be used with --request-file, --template-file, --raw-request and --raw-request-dir")
+		}
+
+		if opts.Filename == "-" {
+			return errors.New("--raw-request-stdin cannot be used with --file -, only one of them can read from stdin")
+		}
+
+		err = opts.Request.LoadRawRequest(os.Stdin, "stdin")
+		if err != nil {
+			return err
+		}
+	}
+
+	// the method may have been read from --request-file or
+	// --raw-request-stdin, it would be replaced without notice
+	if opts.Request.MethodOverride != "" && opts.Request.Method != "" && opts.Request.Method != http.MethodPost {
+		return fmt.Errorf("--method-override cannot be used with the method %v from the request, the request is sent with POST", opts.Request.Method)
+	}
+
+	if err := opts.Request.CheckTemplates(); err != nil {
+		return err
+	}
+
+	if _, err := opts.Request.AWSSigner(); err != nil {
+		return err
+	}
+
+	if opts.Request.CookieFile != "" && opts.Request.RawMode() {
+		return errors.New("--cookie-file cannot be used with --raw-request, --randomize-headers and --content-length")
+	}
+
+	if opts.WarmupURL != "" && opts.Request.RawMode() {
+		return errors.New("--warmup-url cannot be used with --raw-request, --randomize-headers and --content-length")
+	}
+
+	opts.statusPolicy, err = response.ParseStatusPolicy(opts.OnStatus)
+	if err != nil {
+		return err
+	}
+
+	if opts.RetryStatusMax < 0 || opts.RetryStatusDelay < 0 {
+		return errors.New("invalid --retry-status-max or --retry-status-delay, must not be negative")
+	}
+
+	if opts.OnStatusRetries < 0 {
+		return errors.New("invalid number of retries, must not be negative")
+	}
+
+	if opts.MutateCount < 0 {
+		return errors.New("invalid number of mutations, must not be negative")
+	}
+
+	// use a random seed for the mutations unless one was specified
+	if opts.Mutate != "" && opts.MutateSeed == 0 {
+		opts.MutateSeed = time.Now().UnixNano()
+	}
+
+	if opts.RangeRandom < 0 {
+		return errors.New("invalid number of random values, must not be negative")
+	}
+
+	if opts.RangeRandom > 0 && len(opts.Range) == 0 {
+		return errors.New("--range-random requires --range")
+	}
+
+	if opts.RangeRandom > 0 && opts.RangeSeed == 0 {
+		opts.RangeSeed = time.Now().UnixNano()
+	}
+
+	switch opts.ExtractTarget {
+	case "body", "headers", "all":
+	default:
+		return fmt.Errorf("invalid extract target %q, must be one of body, headers, all", opts.ExtractTarget)
+	}
+
+	if opts.ValueMatch != "" {
+		opts.valueMatch, err = regexp.Compile(opts.ValueMatch)
+		if err != nil {
+			return fmt.Errorf("invalid --value-match: %v", err)
+		}
+	}
+
+	if opts.ValueReject != "" {
+		opts.valueReject, err = regexp.Compile(opts.ValueReject)
+		if err != nil {
+			return fmt.Errorf("invalid --value-reject: %v", err)
+		}
+	}
+
+	if opts.Shard != "" && opts.ShardMod != "" {
+		return errors.New("--shard and --shard-mod cannot be used together")
+	}
+
+	if opts.Shard != "" {
+		opts.shard, opts.shards, err = producer.ParseShard(opts.Shard)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.ShardMod != "" {
+		opts.shard, opts.shards, err = producer.ParseShard(opts.ShardMod)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts.extract, err = compileRegexps(opts.Extract)
+	if err != nil {
+		return err
+	}
+
+	opts.extractPipe, err = splitShell(opts.ExtractPipe)
+	if err != nil {
+		return err
+	}
+
+	if opts.ExtractStream && len(opts.ExtractPipe) == 0 {
+		return errors.New("--extract-pipe-stream requires --extract-pipe")
+	}
+
+	if opts.OnMatch != "" {
+		cmds, err := splitShell([]string{opts.OnMatch})
+		if err != nil {
+			return err
+		}
+		opts.onMatch = cmds[0]
+	}
+
+	if opts.OnMatchConcurrency < 1 {
+		return errors.New("invalid number of commands for --on-match-concurrency, must be at least 1")
+	}
+
+	if opts.FiltersFile != "" {
+		err = opts.readFiltersFile(opts.FiltersFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts.hidePattern, err = compileRegexps(opts.HidePattern)
+	if err != nil {
+		return err
+	}
+
+	opts.showPattern, err = compileRegexps(opts.ShowPattern)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !opts.noBodySet:
+		// the interface shows the bodies of the responses
+		opts.NoBody = !opts.needBody() && !opts.TUI
+	case opts.NoBody && opts.needBody():
+		return errors.New("--no-body cannot be used with options which need the response body (see help)")
+	}
+
+	return nil
+}
+
+// needBody returns true if the response body is used for anything other than
+// computing the sizes.
+func (opts *Options) needBody() bool {
+	extractBody := len(opts.Extract) > 0 && opts.ExtractTarget != "headers"
+
+	extractPipe := len(opts.ExtractPipe) > 0 && !opts.ExtractStream
+
+	return extractBody || extractPipe ||
+		len(opts.HidePattern) > 0 || len(opts.ShowPattern) > 0 || opts.MatchExpression != "" || opts.Assert != "" ||
+		opts.SaveResponses != "" || opts.OutputBurp != "" ||
+		opts.ShowReflected || opts.OnlyReflected || opts.NormalizeReflection || opts.ShowWAF ||
+		opts.FollowMetaRefresh
+}
+
+// needGet returns true if the body of the response is needed for --head-first,
+// i.e. for anything including its size, the data streamed to the
+// --extract-pipe commands and the trailers sent after the body.
+func (opts *Options) needGet() bool {
+	extractStream := len(opts.ExtractPipe) > 0 && opts.ExtractStream
+
+	return opts.needBody() || extractStream || opts.ShowTrailers ||
+		len(opts.HideBodySize) > 0 || len(opts.HideSimilarTo) > 0 || opts.ClusterReport
+}
+
+var cmd = &cobra.Command{
+	Use:                   "fuzz [options] URL",
+	DisableFlagsInUseLine: true,
+
+	Short:   helpShort,
+	Long:    helpLong,
+	Example: helpExamples,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts.noBodySet = cmd.Flags().Changed("no-body")
+		return cli.WithContext(func(ctx context.Context, g *errgroup.Group) error {
+			return run(ctx, g, &opts, args)
+		})
+	},
+}
+
+// AddCommand adds the 'run' command to cmd.
+func AddCommand(c *cobra.Command) {
+	c.AddCommand(cmd)
+
+	fs := cmd.Flags()
+	fs.SortFlags = false
+
+	fs.StringSliceVarP(&opts.Range, "range", "r", nil, "set range `from-to[,from-to,...]`")
+	fs.StringVar(&opts.RangeFormat, "range-format", "%d", "set `format` for range")
+	fs.IntVar(&opts.RangeStep, "range-step", 1, "use `n` as the distance between two values of a range")
+	fs.StringVar(&opts.RangeMode, "range-mode", "linear", "set `mode` for ranges: linear (add the step) or geometric (multiply by the factor)")
+	fs.IntVar(&opts.RangeFactor, "range-factor", 2, "multiply by `n` to get the next value of a range (for --range-mode geometric)")
+	fs.IntVar(&opts.RangeRandom, "range-random", 0, "send `n` values chosen randomly from the ranges instead of all values")
+	fs.BoolVar(&opts.RangeUnique, "range-unique", false, "choose each value at most once for --range-random")
+	fs.Int64Var(&opts.RangeSeed, "range-seed", 0, "initialize the random number generator for --range-random with `n` (default: random)")
+
+	fs.StringVarP(&opts.Filename, "file", "f", "", "read values from `filename` (or an HTTP or HTTPS URL)")
+	fs.BoolVar(&opts.Follow, "follow", false, "wait for more values at the end of the file (or FIFO) until the run is stopped (see help)")
+	fs.BoolVar(&opts.Reverse, "reverse", false, "send the values in reverse order, starting with the last one (all values are kept in memory)")
+	fs.StringVar(&opts.Encoding, "input-encoding", "auto", "decode values read from the file as `encoding` (auto, utf-8, utf-16le, utf-16be, latin1)")
+	fs.StringVar(&opts.Delimiter, "input-delimiter", "newline", "split the values read from the file at `delimiter` (newline, nul, tab or a single character)")
+	fs.StringSliceVar(&opts.Zip, "zip", nil, "combine the lines of `fileA,fileB` pairwise")
+	fs.StringVar(&opts.ZipSep, "zip-sep", ":", "join the values for --zip with `separator`")
+	fs.StringArrayVar(&opts.FileWeighted, "file-weighted", nil, "read values from `file:weight`, interleaved randomly according to the weights (can be specified multiple times, see help)")
+	fs.Int64Var(&opts.FileWeightedSeed, "file-weighted-seed", 0, "initialize the random number generator for --file-weighted with `n` (default: random)")
+	fs.StringVar(&opts.CSV, "csv", "", "read values from a column of the CSV file `filename`")
+	fs.StringVar(&opts.CSVColumn, "csv-column", "1", "use the values of `column` (number starting at 1, or name with --csv-header) for --csv")
+	fs.BoolVar(&opts.CSVHeader, "csv-header", false, "skip the first record of the CSV file, which contains the column names")
+	fs.StringVar(&opts.Mutate, "mutate", "", "generate values by randomly mutating `seed`")
+	fs.IntVar(&opts.MutateCount, "mutate-count", 1000, "generate `n` values for --mutate")
+	fs.Int64Var(&opts.MutateSeed, "mutate-seed", 0, "initialize the random number generator for --mutate with `n` (default: random)")
+	fs.StringVar(&opts.Fields, "fields", "", "split each value into the fields `name,...` inserted for placeholders of the same name (see help)")
+	fs.StringVar(&opts.FieldSep, "fields-sep", "\t", "separate the fields for --fields with `separator`")
+	fs.StringVar(&opts.TargetsFile, "targets-file", "", "send the requests to each target URL from `file` instead of the URL argument, the values are sent to all targets (see help)")
+	fs.StringVar(&opts.RawRequestDir, "raw-request-dir", "", "send each file in `dir` as a raw request without modification, the file name is the value (see help)")
+	fs.StringVar(&opts.Logfile, "logfile", "", "write copy of printed messages to `filename`.log")
+	fs.StringVar(&opts.Logdir, "logdir", os.Getenv("MONSOON_LOG_DIR"), "automatically log all output to files in `dir`")
+	fs.StringVar(&opts.LogMaxSize, "log-max-size", "", "continue the logfile and the JSON data in new files when they would grow larger than `size` (e.g. 100MB)")
+	fs.DurationVar(&opts.CheckpointInterval, "checkpoint-interval", 0, "write the progress of the run to the logfile every `duration` (e.g. 60s)")
+	fs.IntVar(&opts.CheckpointPercent, "checkpoint-percent", 0, "write the progress of the run to the logfile every `n` percent of the requests")
+	fs.BoolVar(&opts.NoBanner, "no-banner", false, "only print the responses, without the input URL, table heading and summary")
+	fs.BoolVar(&opts.ExitOnMatch, "exit-on-match", false, "exit with status 0 only if at least one response is shown, 2 otherwise (see help)")
+	fs.BoolVar(&opts.ExitOnNoMatch, "exit-on-no-match", false, "exit with status 0 only if no response is shown, 2 otherwise (see help)")
+	fs.StringVar(&opts.Assert, "assert", "", "evaluate `expression` for all responses and exit with status 2 if the assertion fails (see help)")
+	fs.StringVar(&opts.AssertMode, "assert-mode", "all", "require that `mode` (all or any) responses match the --assert expression")
+
+	fs.IntVarP(&opts.Threads, "threads", "t", 5, "make as many as `n` parallel requests")
+	fs.IntVar(&opts.BufferSize, "buffer-size", 100000, "set number of buffered items to `n`")
+	fs.IntVar(&opts.Skip, "skip", 0, "skip the first `n` requests")
+	fs.IntVar(&opts.Limit, "limit", 0, "only run `n` requests, then exit")
+	fs.IntVar(&opts.MaxRequests, "max-requests", 0, "send at most `n` HTTP requests in total, including retries and redirects")
+	fs.StringVar(&opts.Shard, "shard", "", "only run the part `i/n` of the requests (see help)")
+	fs.StringVar(&opts.ShardMod, "shard-mod", "", "only run every n-th request starting at i for `i/n` (see help)")
+	fs.StringVar(&opts.PrefixFile, "prefix-file", "", "prepend each value read from `filename` to each value (all combinations are used)")
+	fs.StringVar(&opts.SuffixFile, "suffix-file", "", "append each value read from `filename` to each value (all combinations are used)")
+	fs.StringVar(&opts.ValueMatch, "value-match", "", "only send requests for values matching `regex` (see help)")
+	fs.StringVar(&opts.ValueReject, "value-reject", "", "do not send requests for values matching `regex` (see help)")
+	fs.BoolVar(&opts.WarnDuplicates, "warn-duplicates", false, "print a warning at the end if the input contained duplicate values")
+	fs.IntVar(&opts.DedupMaxEntries, "dedup-max-entries", 0, "keep at most `n` values in memory for --warn-duplicates (default: no limit, see help)")
+	fs.Float64Var(&opts.RequestsPerSecond, "requests-per-second", 0, "do at most `n` requests per second (e.g. 0.5)")
+	fs.IntVar(&opts.Burst, "burst", 1, "allow bursts of up to `n` requests for --requests-per-second, keeping the average rate (see help)")
+	fs.Float64Var(&opts.RequestsPerSecondPerHost, "requests-per-second-per-host", 0, "do at most `n` requests per second to each host for --targets-file (see help)")
+	fs.DurationVar(&opts.MaxDuration, "max-duration", 0, "stop gracefully after `duration` (e.g. 10m)")
+	fs.IntVar(&opts.MaxErrors, "max-errors", 0, "abort the run after `n` failed requests (network errors)")
+	fs.IntVar(&opts.MaxConsecutiveErrors, "max-consecutive-errors", 0, "abort the run after `n` failed requests in a row (network errors)")
+	fs.BoolVar(&opts.MaxErrors5xx, "max-errors-5xx", false, "also count responses with status 5xx for --max-errors and --max-consecutive-errors")
+	fs.BoolVar(&opts.BackoffOn5xx, "backoff-on-5xx", false, "slow down automatically when the server returns many errors (5xx)")
+	fs.Float64Var(&opts.BackoffThreshold, "backoff-threshold", 0.5, "slow down when more than `fraction` of the responses are 5xx (for --backoff-on-5xx and --on-status)")
+	fs.StringSliceVar(&opts.OnStatus, "on-status", nil, "run action for status codes, `code=action[,...]` with action continue, backoff, stop or retry (see help)")
+	fs.IntVar(&opts.OnStatusRetries, "on-status-retries", 2, "send a request at most `n` more times for --on-status retry")
+	fs.IntSliceVar(&opts.RetryStatus, "retry-status", nil, "send the request again after a delay for responses with status `code,...` (see help)")
+	fs.IntVar(&opts.RetryStatusMax, "retry-status-max", 3, "send a request at most `n` more times for --retry-status")
+	fs.DurationVar(&opts.RetryStatusDelay, "retry-status-delay", time.Second, "wait `time` before the first retry for --retry-status, doubled for each retry (unless Retry-After is sent)")
+
+	// add all options to define a request
+	opts.Request = request.New("")
+	request.AddFlags(opts.Request, fs)
+
+	fs.IntVar(&opts.FollowRedirect, "follow-redirect", 0, "follow `n` redirects")
+	fs.BoolVar(&opts.RedirectKeepAuth, "redirect-keep-auth", false, "send the Authorization header also when redirected to a different host (see help)")
+	fs.BoolVar(&opts.HeadFirst, "head-first", false, "send HEAD requests and only send GET when the body is needed or HEAD is not supported (see help)")
+	fs.BoolVar(&opts.FollowMetaRefresh, "follow-meta-refresh", false, "also follow redirects with a meta refresh tag or JavaScript in the body, up to the limit of --follow-redirect (see help)")
+	fs.BoolVar(&opts.RedirectKeepMethod, "redirect-keep-method", false, "keep method and body of the request when following redirects with status 301, 302 and 303")
+	fs.StringVar(&opts.WarmupURL, "warmup-url", "", "send a request to `url` (may be relative to the target) before the scan and send the cookies it sets with all requests")
+	fs.StringSliceVar(&opts.AllowedHosts, "allowed-hosts", nil, "only send requests to `host,[*.domain],[...]`, also for redirects (see help)")
+
+	fs.StringSliceVar(&opts.HideStatusCodes, "hide-status", nil, "hide responses with this status `code,[code-code],[-code],[...]`")
+	fs.StringSliceVar(&opts.ShowStatusCodes, "show-status", nil, "show only responses with this status `code,[code-code],[code-],[...]`")
+	fs.StringSliceVar(&opts.HideHeaderSize, "hide-header-size", nil, "hide responses with this header size (`size,from-to,from-,-to`)")
+	fs.StringSliceVar(&opts.HideBodySize, "hide-body-size", nil, "hide responses with this body size (`size,from-to,from-,-to`)")
+	fs.StringSliceVar(&opts.HideTTFB, "hide-ttfb", nil, "hide responses with a time to first byte in this range (`from-to,from-,-to`, e.g. -100ms, see help)")
+	fs.StringSliceVar(&opts.ShowTTFB, "show-ttfb", nil, "show only responses with a time to first byte in this range (`from-to,from-,-to`, e.g. 2s-, see help)")
+	fs.StringArrayVar(&opts.HidePattern, "hide-pattern", nil, "hide responses containing `regex` in response header or body (can be specified multiple times)")
+	fs.StringArrayVar(&opts.ShowPattern, "show-pattern", nil, "show only responses containing `regex` in response header or body (can be specified multiple times)")
+	fs.StringVar(&opts.MatchExpression, "match-expr", "", "show only responses for which `expression` matches (see help)")
+	fs.StringVar(&opts.FiltersFile, "filters-file", "", "read additional filters from `file` (see help)")
+	fs.BoolVar(&opts.Explain, "explain", false, "also print hidden responses and the filter which hid them")
+	fs.BoolVar(&opts.ShowReflected, "show-reflected", false, "print the number of times the value is contained in the response (see help)")
+	fs.BoolVar(&opts.OnlyReflected, "only-reflected", false, "show only responses which contain the value (see help)")
+	fs.StringArrayVar(&opts.HideSimilarTo, "hide-similar-to", nil, "hide responses with a body similar to the one for `value`, requested before the scan (can be specified multiple times, see help)")
+	fs.IntVar(&opts.SimilarDistance, "similar-distance", 3, "hide responses for --hide-similar-to if the fingerprints differ in at most `n` of 64 bits")
+	fs.BoolVar(&opts.HideDuplicateHeaders, "hide-duplicate-headers", false, "only show the first response for each set of response headers, ignoring the body (see help)")
+	fs.StringVar(&opts.HeaderFingerprint, "header-fingerprint", "names", "compare the headers for --hide-duplicate-headers by `mode`: names, or values for the names and values")
+	fs.BoolVar(&opts.ClusterReport, "cluster-report", false, "print the groups of similar responses at the end (see help)")
+	fs.BoolVar(&opts.NormalizeReflection, "normalize-reflection", false, "replace the value in the response before computing the sizes for filtering (see help)")
+	fs.BoolVar(&opts.PrintTTFB, "print-ttfb", false, "print the time to first byte of each response (see help)")
+	fs.BoolVar(&opts.ShowTrailers, "show-trailers", false, "print the trailers received after the response body (e.g. grpc-status)")
+	fs.BoolVar(&opts.ShowWAF, "show-waf", false, "print the name of the web application firewall for responses which look like block pages (see help)")
+	fs.BoolVar(&opts.Collapse, "collapse", false, "print consecutive responses with the same status, sizes and extracted data only once, followed by the number of repetitions")
+	fs.BoolVar(&opts.TUI, "tui", false, "show the responses in an interactive full-screen interface with filter toggles and a detail pane (see help)")
+
+	fs.StringArrayVar(&opts.Extract, "extract", nil, "extract `regex` from response header and body (can be specified multiple times)")
+	fs.StringVar(&opts.ExtractTarget, "extract-target", "all", "run --extract on `part` of the response (body, headers, all)")
+	fs.StringArrayVar(&opts.ExtractPipe, "extract-pipe", nil, "pipe response body to `cmd` to extract data (can be specified multiple times, see help)")
+	fs.BoolVar(&opts.ExtractStream, "extract-pipe-stream", false, "stream the body to the --extract-pipe commands while it is received, for all responses (see help)")
+	fs.StringArrayVar(&opts.RecordHeader, "record-header", nil, "record the response header `name` in the JSON logfile (can be specified multiple times)")
+	fs.IntVar(&opts.MaxBodySize, "max-body-size", 5, "read at most `n` MiB from a returned response body (used for extracting data from the body)")
+	fs.BoolVar(&opts.NoBody, "no-body", false, "do not keep response bodies in memory, only compute the sizes (default: true if the body is not used, see help)")
+	fs.BoolVar(&opts.NoDecompress, "no-decompress", false, "do not decompress gzip, deflate and br response bodies (see help)")
+
+	fs.StringVar(&opts.OnMatch, "on-match", "", "run `cmd` for each response which is not hidden, with details in environment variables (see help)")
+	fs.IntVar(&opts.OnMatchConcurrency, "on-match-concurrency", 4, "run at most `n` commands for --on-match at the same time")
+	fs.StringVar(&opts.MetricsAddr, "metrics-addr", "", "serve Prometheus metrics on `[host]:port` (path /metrics)")
+	fs.StringVar(&opts.TimingDump, "timing-dump", "", "write the duration of each response in seconds to `file`, one per line (e.g. for plotting)")
+	fs.StringVar(&opts.SaveResponses, "save-responses", "", "save each response which is not hidden to a file in `dir`, named after the value")
+	fs.StringVar(&opts.CurlFile, "curl-file", "", "write a curl command reproducing the request for each response which is not hidden to `file`")
+	fs.StringVar(&opts.OutputBurp, "output-burp", "", "write the requests and responses which are not hidden to `file` in the Burp Suite XML format")
+	fs.StringVar(&opts.Publish, "publish", "", "publish the responses which are not hidden as JSON to a NATS server, `url` is nats://host[:port]/subject (tls:// for TLS)")
+	fs.IntVar(&opts.PublishQueue, "publish-queue", 1000, "queue at most `n` messages for --publish before the scan is slowed down")
+	fs.BoolVar(&opts.PublishDrop, "publish-drop", false, "drop messages for --publish when the queue is full instead of slowing down the scan")
+}
+
+// logfilePath returns the prefix for the logfiles, if any.
+func logfilePath(opts *Options, inputURL string) (prefix string, err error) {
+	if opts.Logdir != "" && opts.Logfile == "" {
+		var host string
+		u, err := url.Parse(inputURL)
+		if err == nil {
+			host = u.Host
+		} else {
+			// the placeholder may be used as the port, use only the host name then
+			u, err = url.Parse(strings.Replace(inputURL, opts.Request.Replace, "0", -1))
+			if err != nil {
+				return "", err
+			}
+			host = u.Hostname()
+		}
+
+		ts := time.Now().Format("20060102_150405")
+		fn := fmt.Sprintf("monsoon_%s_%s", host, ts)
+		p := filepath.Join(opts.Logdir, fn)
+		return p, nil
+	}
+
+	return opts.Logfile, nil
+}
+
+// parseSize parses a size like "100MB" or "512k" and returns the number of
+// bytes. The units k, M and G (optionally followed by B or iB) use base 1024.
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"g", 1 << 30},
+		{"m", 1 << 20},
+		{"k", 1 << 10},
+	}
+
+	num := strings.ToLower(strings.TrimSpace(s))
+	num = strings.TrimSuffix(strings.TrimSuffix(num, "b"), "i")
+
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(num, unit.suffix) {
+			factor = unit.factor
+			num = strings.TrimSuffix(num, unit.suffix)
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return n * factor, nil
+}
+
+// countLines returns the number of records separated by delim in the file for
+// which keep returns true.
+func countLines(filename, encoding string, delim byte, keep func(string) bool) (int, error) {
+	rd, err := openReader(filename, encoding)
+	if err != nil {
+		return 0, err
+	}
+
+	sc := bufio.NewScanner(rd)
+	sc.Split(producer.ScanDelimiter(delim))
+	var n int
+	for sc.Scan() {
+		if keep(sc.Text()) {
+			n++
+		}
+	}
+
+	if sc.Err() != nil {
+		_ = rd.Close()
+		return 0, sc.Err()
+	}
+
+	return n, rd.Close()
+}
+
+// keepValue returns true if v is selected by --value-match and --value-reject.
+func (opts *Options) keepValue(v string) bool {
+	if opts.valueMatch != nil && !opts.valueMatch.MatchString(v) {
+		return false
+	}
+
+	return opts.valueReject == nil || !opts.valueReject.MatchString(v)
+}
+
+// readLines returns all records separated by delim in the file.
+func readLines(filename, encoding string, delim byte) (lines []string, err error) {
+	rd, err := openReader(filename, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := bufio.NewScanner(rd)
+	sc.Split(producer.ScanDelimiter(delim))
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+
+	if sc.Err() != nil {
+		_ = rd.Close()
+		return nil, sc.Err()
+	}
+
+	return lines, rd.Close()
+}
+
+// openReader opens the file and returns a reader which decodes the data from
+// encoding. If filename is "-", the data is read from stdin.
+func openReader(filename, encoding string) (io.ReadCloser, error) {
+	if filename == "-" {
+		return producer.NewDecoder(os.Stdin, encoding)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rd, err := producer.NewDecoder(file, encoding)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return rd, nil
+}
+
+// isURL returns true if filename is an HTTP or HTTPS URL.
+func isURL(filename string) bool {
+	return strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://")
+}
+
+// openURL requests the file from the URL and returns a reader which decodes
+// the data from encoding. The request uses the transport settings (e.g.
+// --insecure, --insecure-hosts and the proxy configuration) for the scan.
+func openURL(ctx context.Context, template *request.Request, u, encoding string) (io.ReadCloser, error) {
+	tr, err := response.NewClientTransport(template)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range request.DefaultHeader {
+		req.Header[name] = values
+	}
+
+	res, err := (&http.Client{Transport: tr}).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("download values: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("download values from %v: unexpected status %v", u, res.Status)
+	}
+
+	rd, err := producer.NewDecoder(res.Body, encoding)
+	if err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+
+	return rd, nil
+}
+
+func setupProducer(ctx context.Context, g *errgroup.Group, opts *Options, ch chan<- string, count chan<- int) error {
+	switch {
+	case len(opts.Range) > 0:
+		var ranges []producer.Range
+		for _, r := range opts.Range {
+			rng, err := producer.ParseRange(r)
+			if err != nil {
+				return err
+			}
+
+			rng.Step = opts.RangeStep
+			if opts.RangeMode == "geometric" {
+				if rng.First < 1 {
+					return fmt.Errorf("range %v for --range-mode geometric must start at 1 or higher", rng)
+				}
+				rng.Factor = opts.RangeFactor
+			}
+			ranges = append(ranges, rng)
+		}
+
+		err := producer.CheckOverlap(ranges)
+		if err != nil {
+			return err
+		}
+
+		if opts.RangeRandom > 0 {
+			g.Go(func() error {
+				return producer.RandomRanges(ctx, ranges, opts.RangeFormat, opts.RangeRandom, opts.RangeUnique, opts.RangeSeed, ch, count)
+			})
+			return nil
+		}
+
+		g.Go(func() error {
+			return producer.Ranges(ctx, ranges, opts.RangeFormat, ch, count)
+		})
+		return nil
+
+	case opts.Follow:
+		file, err := producer.OpenFollow(opts.Filename)
+		if err != nil {
+			return err
+		}
+
+		rd, err := producer.NewDecoder(producer.NewFollowReader(ctx, file, followInterval), opts.Encoding)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+
+		g.Go(func() error {
+			return producer.Follow(ctx, rd, opts.delimiter, ch, count)
+		})
+		return nil
+
+	case opts.Filename != "":
+		var rd io.ReadCloser
+		var err error
+		if isURL(opts.Filename) {
+			rd, err = openURL(ctx, opts.Request, opts.Filename, opts.Encoding)
+		} else {
+			rd, err = openReader(opts.Filename, opts.Encoding)
+		}
+		if err != nil {
+			return err
+		}
+
+		g.Go(func() error {
+			return producer.Reader(ctx, rd, opts.delimiter, ch, count)
+		})
+		return nil
+
+	case opts.CSV != "":
+		rd, err := openReader(opts.CSV, opts.Encoding)
+		if err != nil {
+			return err
+		}
+
+		g.Go(func() error {
+			return producer.CSV(ctx, rd, opts.CSVColumn, opts.CSVHeader, ch, count)
+		})
+		return nil
+
+	case len(opts.Zip) > 0:
+		var inputs [2]chan string
+		var counts [2]chan int
+
+		// stop reading the longer file when the shorter one is exhausted
+		zipCtx, cancel := context.WithCancel(ctx)
+
+		for i, filename := range opts.Zip {
+			rd, err := openReader(filename, opts.Encoding)
+			if err != nil {
+				cancel()
+				return err
+			}
+
+			inputs[i] = make(chan string, cap(ch))
+			counts[i] = make(chan int, 1)
+
+			in, count := inputs[i], counts[i]
+			g.Go(func() error {
+				return producer.Reader(zipCtx, rd, opts.delimiter, in, count)
+			})
+		}
+
+		g.Go(func() error {
+			defer cancel()
+			return producer.Zip(zipCtx, inputs[0], inputs[1], counts[0], counts[1], opts.ZipSep, ch, count)
+		})
+		return nil
+
+	case len(opts.FileWeighted) > 0:
+		var inputs []<-chan string
+		var counts []<-chan int
+
+		for _, filename := range opts.weightedFiles {
+			rd, err := openReader(filename, opts.Encoding)
+			if err != nil {
+				return err
+			}
+
+			in := make(chan string, cap(ch))
+			count := make(chan int, 1)
+			inputs = append(inputs, in)
+			counts = append(counts, count)
+
+			g.Go(func() error {
+				return producer.Reader(ctx, rd, opts.delimiter, in, count)
+			})
+		}
+
+		g.Go(func() error {
+			return producer.Weighted(ctx, inputs, counts, opts.weights, opts.FileWeightedSeed, ch, count)
+		})
+		return nil
+
+	case opts.Mutate != "":
+		g.Go(func() error {
+			return producer.Mutate(ctx, opts.Mutate, opts.MutateCount, opts.MutateSeed, ch, count)
+		})
+		return nil
+
+	case opts.RawRequestDir != "":
+		g.Go(func() error {
+			return producer.Dir(ctx, opts.RawRequestDir, ch, count)
+		})
+		return nil
+
+	default:
+		return errors.New("neither file nor range specified, nothing to do")
+	}
+}
+
+// setupTerminal returns the terminal for the messages. If useTUI is set, the
+// interactive interface is started and returned as tui, it's closed by
+// cleanup.
+func setupTerminal(ctx context.Context, g *errgroup.Group, logfilePrefix string, maxSize int64, useTUI bool) (term cli.Terminal, tui *reporter.TUI, cleanup func(), err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cleanup = cancel
+
+	var logfile io.Writer
+	if logfilePrefix != "" {
+		fmt.Printf("logfile is %s.log\n", logfilePrefix)
+
+		f, err := cli.NewRotatingFile(logfilePrefix+".log", maxSize)
+		if err != nil {
+			return nil, nil, cleanup, err
+		}
+
+		fmt.Fprintln(f, shell.Join(os.Args))
+		logfile = f
+	}
+
+	if useTUI {
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			return nil, nil, cleanup, fmt.Errorf("--tui: %v", err)
+		}
+
+		tui, err = reporter.NewTUI(screen)
+		if err != nil {
+			return nil, nil, cleanup, fmt.Errorf("--tui: %v", err)
+		}
+
+		cleanup = func() {
+			cancel()
+			tui.Close()
+		}
+		term = tui
+	} else {
+		term = termstatus.New(os.Stdout, os.Stderr, false)
+	}
+
+	if logfile != nil {
+		// write copies of messages to logfile
+		term = &cli.LogTerminal{
+			Terminal: term,
+			Writer:   logfile,
+		}
+	}
+
+	// make sure error messages logged via the log package are printed nicely
+	w := cli.NewStdioWrapper(term)
+	log.SetOutput(w.Stderr())
+
+	g.Go(func() error {
+		term.Run(ctx)
+		return nil
+	})
+
+	return term, tui, cleanup, nil
+}
+
+func setupResponseFilters(opts *Options) ([]response.Filter, error) {
+	var filters []response.Filter
+
+	filter, err := response.NewFilterStatusCode(opts.HideStatusCodes, opts.ShowStatusCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	filters = append(filters, filter)
+
+	if len(opts.HideHeaderSize) > 0 || len(opts.HideBodySize) > 0 {
+		f, err := response.NewFilterSize(opts.HideHeaderSize, opts.HideBodySize)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	if len(opts.HideTTFB) > 0 || len(opts.ShowTTFB) > 0 {
+		f, err := response.NewFilterTTFB(opts.HideTTFB, opts.ShowTTFB)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	if len(opts.hidePattern) > 0 {
+		filters = append(filters, response.FilterRejectPattern{Pattern: opts.hidePattern})
+	}
+
+	if len(opts.showPattern) > 0 {
+		filters = append(filters, response.FilterAcceptPattern{Pattern: opts.showPattern})
+	}
+
+	if opts.MatchExpression != "" {
+		f, err := response.NewFilterExpression(opts.MatchExpression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match-expr: %v", err)
+		}
+		filters = append(filters, f)
+	}
+
+	if opts.OnlyReflected {
+		filters = append(filters, response.FilterReflected{})
+	}
+
+	return filters, nil
+}
+
+func setupValueFilters(ctx context.Context, opts *Options, valueCh <-chan string, countCh <-chan int) (<-chan string, <-chan int) {
+	filters := producer.ValueFilters{
+		Match:       opts.valueMatch,
+		Reject:      opts.valueReject,
+		MatchValues: opts.matchValues,
+		Reverse:     opts.Reverse,
+		Shard:       opts.shard,
+		Shards:      opts.shards,
+		ShardMod:    opts.ShardMod != "",
+		ShardValues: opts.shardValues,
+		Prefixes:    opts.prefixes,
+		Suffixes:    opts.suffixes,
+		Skip:        opts.Skip,
+		Limit:       opts.Limit,
+	}
+
+	return filters.Apply(ctx, valueCh, countCh)
+}
+
+// checkRedirect decides whether a redirect is followed and modifies the
+// request for the next hop according to the options.
+func (opts *Options) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > opts.FollowRedirect {
+		return http.ErrUseLastResponse
+	}
+
+	orig := via[0]
+
+	// the http client drops the Authorization header when the host changes
+	if opts.RedirectKeepAuth && req.Header.Get("Authorization") == "" {
+		if auth := orig.Header.Get("Authorization"); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	}
+
+	// for 301, 302 and 303 the http client sends a GET request without body
+	if opts.RedirectKeepMethod && req.Method != orig.Method {
+		req.Method = orig.Method
+
+		if orig.GetBody != nil && orig.ContentLength != 0 {
+			body, err := orig.GetBody()
+			if err != nil {
+				return err
+			}
+
+			req.Body = body
+			req.GetBody = orig.GetBody
+			req.ContentLength = orig.ContentLength
+			if ct := orig.Header.Get("Content-Type"); ct != "" {
+				req.Header.Set("Content-Type", ct)
+			}
+		}
+	}
+
+	return nil
+}
+
+// newExtracter returns the extracter for the data in the bodies of the
+// responses, the headers are searched by the runner (see newRunner).
+func newExtracter(opts *Options, term cli.Terminal) *response.Extracter {
+	extracter := &response.Extracter{
+		Error: func(err error) {
+			term.Printf("%v", err)
+		},
+	}
+	if opts.ExtractTarget != "headers" {
+		extracter.Pattern = opts.extract
+	}
+	if !opts.ExtractStream {
+		extracter.Commands = opts.extractPipe
+	}
+	return extracter
+}
+
+// newRunner returns a runner for template, configured from opts.
+func newRunner(opts *Options, term cli.Terminal, jar http.CookieJar, transport, insecureTransport *http.Transport, template *request.Request, in <-chan string, out chan<- response.Response) *response.Runner {
+	runner := response.NewRunner(transport, template, in, out)
+	runner.InsecureTransport = insecureTransport
+	runner.MaxBodySize = opts.MaxBodySize * 1024 * 1024
+	if opts.ExtractTarget != "body" {
+		runner.Extract = opts.extract
+	}
+
+	runner.Client.CheckRedirect = opts.checkRedirect
+	runner.Client.Jar = jar
+	runner.RecordRequest = opts.OutputBurp != ""
+	runner.NoDecompress = opts.NoDecompress
+	runner.NoBody = opts.NoBody
+	runner.FindReflected = opts.ShowReflected || opts.OnlyReflected
+	runner.NormalizeReflection = opts.NormalizeReflection
+	if opts.FollowMetaRefresh {
+		runner.FollowMetaRefresh = opts.FollowRedirect
+	}
+	if opts.HeadFirst {
+		runner.HeadFirst = true
+		if opts.needGet() {
+			// send GET only for responses which are not hidden by the status
+			// code anyway, the filter has been checked before
+			status, _ := response.NewFilterStatusCode(opts.HideStatusCodes, opts.ShowStatusCodes)
+			runner.HeadFirstGet = func(res response.Response) bool {
+				return !status.Reject(res)
+			}
+		}
+	}
+	runner.DetectWAF = opts.ShowWAF
+	if opts.ExtractStream {
+		runner.StreamCommands = opts.extractPipe
+		runner.StreamError = func(err error) {
+			term.Printf("%v\n", err)
+		}
+	}
+	if len(opts.AllowedHosts) > 0 {
+		runner.AllowedHosts = opts.AllowedHosts
+	}
+	runner.Budget = opts.budget
+	if opts.statusPolicy.Has(response.ActionRetry) {
+		runner.MaxRetries = opts.OnStatusRetries
+		runner.Retry = opts.statusPolicy.Retry
+	}
+	runner.RetryStatus = opts.RetryStatus
+	runner.RetryStatusMax = opts.RetryStatusMax
+	runner.RetryStatusDelay = opts.RetryStatusDelay
+	runner.Simhash = len(opts.HideSimilarTo) > 0 || opts.ClusterReport || opts.Logfile != "" || opts.Logdir != ""
+	runner.HeaderFingerprint = opts.HideDuplicateHeaders || opts.Logfile != "" || opts.Logdir != ""
+	runner.HeaderFingerprintValues = opts.HeaderFingerprint == "values"
+
+	return runner
+}
+
+func startRunners(ctx context.Context, opts *Options, term cli.Terminal, jar http.CookieJar, in <-chan string) (<-chan response.Response, error) {
+	out := make(chan response.Response)
+
+	var wg sync.WaitGroup
+
+	// each target has its own runners
+	threads := opts.Threads
+	if len(opts.targets) > 0 {
+		threads *= len(opts.targets)
+	}
+
+	transport, err := response.NewTransport(opts.Request, threads)
+	if err != nil {
+		return nil, err
+	}
+
+	var insecureTransport *http.Transport
+	if len(opts.Request.InsecureHosts) > 0 {
+		insecureTransport, err = response.NewInsecureTransport(opts.Request, threads)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// start the runners for a target, which is empty if the URL from the
+	// template is used
+	start := func(target string, in <-chan string) {
+		for i := 0; i < opts.Threads; i++ {
+			// each thread uses its own copy of the request template, so that the
+			// thread number is available in templates
+			template := *opts.Request
+			template.ThreadID = i + 1
+			if target != "" {
+				template.URL = target
+			}
+
+			runner := newRunner(opts, term, jar, transport, insecureTransport, &template, in, out)
+			runner.Target = target
+			wg.Add(1)
+			go func() {
+				runner.Run(ctx)
+				wg.Done()
+			}()
+		}
+	}
+
+	if len(opts.targets) == 0 {
+		start("", in)
+	} else {
+		for i, ch := range targetInputs(ctx, opts, in) {
+			start(opts.targets[i], ch)
+		}
+	}
+
+	go func() {
+		// wait until the runners are done, then close the output channel
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+func run(ctx context.Context, g *errgroup.Group, opts *Options, args []string) error {
+	// make sure the options and arguments are valid, the URL is optional if
+	// it is read from a request file
+	if len(args) == 0 && opts.Request.RequestFile == "" && !opts.Request.RawStdin && opts.TargetsFile == "" {
+		return errors.New("last argument needs to be the URL")
+	}
+
+	if len(args) > 1 {
+		return errors.New("more than one target URL specified")
+	}
+
+	if len(args) == 1 {
+		if opts.TargetsFile != "" {
+			return errors.New("--targets-file cannot be used with a URL argument")
+		}
+		opts.Request.URL = args[0]
+	}
+
+	// the first target is used where a single URL is needed (e.g. for the
+	// name of the logfile and the recorded template)
+	if opts.TargetsFile != "" {
+		var err error
+		opts.targets, err = readTargets(opts.TargetsFile, opts.Request.Replace)
+		if err != nil {
+			return err
+		}
+		opts.Request.URL = opts.targets[0]
+	}
+
+	err := opts.valid()
+	if err != nil {
+		return err
+	}
+
+	inputURL := opts.Request.URL
+
+	// stop the run gracefully when the maximum duration is reached
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+
+	// allow stopping the run gracefully via --on-status and --max-errors
+	ctx, stopRun := context.WithCancel(ctx)
+	defer stopRun()
+
+	// setup logging and the terminal
+	logfilePrefix, err := logfilePath(opts, inputURL)
+	if err != nil {
+		return err
+	}
+
+	term, tui, cleanup, err := setupTerminal(ctx, g, logfilePrefix, opts.logMaxSize, opts.TUI)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	// collect the filters for the responses
+	responseFilters, err := setupResponseFilters(opts)
+	if err != nil {
+		return err
+	}
+
+	// setup the pipeline for the values
+	vch := make(chan string, opts.BufferSize)
+	var valueCh <-chan string = vch
+	cch := make(chan int, 1)
+	var countCh <-chan int = cch
+
+	// start a producer from the options
+	err = setupProducer(ctx, g, opts, vch, cch)
+	if err != nil {
+		return err
+	}
+
+	// count duplicate values before anything is skipped
+	var duplicates *producer.CountDuplicates
+	if opts.WarnDuplicates {
+		duplicates = &producer.CountDuplicates{MaxEntries: opts.DedupMaxEntries}
+		valueCh = duplicates.Select(ctx, valueCh)
+	}
+
+	// the part of the values for a shard can only be computed when the
+	// number of values is known, so count the lines of the input file
+	// beforehand instead of keeping all values in memory (which is done for
+	// stdin and URLs), the same is done for the lines selected by
+	// --value-match and --value-reject so that the progress can be shown
+	regularFile := opts.Filename != "" && opts.Filename != "-" && !isURL(opts.Filename) && !opts.Follow
+	filterValues := opts.valueMatch != nil || opts.valueReject != nil
+	if (opts.Shard != "" || filterValues) && regularFile {
+		n, err := countLines(opts.Filename, opts.Encoding, opts.delimiter, opts.keepValue)
+		if err != nil {
+			return err
+		}
+
+		opts.shardValues = n
+		if filterValues {
+			opts.matchValues = n
+		}
+	}
+
+	if opts.PrefixFile != "" {
+		opts.prefixes, err = readLines(opts.PrefixFile, opts.Encoding, opts.delimiter)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.SuffixFile != "" {
+		opts.suffixes, err = readLines(opts.SuffixFile, opts.Encoding, opts.delimiter)
+		if err != nil {
+			return err
+		}
+	}
+
+	// filter values (reverse, shard, prefix and suffix, skip, limit)
+	valueCh, countCh = setupValueFilters(ctx, opts, valueCh, countCh)
+
+	// limit the throughput (if requested), only values which are actually
+	// sent are delayed, for several targets this is done for the requests
+	// to each target instead (see targetInputs)
+	if opts.RequestsPerSecond > 0 && len(opts.targets) == 0 {
+		valueCh = producer.Limit(ctx, opts.RequestsPerSecond, opts.Burst, valueCh)
+	}
+
+	// each value is sent to all targets
+	if len(opts.targets) > 0 {
+		countCh = producer.FanOutCount(ctx, countCh, len(opts.targets))
+	}
+
+	// slow down when the server returns too many errors
+	var backoff *producer.Backoff
+	if opts.BackoffOn5xx || opts.statusPolicy.Has(response.ActionBackoff) {
+		backoff = &producer.Backoff{
+			Threshold: opts.BackoffThreshold,
+			Log: func(msg string) {
+				term.Printf("backoff: %v\n", msg)
+			},
+		}
+		valueCh = backoff.Select(ctx, valueCh)
+	}
+
+	// load the cookies from the file and establish a session before the
+	// scan starts, both are sent with all requests
+	var jar http.CookieJar
+	cookies, warnings, err := opts.Request.CookieJar()
+	if err != nil {
+		return err
+	}
+	for _, msg := range warnings {
+		term.Printf("warning: %v\n", msg)
+	}
+	if cookies != nil {
+		jar = readOnlyJar{cookies}
+	}
+
+	if opts.WarmupURL != "" {
+		var msg string
+		jar, msg, err = warmup(ctx, opts, inputURL, cookies)
+		if err != nil {
+			return err
+		}
+		term.Printf("%v\n", msg)
+	}
+
+	// learn the fingerprints of the bodies to hide before the scan starts
+	if len(opts.HideSimilarTo) > 0 {
+		baselines, err := learnBaselines(ctx, opts, term, jar)
+		if err != nil {
+			return err
+		}
+
+		responseFilters = append(responseFilters, response.FilterSimilar{
+			Baselines: baselines,
+			Distance:  opts.SimilarDistance,
+		})
+	}
+
+	// all runners share the maximum number of requests, the baseline
+	// requests are not counted
+	if opts.MaxRequests > 0 {
+		opts.budget = &response.Budget{Max: int64(opts.MaxRequests)}
+	}
+
+	// this filter needs to be the last one, so that only the responses which
+	// are shown count
+	if opts.HideDuplicateHeaders {
+		responseFilters = append(responseFilters, &response.FilterDuplicateHeaders{})
+	}
+
+	// start the runners
+	responseCh, err := startRunners(ctx, opts, term, jar, valueCh)
+	if err != nil {
+		return err
+	}
+
+	// record the durations of all responses, also hidden ones
+	if opts.TimingDump != "" {
+		dump, err := recorder.NewTimingDump(opts.TimingDump)
+		if err != nil {
+			return err
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return dump.Run(ctx, in, out)
+		})
+	}
+
+	// warn once for each host requests to which were blocked
+	if len(opts.AllowedHosts) > 0 {
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			defer close(out)
+			warned := make(map[string]struct{})
+			for res := range in {
+				if host, ok := res.BlockedHost(); ok {
+					if _, ok := warned[host]; !ok {
+						warned[host] = struct{}{}
+						term.Printf("warning: blocked request to host %v for value %q, it is not allowed by --allowed-hosts\n", host, res.Item)
+					}
+				}
+
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+
+	// warn once if many requests seem to be blocked by a WAF
+	if opts.ShowWAF {
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			defer close(out)
+			blocked := 0
+			for res := range in {
+				if res.WAF != "" {
+					blocked++
+					if blocked == wafWarnResponses {
+						term.Printf("warning: %d responses look like block pages of a web application firewall (last: %v), consider reducing the rate with --requests-per-second\n", blocked, res.WAF)
+					}
+				}
+
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+
+	// abort the run when too many requests failed, the error is set before
+	// the output channel is closed
+	var abortErr error
+	if opts.MaxErrors > 0 || opts.MaxConsecutiveErrors > 0 {
+		limit := &response.ErrorLimit{
+			Max:            opts.MaxErrors,
+			MaxConsecutive: opts.MaxConsecutiveErrors,
+			ServerErrors:   opts.MaxErrors5xx,
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			defer close(out)
+			for res := range in {
+				err := limit.Record(res)
+
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return nil
+				}
+
+				if err != nil && abortErr == nil {
+					abortErr = err
+					term.Printf("aborting the run: %v\n", err)
+					stopRun()
+				}
+			}
+			return nil
+		})
+	}
+
+	// evaluate the assertion for all responses, regardless of the filters
+	if opts.assertion != nil {
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			defer close(out)
+			for res := range in {
+				opts.assertion.Record(res)
+
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+
+	// filter the responses
+	responseCh = response.Mark(responseCh, responseFilters)
+
+	// group the displayed responses for the report at the end
+	var clusters *response.Clusters
+	if opts.ClusterReport {
+		clusters = &response.Clusters{Distance: opts.SimilarDistance}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			defer close(out)
+			for res := range in {
+				if !res.Hide {
+					clusters.Add(res)
+				}
+
+				select {
+				case out <- res:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+
+	// slow down and stop the run for the status codes configured with
+	// --on-status and --backoff-on-5xx
+	if backoff != nil || opts.statusPolicy.Has(response.ActionStop) {
+		actions := &response.StatusActions{
+			Policy:       opts.statusPolicy,
+			BackoffOn5xx: opts.BackoffOn5xx,
+			Stop: func(res response.Response) {
+				term.Printf("stopping the run (--on-status): received status %d for value %q\n", res.Status(), res.Item)
+				stopRun()
+			},
+		}
+		if backoff != nil {
+			actions.Backoff = backoff.Record
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return actions.Run(ctx, in, out)
+		})
+	}
+
+	// extract data from all interesting (non-hidden) responses
+	responseCh = newExtracter(opts, term).Run(responseCh)
+
+	if opts.onMatch != nil {
+		onMatch := &response.OnMatch{
+			Command:     opts.onMatch,
+			Concurrency: opts.OnMatchConcurrency,
+			Error: func(err error) {
+				term.Printf("%v\n", err)
+			},
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return onMatch.Run(ctx, in, out)
+		})
+	}
+
+	if logfilePrefix != "" {
+		rec, err := recorder.New(logfilePrefix+".json", opts.Request)
+		if err != nil {
+			return err
+		}
+		rec.MaxSize = opts.logMaxSize
 
 		// fill in information for generating the request
 		rec.Data.InputFile = opts.Filename
+		rec.Data.Zip = opts.Zip
+		rec.Data.CSV = opts.CSV
+		if opts.CSV != "" {
+			rec.Data.CSVColumn = opts.CSVColumn
+		}
 		rec.Data.Ranges = opts.Range
 		rec.Data.RangeFormat = opts.RangeFormat
+		rec.Data.RangeStep = opts.RangeStep
+		if opts.RangeMode == "geometric" {
+			rec.Data.RangeFactor = opts.RangeFactor
+		}
+		if opts.RangeRandom > 0 {
+			rec.Data.RangeRandom = opts.RangeRandom
+			rec.Data.RangeUnique = opts.RangeUnique
+			rec.Data.RangeSeed = opts.RangeSeed
+		}
+		if len(opts.FileWeighted) > 0 {
+			rec.Data.FileWeighted = opts.FileWeighted
+			rec.Data.FileWeightedSeed = opts.FileWeightedSeed
+		}
+		rec.Data.Mutate = opts.Mutate
+		if opts.Mutate != "" {
+			rec.Data.MutateCount = opts.MutateCount
+			rec.Data.MutateSeed = opts.MutateSeed
+		}
+		rec.Data.RawRequestDir = opts.RawRequestDir
+		rec.Data.Targets = opts.targets
 		rec.Data.Extract = opts.Extract
 		rec.Data.ExtractPipe = opts.ExtractPipe
+		rec.Data.RecordHeaders = opts.RecordHeader
 
 		out := make(chan response.Response)
 		in := responseCh
@@ -453,8 +1991,174 @@ func run(ctx context.Context, g *errgroup.Group, opts *Options, args []string) e
 		})
 	}
 
+	if opts.SaveResponses != "" {
+		save, err := recorder.NewSaveResponses(opts.SaveResponses)
+		if err != nil {
+			return err
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return save.Run(ctx, in, out)
+		})
+	}
+
+	if opts.CurlFile != "" {
+		curl, err := recorder.NewCurlFile(opts.CurlFile, opts.Request)
+		if err != nil {
+			return err
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return curl.Run(ctx, in, out)
+		})
+	}
+
+	if opts.OutputBurp != "" {
+		burp, err := recorder.NewBurp(opts.OutputBurp)
+		if err != nil {
+			return err
+		}
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return burp.Run(ctx, in, out)
+		})
+	}
+
+	var publisher *recorder.Publisher
+	if opts.Publish != "" {
+		publisher, err = recorder.NewPublisher(ctx, opts.Publish, opts.PublishQueue)
+		if err != nil {
+			return err
+		}
+		publisher.Drop = opts.PublishDrop
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return publisher.Run(ctx, in, out)
+		})
+	}
+
+	// start serving metrics only after all setup which may fail, from then on
+	// the server is shut down by Run
+	if opts.MetricsAddr != "" {
+		m, err := metrics.New(opts.MetricsAddr, func() int { return len(vch) })
+		if err != nil {
+			return err
+		}
+
+		term.Printf("serving metrics on http://%v/metrics\n", m.Addr())
+
+		out := make(chan response.Response)
+		in := responseCh
+		responseCh = out
+
+		g.Go(func() error {
+			return m.Run(ctx, in, out)
+		})
+	}
+
 	// run the reporter
-	term.Printf("input URL %v\n\n", inputURL)
-	reporter := reporter.New(term)
-	return reporter.Display(responseCh, countCh)
+	if !opts.NoBanner {
+		if opts.Mutate != "" {
+			term.Printf("mutating seed value %q, random seed %d\n", opts.Mutate, opts.MutateSeed)
+		}
+		if opts.RangeRandom > 0 {
+			term.Printf("choosing %d random values from the ranges, random seed %d\n", opts.RangeRandom, opts.RangeSeed)
+		}
+		if len(opts.FileWeighted) > 0 {
+			term.Printf("interleaving values from %d files by weight, random seed %d\n", len(opts.FileWeighted), opts.FileWeightedSeed)
+		}
+		if len(opts.targets) > 0 {
+			term.Printf("sending the values to %d targets from %v, %d threads each\n\n", len(opts.targets), opts.TargetsFile, opts.Threads)
+		} else {
+			term.Printf("input URL %v\n\n", inputURL)
+		}
+	}
+	var shown func() int
+	if tui != nil {
+		// quitting the interface stops the run
+		tui.Stop = stopRun
+		tui.Columns = response.Columns{Reflected: opts.ShowReflected, TTFB: opts.PrintTTFB, WAF: opts.ShowWAF, Trailers: opts.ShowTrailers}
+		if lt, ok := term.(*cli.LogTerminal); ok {
+			tui.Log = lt.Writer
+			tui.CheckpointInterval = opts.CheckpointInterval
+			tui.CheckpointPercent = opts.CheckpointPercent
+		}
+		err = tui.Display(responseCh, countCh)
+		shown = tui.Shown
+	} else {
+		reporter := reporter.New(term)
+		reporter.Explain = opts.Explain
+		reporter.NoBanner = opts.NoBanner
+		reporter.Collapse = opts.Collapse
+		reporter.ShowReflected = opts.ShowReflected
+		reporter.ShowTTFB = opts.PrintTTFB
+		reporter.ShowWAF = opts.ShowWAF
+		reporter.ShowTrailers = opts.ShowTrailers
+		if lt, ok := term.(*cli.LogTerminal); ok {
+			reporter.Log = lt.Writer
+			reporter.CheckpointInterval = opts.CheckpointInterval
+			reporter.CheckpointPercent = opts.CheckpointPercent
+		}
+		err = reporter.Display(responseCh, countCh)
+		shown = reporter.Shown
+	}
+	if err != nil {
+		return err
+	}
+
+	if publisher != nil && publisher.Dropped() > 0 {
+		term.Printf("warning: dropped %d messages for --publish, the server was too slow\n", publisher.Dropped())
+	}
+
+	if duplicates != nil && duplicates.Duplicates() > 0 {
+		term.Printf("warning: wordlist contained %d duplicate values\n", duplicates.Duplicates())
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		term.Printf("run stopped after reaching the maximum duration of %v\n", opts.MaxDuration)
+	}
+
+	if opts.budget != nil && opts.budget.Exhausted() {
+		term.Printf("reached the maximum number of %d requests (--max-requests)\n", opts.MaxRequests)
+	}
+
+	if clusters != nil {
+		printClusters(term, clusters.List())
+	}
+
+	if abortErr != nil {
+		return &cli.ExitError{Code: exitCodeAborted, Message: "run aborted, too many requests failed (--max-errors, --max-consecutive-errors)"}
+	}
+
+	if opts.assertion != nil {
+		term.Printf("assertion (--assert-mode %v): %d passed, %d failed\n", opts.AssertMode, opts.assertion.Passed(), opts.assertion.Failed())
+		if err := opts.assertion.Err(); err != nil {
+			return &cli.ExitError{Code: exitCodeMatch, Message: fmt.Sprintf("assertion failed: %v", err)}
+		}
+	}
+
+	switch {
+	case opts.ExitOnMatch && shown() == 0:
+		return &cli.ExitError{Code: exitCodeMatch, Message: "no matches found (--exit-on-match)"}
+	case opts.ExitOnNoMatch && shown() > 0:
+		return &cli.ExitError{Code: exitCodeMatch, Message: fmt.Sprintf("%d matches found (--exit-on-no-match)", shown())}
+	}
+
+	return nil
 }
@@ -0,0 +1,297 @@
+package fuzz
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestOpenURL(t *testing.T) {
+	var tests = []struct {
+		status   int
+		data     string
+		encoding string
+		want     string
+		err      string
+	}{
+		{
+			status: http.StatusOK,
+			data:   "one\ntwo\n",
+			want:   "one\ntwo\n",
+		},
+		{
+			// byte order mark for UTF-16LE
+			status: http.StatusOK,
+			data:   "\xff\xfeo\x00n\x00e\x00\n\x00",
+			want:   "one\n",
+		},
+		{
+			status:   http.StatusOK,
+			data:     "caf\xe9\n",
+			encoding: "latin1",
+			want:     "café\n",
+		},
+		{
+			status:   http.StatusOK,
+			data:     "one\n",
+			encoding: "ebcdic",
+			err:      `unknown input encoding "ebcdic"`,
+		},
+		{
+			status: http.StatusNotFound,
+			data:   "not found",
+			err:    "unexpected status 404 Not Found",
+		},
+		{
+			status: http.StatusInternalServerError,
+			data:   "one\ntwo\n",
+			err:    "unexpected status 500 Internal Server Error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if ua := req.Header.Get("User-Agent"); ua != request.DefaultHeader.Get("User-Agent") {
+					t.Errorf("wrong User-Agent header %q", ua)
+				}
+
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.data))
+			}))
+			defer srv.Close()
+
+			rd, err := openURL(context.Background(), request.New(""), srv.URL+"/list.txt", test.encoding)
+			if test.err != "" {
+				if err == nil {
+					_ = rd.Close()
+					t.Fatalf("expected error %q not found", test.err)
+				}
+
+				if !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("wrong error, want %q, got %q", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf, err := ioutil.ReadAll(rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = rd.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(buf) != test.want {
+				t.Errorf("wrong data returned, want %q, got %q", test.want, buf)
+			}
+		})
+	}
+}
+
+// produce runs setupProducer for opts and returns the values and the count.
+func produce(t *testing.T, opts *Options) ([]string, []int) {
+	ch := make(chan string)
+	count := make(chan int, 1)
+
+	var g errgroup.Group
+	err := setupProducer(context.Background(), &g, opts, ch, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	for v := range ch {
+		values = append(values, v)
+	}
+
+	err = g.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(count)
+
+	var counts []int
+	for n := range count {
+		counts = append(counts, n)
+	}
+
+	return values, counts
+}
+
+func TestProducerURL(t *testing.T) {
+	const data = "one\ntwo\n\nfour\nfive"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(data))
+	}))
+	defer srv.Close()
+
+	values, counts := produce(t, &Options{
+		Filename:  srv.URL + "/list.txt",
+		Request:   request.New(""),
+		delimiter: '\n',
+	})
+
+	// read the same data from stdin
+	rd, wr, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_, _ = wr.Write([]byte(data))
+		_ = wr.Close()
+	}()
+
+	stdin := os.Stdin
+	os.Stdin = rd
+	defer func() {
+		os.Stdin = stdin
+	}()
+
+	stdinValues, stdinCounts := produce(t, &Options{
+		Filename:  "-",
+		Request:   request.New(""),
+		delimiter: '\n',
+	})
+
+	want := []string{"one", "two", "", "four", "five"}
+	if !cmp.Equal(want, values) {
+		t.Errorf("wrong values: %v", cmp.Diff(want, values))
+	}
+
+	if !cmp.Equal(stdinValues, values) {
+		t.Errorf("values differ from stdin: %v", cmp.Diff(stdinValues, values))
+	}
+
+	// the count is sent when all values have been read, like for stdin
+	if !cmp.Equal([]int{len(want)}, counts) {
+		t.Errorf("wrong count, want %v, got %v", len(want), counts)
+	}
+
+	if !cmp.Equal(stdinCounts, counts) {
+		t.Errorf("count differs from stdin: %v", cmp.Diff(stdinCounts, counts))
+	}
+}
+
+// testTerminal collects the lines printed.
+type testTerminal struct {
+	lines []string
+}
+
+func (t *testTerminal) Printf(msg string, data ...interface{}) {
+	t.lines = append(t.lines, fmt.Sprintf(msg, data...))
+}
+
+func (t *testTerminal) Print(msg string) {
+	t.lines = append(t.lines, msg)
+}
+
+func (t *testTerminal) SetStatus([]string)      {}
+func (t *testTerminal) Run(ctx context.Context) {}
+
+func TestExtractTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Token", "hdr123")
+		_, _ = w.Write([]byte("<p>bdy456</p>"))
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		target string
+		want   []string
+	}{
+		{"body", []string{"bdy456"}},
+		{"headers", []string{"hdr123"}},
+		{"all", []string{"hdr123", "bdy456"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+
+			opts := &Options{
+				Request:       template,
+				Extract:       []string{"[a-z]{3}[0-9]{3}"},
+				extract:       []*regexp.Regexp{regexp.MustCompile("[a-z]{3}[0-9]{3}")},
+				ExtractTarget: test.target,
+				MaxBodySize:   1,
+			}
+			// like in valid()
+			opts.NoBody = !opts.needBody()
+
+			tr, err := response.NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			term := &testTerminal{}
+			in := make(chan string, 1)
+			in <- "test"
+			close(in)
+
+			out := make(chan response.Response, 1)
+			newRunner(opts, term, nil, tr, nil, template, in, out).Run(context.Background())
+			close(out)
+
+			var extracted [][]string
+			for res := range newExtracter(opts, term).Run(out) {
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				extracted = append(extracted, res.Extract)
+			}
+
+			if !cmp.Equal([][]string{test.want}, extracted) {
+				t.Error(cmp.Diff([][]string{test.want}, extracted))
+			}
+
+			if len(term.lines) > 0 {
+				t.Errorf("unexpected messages: %v", term.lines)
+			}
+		})
+	}
+}
+
+func TestNeedGet(t *testing.T) {
+	var tests = []struct {
+		opts Options
+		want bool
+	}{
+		{Options{}, false},
+		{Options{HideStatusCodes: []string{"404"}}, false},
+		{Options{HidePattern: []string{"x"}}, true},
+		{Options{HideBodySize: []string{"0"}}, true},
+		{Options{ExtractPipe: []string{"wc -c"}}, true},
+		{Options{ExtractPipe: []string{"wc -c"}, ExtractStream: true}, true},
+		{Options{ShowTrailers: true}, true},
+		{Options{Extract: []string{"x"}, ExtractTarget: "headers"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if got := test.opts.needGet(); got != test.want {
+				t.Errorf("wrong result, want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
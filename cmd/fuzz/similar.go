@@ -0,0 +1,95 @@
+package fuzz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/RedTeamPentesting/monsoon/cli"
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// learnBaselines sends a request for each value in opts.HideSimilarTo before
+// the scan in the same way as the runners do and returns the fingerprints of
+// the bodies. The results are printed to term.
+func learnBaselines(ctx context.Context, opts *Options, term cli.Terminal, jar http.CookieJar) ([]uint64, error) {
+	transport, err := response.NewTransport(opts.Request, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.CloseIdleConnections()
+
+	var insecureTransport *http.Transport
+	if len(opts.Request.InsecureHosts) > 0 {
+		insecureTransport, err = response.NewInsecureTransport(opts.Request, 1)
+		if err != nil {
+			return nil, err
+		}
+		defer insecureTransport.CloseIdleConnections()
+	}
+
+	in := make(chan string, len(opts.HideSimilarTo))
+	for _, value := range opts.HideSimilarTo {
+		in <- value
+	}
+	close(in)
+
+	out := make(chan response.Response, len(opts.HideSimilarTo))
+
+	template := *opts.Request
+	template.ThreadID = 1
+
+	runner := newRunner(opts, term, jar, transport, insecureTransport, &template, in, out)
+	runner.Simhash = true
+	runner.HeadFirst = false // the body is needed for the fingerprint
+	runner.Run(ctx)
+	close(out)
+
+	var baselines []uint64
+	for res := range out {
+		if res.Error != nil {
+			return nil, fmt.Errorf("baseline request for value %q failed: %v", res.Item, res.Error)
+		}
+
+		term.Printf("baseline for value %q: status %v, %d bytes, simhash %v\n",
+			res.Item, res.HTTPResponse.StatusCode, res.Body.Bytes, response.FormatSimhash(res.Simhash))
+		baselines = append(baselines, res.Simhash)
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return baselines, nil
+}
+
+// maxClusters is the maximum number of clusters printed by printClusters.
+const maxClusters = 20
+
+// printClusters prints the clusters for --cluster-report as a table.
+func printClusters(term cli.Terminal, clusters []response.Cluster) {
+	total := 0
+	for _, cl := range clusters {
+		total += cl.Count
+	}
+
+	term.Printf("\n%d clusters of similar responses for %d displayed responses:\n", len(clusters), total)
+	if len(clusters) == 0 {
+		return
+	}
+
+	term.Printf("%8s %7s %15s %18s   %v\n", "count", "status", "body size", "simhash", "example")
+	for i, cl := range clusters {
+		if i == maxClusters {
+			term.Printf("and %d more clusters\n", len(clusters)-maxClusters)
+			break
+		}
+
+		size := fmt.Sprintf("%d", cl.MinSize)
+		if cl.MaxSize != cl.MinSize {
+			size = fmt.Sprintf("%d-%d", cl.MinSize, cl.MaxSize)
+		}
+
+		term.Printf("%8d %7d %15s %18s   %q\n", cl.Count, cl.Status, size, response.FormatSimhash(cl.Simhash), cl.Example)
+	}
+}
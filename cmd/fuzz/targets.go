@@ -0,0 +1,99 @@
+package fuzz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/RedTeamPentesting/monsoon/producer"
+)
+
+// readTargets returns the target URLs from the file, one per line. Empty
+// lines and lines starting with # are ignored. The placeholder may be used
+// like in the URL passed as an argument.
+func readTargets(filename, placeholder string) (targets []string, err error) {
+	lines, err := readLines(filename, "auto", '\n')
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	for _, line := range lines {
+		target := strings.TrimSpace(line)
+		if target == "" || strings.HasPrefix(target, "#") {
+			continue
+		}
+
+		if _, err := parseTarget(target, placeholder); err != nil {
+			return nil, fmt.Errorf("invalid target %q in %v: %v", target, filename, err)
+		}
+
+		// the targets are used to tell the responses apart
+		if _, ok := seen[target]; ok {
+			return nil, fmt.Errorf("target %q is listed more than once in %v", target, filename)
+		}
+		seen[target] = struct{}{}
+
+		targets = append(targets, target)
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no targets found in %v", filename)
+	}
+
+	return targets, nil
+}
+
+// parseTarget parses the target URL. If the placeholder is used in the port,
+// the URL cannot be parsed with it, so it is replaced with 0.
+func parseTarget(target, placeholder string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil && placeholder != "" {
+		u, err = url.Parse(strings.Replace(target, placeholder, "0", -1))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	return u, nil
+}
+
+// targetInputs passes each value from in on to all targets in opts.targets
+// and returns the channels for the runners of each target. All requests share
+// the rate set with --requests-per-second, and all requests to the same host
+// share the rate set with --requests-per-second-per-host, a request is only
+// sent when both allow it.
+func targetInputs(ctx context.Context, opts *Options, in <-chan string) []<-chan string {
+	inputs := producer.FanOut(ctx, in, len(opts.targets), opts.Threads)
+
+	var global *producer.Limiter
+	if opts.RequestsPerSecond > 0 {
+		global = producer.NewLimiter(opts.RequestsPerSecond, opts.Burst)
+	}
+
+	hosts := make(map[string]*producer.Limiter)
+	for i, target := range opts.targets {
+		if opts.RequestsPerSecondPerHost > 0 {
+			// the targets have been checked when the file was read
+			u, _ := parseTarget(target, opts.Request.Replace)
+
+			limiter, ok := hosts[u.Host]
+			if !ok {
+				limiter = producer.NewLimiter(opts.RequestsPerSecondPerHost, opts.Burst)
+				hosts[u.Host] = limiter
+			}
+			inputs[i] = limiter.Limit(ctx, inputs[i])
+		}
+
+		if global != nil {
+			inputs[i] = global.Limit(ctx, inputs[i])
+		}
+	}
+
+	return inputs
+}
@@ -0,0 +1,92 @@
+package fuzz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// readOnlyJar returns the cookies stored in the jar, but ignores all cookies
+// set in responses, so that all requests use the same session.
+type readOnlyJar struct {
+	http.CookieJar
+}
+
+// SetCookies does nothing.
+func (readOnlyJar) SetCookies(*url.URL, []*http.Cookie) {}
+
+// warmup sends a GET request to the warmup URL, which may be relative to
+// target, and returns a jar with the cookies the server set. If cookies is not
+// nil, the cookies in it are sent with the request and kept. The message
+// describes the result for the user.
+func warmup(ctx context.Context, opts *Options, target string, cookies *cookiejar.Jar) (jar http.CookieJar, msg string, err error) {
+	base, err := url.Parse(target)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ref, err := url.Parse(opts.WarmupURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid warmup URL: %v", err)
+	}
+	u := base.ResolveReference(ref)
+
+	// the warmup request is subject to --allowed-hosts like all other requests
+	var allowed response.HostList
+	if len(opts.AllowedHosts) > 0 {
+		allowed = response.HostList(opts.AllowedHosts)
+	}
+
+	err = allowed.Check(u)
+	if err != nil {
+		return nil, "", fmt.Errorf("warmup request failed: %v", err)
+	}
+
+	tr, err := response.NewClientTransport(opts.Request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if cookies == nil {
+		cookies, err = cookiejar.New(nil)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	client := &http.Client{
+		Transport:     tr,
+		Jar:           cookies,
+		CheckRedirect: opts.checkRedirect,
+	}
+	if allowed != nil {
+		client.CheckRedirect = allowed.CheckRedirect(client.CheckRedirect)
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for name, values := range request.DefaultHeader {
+		req.Header[name] = values
+	}
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, "", fmt.Errorf("warmup request failed: %v", err)
+	}
+
+	err = res.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	msg = fmt.Sprintf("warmup request to %v returned status %v, received %d cookies", u, res.Status, len(cookies.Cookies(u)))
+	return readOnlyJar{cookies}, msg, nil
+}
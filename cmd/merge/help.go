@@ -0,0 +1,32 @@
+package merge
+
+import "strings"
+
+const helpShort = "Combine the results of several runs of 'fuzz'"
+
+var helpLong = strings.TrimSpace(`
+The 'merge' command reads the JSON data written by several runs of the 'fuzz'
+command (with --logfile or --logdir), e.g. for the shards of a scan run on
+different machines with --shard, and writes the combined data in the same
+format, so it can be used with 'diff' and 'repro'.
+
+The responses are sorted by value. If a value was recorded more than once with
+the same response (status code, error, body size, words and lines, and
+extracted data like for 'diff'), it is only kept once. If the responses differ,
+the one from the first file containing the value is kept and the conflict is
+reported. For runs with --targets-file, the responses for each target are
+merged separately. The request and the input options are taken from the first
+file, a warning is printed if the request or the targets in another file are
+different. The start and end time cover all runs, and the numbers of requests
+are added up.
+`)
+
+const helpExamples = `
+Combine the data of three shards into one file:
+
+    monsoon merge --output all.json shard1.json shard2.json shard3.json
+
+Fail if the runs recorded different responses for the same value:
+
+    monsoon merge --fail-on-conflicts run1.json run2.json > merged.json
+`
@@ -0,0 +1,118 @@
+package merge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/RedTeamPentesting/monsoon/recorder"
+	"github.com/spf13/cobra"
+)
+
+// Options collect options for the command.
+type Options struct {
+	Output          string
+	FailOnConflicts bool
+}
+
+var opts Options
+
+// AddCommand adds the command to c.
+func AddCommand(c *cobra.Command) {
+	c.AddCommand(cmd)
+
+	fs := cmd.Flags()
+	fs.SortFlags = false
+
+	fs.StringVarP(&opts.Output, "output", "o", "", "write the merged JSON data to `file` instead of stdout")
+	fs.BoolVar(&opts.FailOnConflicts, "fail-on-conflicts", false, "exit with an error (after writing the data) if the files contain different responses for a value")
+}
+
+var cmd = &cobra.Command{
+	Use:                   "merge [options] FILE...",
+	DisableFlagsInUseLine: true,
+
+	Short:   helpShort,
+	Long:    helpLong,
+	Example: helpExamples,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("need at least one file with JSON data")
+		}
+
+		return run(opts, args)
+	},
+}
+
+func run(opts Options, filenames []string) error {
+	var runs []recorder.Data
+	for _, filename := range filenames {
+		data, err := recorder.Load(filename)
+		if err != nil {
+			return err
+		}
+
+		if len(runs) > 0 && !reflect.DeepEqual(runs[0].Template, data.Template) {
+			fmt.Fprintf(os.Stderr, "warning: the request in %v differs from the one in %v, which is used\n", filename, filenames[0])
+		}
+
+		if len(runs) > 0 && !reflect.DeepEqual(runs[0].Targets, data.Targets) {
+			fmt.Fprintf(os.Stderr, "warning: the targets in %v differ from the ones in %v, which are used\n", filename, filenames[0])
+		}
+
+		runs = append(runs, data)
+	}
+
+	merged, conflicts := recorder.Merge(runs)
+
+	for _, conflict := range conflicts {
+		if conflict.Target != "" {
+			fmt.Fprintf(os.Stderr, "conflict for value %q, target %v:\n", conflict.Item, conflict.Target)
+		} else {
+			fmt.Fprintf(os.Stderr, "conflict for value %q:\n", conflict.Item)
+		}
+		for i, res := range conflict.Responses {
+			fmt.Fprintf(os.Stderr, "  %v: %v\n", filenames[conflict.Runs[i]], formatResponse(res))
+		}
+	}
+
+	buf, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	if opts.Output != "" {
+		err = ioutil.WriteFile(opts.Output, buf, 0644)
+	} else {
+		_, err = os.Stdout.Write(buf)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "merged %d files, %d responses, %d conflicts\n", len(filenames), len(merged.Responses), len(conflicts))
+
+	if opts.FailOnConflicts && len(conflicts) > 0 {
+		return fmt.Errorf("%d values with conflicting responses", len(conflicts))
+	}
+
+	return nil
+}
+
+// formatResponse returns the status and the body size of res.
+func formatResponse(res recorder.Response) string {
+	if res.Error != "" {
+		return "error: " + res.Error
+	}
+
+	s := fmt.Sprintf("status %d, body %d bytes", res.StatusCode, res.Body.Bytes)
+	if len(res.ExtractedData) > 0 {
+		s += fmt.Sprintf(", data: %q", res.ExtractedData)
+	}
+	return s
+}
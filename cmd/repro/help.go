@@ -0,0 +1,38 @@
+package repro
+
+import "strings"
+
+const helpShort = "Print curl commands to reproduce recorded requests"
+
+var helpLong = strings.TrimSpace(`
+The 'repro' command reads the JSON data written by the 'fuzz' command (with
+--logfile or --logdir) and prints a curl command for each recorded response,
+which sends the same request again: the method, URL, headers and body are
+taken from the template in the file, with the placeholder replaced by the
+value. If values are passed after the file name, only the commands for these
+values are printed, which is useful for sharing a finding. For runs with
+--targets-file, the URL of the target each response was received from is used
+and a value selects the responses for all targets. Values which were split
+with --fields are inserted for the names of the fields like by 'fuzz', pass
+the whole line to select a value. The arguments are quoted for a POSIX shell.
+
+The template is recorded with the placeholder inserted as it is, so options
+which transform the value or compute data from it (like --data-urlencode,
+--expand-templates or --aws-access-key) and options which are not part of the
+request (like --insecure or a proxy) are not reproduced. Raw requests
+(--raw-request and --raw-request-dir) are not recorded in the template, so
+they cannot be reproduced. Only the responses which were not hidden are saved
+in the JSON data.
+`)
+
+const helpExamples = `
+Print the curl command for the value 'admin' from a run saved in the log
+directory:
+
+    monsoon repro logs/monsoon_example.com_20200101_120000.json admin
+
+Print the commands for all recorded responses of a run which used the
+placeholder XX, which was not recorded by older versions:
+
+    monsoon repro --placeholder XX old.json
+`
@@ -0,0 +1,122 @@
+package repro
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/RedTeamPentesting/monsoon/recorder"
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/spf13/cobra"
+)
+
+// Options collect options for the command.
+type Options struct {
+	Placeholder string
+}
+
+var opts Options
+
+// AddCommand adds the command to c.
+func AddCommand(c *cobra.Command) {
+	c.AddCommand(cmd)
+
+	fs := cmd.Flags()
+	fs.SortFlags = false
+
+	fs.StringVar(&opts.Placeholder, "placeholder", "", "replace `string` in the template by the value (default: the placeholder recorded in the file, or FUZZ)")
+}
+
+var cmd = &cobra.Command{
+	Use:                   "repro [options] FILE [VALUE...]",
+	DisableFlagsInUseLine: true,
+
+	Short:   helpShort,
+	Long:    helpLong,
+	Example: helpExamples,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("need the file with the JSON data")
+		}
+
+		return run(opts, args[0], args[1:])
+	},
+}
+
+func run(opts Options, filename string, values []string) error {
+	data, err := recorder.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	placeholder := opts.Placeholder
+	if placeholder == "" {
+		placeholder = data.Placeholder
+	}
+	if placeholder == "" {
+		// files written before the placeholder was recorded
+		placeholder = "FUZZ"
+	}
+
+	// values which were split into fields with --fields are inserted like
+	// for the original request
+	var fields *request.Request
+	if len(data.Fields) > 0 {
+		fields = &request.Request{Replace: placeholder, Fields: data.Fields, FieldSep: data.FieldSep}
+	}
+
+	responses := data.Responses
+	if len(values) > 0 {
+		// with --targets-file, each value was sent to several targets
+		type key struct {
+			target, item string
+		}
+		recorded := make(map[key]recorder.Response)
+		targets := make(map[string][]string)
+		for _, res := range data.Responses {
+			k := key{res.Target, res.Item}
+			if _, ok := recorded[k]; !ok {
+				targets[res.Item] = append(targets[res.Item], res.Target)
+			}
+			recorded[k] = res
+		}
+
+		responses = nil
+		for _, value := range values {
+			if len(targets[value]) == 0 {
+				return fmt.Errorf("value %q not found in %v", value, filename)
+			}
+			for _, target := range targets[value] {
+				responses = append(responses, recorded[key{target, value}])
+			}
+		}
+	}
+
+	for _, res := range responses {
+		if len(responses) > 1 {
+			status := res.StatusText
+			if res.Error != "" {
+				status = res.Error
+			}
+			if res.Target != "" {
+				fmt.Printf("# %v (%v): %v\n", res.Item, res.Target, status)
+			} else {
+				fmt.Printf("# %v: %v\n", res.Item, status)
+			}
+		}
+
+		// the request was sent to the URL of the response's own target
+		template := data.Template
+		if res.Target != "" {
+			template.URL = res.Target
+		}
+
+		if fields != nil {
+			fmt.Println(template.CurlInsert(fields.Inserter(res.Item)))
+		} else {
+			fmt.Println(template.Curl(placeholder, res.Item))
+		}
+	}
+
+	return nil
+}
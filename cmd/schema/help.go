@@ -0,0 +1,29 @@
+package schema
+
+import "strings"
+
+const helpShort = "Print the JSON schema of the data written by 'fuzz'"
+
+var helpLong = strings.TrimSpace(`
+The 'schema' command prints a JSON schema (draft 7) describing the JSON data
+written by the 'fuzz' command with --logfile or --logdir, which is also read by
+'list' and 'diff'. The schema is generated from the data structures, so it
+always matches the current version of monsoon.
+
+Every file contains the version of the data format in the field "version"
+(files written before it was introduced have no version and are treated as
+version 0), which must match the version printed with --format-version. New
+fields are only ever added within a version (and omitted when empty), so
+programs reading the data should ignore fields they don't know. The version is
+increased when existing fields are changed or removed.
+`)
+
+const helpExamples = `
+Print the JSON schema:
+
+    monsoon schema
+
+Check that a tool supports the data written by this version:
+
+    test "$(monsoon schema --format-version)" = 1
+`
@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/RedTeamPentesting/monsoon/recorder"
+	"github.com/spf13/cobra"
+)
+
+// Options collect options for the command.
+type Options struct {
+	FormatVersion bool
+}
+
+var opts Options
+
+// AddCommand adds the command to c.
+func AddCommand(c *cobra.Command) {
+	c.AddCommand(cmd)
+
+	fs := cmd.Flags()
+	fs.SortFlags = false
+
+	fs.BoolVar(&opts.FormatVersion, "format-version", false, "only print the version of the data format")
+}
+
+var cmd = &cobra.Command{
+	Use:                   "schema [options]",
+	DisableFlagsInUseLine: true,
+
+	Short:   helpShort,
+	Long:    helpLong,
+	Example: helpExamples,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("no arguments allowed")
+		}
+
+		return run(opts)
+	},
+}
+
+func run(opts Options) error {
+	if opts.FormatVersion {
+		_, err := fmt.Printf("%d\n", recorder.FormatVersion)
+		return err
+	}
+
+	buf, err := json.MarshalIndent(recorder.Schema(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Printf("%s\n", buf)
+	return err
+}
@@ -41,7 +41,7 @@ var cmd = &cobra.Command{
 	Example: helpExamples,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
+		if len(args) == 0 && opts.Request.RequestFile == "" {
 			return errors.New("last argument needs to be the URL")
 		}
 
@@ -49,7 +49,20 @@ var cmd = &cobra.Command{
 			return errors.New("more than one target URL specified")
 		}
 
-		opts.Request.URL = args[0]
+		if len(args) == 1 {
+			opts.Request.URL = args[0]
+		}
+
+		if opts.Request.RequestFile != "" {
+			err := opts.Request.LoadRequestFile(opts.Request.RequestFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		if opts.Request.RawMode() {
+			return showRaw(opts.Request, opts.Value)
+		}
 
 		req, err := opts.Request.Apply(opts.Value)
 		if err != nil {
@@ -78,3 +91,28 @@ var cmd = &cobra.Command{
 		return err
 	},
 }
+
+// showRaw prints the raw request, which is sent without modification.
+func showRaw(template *request.Request, value string) error {
+	raw, err := template.ApplyRaw(value)
+	if err != nil {
+		return err
+	}
+
+	host, port, err := raw.Target()
+	if err != nil {
+		return err
+	}
+
+	// remote server
+	fmt.Printf("remote %v, port %v\n\n", host, port)
+
+	buf := raw.Data
+
+	// be nice to the CLI user and append a newline if there isn't one yet
+	if !bytes.HasSuffix(buf, []byte("\n")) {
+		buf = append(buf, '\n')
+	}
+	_, err = os.Stdout.Write(buf)
+	return err
+}
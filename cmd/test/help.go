@@ -9,9 +9,17 @@ import (
 const helpShort = "Send an HTTP request to a server and show the result"
 
 var helpLong = strings.TrimSpace(`
-The 'test' command can be use to send a request to the server and display the
-result. The options are the same as for the 'fuzz' command, so they can
-directly applied to it once the request works.
+The 'test' command (or 'probe') can be use to send a request to the server and
+display the result. The options are the same as for the 'fuzz' command, so they
+can directly applied to it once the request works. With --show-request, the
+request is printed as it was sent, including headers added automatically.
+
+Control characters in the response header are printed as escape sequences
+(e.g. \x1b), so that they cannot change the state of the terminal. A body which
+is not printable text (invalid UTF-8 or control characters other than tabs and
+line breaks) is printed according to --binary-output: as a hex dump (the
+default), as base64, or only its size with 'skip'. Use 'raw' to print the
+header and body unmodified, e.g. to redirect the output to a file.
 ` + request.LongHelp)
 
 const helpExamples = `
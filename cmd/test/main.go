@@ -18,9 +18,10 @@ import (
 
 // Options collect options for the command.
 type Options struct {
-	Request     *request.Request // the template for the HTTP request
-	Value       string
-	ShowRequest bool
+	Request      *request.Request // the template for the HTTP request
+	Value        string
+	ShowRequest  bool
+	BinaryOutput string
 }
 
 var opts Options
@@ -37,6 +38,7 @@ func AddCommand(c *cobra.Command) {
 
 	fs.StringVarP(&opts.Value, "value", "v", "test", "Use `string` for the placeholder")
 	fs.BoolVar(&opts.ShowRequest, "show-request", false, "Also print HTTP request")
+	fs.StringVar(&opts.BinaryOutput, "binary-output", response.BinaryHex, "Print binary response bodies as `mode`: hex, base64, skip (only the size) or raw")
 }
 
 func header(name string) string {
@@ -53,6 +55,7 @@ func header(name string) string {
 
 var cmd = &cobra.Command{
 	Use:                   "test [options] URL",
+	Aliases:               []string{"probe"},
 	DisableFlagsInUseLine: true,
 
 	Short:   helpShort,
@@ -67,7 +70,7 @@ var cmd = &cobra.Command{
 }
 
 func run(ctx context.Context, g *errgroup.Group, opts *Options, args []string) error {
-	if len(args) == 0 {
+	if len(args) == 0 && opts.Request.RequestFile == "" {
 		return errors.New("last argument needs to be the URL")
 	}
 
@@ -75,58 +78,124 @@ func run(ctx context.Context, g *errgroup.Group, opts *Options, args []string) e
 		return errors.New("more than one target URL specified")
 	}
 
-	opts.Request.URL = args[0]
+	if len(args) == 1 {
+		opts.Request.URL = args[0]
+	}
 
-	req, err := opts.Request.Apply(opts.Value)
-	if err != nil {
-		return err
+	validMode := false
+	for _, mode := range response.BinaryModes {
+		if opts.BinaryOutput == mode {
+			validMode = true
+		}
+	}
+	if !validMode {
+		return fmt.Errorf("invalid --binary-output %q, supported: %v", opts.BinaryOutput, strings.Join(response.BinaryModes, ", "))
 	}
 
-	host, port, err := request.Target(req)
+	if opts.Request.RequestFile != "" {
+		err := opts.Request.LoadRequestFile(opts.Request.RequestFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := opts.Request.CheckTemplates()
 	if err != nil {
 		return err
 	}
 
-	// remote server
-	fmt.Printf("remote %v, port %v\n\n", host, port)
+	var host, port string
+	var buf []byte
 
-	if opts.ShowRequest {
-		fmt.Println(header("request"))
-		// print request with body
-		buf, err := httputil.DumpRequestOut(req, true)
+	if opts.Request.RawMode() {
+		raw, err := opts.Request.ApplyRaw(opts.Value)
 		if err != nil {
 			return err
 		}
 
-		// be nice to the CLI user and append a newline if there isn't one yet
-		if !bytes.HasSuffix(buf, []byte("\n")) {
-			buf = append(buf, '\n')
+		host, port, err = raw.Target()
+		if err != nil {
+			return err
 		}
 
-		_, err = os.Stdout.Write(buf)
+		buf = raw.Data
+	} else {
+		req, err := opts.Request.Apply(opts.Value)
+		if err != nil {
+			return err
+		}
+
+		host, port, err = request.Target(req)
+		if err != nil {
+			return err
+		}
+
+		// print request with body
+		buf, err = httputil.DumpRequestOut(req, true)
 		if err != nil {
 			return err
 		}
 	}
 
+	// remote server
+	fmt.Printf("remote %v, port %v\n\n", host, port)
+
 	input := make(chan string, 1)
 	input <- opts.Value
 	close(input)
 
 	output := make(chan response.Response, 1)
 
-	tr, err := response.NewTransport(opts.Request.Insecure, opts.Request.TLSClientKeyCertFile,
-		opts.Request.DisableHTTP2, 1)
+	tr, err := response.NewTransport(opts.Request, 1)
 	if err != nil {
 		return err
 	}
 
 	runner := response.NewRunner(tr, opts.Request, input, output)
+	runner.RecordRequest = opts.ShowRequest
+
+	if len(opts.Request.InsecureHosts) > 0 {
+		runner.InsecureTransport, err = response.NewInsecureTransport(opts.Request, 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	jar, warnings, err := opts.Request.CookieJar()
+	if err != nil {
+		return err
+	}
+	for _, msg := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", msg)
+	}
+	if jar != nil {
+		runner.Client.Jar = jar
+	}
 	runner.Run(ctx)
 	close(output)
 
 	res := <-output
 
+	if opts.ShowRequest {
+		fmt.Println(header("request"))
+
+		// show the request as it was sent by the runner (e.g. including
+		// the Accept-Encoding header), if it got that far
+		if len(res.RawRequest) > 0 {
+			buf = res.RawRequest
+		}
+
+		// be nice to the CLI user and append a newline if there isn't one yet
+		if !bytes.HasSuffix(buf, []byte("\n")) {
+			buf = append(buf, '\n')
+		}
+
+		_, err := os.Stdout.Write(buf)
+		if err != nil {
+			return err
+		}
+	}
+
 	if opts.ShowRequest {
 		// we only need the separator when request and response are both shown
 		fmt.Println(header("response"))
@@ -137,19 +206,26 @@ func run(ctx context.Context, g *errgroup.Group, opts *Options, args []string) e
 		return nil
 	}
 
-	// print response
-	_, err = os.Stdout.Write(res.RawHeader)
+	// print response, without control characters which could change the
+	// state of the terminal unless the raw data was requested
+	header, body := res.RawHeader, res.RawBody
+	if opts.BinaryOutput != response.BinaryRaw {
+		header = response.SanitizeHeader(header)
+		body = response.FormatBody(body, opts.BinaryOutput)
+	}
+
+	_, err = os.Stdout.Write(header)
 	if err != nil {
 		return err
 	}
 
-	_, err = os.Stdout.Write(res.RawBody)
+	_, err = os.Stdout.Write(body)
 	if err != nil {
 		return err
 	}
 
 	// be nice to the CLI user and append a newline if there isn't one yet
-	if !bytes.HasSuffix(res.RawBody, []byte("\n")) {
+	if !bytes.HasSuffix(body, []byte("\n")) {
 		fmt.Println()
 	}
 
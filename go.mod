@@ -1,16 +1,23 @@
 module github.com/RedTeamPentesting/monsoon
 
 require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/aws/aws-sdk-go-v2 v0.24.0
 	github.com/fd0/termstatus v1.0.1
-	github.com/google/go-cmp v0.2.0
+	github.com/gdamore/tcell v1.4.0
+	github.com/google/go-cmp v0.4.0
 	github.com/juju/ratelimit v1.0.1
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/mattn/go-isatty v0.0.4 // indirect
+	github.com/mattn/go-runewidth v0.0.7
+	github.com/nats-io/nats.go v1.10.0
+	github.com/prometheus/client_golang v1.2.1
+	github.com/refraction-networking/utls v1.1.0
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/pflag v1.0.5
-	golang.org/x/net v0.0.0-20191014212845-da9a3fd4c582
+	golang.org/x/net v0.0.0-20200202094626-16171245cfb2
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
-	golang.org/x/sys v0.0.0-20190215142949-d0b11bdaac8a
+	golang.org/x/sys v0.0.0-20191010194322-b09406accb47
 	golang.org/x/text v0.3.2 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
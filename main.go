@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/RedTeamPentesting/monsoon/cli"
+	"github.com/RedTeamPentesting/monsoon/cmd/diff"
 	"github.com/RedTeamPentesting/monsoon/cmd/fuzz"
 	"github.com/RedTeamPentesting/monsoon/cmd/list"
+	"github.com/RedTeamPentesting/monsoon/cmd/merge"
+	"github.com/RedTeamPentesting/monsoon/cmd/repro"
+	"github.com/RedTeamPentesting/monsoon/cmd/schema"
 	"github.com/RedTeamPentesting/monsoon/cmd/show"
 	"github.com/RedTeamPentesting/monsoon/cmd/test"
 	"github.com/spf13/cobra"
@@ -24,12 +30,19 @@ func init() {
 	show.AddCommand(cmdRoot)
 	test.AddCommand(cmdRoot)
 	list.AddCommand(cmdRoot)
+	diff.AddCommand(cmdRoot)
+	merge.AddCommand(cmdRoot)
+	repro.AddCommand(cmdRoot)
+	schema.AddCommand(cmdRoot)
 }
 
 func injectDefaultCommand(args []string) []string {
 	validCommands := make(map[string]struct{})
 	for _, cmd := range cmdRoot.Commands() {
 		validCommands[cmd.Name()] = struct{}{}
+		for _, alias := range cmd.Aliases {
+			validCommands[alias] = struct{}{}
+		}
 	}
 
 	// check that there's a command in the arguments
@@ -56,6 +69,15 @@ func main() {
 	cmdRoot.SetArgs(os.Args[1:])
 
 	err := cmdRoot.Execute()
+
+	var exitErr *cli.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Message != "" {
+			fmt.Fprintf(os.Stderr, "%v\n", exitErr.Message)
+		}
+		os.Exit(exitErr.Code)
+	}
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,201 @@
+// Package metrics exports statistics about a run via HTTP for Prometheus.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// rateWindow is the number of seconds used to compute the current rate.
+const rateWindow = 10
+
+// Metrics collects statistics about responses and serves them via HTTP.
+type Metrics struct {
+	listener net.Listener
+	srv      *http.Server
+
+	registry  *prometheus.Registry
+	completed prometheus.Counter
+	responses *prometheus.CounterVec
+	errors    prometheus.Counter
+	shown     prometheus.Counter
+
+	mu    sync.Mutex
+	start time.Time
+
+	// number of responses in the last seconds, indexed by the second modulo
+	// rateWindow
+	buckets   [rateWindow]int
+	bucketSec [rateWindow]int64
+}
+
+// newMetrics returns metrics registered with a new registry, the rate is
+// computed relative to start. The function queueDepth is called to find out
+// how many values are waiting to be sent, it may be nil.
+func newMetrics(start time.Time, queueDepth func() int) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		start:    start,
+		completed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "monsoon_completed_requests_total",
+			Help: "Number of requests for which a response or an error was received.",
+		}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "monsoon_responses_total",
+			Help: "Number of HTTP responses by status code.",
+		}, []string{"status"}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "monsoon_errors_total",
+			Help: "Number of requests which failed without an HTTP response.",
+		}),
+		shown: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "monsoon_shown_responses_total",
+			Help: "Number of responses not hidden by a filter.",
+		}),
+	}
+
+	m.registry.MustRegister(m.completed, m.responses, m.errors, m.shown)
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "monsoon_request_rate",
+		Help: fmt.Sprintf("Requests per second over the last %d seconds.", rateWindow),
+	}, func() float64 {
+		return m.rate(time.Now())
+	}))
+
+	if queueDepth != nil {
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "monsoon_queue_depth",
+			Help: "Number of values waiting to be sent.",
+		}, func() float64 {
+			return float64(queueDepth())
+		}))
+	}
+
+	return m
+}
+
+// New listens on addr and starts serving metrics on the path /metrics.
+// The function queueDepth is called to find out how many values are waiting
+// to be sent, it may be nil.
+func New(addr string, queueDepth func() int) (*Metrics, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen for metrics: %v", err)
+	}
+
+	m := newMetrics(time.Now(), queueDepth)
+	m.listener = l
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	m.srv = &http.Server{Handler: mux}
+
+	go func() {
+		// the error is always non-nil when the server is closed
+		_ = m.srv.Serve(l)
+	}()
+
+	return m, nil
+}
+
+// Addr returns the address the metrics are served on.
+func (m *Metrics) Addr() net.Addr {
+	return m.listener.Addr()
+}
+
+// Run reads responses from in and forwards them to out, updating the
+// statistics on the way. When in is closed or the context is cancelled,
+// processing stops, the HTTP server is shut down, and out is closed.
+func (m *Metrics) Run(ctx context.Context, in <-chan response.Response, out chan<- response.Response) error {
+	defer close(out)
+	defer func() {
+		_ = m.srv.Close()
+	}()
+
+	for {
+		var res response.Response
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, ok = <-in:
+			if !ok {
+				return nil
+			}
+		}
+
+		if !res.Cancelled() {
+			m.update(res, time.Now())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- res:
+		}
+	}
+}
+
+// update records the response received at the time now.
+func (m *Metrics) update(res response.Response, now time.Time) {
+	m.completed.Inc()
+	if res.Error != nil {
+		m.errors.Inc()
+	} else {
+		m.responses.WithLabelValues(strconv.Itoa(res.HTTPResponse.StatusCode)).Inc()
+	}
+
+	if !res.Hide {
+		m.shown.Inc()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sec := now.Unix()
+	i := sec % rateWindow
+	if m.bucketSec[i] != sec {
+		m.bucketSec[i] = sec
+		m.buckets[i] = 0
+	}
+	m.buckets[i]++
+}
+
+// rate returns the number of responses per second in the last seconds before
+// now.
+func (m *Metrics) rate(now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sec := now.Unix()
+
+	var sum int
+	for i, s := range m.bucketSec {
+		// ignore the current second, it's not complete yet
+		if s < sec && s >= sec-rateWindow {
+			sum += m.buckets[i]
+		}
+	}
+
+	window := float64(rateWindow)
+	if elapsed := now.Sub(m.start).Seconds(); elapsed < window {
+		window = elapsed
+	}
+
+	if window < 1 {
+		return 0
+	}
+
+	return float64(sum) / window
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCounters(t *testing.T) {
+	m := newMetrics(time.Now(), nil)
+
+	responses := []response.Response{
+		{HTTPResponse: &http.Response{StatusCode: 200}},
+		{HTTPResponse: &http.Response{StatusCode: 404}, Hide: true},
+		{HTTPResponse: &http.Response{StatusCode: 404}, Hide: true},
+		{Error: errors.New("connection refused")},
+		{HTTPResponse: &http.Response{StatusCode: 200}},
+	}
+
+	for _, res := range responses {
+		m.update(res, time.Now())
+	}
+
+	var tests = []struct {
+		name  string
+		value float64
+		want  float64
+	}{
+		{"completed", testutil.ToFloat64(m.completed), 5},
+		{"errors", testutil.ToFloat64(m.errors), 1},
+		{"shown", testutil.ToFloat64(m.shown), 3},
+		{"status 200", testutil.ToFloat64(m.responses.WithLabelValues("200")), 2},
+		{"status 404", testutil.ToFloat64(m.responses.WithLabelValues("404")), 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.value != test.want {
+				t.Errorf("wrong value, want %v, got %v", test.want, test.value)
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	var tests = []struct {
+		// seconds after start at which a response is received
+		responses []int
+		// seconds after start at which the rate is computed
+		at   int
+		want float64
+	}{
+		// not a full second yet
+		{[]int{0, 0}, 0, 0},
+		// the current second is not counted
+		{[]int{0, 0, 1}, 1, 2},
+		// only the elapsed seconds are used for the window
+		{[]int{0, 1, 1, 1, 2}, 2, 2},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 12, 1},
+		// responses older than the window are ignored
+		{[]int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 15, 16}, 20, 0.2},
+		{[]int{0, 1}, 30, 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			m := newMetrics(start, nil)
+			for _, sec := range test.responses {
+				m.update(response.Response{Error: errors.New("timeout")}, start.Add(time.Duration(sec)*time.Second))
+			}
+
+			rate := m.rate(start.Add(time.Duration(test.at) * time.Second))
+			if rate != test.want {
+				t.Errorf("wrong rate, want %v, got %v", test.want, rate)
+			}
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	m, err := New("127.0.0.1:0", func() int { return 23 })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response, 2)
+	out := make(chan response.Response, 2)
+	in <- response.Response{HTTPResponse: &http.Response{StatusCode: 302}}
+	in <- response.Response{Error: errors.New("timeout"), Hide: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Run(ctx, in, out)
+	}()
+
+	<-out
+	<-out
+
+	res, err := http.Get("http://" + m.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("wrong status code, want %d, got %d", http.StatusOK, res.StatusCode)
+	}
+
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("wrong content type %q", ct)
+	}
+
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(string(buf), "\n") {
+		lines[line] = true
+	}
+
+	for _, line := range []string{
+		"# TYPE monsoon_completed_requests_total counter",
+		"monsoon_completed_requests_total 2",
+		"monsoon_errors_total 1",
+		"monsoon_shown_responses_total 1",
+		`monsoon_responses_total{status="302"} 1`,
+		"# TYPE monsoon_request_rate gauge",
+		"monsoon_request_rate 0",
+		"# TYPE monsoon_queue_depth gauge",
+		"monsoon_queue_depth 23",
+	} {
+		if !lines[line] {
+			t.Errorf("line %q not found in output:\n%s", line, buf)
+		}
+	}
+
+	cancel()
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the server is shut down by Run
+	_, err = http.Get("http://" + m.Addr().String() + "/metrics")
+	if err == nil {
+		t.Errorf("server is still running")
+	}
+}
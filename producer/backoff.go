@@ -0,0 +1,128 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Backoff delays values when too many server errors are reported, and
+// reduces the delay again when the errors decrease.
+type Backoff struct {
+	// Threshold is the fraction of server errors in the window which causes
+	// the delay to be increased.
+	Threshold float64
+
+	// Log is called with a message when the delay changes, it may be nil.
+	Log func(string)
+
+	mu     sync.Mutex
+	delay  time.Duration
+	window [backoffWindow]bool
+	pos    int
+	filled int
+}
+
+const (
+	// backoffWindow is the number of responses the decision is based on
+	backoffWindow = 50
+
+	minBackoffDelay = 50 * time.Millisecond
+	maxBackoffDelay = 10 * time.Second
+)
+
+// Delay returns the current delay between two values.
+func (b *Backoff) Delay() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.delay
+}
+
+// Record adds the result of a request to the window and adjusts the delay
+// when the window is full.
+func (b *Backoff) Record(serverError bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.window[b.pos] = serverError
+	b.pos = (b.pos + 1) % backoffWindow
+	if b.filled < backoffWindow {
+		b.filled++
+	}
+
+	if b.filled < backoffWindow {
+		return
+	}
+
+	var errors int
+	for _, e := range b.window {
+		if e {
+			errors++
+		}
+	}
+	ratio := float64(errors) / backoffWindow
+
+	old := b.delay
+	switch {
+	case ratio > b.Threshold:
+		b.delay *= 2
+		if b.delay < minBackoffDelay {
+			b.delay = minBackoffDelay
+		}
+		if b.delay > maxBackoffDelay {
+			b.delay = maxBackoffDelay
+		}
+	case ratio <= b.Threshold/2 && b.delay > 0:
+		b.delay /= 2
+		if b.delay < minBackoffDelay {
+			b.delay = 0
+		}
+	default:
+		return
+	}
+
+	// start with a new window so the effect of the change can be observed
+	b.filled = 0
+
+	if b.delay == old || b.Log == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("%.0f%% of the last %d responses were server errors (5xx)", ratio*100, backoffWindow)
+	if b.delay == 0 {
+		msg += ", no longer delaying requests"
+	} else {
+		msg += fmt.Sprintf(", delaying requests by %v", b.delay)
+	}
+	b.Log(msg)
+}
+
+// Select passes through all values from in, waiting for the current delay
+// before each value. A new goroutine is started, which terminates when in is
+// closed or the context is cancelled.
+func (b *Backoff) Select(ctx context.Context, in <-chan string) <-chan string {
+	out := make(chan string)
+
+	go func() {
+		defer close(out)
+		for s := range in {
+			if delay := b.Delay(); delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case out <- s:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
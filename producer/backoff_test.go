@@ -0,0 +1,54 @@
+package producer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	var msgs []string
+	b := &Backoff{
+		Threshold: 0.5,
+		Log:       func(s string) { msgs = append(msgs, s) },
+	}
+
+	record := func(n int, serverError bool) {
+		for i := 0; i < n; i++ {
+			b.Record(serverError)
+		}
+	}
+
+	var tests = []struct {
+		n           int
+		serverError bool
+		delay       time.Duration
+	}{
+		{backoffWindow, false, 0},
+		// exactly at the threshold: unchanged
+		{backoffWindow / 2, true, 0},
+		{1, true, minBackoffDelay},
+		{backoffWindow, true, 2 * minBackoffDelay},
+		{backoffWindow, true, 4 * minBackoffDelay},
+		// the window is not full yet
+		{backoffWindow / 2, false, 4 * minBackoffDelay},
+		// between half of the threshold and the threshold: unchanged
+		{backoffWindow / 2, true, 4 * minBackoffDelay},
+		{backoffWindow, false, 2 * minBackoffDelay},
+		{backoffWindow, false, minBackoffDelay},
+		{backoffWindow, false, 0},
+		{backoffWindow, false, 0},
+		{backoffWindow * 20, true, maxBackoffDelay},
+	}
+
+	for i, test := range tests {
+		record(test.n, test.serverError)
+		if b.Delay() != test.delay {
+			t.Fatalf("step %d: wrong delay, want %v, got %v", i, test.delay, b.Delay())
+		}
+	}
+
+	// one message for each change of the delay
+	if len(msgs) != 15 {
+		t.Errorf("wrong number of log messages, want 15, got %d: %q", len(msgs), msgs)
+	}
+}
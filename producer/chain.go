@@ -0,0 +1,96 @@
+package producer
+
+import (
+	"context"
+	"regexp"
+)
+
+// Chain applies the filters to the values and the count in the given order,
+// so that each filter works on the values selected by the previous one.
+func Chain(ctx context.Context, valueCh <-chan string, countCh <-chan int, filters ...Filter) (<-chan string, <-chan int) {
+	for _, f := range filters {
+		countCh = f.Count(ctx, countCh)
+		valueCh = f.Select(ctx, valueCh)
+	}
+
+	return valueCh, countCh
+}
+
+// ValueFilters describes the filters applied to the values from the producer
+// before requests are sent. The order is fixed:
+//
+//  1. Match/Reject: only matching values are selected
+//  2. Reverse: the values are reversed
+//  3. Shard/Shards: the part of the values for a shard is selected
+//  4. Prefixes/Suffixes: each value is wrapped
+//  5. Skip: the first values are skipped
+//  6. Limit: at most Limit values are passed on
+//
+// So Skip and Limit count the values which are actually sent (those of the
+// shard, after wrapping), and a run can be resumed by skipping the values
+// already sent. Duplicate values are not removed, so they are counted as well.
+type ValueFilters struct {
+	Match, Reject *regexp.Regexp
+	MatchValues   int // number of values selected by Match and Reject if known beforehand
+
+	Reverse bool
+
+	Shard, Shards int
+	ShardMod      bool // select every n-th value instead of a contiguous part
+	ShardValues   int  // number of values for the shard, see FilterShard
+
+	Prefixes, Suffixes []string
+
+	Skip  int
+	Limit int
+}
+
+// Filters returns the filters in the order in which they are applied. Filters
+// which are not configured are omitted.
+func (v ValueFilters) Filters() []Filter {
+	var filters []Filter
+
+	// the number of values is passed on by the last of the two filters
+	if v.Match != nil {
+		f := &FilterMatch{Pattern: v.Match}
+		if v.Reject == nil {
+			f.Values = v.MatchValues
+		}
+		filters = append(filters, f)
+	}
+
+	if v.Reject != nil {
+		filters = append(filters, &FilterReject{Pattern: v.Reject, Values: v.MatchValues})
+	}
+
+	if v.Reverse {
+		filters = append(filters, &FilterReverse{})
+	}
+
+	if v.Shards > 0 {
+		if v.ShardMod {
+			filters = append(filters, &FilterShardMod{Shard: v.Shard, Shards: v.Shards})
+		} else {
+			filters = append(filters, &FilterShard{Shard: v.Shard, Shards: v.Shards, Values: v.ShardValues})
+		}
+	}
+
+	if len(v.Prefixes) > 0 || len(v.Suffixes) > 0 {
+		filters = append(filters, &FilterWrap{Prefixes: v.Prefixes, Suffixes: v.Suffixes})
+	}
+
+	if v.Skip > 0 {
+		filters = append(filters, &FilterSkip{Skip: v.Skip})
+	}
+
+	if v.Limit > 0 {
+		filters = append(filters, &FilterLimit{Max: v.Limit})
+	}
+
+	return filters
+}
+
+// Apply runs the values and the count through the filters.
+func (v ValueFilters) Apply(ctx context.Context, valueCh <-chan string, countCh <-chan int) (<-chan string, <-chan int) {
+	return Chain(ctx, valueCh, countCh, v.Filters()...)
+}
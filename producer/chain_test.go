@@ -0,0 +1,151 @@
+package producer
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValueFilters(t *testing.T) {
+	var tests = []struct {
+		filters ValueFilters
+		values  []string
+		count   int // sent to the filters, the number of values if zero
+		want    []string
+		// the count passed on, len(want) if zero
+		wantCount int
+	}{
+		{
+			values: numbers(1, 5),
+			want:   numbers(1, 5),
+		},
+		{
+			// skip is applied before limit
+			filters: ValueFilters{Skip: 2, Limit: 3},
+			values:  numbers(1, 10),
+			want:    []string{"3", "4", "5"},
+		},
+		{
+			filters: ValueFilters{Skip: 8, Limit: 3},
+			values:  numbers(1, 10),
+			want:    []string{"9", "10"},
+		},
+		{
+			// skip and limit count the reversed values
+			filters: ValueFilters{Reverse: true, Skip: 1, Limit: 2},
+			values:  numbers(1, 10),
+			want:    []string{"9", "8"},
+		},
+		{
+			// skip and limit count the values of the shard
+			filters: ValueFilters{Shard: 2, Shards: 2, Skip: 1, Limit: 2},
+			values:  numbers(1, 10),
+			want:    []string{"7", "8"},
+		},
+		{
+			filters: ValueFilters{Shard: 2, Shards: 3, ShardMod: true, Skip: 1},
+			values:  numbers(1, 10),
+			want:    []string{"5", "8"},
+		},
+		{
+			// the shard is selected from the reversed values
+			filters: ValueFilters{Reverse: true, Shard: 1, Shards: 2},
+			values:  numbers(1, 6),
+			want:    []string{"6", "5", "4"},
+		},
+		{
+			// skip and limit count the wrapped values
+			filters: ValueFilters{Prefixes: []string{"a", "b"}, Skip: 1, Limit: 2},
+			values:  []string{"1", "2"},
+			want:    []string{"b1", "a2"},
+		},
+		{
+			// the shard is selected before wrapping
+			filters: ValueFilters{Shard: 1, Shards: 2, Suffixes: []string{"x", "y"}},
+			values:  numbers(1, 4),
+			want:    []string{"1x", "1y", "2x", "2y"},
+		},
+		{
+			// duplicates are counted
+			filters: ValueFilters{Skip: 1, Limit: 2},
+			values:  []string{"a", "a", "a", "b"},
+			want:    []string{"a", "a"},
+		},
+		{
+			filters:   ValueFilters{Skip: 1, Limit: 2},
+			values:    numbers(1, 10),
+			count:     UnknownCount,
+			want:      []string{"2", "3"},
+			wantCount: UnknownCount,
+		},
+		{
+			filters:   ValueFilters{Skip: 1},
+			values:    numbers(1, 10),
+			count:     UnknownCount,
+			want:      numbers(2, 10),
+			wantCount: UnknownCount,
+		},
+		{
+			// the number of matching values is unknown
+			filters:   ValueFilters{Match: regexp.MustCompile(`^1`)},
+			values:    numbers(1, 12),
+			want:      []string{"1", "10", "11", "12"},
+			wantCount: UnknownCount,
+		},
+		{
+			filters: ValueFilters{Match: regexp.MustCompile(`^1`), Reject: regexp.MustCompile(`2`), MatchValues: 3},
+			values:  numbers(1, 12),
+			want:    []string{"1", "10", "11"},
+		},
+		{
+			// skip, limit and the shard count the matching values
+			filters: ValueFilters{Reject: regexp.MustCompile(`[13579]$`), MatchValues: 5, Shard: 2, Shards: 2, Skip: 1},
+			values:  numbers(1, 10),
+			want:    []string{"8", "10"},
+		},
+		{
+			filters:   ValueFilters{Reject: regexp.MustCompile(`.`)},
+			values:    numbers(1, 3),
+			wantCount: UnknownCount,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			in := make(chan string, len(test.values))
+			for _, v := range test.values {
+				in <- v
+			}
+			close(in)
+
+			count := make(chan int, 1)
+			if test.count != 0 {
+				count <- test.count
+			} else {
+				count <- len(test.values)
+			}
+
+			valueCh, countCh := test.filters.Apply(context.Background(), in, count)
+
+			var values []string
+			for v := range valueCh {
+				values = append(values, v)
+			}
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			wantCount := test.wantCount
+			if wantCount == 0 {
+				wantCount = len(test.want)
+			}
+
+			if n := <-countCh; n != wantCount {
+				t.Errorf("wrong count, want %d, got %d", wantCount, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package producer
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CSV reads CSV records from rd and sends the values of column to ch, the
+// number of values is sent to count. The column is either a number (starting
+// at 1) or, if header is set, the name of the column in the first record.
+// When header is set, the first record is not sent. Sending stops and ch is
+// closed when an error occurs or the context is cancelled. The reader is
+// closed when this function returns.
+func CSV(ctx context.Context, rd io.ReadCloser, column string, header bool, ch chan<- string, count chan<- int) (err error) {
+	defer close(ch)
+	defer func() {
+		// ignore error
+		_ = rd.Close()
+	}()
+
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	idx := -1
+	if header {
+		names, err := cr.Read()
+		if err == io.EOF {
+			return fmt.Errorf("CSV file is empty, no header found")
+		}
+		if err != nil {
+			return err
+		}
+
+		for i, name := range names {
+			if name == column {
+				idx = i
+				break
+			}
+		}
+	}
+
+	if idx < 0 {
+		n, err := strconv.Atoi(column)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid CSV column %q, must be a number (starting at 1) or the name of a column", column)
+		}
+		idx = n - 1
+	}
+
+	num := 0
+	line := 0
+	if header {
+		line++
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		line++
+		if idx >= len(record) {
+			return fmt.Errorf("CSV record %d has only %d columns", line, len(record))
+		}
+
+		num++
+
+		select {
+		case ch <- record[idx]:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	count <- num
+	return nil
+}
@@ -0,0 +1,65 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCSV(t *testing.T) {
+	var tests = []struct {
+		data   string
+		column string
+		header bool
+		want   []string
+		err    string
+	}{
+		{"a,1\nb,2\nc,3\n", "1", false, []string{"a", "b", "c"}, ""},
+		{"a,1\nb,2\nc,3", "2", false, []string{"1", "2", "3"}, ""},
+		{"name,id\nadmin,1\n\"foo,bar\",2\n", "name", true, []string{"admin", "foo,bar"}, ""},
+		{"name,id\nadmin,1\nfoo,2\n", "2", true, []string{"1", "2"}, ""},
+		{"name,id\n", "id", true, nil, ""},
+		{"a,1\nb\n", "2", false, []string{"1"}, "CSV record 2 has only 1 columns"},
+		{"a,1\n", "name", false, nil, `invalid CSV column "name", must be a number (starting at 1) or the name of a column`},
+		{"a,1\n", "0", false, nil, `invalid CSV column "0", must be a number (starting at 1) or the name of a column`},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			ch := make(chan string)
+			count := make(chan int, 1)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- CSV(context.Background(), ioutil.NopCloser(strings.NewReader(test.data)), test.column, test.header, ch, count)
+			}()
+
+			var values []string
+			for v := range ch {
+				values = append(values, v)
+			}
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			err := <-errCh
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("wrong error, want %q, got %v", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c := <-count; c != len(test.want) {
+				t.Errorf("wrong count, want %d, got %d", len(test.want), c)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package producer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings lists the supported input encodings.
+var Encodings = []string{"auto", "utf-8", "utf-16le", "utf-16be", "latin1"}
+
+// decoder converts text read from an underlying reader to UTF-8.
+type decoder struct {
+	rd       *bufio.Reader
+	encoding string
+	next     func() (rune, error) // nil means the data is passed through
+	detected bool
+	io.Closer
+
+	line int
+	buf  []byte
+	err  error
+}
+
+// NewDecoder returns a reader which converts the data read from rd from
+// encoding to UTF-8. For the encoding "auto", a byte order mark at the start
+// of the data is used to detect UTF-8 and UTF-16. If none is found, the data
+// is passed through unmodified. Invalid byte sequences are reported as an
+// error including the line number. The returned reader closes rd when Close()
+// is called.
+func NewDecoder(rd io.ReadCloser, encoding string) (io.ReadCloser, error) {
+	encoding = strings.ToLower(encoding)
+	switch encoding {
+	case "":
+		encoding = "auto"
+	case "utf8":
+		encoding = "utf-8"
+	case "utf16le":
+		encoding = "utf-16le"
+	case "utf16be":
+		encoding = "utf-16be"
+	case "latin-1", "iso-8859-1":
+		encoding = "latin1"
+	}
+
+	known := false
+	for _, enc := range Encodings {
+		if enc == encoding {
+			known = true
+		}
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown input encoding %q, supported: %v", encoding, strings.Join(Encodings, ", "))
+	}
+
+	return &decoder{
+		rd:       bufio.NewReader(rd),
+		encoding: encoding,
+		Closer:   rd,
+		line:     1,
+	}, nil
+}
+
+// detect looks at the beginning of the data for a byte order mark and
+// configures the decoding function. It is called for the first read, so that
+// constructing a decoder does not block.
+func (d *decoder) detect() {
+	// errors are detected on the next read
+	bom, _ := d.rd.Peek(3)
+
+	d.detected = true
+
+	var (
+		bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+		bomUTF16LE = []byte{0xff, 0xfe}
+		bomUTF16BE = []byte{0xfe, 0xff}
+	)
+
+	switch d.encoding {
+	case "auto":
+		switch {
+		case bytes.HasPrefix(bom, bomUTF8):
+			_, _ = d.rd.Discard(len(bomUTF8))
+			d.next = d.nextUTF8
+		case bytes.HasPrefix(bom, bomUTF16LE):
+			_, _ = d.rd.Discard(len(bomUTF16LE))
+			d.next = d.nextUTF16(false)
+		case bytes.HasPrefix(bom, bomUTF16BE):
+			_, _ = d.rd.Discard(len(bomUTF16BE))
+			d.next = d.nextUTF16(true)
+		}
+
+		// if no byte order mark is found, the data is passed through unmodified
+	case "utf-8":
+		if bytes.HasPrefix(bom, bomUTF8) {
+			_, _ = d.rd.Discard(len(bomUTF8))
+		}
+		d.next = d.nextUTF8
+	case "utf-16le":
+		if bytes.HasPrefix(bom, bomUTF16LE) {
+			_, _ = d.rd.Discard(len(bomUTF16LE))
+		}
+		d.next = d.nextUTF16(false)
+	case "utf-16be":
+		if bytes.HasPrefix(bom, bomUTF16BE) {
+			_, _ = d.rd.Discard(len(bomUTF16BE))
+		}
+		d.next = d.nextUTF16(true)
+	case "latin1":
+		d.next = d.nextLatin1
+	}
+}
+
+func (d *decoder) nextUTF8() (rune, error) {
+	r, size, err := d.rd.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	if r == utf8.RuneError && size == 1 {
+		return 0, fmt.Errorf("invalid UTF-8 sequence in line %d", d.line)
+	}
+
+	return r, nil
+}
+
+func (d *decoder) nextUTF16(bigEndian bool) func() (rune, error) {
+	var unit [2]byte
+	readUnit := func() (uint16, error) {
+		_, err := io.ReadFull(d.rd, unit[:])
+		if err == io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("truncated UTF-16 sequence in line %d", d.line)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if bigEndian {
+			return uint16(unit[0])<<8 | uint16(unit[1]), nil
+		}
+		return uint16(unit[1])<<8 | uint16(unit[0]), nil
+	}
+
+	return func() (rune, error) {
+		u1, err := readUnit()
+		if err != nil {
+			return 0, err
+		}
+
+		r := rune(u1)
+		if !utf16.IsSurrogate(r) {
+			return r, nil
+		}
+
+		u2, err := readUnit()
+		if err == io.EOF {
+			err = fmt.Errorf("truncated UTF-16 sequence in line %d", d.line)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		r = utf16.DecodeRune(r, rune(u2))
+		if r == utf8.RuneError {
+			return 0, fmt.Errorf("invalid UTF-16 surrogate pair in line %d", d.line)
+		}
+
+		return r, nil
+	}
+}
+
+func (d *decoder) nextLatin1() (rune, error) {
+	b, err := d.rd.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	return rune(b), nil
+}
+
+// Read reads decoded data into buf.
+func (d *decoder) Read(buf []byte) (int, error) {
+	if !d.detected {
+		d.detect()
+	}
+
+	if d.next == nil {
+		return d.rd.Read(buf)
+	}
+
+	for len(d.buf) < len(buf) && d.err == nil {
+		r, err := d.next()
+		if err != nil {
+			d.err = err
+			break
+		}
+
+		if r == '\n' {
+			d.line++
+		}
+
+		var tmp [utf8.UTFMax]byte
+		n := utf8.EncodeRune(tmp[:], r)
+		d.buf = append(d.buf, tmp[:n]...)
+	}
+
+	n := copy(buf, d.buf)
+	d.buf = d.buf[n:]
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, d.err
+}
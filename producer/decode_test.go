@@ -0,0 +1,99 @@
+package producer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var tests = []struct {
+		encoding string
+		input    []byte
+		want     string
+		err      string
+	}{
+		{
+			encoding: "auto",
+			input:    []byte("foo\nbar\n"),
+			want:     "foo\nbar\n",
+		},
+		{
+			// without a byte order mark, data is passed through unmodified
+			encoding: "auto",
+			input:    []byte("foo\xff\nbar\n"),
+			want:     "foo\xff\nbar\n",
+		},
+		{
+			encoding: "auto",
+			input:    []byte("\xef\xbb\xbffoo\nbär\n"),
+			want:     "foo\nbär\n",
+		},
+		{
+			encoding: "auto",
+			input:    []byte("\xff\xfef\x00o\x00o\x00\n\x00b\x00\xe4\x00r\x00"),
+			want:     "foo\nbär",
+		},
+		{
+			encoding: "auto",
+			input:    []byte("\xfe\xff\x00f\x00o\x00o\x00\n\x00b\x00\xe4\x00r"),
+			want:     "foo\nbär",
+		},
+		{
+			encoding: "utf-16le",
+			input:    []byte("f\x00o\x00o\x00\n\x00=\xd8\x00\xde"),
+			want:     "foo\n\U0001f600",
+		},
+		{
+			encoding: "utf-16be",
+			input:    []byte("\x00f\x00o\x00o\x00\n\xd8\x3d\xde\x00"),
+			want:     "foo\n\U0001f600",
+		},
+		{
+			encoding: "latin1",
+			input:    []byte("foo\nb\xe4r\n"),
+			want:     "foo\nbär\n",
+		},
+		{
+			encoding: "utf-8",
+			input:    []byte("foo\nb\xe4r\n"),
+			err:      "invalid UTF-8 sequence in line 2",
+		},
+		{
+			encoding: "utf-16le",
+			input:    []byte("f\x00o\x00o\x00\n\x00b\x00\n\x00="),
+			err:      "truncated UTF-16 sequence in line 3",
+		},
+		{
+			encoding: "utf-16le",
+			input:    []byte("f\x00=\xd8o\x00"),
+			err:      "invalid UTF-16 surrogate pair in line 1",
+		},
+		{
+			encoding: "ebcdic",
+			err:      "unknown input encoding",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			rd, err := NewDecoder(ioutil.NopCloser(bytes.NewReader(test.input)), test.encoding)
+			if err == nil {
+				var buf []byte
+				buf, err = ioutil.ReadAll(rd)
+				if err == nil && string(buf) != test.want {
+					t.Fatalf("wrong data returned, want %q, got %q", test.want, buf)
+				}
+			}
+
+			if test.err == "" && err != nil {
+				t.Fatal(err)
+			}
+
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("wrong error returned, want %q, got %v", test.err, err)
+			}
+		})
+	}
+}
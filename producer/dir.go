@@ -0,0 +1,59 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir sends the names of the files in the directory dir to ch in lexical
+// order, and the number of files to count. Symlinks are followed,
+// subdirectories, other special files and hidden files (starting with a dot)
+// are skipped. Sending stops and ch is closed when all names have been sent or
+// the context is cancelled.
+func Dir(ctx context.Context, dir string, ch chan<- string, count chan<- int) error {
+	defer close(ch)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, fi := range entries {
+		if strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+
+		// follow symlinks
+		if fi.Mode()&os.ModeSymlink != 0 {
+			fi, err = os.Stat(filepath.Join(dir, fi.Name()))
+			if err != nil {
+				return err
+			}
+		}
+
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		names = append(names, fi.Name())
+	}
+
+	select {
+	case count <- len(names):
+	case <-ctx.Done():
+		return nil
+	}
+
+	for _, name := range names {
+		select {
+		case ch <- name:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
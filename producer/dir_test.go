@@ -0,0 +1,86 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDir(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-dir-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for _, name := range []string{"b.txt", "a.txt", ".hidden", "c"} {
+		err = ioutil.WriteFile(filepath.Join(tempdir, name), []byte("GET / HTTP/1.0\r\n\r\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = os.Mkdir(filepath.Join(tempdir, "subdir"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink("a.txt", filepath.Join(tempdir, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink("subdir", filepath.Join(tempdir, "dirlink"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan string)
+	count := make(chan int, 1)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Dir(context.Background(), tempdir, ch, count)
+	}()
+
+	var values []string
+	for v := range ch {
+		values = append(values, v)
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a.txt", "b.txt", "c", "link"}
+	if !cmp.Equal(want, values) {
+		t.Error(cmp.Diff(want, values))
+	}
+
+	if n := <-count; n != len(want) {
+		t.Errorf("wrong count, want %d, got %d", len(want), n)
+	}
+}
+
+func TestDirNotFound(t *testing.T) {
+	ch := make(chan string)
+	count := make(chan int, 1)
+
+	err := Dir(context.Background(), "/invalid/directory/does/not/exist", ch, count)
+	if err == nil {
+		t.Fatal("expected error not returned")
+	}
+
+	if _, ok := <-ch; ok {
+		t.Error("channel not closed")
+	}
+}
@@ -0,0 +1,64 @@
+package producer
+
+import "context"
+
+// FanOut passes each value from in on to all of the n returned channels, e.g.
+// to send the requests for several targets. Each channel buffers up to buffer
+// values, the next value is only read from in when all channels accepted the
+// previous one, so a slow consumer eventually slows down the others. A new
+// goroutine is started, which closes the channels when in is closed or the
+// context is cancelled.
+func FanOut(ctx context.Context, in <-chan string, n, buffer int) []<-chan string {
+	chans := make([]chan string, n)
+	outs := make([]<-chan string, n)
+	for i := range chans {
+		chans[i] = make(chan string, buffer)
+		outs[i] = chans[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, ch := range chans {
+				close(ch)
+			}
+		}()
+
+		for s := range in {
+			for _, ch := range chans {
+				select {
+				case ch <- s:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return outs
+}
+
+// FanOutCount multiplies the number of values received from in by n, the
+// number of channels returned by FanOut.
+func FanOutCount(ctx context.Context, in <-chan int, n int) <-chan int {
+	out := make(chan int, 1)
+
+	go func() {
+		defer close(out)
+		var total int
+		select {
+		case total = <-in:
+		case <-ctx.Done():
+		}
+
+		if total != UnknownCount {
+			total *= n
+		}
+
+		select {
+		case out <- total:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
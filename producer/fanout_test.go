@@ -0,0 +1,82 @@
+package producer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFanOut(t *testing.T) {
+	var tests = []struct {
+		values []string
+		n      int
+		buffer int
+	}{
+		{[]string{"a", "b", "c"}, 1, 0},
+		{[]string{"a", "b", "c"}, 3, 0},
+		{[]string{"a", "b", "c", "d"}, 2, 5},
+		{nil, 2, 1},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			in := make(chan string)
+			go func() {
+				for _, v := range test.values {
+					in <- v
+				}
+				close(in)
+			}()
+
+			outs := FanOut(context.Background(), in, test.n, test.buffer)
+			if len(outs) != test.n {
+				t.Fatalf("wrong number of channels, want %d, got %d", test.n, len(outs))
+			}
+
+			results := make([][]string, test.n)
+			var wg sync.WaitGroup
+			for i, ch := range outs {
+				wg.Add(1)
+				go func(i int, ch <-chan string) {
+					defer wg.Done()
+					for v := range ch {
+						results[i] = append(results[i], v)
+					}
+				}(i, ch)
+			}
+			wg.Wait()
+
+			for i, res := range results {
+				if !cmp.Equal(test.values, res) {
+					t.Errorf("channel %d: %v", i, cmp.Diff(test.values, res))
+				}
+			}
+		})
+	}
+}
+
+func TestFanOutCount(t *testing.T) {
+	var tests = []struct {
+		count int
+		n     int
+		want  int
+	}{
+		{10, 3, 30},
+		{0, 3, 0},
+		{UnknownCount, 3, UnknownCount},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			in := make(chan int, 1)
+			in <- test.count
+
+			total := <-FanOutCount(context.Background(), in, test.n)
+			if total != test.want {
+				t.Errorf("wrong count, want %d, got %d", test.want, total)
+			}
+		})
+	}
+}
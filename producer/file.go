@@ -2,15 +2,64 @@ package producer
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 )
 
-// Reader sends all lines read from reader channel ch, and the number of
-// items to the channel count. Sending stops and ch and count are closed when
-// an error occurs or the context is cancelled. The reader is closed when this
-// function returns.
-func Reader(ctx context.Context, rd io.ReadCloser, ch chan<- string, count chan<- int) (err error) {
+// ParseDelimiter returns the byte which separates the values for the
+// delimiter s. Apart from a single byte, the names "newline", "nul" and "tab"
+// are accepted.
+func ParseDelimiter(s string) (byte, error) {
+	switch s {
+	case "", "newline":
+		return '\n', nil
+	case "nul", "null":
+		return 0, nil
+	case "tab":
+		return '\t', nil
+	}
+
+	if len(s) != 1 {
+		return 0, fmt.Errorf("invalid delimiter %q, must be a single byte, newline, nul or tab", s)
+	}
+
+	return s[0], nil
+}
+
+// ScanDelimiter returns a split function for a bufio.Scanner which returns the
+// records terminated by delim, without the delimiter. For a newline, the
+// lines are returned as with bufio.ScanLines, so a trailing \r is removed.
+func ScanDelimiter(delim byte) bufio.SplitFunc {
+	if delim == '\n' {
+		return bufio.ScanLines
+	}
+
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		if i := bytes.IndexByte(data, delim); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+
+		// return the last record, which is not terminated by the delimiter
+		if atEOF {
+			return len(data), data, nil
+		}
+
+		// request more data
+		return 0, nil, nil
+	}
+}
+
+// Reader sends all records separated by delim read from reader channel ch,
+// and the number of items to the channel count. Sending stops and ch and count
+// are closed when an error occurs or the context is cancelled. The reader is
+// closed when this function returns.
+func Reader(ctx context.Context, rd io.ReadCloser, delim byte, ch chan<- string, count chan<- int) (err error) {
 	defer close(ch)
 	defer func() {
 		// ignore error
@@ -18,12 +67,9 @@ func Reader(ctx context.Context, rd io.ReadCloser, ch chan<- string, count chan<
 	}()
 
 	sc := bufio.NewScanner(rd)
+	sc.Split(ScanDelimiter(delim))
 	num := 0
 	for sc.Scan() {
-		if sc.Err() != nil {
-			return sc.Err()
-		}
-
 		num++
 
 		select {
@@ -32,6 +78,11 @@ func Reader(ctx context.Context, rd io.ReadCloser, ch chan<- string, count chan<
 			return nil
 		}
 	}
+
+	if sc.Err() != nil {
+		return sc.Err()
+	}
+
 	count <- num
 	return nil
 }
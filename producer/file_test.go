@@ -0,0 +1,65 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader(t *testing.T) {
+	var tests = []struct {
+		data  string
+		delim string
+		want  []string
+	}{
+		{"a\nb\r\nc", "newline", []string{"a", "b", "c"}},
+		{"a\nb\n\nc\n", "", []string{"a", "b", "", "c"}},
+		{"./foo\x00./with\nnewline\x00./bar\x00", "nul", []string{"./foo", "./with\nnewline", "./bar"}},
+		{"a\tb\tc", "tab", []string{"a", "b", "c"}},
+		{"a,b\r\n,,c", ",", []string{"a", "b\r\n", "", "c"}},
+		{"", "nul", nil},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			delim, err := ParseDelimiter(test.delim)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ch := make(chan string)
+			count := make(chan int, 1)
+			go func() {
+				err := Reader(context.Background(), ioutil.NopCloser(strings.NewReader(test.data)), delim, ch, count)
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+
+			var values []string
+			for v := range ch {
+				values = append(values, v)
+			}
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			if c := <-count; c != len(test.want) {
+				t.Errorf("wrong count, want %d, got %d", len(test.want), c)
+			}
+		})
+	}
+}
+
+func TestParseDelimiterInvalid(t *testing.T) {
+	for _, s := range []string{"ab", "newlines"} {
+		_, err := ParseDelimiter(s)
+		if err == nil {
+			t.Errorf("expected error for delimiter %q not returned", s)
+		}
+	}
+}
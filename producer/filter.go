@@ -1,6 +1,15 @@
 package producer
 
-import "context"
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// UnknownCount is sent over the count channel by producers which cannot know
+// the number of values in advance. Filters pass it on when the number of
+// values they select cannot be computed either.
+const UnknownCount = -1
 
 // Filter selects/rejects items received from a producer.
 type Filter interface {
@@ -29,9 +38,11 @@ func (f *FilterSkip) Count(ctx context.Context, in <-chan int) <-chan int {
 		}
 
 		// calculate the correct total count
-		if total < f.Skip {
+		switch {
+		case total == UnknownCount:
+		case total < f.Skip:
 			total = 0
-		} else {
+		default:
 			total -= f.Skip
 		}
 
@@ -81,7 +92,9 @@ func (f *FilterSkip) Select(ctx context.Context, in <-chan string) <-chan string
 	return out
 }
 
-// FilterLimit passes through at most Max values.
+// FilterLimit passes through at most Max values. The output channel is closed
+// as soon as Max values have been passed through, so a producer which never
+// ends (e.g. for --follow) does not keep the run going.
 type FilterLimit struct {
 	Max int
 }
@@ -98,8 +111,9 @@ func (f *FilterLimit) Count(ctx context.Context, in <-chan int) <-chan int {
 		case <-ctx.Done():
 		}
 
-		// calculate the correct total count
-		if total > f.Max {
+		// calculate the correct total count, at most Max values are passed
+		// through but the number is still unknown if there are fewer
+		if total != UnknownCount && total > f.Max {
 			total = f.Max
 		}
 
@@ -116,9 +130,74 @@ func (f *FilterLimit) Count(ctx context.Context, in <-chan int) <-chan int {
 func (f *FilterLimit) Select(ctx context.Context, in <-chan string) <-chan string {
 	out := make(chan string)
 
+	go func() {
+		for cur := 0; cur < f.Max; cur++ {
+			var v string
+			var ok bool
+			select {
+			case <-ctx.Done():
+				close(out)
+				return
+			case v, ok = <-in:
+				// when the input channel is closed we're done
+				if !ok {
+					close(out)
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				close(out)
+				return
+			case out <- v:
+			}
+		}
+
+		close(out)
+
+		// drop the remaining values, so that the producer can finish and
+		// send the number of values
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-in:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// CountDuplicates passes through all values unmodified and counts how many of
+// them have been seen before. All distinct values are kept in memory, unless
+// MaxEntries is set: then at most MaxEntries values are kept and the least
+// recently seen value is forgotten when a new one is added, so duplicates
+// which are further apart than MaxEntries distinct values are not counted.
+type CountDuplicates struct {
+	MaxEntries int
+
+	mu         sync.Mutex
+	seen       map[string]*list.Element
+	lru        *list.List // most recently seen value at the front, only used with MaxEntries
+	duplicates int
+}
+
+// Count passes through the number of values.
+func (f *CountDuplicates) Count(ctx context.Context, in <-chan int) <-chan int {
+	return in
+}
+
+// Select passes through the values sent over in and records duplicates.
+func (f *CountDuplicates) Select(ctx context.Context, in <-chan string) <-chan string {
+	out := make(chan string)
+
 	go func() {
 		defer close(out)
-		var cur int
 		for {
 			var v string
 			var ok bool
@@ -132,11 +211,9 @@ func (f *FilterLimit) Select(ctx context.Context, in <-chan string) <-chan strin
 				}
 			}
 
-			if cur >= f.Max {
-				// drop value, receive next
-				continue
-			}
-			cur++
+			f.mu.Lock()
+			f.record(v)
+			f.mu.Unlock()
 
 			select {
 			case <-ctx.Done():
@@ -148,3 +225,40 @@ func (f *FilterLimit) Select(ctx context.Context, in <-chan string) <-chan strin
 
 	return out
 }
+
+// record counts v as a duplicate if it is contained in the set of values seen
+// before, and adds it otherwise. f.mu must be held.
+func (f *CountDuplicates) record(v string) {
+	if f.seen == nil {
+		f.seen = make(map[string]*list.Element)
+		f.lru = list.New()
+	}
+
+	if e, ok := f.seen[v]; ok {
+		f.duplicates++
+		if e != nil {
+			f.lru.MoveToFront(e)
+		}
+		return
+	}
+
+	if f.MaxEntries <= 0 {
+		f.seen[v] = nil
+		return
+	}
+
+	f.seen[v] = f.lru.PushFront(v)
+	if f.lru.Len() > f.MaxEntries {
+		oldest := f.lru.Back()
+		f.lru.Remove(oldest)
+		delete(f.seen, oldest.Value.(string))
+	}
+}
+
+// Duplicates returns the number of duplicate values seen so far.
+func (f *CountDuplicates) Duplicates() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.duplicates
+}
@@ -0,0 +1,112 @@
+package producer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCountDuplicates(t *testing.T) {
+	var tests = []struct {
+		values     []string
+		maxEntries int
+		duplicates int
+	}{
+		{nil, 0, 0},
+		{[]string{"a", "b", "c"}, 0, 0},
+		{[]string{"a", "b", "a", "a", "c", "b"}, 0, 3},
+		{[]string{"", ""}, 0, 1},
+		{[]string{"a", "b", "a"}, 1, 0},
+		{[]string{"a", "b", "a"}, 2, 1},
+		{[]string{"a", "b", "a", "c", "a", "b"}, 2, 2},
+		{[]string{"a", "a", "a"}, 1, 2},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			in := make(chan string, len(test.values))
+			for _, v := range test.values {
+				in <- v
+			}
+			close(in)
+
+			f := &CountDuplicates{MaxEntries: test.maxEntries}
+			var values []string
+			for v := range f.Select(context.Background(), in) {
+				values = append(values, v)
+			}
+
+			if !cmp.Equal(test.values, values) {
+				t.Error(cmp.Diff(test.values, values))
+			}
+
+			if f.Duplicates() != test.duplicates {
+				t.Errorf("wrong number of duplicates, want %d, got %d", test.duplicates, f.Duplicates())
+			}
+		})
+	}
+}
+
+func TestFilterReverse(t *testing.T) {
+	var tests = []struct {
+		values []string
+		want   []string
+	}{
+		{nil, nil},
+		{[]string{"a"}, []string{"a"}},
+		{[]string{"a", "b", "", "c"}, []string{"c", "", "b", "a"}},
+		{numbers(0, 9), []string{"9", "8", "7", "6", "5", "4", "3", "2", "1", "0"}},
+	}
+
+	for _, test := range tests {
+		for _, countFirst := range []bool{true, false} {
+			t.Run("", func(t *testing.T) {
+				values, count := runFilter(t, &FilterReverse{}, test.values, countFirst)
+
+				if !cmp.Equal(test.want, values) {
+					t.Error(cmp.Diff(test.want, values))
+				}
+
+				if count != len(test.values) {
+					t.Errorf("wrong count, want %d, got %d", len(test.values), count)
+				}
+			})
+		}
+	}
+}
+
+func TestFilterUnknownCount(t *testing.T) {
+	var tests = []struct {
+		filter Filter
+		want   []string
+	}{
+		{&FilterSkip{Skip: 3}, numbers(3, 9)},
+		{&FilterLimit{Max: 4}, numbers(0, 3)},
+		{&FilterLimit{Max: 20}, numbers(0, 9)},
+		{&FilterWrap{Prefixes: []string{"", "x"}}, []string{
+			"0", "x0", "1", "x1", "2", "x2", "3", "x3", "4", "x4",
+			"5", "x5", "6", "x6", "7", "x7", "8", "x8", "9", "x9",
+		}},
+		{&FilterShardMod{Shard: 2, Shards: 3}, []string{"1", "4", "7"}},
+		{&FilterShard{Shard: 2, Shards: 3}, numbers(3, 5)},
+		{&FilterShard{Shard: 3, Shards: 3}, numbers(6, 9)},
+		{&FilterReverse{}, []string{"9", "8", "7", "6", "5", "4", "3", "2", "1", "0"}},
+	}
+
+	for _, test := range tests {
+		for _, countFirst := range []bool{true, false} {
+			t.Run("", func(t *testing.T) {
+				values, count := runFilterCount(t, test.filter, numbers(0, 9), UnknownCount, countFirst)
+
+				if !cmp.Equal(test.want, values) {
+					t.Error(cmp.Diff(test.want, values))
+				}
+
+				if count != UnknownCount {
+					t.Errorf("wrong count, want %d, got %d", UnknownCount, count)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package producer
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// followReader reads from a file and waits for more data at the end of the
+// file instead of returning io.EOF, until the context is cancelled.
+type followReader struct {
+	ctx      context.Context
+	file     *os.File
+	interval time.Duration
+	done     chan struct{}
+}
+
+// OpenFollow opens the file filename for NewFollowReader. A named pipe (FIFO)
+// is opened for writing as well, so that reading does not return io.EOF when
+// the last writer closes it, but blocks until the next writer sends data.
+func OpenFollow(filename string) (*os.File, error) {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.Mode()&os.ModeNamedPipe != 0 {
+		return os.OpenFile(filename, os.O_RDWR, 0)
+	}
+
+	return os.Open(filename)
+}
+
+// NewFollowReader returns a reader for file which (like 'tail -f') checks
+// every interval for new data when the end of the file has been reached. It
+// only returns io.EOF when ctx is cancelled. The file is closed when ctx is
+// cancelled or the reader is closed.
+func NewFollowReader(ctx context.Context, file *os.File, interval time.Duration) io.ReadCloser {
+	rd := &followReader{
+		ctx:      ctx,
+		file:     file,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+
+	// closing the file unblocks a read from a FIFO which waits for data
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+		case <-rd.done:
+		}
+	}()
+
+	return rd
+}
+
+func (rd *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := rd.file.Read(p)
+		if rd.ctx.Err() != nil {
+			return 0, io.EOF
+		}
+
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+
+		select {
+		case <-rd.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(rd.interval):
+		}
+	}
+}
+
+func (rd *followReader) Close() error {
+	close(rd.done)
+	return rd.file.Close()
+}
+
+// Follow sends all records separated by delim read from rd to ch like Reader,
+// but rd is expected to never end (e.g. a reader returned by
+// NewFollowReader), so UnknownCount is sent to count right away. Sending stops
+// and ch is closed when an error occurs or the context is cancelled.
+func Follow(ctx context.Context, rd io.ReadCloser, delim byte, ch chan<- string, count chan<- int) error {
+	count <- UnknownCount
+
+	// the number of values Reader sends at the end is discarded
+	return Reader(ctx, rd, delim, ch, make(chan int, 1))
+}
@@ -0,0 +1,163 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFollow(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-follow-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	filename := filepath.Join(tempdir, "values")
+	err = ioutil.WriteFile(filename, []byte("a\nb\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	file, err := OpenFollow(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan string)
+	count := make(chan int, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, NewFollowReader(ctx, file, 10*time.Millisecond), '\n', ch, count)
+	}()
+
+	if c := <-count; c != UnknownCount {
+		t.Errorf("wrong count, want %d, got %d", UnknownCount, c)
+	}
+
+	var values []string
+	for len(values) < 2 {
+		values = append(values, <-ch)
+	}
+
+	// append more values after the end of the file has been reached
+	wr, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = wr.WriteString("c\nd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last value is not complete yet
+	values = append(values, <-ch)
+
+	select {
+	case v := <-ch:
+		t.Fatalf("received incomplete value %q", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = wr.WriteString("e\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values = append(values, <-ch)
+
+	want := []string{"a", "b", "c", "de"}
+	if !cmp.Equal(want, values) {
+		t.Error(cmp.Diff(want, values))
+	}
+
+	// the reader only stops when the context is cancelled
+	select {
+	case err := <-done:
+		t.Fatalf("Follow returned before the context was cancelled: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	for v := range ch {
+		t.Errorf("received unexpected value %q", v)
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowLimit(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-follow-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	filename := filepath.Join(tempdir, "values")
+	err = ioutil.WriteFile(filename, []byte("a\nb\nc\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	file, err := OpenFollow(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan string)
+	count := make(chan int, 1)
+	go func() {
+		_ = Follow(ctx, NewFollowReader(ctx, file, 10*time.Millisecond), '\n', ch, count)
+	}()
+
+	limit := &FilterLimit{Max: 2}
+	outCount := limit.Count(ctx, count)
+	out := limit.Select(ctx, ch)
+
+	// the output is closed after the last value although the file is never
+	// closed
+	var values []string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				done = true
+				break
+			}
+			values = append(values, v)
+		case <-timeout:
+			t.Fatalf("output channel was not closed, received %q", values)
+		}
+	}
+
+	want := []string{"a", "b"}
+	if !cmp.Equal(want, values) {
+		t.Error(cmp.Diff(want, values))
+	}
+
+	if c := <-outCount; c != UnknownCount {
+		t.Errorf("wrong count, want %d, got %d", UnknownCount, c)
+	}
+}
@@ -7,19 +7,34 @@ import (
 	"github.com/juju/ratelimit"
 )
 
-// Limit limits the number of values per second to the value perSecond. A new
-// goroutine is started, which terminates when in is closed or the context is
-// cancelled.
-func Limit(ctx context.Context, perSecond float64, in <-chan string) <-chan string {
+// Limiter limits the number of values per second, it can be shared between
+// several channels so that the rate applies to all values together.
+type Limiter struct {
+	bucket *ratelimit.Bucket
+}
+
+// NewLimiter returns a limiter for perSecond values per second. Up to burst
+// values (at least one) are passed on without delay if the rate has been
+// lower before (a token bucket with the capacity burst, which is full at the
+// start), the average rate is still at most perSecond.
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
 	fillInterval := time.Duration(float64(time.Second) / float64(perSecond))
-	bucket := ratelimit.NewBucket(fillInterval, 1)
+	return &Limiter{bucket: ratelimit.NewBucket(fillInterval, int64(burst))}
+}
 
+// Limit passes on the values from in, delayed by the limiter. A new goroutine
+// is started, which terminates when in is closed or the context is cancelled.
+func (l *Limiter) Limit(ctx context.Context, in <-chan string) <-chan string {
 	out := make(chan string)
 
 	go func() {
 		defer close(out)
 		for s := range in {
-			timeout := bucket.Take(1)
+			timeout := l.bucket.Take(1)
 			select {
 			case <-time.After(timeout):
 			case <-ctx.Done():
@@ -36,3 +51,10 @@ func Limit(ctx context.Context, perSecond float64, in <-chan string) <-chan stri
 
 	return out
 }
+
+// Limit limits the number of values per second to the value perSecond, with
+// bursts of up to burst values (see NewLimiter). A new goroutine is started,
+// which terminates when in is closed or the context is cancelled.
+func Limit(ctx context.Context, perSecond float64, burst int, in <-chan string) <-chan string {
+	return NewLimiter(perSecond, burst).Limit(ctx, in)
+}
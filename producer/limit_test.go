@@ -0,0 +1,94 @@
+package producer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimit(t *testing.T) {
+	var tests = []struct {
+		perSecond float64
+		burst     int
+		values    int
+
+		// the number of values received without delay
+		immediate int
+	}{
+		{20, 1, 4, 1},
+		{20, 5, 8, 5},
+		{20, 0, 3, 1},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			in := make(chan string, test.values)
+			for i := 0; i < test.values; i++ {
+				in <- "x"
+			}
+			close(in)
+
+			start := time.Now()
+			var times []time.Duration
+			for range Limit(context.Background(), test.perSecond, test.burst, in) {
+				times = append(times, time.Since(start))
+			}
+
+			if len(times) != test.values {
+				t.Fatalf("wrong number of values, want %d, got %d", test.values, len(times))
+			}
+
+			interval := time.Duration(float64(time.Second) / test.perSecond)
+			for i, d := range times {
+				if i < test.immediate && d > interval/2 {
+					t.Errorf("value %d delayed by %v, want no delay", i, d)
+				}
+
+				// the values after the burst arrive at the rate
+				if i >= test.immediate {
+					min := time.Duration(i-test.immediate+1)*interval - interval/2
+					if d < min {
+						t.Errorf("value %d received after %v, want at least %v", i, d, min)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterShared(t *testing.T) {
+	const perSecond = 20
+	interval := time.Duration(float64(time.Second) / perSecond)
+
+	limiter := NewLimiter(perSecond, 1)
+	ctx := context.Background()
+
+	var outs []<-chan string
+	for i := 0; i < 2; i++ {
+		in := make(chan string, 3)
+		for j := 0; j < 3; j++ {
+			in <- "x"
+		}
+		close(in)
+		outs = append(outs, limiter.Limit(ctx, in))
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, out := range outs {
+		wg.Add(1)
+		go func(out <-chan string) {
+			defer wg.Done()
+			for range out {
+			}
+		}(out)
+	}
+	wg.Wait()
+
+	// six values in total, the first one without delay
+	min := 5*interval - interval/2
+	if d := time.Since(start); d < min {
+		t.Errorf("values received after %v, want at least %v", d, min)
+	}
+}
@@ -0,0 +1,110 @@
+package producer
+
+import (
+	"context"
+	"regexp"
+)
+
+// FilterMatch passes through only the values matching Pattern. The number of
+// selected values is only known if it has been computed beforehand and was
+// set as Values (e.g. by counting the matching lines of the input file),
+// otherwise UnknownCount is passed on.
+type FilterMatch struct {
+	Pattern *regexp.Regexp
+	Values  int
+}
+
+// Count filters the number of values.
+func (f *FilterMatch) Count(ctx context.Context, in <-chan int) <-chan int {
+	return matchCount(ctx, in, f.Values)
+}
+
+// Select filters values sent over in.
+func (f *FilterMatch) Select(ctx context.Context, in <-chan string) <-chan string {
+	return matchSelect(ctx, in, func(v string) bool {
+		return f.Pattern.MatchString(v)
+	})
+}
+
+// FilterReject drops all values matching Pattern. The number of values passed
+// on is computed like for FilterMatch.
+type FilterReject struct {
+	Pattern *regexp.Regexp
+	Values  int
+}
+
+// Count filters the number of values.
+func (f *FilterReject) Count(ctx context.Context, in <-chan int) <-chan int {
+	return matchCount(ctx, in, f.Values)
+}
+
+// Select filters values sent over in.
+func (f *FilterReject) Select(ctx context.Context, in <-chan string) <-chan string {
+	return matchSelect(ctx, in, func(v string) bool {
+		return !f.Pattern.MatchString(v)
+	})
+}
+
+// matchCount passes on values if it is set, zero if no values are received at
+// all and UnknownCount otherwise.
+func matchCount(ctx context.Context, in <-chan int, values int) <-chan int {
+	out := make(chan int, 1)
+
+	go func() {
+		defer close(out)
+		var total int
+		select {
+		case total = <-in:
+		case <-ctx.Done():
+		}
+
+		switch {
+		case values > 0:
+			total = values
+		case total != 0:
+			total = UnknownCount
+		}
+
+		select {
+		case out <- total:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
+// matchSelect passes through all values for which keep returns true.
+func matchSelect(ctx context.Context, in <-chan string, keep func(string) bool) <-chan string {
+	out := make(chan string)
+
+	go func() {
+		defer close(out)
+		for {
+			var v string
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+				// when the input channel is closed we're done
+				if !ok {
+					return
+				}
+			}
+
+			if !keep(v) {
+				// drop value, receive next
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
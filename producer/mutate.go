@@ -0,0 +1,107 @@
+package producer
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+)
+
+// interestingValues are inserted into or replace parts of the seed value.
+var interestingValues = []string{
+	"", "0", "-1", "1", "2147483647", "-2147483648", "4294967295",
+	"18446744073709551615", "1e308", "NaN", "null", "undefined", "true",
+	"%00", "%0d%0a", "\x00", "\r\n", "\n", "'", "\"", "`", "\\", "../",
+	"..\\", "%s%s%s%s", "%n", "{{7*7}}", "${7*7}", "<script>", "';--",
+	"\" or \"1\"=\"1", "||", "&&", ";", "|", "$(id)", "\xff\xfe",
+	strings.Repeat("A", 256), strings.Repeat("A", 4096),
+}
+
+// mutators modify a value using the random number generator.
+var mutators = []func(*rand.Rand, []byte) []byte{
+	// flip a single bit
+	func(rnd *rand.Rand, buf []byte) []byte {
+		if len(buf) == 0 {
+			return buf
+		}
+		buf[rnd.Intn(len(buf))] ^= 1 << uint(rnd.Intn(8))
+		return buf
+	},
+
+	// insert a random byte
+	func(rnd *rand.Rand, buf []byte) []byte {
+		pos := rnd.Intn(len(buf) + 1)
+		return insert(buf, pos, []byte{byte(rnd.Intn(256))})
+	},
+
+	// remove a byte
+	func(rnd *rand.Rand, buf []byte) []byte {
+		if len(buf) == 0 {
+			return buf
+		}
+		pos := rnd.Intn(len(buf))
+		return append(buf[:pos], buf[pos+1:]...)
+	},
+
+	// duplicate a part of the value
+	func(rnd *rand.Rand, buf []byte) []byte {
+		if len(buf) == 0 {
+			return buf
+		}
+		start := rnd.Intn(len(buf))
+		end := start + 1 + rnd.Intn(len(buf)-start)
+		return insert(buf, end, append([]byte(nil), buf[start:end]...))
+	},
+
+	// insert an interesting value
+	func(rnd *rand.Rand, buf []byte) []byte {
+		pos := rnd.Intn(len(buf) + 1)
+		return insert(buf, pos, []byte(interestingValues[rnd.Intn(len(interestingValues))]))
+	},
+
+	// replace a part of the value with an interesting value
+	func(rnd *rand.Rand, buf []byte) []byte {
+		start := rnd.Intn(len(buf) + 1)
+		end := start + rnd.Intn(len(buf)-start+1)
+		v := []byte(interestingValues[rnd.Intn(len(interestingValues))])
+		return insert(append(buf[:start:start], buf[end:]...), start, v)
+	},
+}
+
+func insert(buf []byte, pos int, data []byte) []byte {
+	res := make([]byte, 0, len(buf)+len(data))
+	res = append(res, buf[:pos]...)
+	res = append(res, data...)
+	return append(res, buf[pos:]...)
+}
+
+// maxMutations is the maximum number of mutations applied to the seed to
+// generate a single value.
+const maxMutations = 3
+
+// Mutate sends n values to ch, each generated by applying one or more random
+// mutations (bit flips, insertions, deletions, interesting values) to seed.
+// The random number generator is initialized with rngSeed, so the same
+// values are generated for the same seed.
+func Mutate(ctx context.Context, seed string, n int, rngSeed int64, ch chan<- string, count chan<- int) error {
+	count <- n
+
+	defer close(ch)
+
+	rnd := rand.New(rand.NewSource(rngSeed))
+
+	for i := 0; i < n; i++ {
+		buf := []byte(seed)
+		mutations := 1 + rnd.Intn(maxMutations)
+		for j := 0; j < mutations; j++ {
+			buf = mutators[rnd.Intn(len(mutators))](rnd, buf)
+		}
+
+		select {
+		case ch <- string(buf):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package producer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mutate(t testing.TB, seed string, n int, rngSeed int64) []string {
+	ch := make(chan string)
+	count := make(chan int, 1)
+
+	go func() {
+		err := Mutate(context.Background(), seed, n, rngSeed, ch, count)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var values []string
+	for v := range ch {
+		values = append(values, v)
+	}
+
+	if c := <-count; c != n {
+		t.Errorf("wrong count, want %d, got %d", n, c)
+	}
+
+	return values
+}
+
+func TestMutate(t *testing.T) {
+	var tests = []struct {
+		seed string
+		n    int
+	}{
+		{"admin", 100},
+		{"", 50},
+		{"x", 1},
+		{"foo", 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			values := mutate(t, test.seed, test.n, 42)
+			if len(values) != test.n {
+				t.Fatalf("wrong number of values, want %d, got %d", test.n, len(values))
+			}
+
+			// the same seed for the random number generator yields the same values
+			again := mutate(t, test.seed, test.n, 42)
+			if !cmp.Equal(values, again) {
+				t.Errorf("values differ for the same seed: %v", cmp.Diff(values, again))
+			}
+
+			if test.n < 20 {
+				return
+			}
+
+			distinct := make(map[string]struct{})
+			for _, v := range values {
+				distinct[v] = struct{}{}
+			}
+
+			if len(distinct) < test.n/2 {
+				t.Errorf("too few distinct values: %d of %d", len(distinct), test.n)
+			}
+
+			other := mutate(t, test.seed, test.n, 23)
+			if cmp.Equal(values, other) {
+				t.Errorf("values are equal for different seeds")
+			}
+		})
+	}
+}
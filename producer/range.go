@@ -3,12 +3,15 @@ package producer
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"strconv"
 )
 
 // Range defines a range of values which should be tested.
 type Range struct {
 	First, Last int
+	Step        int // distance between two values, 1 is used if unset
+	Factor      int // if larger than 1, each value is multiplied by Factor to get the next one (Step is ignored)
 }
 
 // ParseRange parses a range from the string s. Valid formats are `n` and `n-m`.
@@ -32,9 +35,63 @@ func ParseRange(s string) (r Range, err error) {
 	return r, nil
 }
 
+func (r Range) step() int {
+	if r.Step <= 0 {
+		return 1
+	}
+	return r.Step
+}
+
+// next returns the value after i, ok is false if the value is not in the
+// range any more.
+func (r Range) next(i int) (n int, ok bool) {
+	if r.Factor > 1 {
+		// make sure the multiplication does not overflow
+		if i > r.Last/r.Factor {
+			return 0, false
+		}
+		n = i * r.Factor
+	} else {
+		if i > r.Last-r.step() {
+			return 0, false
+		}
+		n = i + r.step()
+	}
+
+	return n, n <= r.Last
+}
+
 // Count returns the number of items in the range.
 func (r Range) Count() int {
-	return r.Last - r.First + 1
+	if r.Factor <= 1 {
+		return (r.Last-r.First)/r.step() + 1
+	}
+
+	n := 1
+	for i, ok := r.next(r.First); ok; i, ok = r.next(i) {
+		n++
+	}
+	return n
+}
+
+func (r Range) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// CheckOverlap returns an error if two of the ranges overlap.
+func CheckOverlap(ranges []Range) error {
+	for i, r1 := range ranges {
+		for _, r2 := range ranges[i+1:] {
+			if r1.First <= r2.Last && r2.First <= r1.Last {
+				return fmt.Errorf("ranges %v and %v overlap", r1, r2)
+			}
+		}
+	}
+
+	return nil
 }
 
 // Ranges sends all range values to the channel ch, and the number of items to
@@ -56,7 +113,7 @@ func Ranges(ctx context.Context, ranges []Range, format string, ch chan<- string
 	defer close(ch)
 
 	for _, r := range ranges {
-		for i := r.First; i <= r.Last; i++ {
+		for i, ok := r.First, true; ok; i, ok = r.next(i) {
 			v := fmt.Sprintf(format, i)
 			select {
 			case ch <- v:
@@ -68,3 +125,83 @@ func Ranges(ctx context.Context, ranges []Range, format string, ch chan<- string
 
 	return nil
 }
+
+// nth returns the value with index k in the range.
+func (r Range) nth(k int) int {
+	if r.Factor <= 1 {
+		return r.First + k*r.step()
+	}
+
+	n := r.First
+	for i := 0; i < k; i++ {
+		n, _ = r.next(n)
+	}
+	return n
+}
+
+// RandomRanges sends n values chosen randomly from the ranges to ch, and n to
+// the channel count. If unique is set, each value is sent at most once, so n
+// must not be larger than the number of values in the ranges. The random
+// number generator is initialized with seed, so the same values are generated
+// for the same seed. Sending stops and ch is closed when the context is
+// cancelled. When format is the empty string, "%d" is used.
+func RandomRanges(ctx context.Context, ranges []Range, format string, n int, unique bool, seed int64, ch chan<- string, count chan<- int) error {
+	if format == "" {
+		format = "%d"
+	}
+
+	var total int
+	for _, r := range ranges {
+		c := r.Count()
+		if c <= 0 || total+c <= 0 {
+			close(ch)
+			return fmt.Errorf("range %v is too large for random values", r)
+		}
+		total += c
+	}
+
+	if unique && n > total {
+		close(ch)
+		return fmt.Errorf("cannot choose %d unique random values from %d values in the ranges", n, total)
+	}
+
+	count <- n
+
+	defer close(ch)
+
+	rnd := rand.New(rand.NewSource(seed))
+	seen := make(map[int]struct{})
+
+	for i := 0; i < n; i++ {
+		k := int(rnd.Int63n(int64(total)))
+		if unique {
+			// choose another value until an unused one is found
+			for {
+				if _, ok := seen[k]; !ok {
+					break
+				}
+				k = int(rnd.Int63n(int64(total)))
+			}
+			seen[k] = struct{}{}
+		}
+
+		// find the range for the index
+		var v int
+		for _, r := range ranges {
+			c := r.Count()
+			if k < c {
+				v = r.nth(k)
+				break
+			}
+			k -= c
+		}
+
+		select {
+		case ch <- fmt.Sprintf(format, v):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
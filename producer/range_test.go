@@ -0,0 +1,227 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRanges(t *testing.T) {
+	var tests = []struct {
+		ranges []string
+		step   int
+		factor int
+		format string
+		want   []string
+	}{
+		{
+			ranges: []string{"1-3"},
+			want:   []string{"1", "2", "3"},
+		},
+		{
+			ranges: []string{"1-3", "7", "9-10"},
+			want:   []string{"1", "2", "3", "7", "9", "10"},
+		},
+		{
+			ranges: []string{"0-10", "100-105"},
+			step:   5,
+			want:   []string{"0", "5", "10", "100", "105"},
+		},
+		{
+			ranges: []string{"1-8", "20-22"},
+			step:   3,
+			format: "%03d",
+			want:   []string{"001", "004", "007", "020"},
+		},
+		{
+			ranges: []string{"1-100"},
+			factor: 2,
+			want:   []string{"1", "2", "4", "8", "16", "32", "64"},
+		},
+		{
+			ranges: []string{"3-300", "1000-10000"},
+			factor: 10,
+			want:   []string{"3", "30", "300", "1000", "10000"},
+		},
+		{
+			ranges: []string{"5-9"},
+			factor: 2,
+			want:   []string{"5"},
+		},
+		{
+			// no overflow for large values
+			ranges: []string{"4611686018427387904-9223372036854775807"},
+			factor: 2,
+			want:   []string{"4611686018427387904"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var ranges []Range
+			for _, s := range test.ranges {
+				r, err := ParseRange(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.Step = test.step
+				r.Factor = test.factor
+				ranges = append(ranges, r)
+			}
+
+			ch := make(chan string)
+			count := make(chan int, 1)
+			go func() {
+				err := Ranges(context.Background(), ranges, test.format, ch, count)
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+
+			var values []string
+			for v := range ch {
+				values = append(values, v)
+			}
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			if c := <-count; c != len(test.want) {
+				t.Errorf("wrong count, want %d, got %d", len(test.want), c)
+			}
+		})
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	var tests = []struct {
+		ranges []string
+		err    string
+	}{
+		{[]string{"1-x"}, "wrong format"},
+		{[]string{"10-1"}, "smaller than first"},
+		{[]string{"1-10", "5-20"}, "ranges 1-10 and 5-20 overlap"},
+		{[]string{"1-10", "20-30", "30"}, "ranges 20-30 and 30 overlap"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var ranges []Range
+			var err error
+			for _, s := range test.ranges {
+				var r Range
+				r, err = ParseRange(s)
+				if err != nil {
+					break
+				}
+				ranges = append(ranges, r)
+			}
+
+			if err == nil {
+				err = CheckOverlap(ranges)
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("wrong error, want %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestRandomRanges(t *testing.T) {
+	var tests = []struct {
+		ranges []string
+		step   int
+		factor int
+		n      int
+		unique bool
+	}{
+		{ranges: []string{"1-1000000"}, n: 100},
+		{ranges: []string{"1-10"}, n: 10, unique: true},
+		{ranges: []string{"1-3", "100-103"}, n: 7, unique: true},
+		{ranges: []string{"1-5"}, n: 50},
+		{ranges: []string{"0-100"}, step: 10, n: 11, unique: true},
+		{ranges: []string{"1-1000"}, factor: 2, n: 10, unique: true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var ranges []Range
+			valid := make(map[string]struct{})
+			for _, s := range test.ranges {
+				r, err := ParseRange(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.Step = test.step
+				r.Factor = test.factor
+				ranges = append(ranges, r)
+
+				for i, ok := r.First, true; ok; i, ok = r.next(i) {
+					valid[fmt.Sprintf("%d", i)] = struct{}{}
+				}
+			}
+
+			run := func(seed int64) []string {
+				ch := make(chan string)
+				count := make(chan int, 1)
+				go func() {
+					err := RandomRanges(context.Background(), ranges, "", test.n, test.unique, seed, ch, count)
+					if err != nil {
+						t.Error(err)
+					}
+				}()
+
+				var values []string
+				for v := range ch {
+					values = append(values, v)
+				}
+
+				if c := <-count; c != test.n {
+					t.Errorf("wrong count, want %d, got %d", test.n, c)
+				}
+
+				return values
+			}
+
+			values := run(23)
+			if len(values) != test.n {
+				t.Fatalf("wrong number of values, want %d, got %d", test.n, len(values))
+			}
+
+			seen := make(map[string]struct{})
+			for _, v := range values {
+				if _, ok := valid[v]; !ok {
+					t.Errorf("value %v is not in the ranges", v)
+				}
+
+				if _, ok := seen[v]; ok && test.unique {
+					t.Errorf("value %v was sent more than once", v)
+				}
+				seen[v] = struct{}{}
+			}
+
+			// the same seed generates the same values
+			if again := run(23); !cmp.Equal(values, again) {
+				t.Error(cmp.Diff(values, again))
+			}
+		})
+	}
+}
+
+func TestRandomRangesTooManyUnique(t *testing.T) {
+	ch := make(chan string)
+	count := make(chan int, 1)
+	err := RandomRanges(context.Background(), []Range{{First: 1, Last: 5}}, "", 6, true, 1, ch, count)
+	if err == nil {
+		t.Fatal("expected error not returned")
+	}
+
+	// the channel is closed
+	for range ch {
+	}
+}
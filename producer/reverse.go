@@ -0,0 +1,49 @@
+package producer
+
+import "context"
+
+// FilterReverse sends the values in reverse order. All values are read and
+// kept in memory before the first one is sent.
+type FilterReverse struct{}
+
+// Count passes through the number of values.
+func (f *FilterReverse) Count(ctx context.Context, in <-chan int) <-chan int {
+	return in
+}
+
+// Select collects all values sent over in and sends them in reverse order.
+func (f *FilterReverse) Select(ctx context.Context, in <-chan string) <-chan string {
+	out := make(chan string)
+
+	go func() {
+		defer close(out)
+
+		var values []string
+		for {
+			var v string
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+			}
+
+			// when the input channel is closed all values have been read
+			if !ok {
+				break
+			}
+
+			values = append(values, v)
+		}
+
+		for i := len(values) - 1; i >= 0; i-- {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- values[i]:
+			}
+		}
+	}()
+
+	return out
+}
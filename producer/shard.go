@@ -0,0 +1,271 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParseShard parses a shard specification "i/n", which selects the shard i
+// (starting at 1) of n shards.
+func ParseShard(s string) (shard, shards int, err error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid shard %q, must be i/n", s)
+	}
+
+	shard, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: %v", s, err)
+	}
+
+	shards, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: %v", s, err)
+	}
+
+	if shards < 1 || shard < 1 || shard > shards {
+		return 0, 0, fmt.Errorf("invalid shard %q, must be i/n with 1 <= i <= n", s)
+	}
+
+	return shard, shards, nil
+}
+
+// FilterShard passes through the contiguous part of the values for shard
+// Shard (starting at 1) of Shards. The number of values must be known to
+// compute the part, if Values is zero it is taken from the count channel and
+// all values received before the count are kept in memory. If the producer
+// sends UnknownCount, all values are kept in memory until the input is closed.
+type FilterShard struct {
+	Shard  int
+	Shards int
+	Values int
+
+	once  sync.Once
+	total chan int
+}
+
+func (f *FilterShard) init() {
+	f.once.Do(func() {
+		f.total = make(chan int, 1)
+	})
+}
+
+// bounds returns the indexes of the first value of the shard and the first
+// value after the shard.
+func (f *FilterShard) bounds(total int) (start, end int) {
+	return (f.Shard - 1) * total / f.Shards, f.Shard * total / f.Shards
+}
+
+// Count filters the number of values.
+func (f *FilterShard) Count(ctx context.Context, in <-chan int) <-chan int {
+	out := make(chan int, 1)
+	f.init()
+
+	go func() {
+		defer close(out)
+
+		total := f.Values
+		if total == 0 {
+			select {
+			case total = <-in:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// pass on the number of values for Select
+		f.total <- total
+
+		n := UnknownCount
+		if total != UnknownCount {
+			start, end := f.bounds(total)
+			n = end - start
+		}
+
+		select {
+		case out <- n:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
+// Select filters values sent over ch. The values are only sent once the
+// number of values has been received by Count.
+func (f *FilterShard) Select(ctx context.Context, in <-chan string) <-chan string {
+	out := make(chan string)
+	f.init()
+
+	go func() {
+		defer close(out)
+
+		var (
+			cur        int
+			start, end = -1, -1
+			buf        []string
+			total      = f.total
+		)
+
+		send := func(v string) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- v:
+				return true
+			}
+		}
+
+		// flush sets the bounds for n values and sends the values received
+		// so far which belong to the shard
+		flush := func(n int) bool {
+			start, end = f.bounds(n)
+			for i, v := range buf {
+				if i >= start && i < end && !send(v) {
+					return false
+				}
+			}
+			buf = nil
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case n := <-total:
+				total = nil
+
+				// the number of values is only known when the input is closed
+				if n == UnknownCount {
+					if in == nil {
+						flush(cur)
+						return
+					}
+					continue
+				}
+
+				if !flush(n) {
+					return
+				}
+
+				// the input was closed before the number of values was known
+				if in == nil {
+					return
+				}
+
+			case v, ok := <-in:
+				// when the input channel is closed we're done, unless the
+				// number of values is still needed to send the buffer
+				if !ok {
+					if total != nil {
+						in = nil
+						continue
+					}
+
+					// the count was unknown, all values have been received
+					if start < 0 {
+						flush(cur)
+					}
+					return
+				}
+
+				idx := cur
+				cur++
+
+				if start < 0 {
+					// the number of values is not known yet
+					buf = append(buf, v)
+					continue
+				}
+
+				if idx >= start && idx < end && !send(v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// FilterShardMod passes through every value for which the index modulo
+// Shards selects shard Shard (starting at 1), so that each shard receives an
+// interleaved part of the values.
+type FilterShardMod struct {
+	Shard  int
+	Shards int
+}
+
+// Count filters the number of values.
+func (f *FilterShardMod) Count(ctx context.Context, in <-chan int) <-chan int {
+	out := make(chan int, 1)
+
+	go func() {
+		defer close(out)
+		var total int
+		select {
+		case total = <-in:
+		case <-ctx.Done():
+		}
+
+		// calculate the number of indexes i < total with i % Shards == Shard-1
+		if total != UnknownCount {
+			total -= f.Shard - 1
+			if total < 0 {
+				total = 0
+			}
+			total = (total + f.Shards - 1) / f.Shards
+		}
+
+		select {
+		case out <- total:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
+// Select filters values sent over ch.
+func (f *FilterShardMod) Select(ctx context.Context, in <-chan string) <-chan string {
+	out := make(chan string)
+
+	go func() {
+		defer close(out)
+		var cur int
+		for {
+			var v string
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+				// when the input channel is closed we're done
+				if !ok {
+					return
+				}
+			}
+
+			idx := cur
+			cur++
+
+			if idx%f.Shards != f.Shard-1 {
+				// drop value, receive next
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
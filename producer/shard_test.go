@@ -0,0 +1,159 @@
+package producer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// runFilter sends the values and then the count through f and returns the
+// values and the count passed on by the filter.
+func runFilter(t testing.TB, f Filter, values []string, countFirst bool) ([]string, int) {
+	return runFilterCount(t, f, values, len(values), countFirst)
+}
+
+// runFilterCount is like runFilter, but sends n as the number of values.
+func runFilterCount(t testing.TB, f Filter, values []string, n int, countFirst bool) ([]string, int) {
+	ctx := context.Background()
+
+	in := make(chan string)
+	count := make(chan int, 1)
+
+	outCount := f.Count(ctx, count)
+	out := f.Select(ctx, in)
+
+	go func() {
+		if countFirst {
+			count <- n
+		}
+		for _, v := range values {
+			in <- v
+		}
+		if !countFirst {
+			count <- n
+		}
+		close(in)
+	}()
+
+	var res []string
+	for v := range out {
+		res = append(res, v)
+	}
+
+	return res, <-outCount
+}
+
+func numbers(from, to int) (res []string) {
+	for i := from; i <= to; i++ {
+		res = append(res, strconv.Itoa(i))
+	}
+	return res
+}
+
+func TestFilterShard(t *testing.T) {
+	var tests = []struct {
+		shard, shards int
+		values        int
+		want          []string
+	}{
+		{1, 1, 10, numbers(0, 9)},
+		{1, 3, 10, numbers(0, 2)},
+		{2, 3, 10, numbers(3, 5)},
+		{3, 3, 10, numbers(6, 9)},
+		{1, 4, 2, nil},
+		{2, 4, 2, numbers(0, 0)},
+		{4, 4, 2, numbers(1, 1)},
+		{2, 2, 0, nil},
+	}
+
+	for _, test := range tests {
+		for _, variant := range []string{"count-first", "count-last", "known"} {
+			t.Run(variant, func(t *testing.T) {
+				f := &FilterShard{Shard: test.shard, Shards: test.shards}
+				if variant == "known" {
+					f.Values = test.values
+				}
+
+				values, count := runFilter(t, f, numbers(0, test.values-1), variant == "count-first")
+
+				if !cmp.Equal(test.want, values) {
+					t.Error(cmp.Diff(test.want, values))
+				}
+
+				if count != len(test.want) {
+					t.Errorf("wrong count, want %d, got %d", len(test.want), count)
+				}
+			})
+		}
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	var tests = []struct {
+		spec          string
+		shard, shards int
+		err           bool
+	}{
+		{"1/1", 1, 1, false},
+		{"2/5", 2, 5, false},
+		{"0/5", 0, 0, true},
+		{"6/5", 0, 0, true},
+		{"1/0", 0, 0, true},
+		{"1", 0, 0, true},
+		{"a/5", 0, 0, true},
+		{"1/2/3", 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			shard, shards, err := ParseShard(test.spec)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error for %q not found", test.spec)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if shard != test.shard || shards != test.shards {
+				t.Fatalf("wrong result for %q, want %d/%d, got %d/%d", test.spec, test.shard, test.shards, shard, shards)
+			}
+		})
+	}
+}
+
+func TestFilterShardMod(t *testing.T) {
+	var tests = []struct {
+		shard, shards int
+		values        int
+		want          []string
+	}{
+		{1, 1, 5, numbers(0, 4)},
+		{1, 3, 10, []string{"0", "3", "6", "9"}},
+		{2, 3, 10, []string{"1", "4", "7"}},
+		{3, 3, 10, []string{"2", "5", "8"}},
+		{3, 4, 2, nil},
+		{2, 4, 2, []string{"1"}},
+		{1, 2, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f := &FilterShardMod{Shard: test.shard, Shards: test.shards}
+			values, count := runFilter(t, f, numbers(0, test.values-1), true)
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			if count != len(test.want) {
+				t.Errorf("wrong count, want %d, got %d", len(test.want), count)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ParseWeight splits spec in the form name:weight (e.g. "list.txt:3") into
+// the name and the weight, which must be a positive integer. The name may
+// contain colons, the weight is separated by the last one.
+func ParseWeight(spec string) (name string, weight int, err error) {
+	i := strings.LastIndexByte(spec, ':')
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid weighted file %q, use name:weight", spec)
+	}
+
+	weight, err = strconv.Atoi(spec[i+1:])
+	if err != nil || weight <= 0 {
+		return "", 0, fmt.Errorf("invalid weight %q for %v, must be a positive integer", spec[i+1:], spec[:i])
+	}
+
+	return spec[:i], weight, nil
+}
+
+// Weighted sends the values received from all inputs to ch. For each value,
+// one of the inputs which are not exhausted yet is chosen randomly with a
+// probability proportional to its weight, so inputs with a higher weight are
+// sent earlier on average. The random number generator is initialized with
+// seed, so the same seed results in the same order. The number of items sent
+// to count is the sum of the counts of all inputs, or UnknownCount if one of
+// them is unknown. Sending stops and ch is closed when all inputs are closed
+// or the context is cancelled.
+func Weighted(ctx context.Context, inputs []<-chan string, counts []<-chan int, weights []int, seed int64, ch chan<- string, count chan<- int) error {
+	defer close(ch)
+
+	if len(inputs) != len(counts) || len(inputs) != len(weights) {
+		panic("number of inputs, counts and weights differ")
+	}
+
+	go func() {
+		total := 0
+		for _, c := range counts {
+			select {
+			case n := <-c:
+				if n == UnknownCount || total == UnknownCount {
+					total = UnknownCount
+				} else {
+					total += n
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case count <- total:
+		case <-ctx.Done():
+		}
+	}()
+
+	rnd := rand.New(rand.NewSource(seed))
+
+	// copy the slices, exhausted inputs are removed
+	inputs = append([]<-chan string(nil), inputs...)
+	weights = append([]int(nil), weights...)
+
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+
+	for len(inputs) > 0 {
+		// choose the input
+		n := rnd.Intn(sum)
+		i := 0
+		for n >= weights[i] {
+			n -= weights[i]
+			i++
+		}
+
+		var v string
+		var ok bool
+		select {
+		case v, ok = <-inputs[i]:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if !ok {
+			sum -= weights[i]
+			inputs = append(inputs[:i], inputs[i+1:]...)
+			weights = append(weights[:i], weights[i+1:]...)
+			continue
+		}
+
+		select {
+		case ch <- v:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package producer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseWeight(t *testing.T) {
+	var tests = []struct {
+		spec   string
+		name   string
+		weight int
+		err    bool
+	}{
+		{"list.txt:3", "list.txt", 3, false},
+		{"c:/lists/a.txt:1", "c:/lists/a.txt", 1, false},
+		{"list.txt", "", 0, true},
+		{":3", "", 0, true},
+		{"list.txt:0", "", 0, true},
+		{"list.txt:-1", "", 0, true},
+		{"list.txt:x", "", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			name, weight, err := ParseWeight(test.spec)
+			if test.err {
+				if err == nil {
+					t.Fatalf("ParseWeight(%q) did not return an error", test.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if name != test.name || weight != test.weight {
+				t.Errorf("wrong result, want %q %d, got %q %d", test.name, test.weight, name, weight)
+			}
+		})
+	}
+}
+
+// runWeighted sends the lists with the counts through Weighted and returns
+// the values and the count.
+func runWeighted(t testing.TB, lists [][]string, counts []int, weights []int, seed int64) ([]string, int) {
+	var inputs []<-chan string
+	var countChs []<-chan int
+	for i, list := range lists {
+		in := make(chan string, len(list))
+		for _, v := range list {
+			in <- v
+		}
+		close(in)
+		inputs = append(inputs, in)
+
+		c := make(chan int, 1)
+		c <- counts[i]
+		countChs = append(countChs, c)
+	}
+
+	ch := make(chan string)
+	count := make(chan int, 1)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Weighted(context.Background(), inputs, countChs, weights, seed, ch, count)
+	}()
+
+	var values []string
+	for v := range ch {
+		values = append(values, v)
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	return values, <-count
+}
+
+func TestWeighted(t *testing.T) {
+	short := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	long := numbers(0, 999)
+
+	values, count := runWeighted(t, [][]string{short, long}, []int{len(short), len(long)}, []int{10, 1}, 23)
+
+	if count != len(short)+len(long) {
+		t.Errorf("wrong count, want %d, got %d", len(short)+len(long), count)
+	}
+
+	// all values are sent exactly once, in the original order for each input
+	var fromShort, fromLong []string
+	for _, v := range values {
+		if strings.IndexFunc(v, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0 {
+			fromShort = append(fromShort, v)
+		} else {
+			fromLong = append(fromLong, v)
+		}
+	}
+
+	if !cmp.Equal(short, fromShort) {
+		t.Error(cmp.Diff(short, fromShort))
+	}
+
+	if !cmp.Equal(long, fromLong) {
+		t.Error(cmp.Diff(long, fromLong))
+	}
+
+	// with a weight of 10:1, the short list is sent early
+	last := 0
+	for i, v := range values {
+		if v == "j" {
+			last = i
+		}
+	}
+	if last > 50 {
+		t.Errorf("last value of the short list sent at position %d", last)
+	}
+
+	// the same seed results in the same order
+	again, _ := runWeighted(t, [][]string{short, long}, []int{len(short), len(long)}, []int{10, 1}, 23)
+	if !cmp.Equal(values, again) {
+		t.Errorf("different order for the same seed")
+	}
+
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	other, _ := runWeighted(t, [][]string{short, long}, []int{len(short), len(long)}, []int{10, 1}, 42)
+	sort.Strings(other)
+	if !cmp.Equal(sorted, other) {
+		t.Errorf("different values for another seed")
+	}
+}
+
+func TestWeightedUnknownCount(t *testing.T) {
+	values, count := runWeighted(t, [][]string{{"a"}, {"b", "c"}}, []int{1, UnknownCount}, []int{1, 1}, 1)
+
+	if len(values) != 3 {
+		t.Errorf("wrong number of values, want 3, got %v", values)
+	}
+
+	if count != UnknownCount {
+		t.Errorf("wrong count, want %d, got %d", UnknownCount, count)
+	}
+}
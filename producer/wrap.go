@@ -0,0 +1,78 @@
+package producer
+
+import "context"
+
+// FilterWrap replaces each value with all combinations of prefix + value +
+// suffix for the values in Prefixes and Suffixes. If one of the lists is
+// empty, the value is used without prefix or suffix.
+type FilterWrap struct {
+	Prefixes []string
+	Suffixes []string
+}
+
+func orEmpty(list []string) []string {
+	if len(list) == 0 {
+		return []string{""}
+	}
+	return list
+}
+
+// Count filters the number of values.
+func (f *FilterWrap) Count(ctx context.Context, in <-chan int) <-chan int {
+	out := make(chan int, 1)
+
+	go func() {
+		defer close(out)
+		var total int
+		select {
+		case total = <-in:
+		case <-ctx.Done():
+		}
+
+		if total != UnknownCount {
+			total *= len(orEmpty(f.Prefixes)) * len(orEmpty(f.Suffixes))
+		}
+
+		select {
+		case out <- total:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
+// Select filters values sent over ch.
+func (f *FilterWrap) Select(ctx context.Context, in <-chan string) <-chan string {
+	out := make(chan string)
+	prefixes, suffixes := orEmpty(f.Prefixes), orEmpty(f.Suffixes)
+
+	go func() {
+		defer close(out)
+		for {
+			var v string
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+				// when the input channel is closed we're done
+				if !ok {
+					return
+				}
+			}
+
+			for _, prefix := range prefixes {
+				for _, suffix := range suffixes {
+					select {
+					case <-ctx.Done():
+						return
+					case out <- prefix + v + suffix:
+					}
+				}
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,37 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterWrap(t *testing.T) {
+	var tests = []struct {
+		prefixes, suffixes []string
+		values             []string
+		want               []string
+	}{
+		{nil, nil, []string{"a", "b"}, []string{"a", "b"}},
+		{[]string{"x", "y"}, nil, []string{"a", "b"}, []string{"xa", "ya", "xb", "yb"}},
+		{nil, []string{".php", ".bak"}, []string{"a"}, []string{"a.php", "a.bak"}},
+		{[]string{"", "old_"}, []string{"", "~"}, []string{"a", "b"},
+			[]string{"a", "a~", "old_a", "old_a~", "b", "b~", "old_b", "old_b~"}},
+		{[]string{"x"}, []string{"y"}, nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f := &FilterWrap{Prefixes: test.prefixes, Suffixes: test.suffixes}
+			values, count := runFilter(t, f, test.values, false)
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			if count != len(test.want) {
+				t.Errorf("wrong count, want %d, got %d", len(test.want), count)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package producer
+
+import "context"
+
+// Zip combines the values received from a and b pairwise, joined by sep, and
+// sends them to ch. Sending stops and ch is closed when one of the inputs is
+// closed or the context is cancelled. The number of items is the minimum of
+// the counts received from countA and countB. If one input is closed before
+// both counts are known or one of them is UnknownCount, the number of values
+// sent so far is used instead.
+func Zip(ctx context.Context, a, b <-chan string, countA, countB <-chan int, sep string, ch chan<- string, count chan<- int) error {
+	defer close(ch)
+
+	var (
+		numA, numB     int
+		knownA, knownB bool
+		sent           int
+		countSent      bool
+		inputs         = [2]<-chan string{a, b}
+	)
+
+	sendCount := func(n int) {
+		if countSent {
+			return
+		}
+
+		countSent = true
+		select {
+		case count <- n:
+		case <-ctx.Done():
+		}
+	}
+
+	// checkCounts receives the counts from the inputs, if available
+	checkCounts := func() {
+		select {
+		case n := <-countA:
+			numA, knownA, countA = n, n != UnknownCount, nil
+		default:
+		}
+
+		select {
+		case n := <-countB:
+			numB, knownB, countB = n, n != UnknownCount, nil
+		default:
+		}
+
+		if knownA && knownB {
+			if numA < numB {
+				sendCount(numA)
+			} else {
+				sendCount(numB)
+			}
+		}
+	}
+
+	for {
+		var values [2]string
+		for i, in := range inputs {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					// one of the lists is exhausted, we're done
+					checkCounts()
+					sendCount(sent)
+					return nil
+				}
+				values[i] = v
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		checkCounts()
+
+		select {
+		case ch <- values[0] + sep + values[1]:
+			sent++
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestZip(t *testing.T) {
+	var tests = []struct {
+		a, b  string
+		want  []string
+		count int
+	}{
+		{"a\nb\nc\n", "1\n2\n3\n", []string{"a:1", "b:2", "c:3"}, 3},
+		{"a\nb\nc\nd\n", "1\n2\n", []string{"a:1", "b:2"}, 2},
+		{"a\n", "1\n2\n3\n", []string{"a:1"}, 1},
+		{"", "1\n2\n3\n", nil, 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var inputs [2]chan string
+			var counts [2]chan int
+			for i, data := range []string{test.a, test.b} {
+				inputs[i] = make(chan string)
+				counts[i] = make(chan int, 1)
+				go func(rd string, ch chan string, count chan int) {
+					err := Reader(ctx, ioutil.NopCloser(strings.NewReader(rd)), '\n', ch, count)
+					if err != nil {
+						t.Error(err)
+					}
+				}(data, inputs[i], counts[i])
+			}
+
+			ch := make(chan string)
+			count := make(chan int, 1)
+			go func() {
+				err := Zip(ctx, inputs[0], inputs[1], counts[0], counts[1], ":", ch, count)
+				if err != nil {
+					t.Error(err)
+				}
+				cancel()
+			}()
+
+			var values []string
+			for v := range ch {
+				values = append(values, v)
+			}
+
+			if !cmp.Equal(test.want, values) {
+				t.Error(cmp.Diff(test.want, values))
+			}
+
+			if c := <-count; c != test.count {
+				t.Errorf("wrong count, want %d, got %d", test.count, c)
+			}
+		})
+	}
+}
+
+func TestZipUnknownCount(t *testing.T) {
+	ctx := context.Background()
+
+	a, b := make(chan string, 2), make(chan string, 3)
+	a <- "a"
+	a <- "b"
+	close(a)
+	b <- "1"
+	b <- "2"
+	b <- "3"
+	close(b)
+
+	countA, countB := make(chan int, 1), make(chan int, 1)
+	countA <- UnknownCount
+	countB <- 3
+
+	ch := make(chan string)
+	count := make(chan int, 1)
+	go func() {
+		err := Zip(ctx, a, b, countA, countB, ":", ch, count)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var values []string
+	for v := range ch {
+		values = append(values, v)
+	}
+
+	want := []string{"a:1", "b:2"}
+	if !cmp.Equal(want, values) {
+		t.Error(cmp.Diff(want, values))
+	}
+
+	// the number of values is only known when one input is exhausted
+	if c := <-count; c != 2 {
+		t.Errorf("wrong count, want 2, got %d", c)
+	}
+}
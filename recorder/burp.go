@@ -0,0 +1,221 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// BurpItem is a request and response in the format used by Burp Suite to
+// export and import items.
+type BurpItem struct {
+	XMLName        xml.Name   `xml:"item"`
+	Time           string     `xml:"time"`
+	URL            cdata      `xml:"url"`
+	Host           burpHost   `xml:"host"`
+	Port           string     `xml:"port"`
+	Protocol       string     `xml:"protocol"`
+	Method         cdata      `xml:"method"`
+	Path           cdata      `xml:"path"`
+	Extension      string     `xml:"extension"`
+	Request        burpBase64 `xml:"request"`
+	Status         int        `xml:"status"`
+	ResponseLength int        `xml:"responselength"`
+	MIMEType       string     `xml:"mimetype"`
+	Response       burpBase64 `xml:"response"`
+	Comment        string     `xml:"comment"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+type burpHost struct {
+	IP   string `xml:"ip,attr"`
+	Name string `xml:",chardata"`
+}
+
+type burpBase64 struct {
+	Base64 bool   `xml:"base64,attr"`
+	Data   string `xml:",cdata"`
+}
+
+// NewBurpItem builds an item from a response. The response needs to contain
+// the raw request.
+func NewBurpItem(res response.Response) (BurpItem, error) {
+	u, err := url.Parse(res.URL)
+	if err != nil {
+		return BurpItem{}, err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	ext := strings.TrimPrefix(path.Ext(u.Path), ".")
+	if ext == "" {
+		ext = "null"
+	}
+
+	var method string
+	if i := bytes.IndexByte(res.RawRequest, ' '); i > 0 {
+		method = string(res.RawRequest[:i])
+	}
+
+	rawResponse := res.RawResponse()
+
+	item := BurpItem{
+		Time:           time.Now().Format(time.UnixDate),
+		URL:            cdata{res.URL},
+		Host:           burpHost{Name: u.Hostname()},
+		Port:           port,
+		Protocol:       u.Scheme,
+		Method:         cdata{method},
+		Path:           cdata{u.RequestURI()},
+		Extension:      ext,
+		Request:        burpBase64{true, base64.StdEncoding.EncodeToString(res.RawRequest)},
+		ResponseLength: len(rawResponse),
+		Response:       burpBase64{true, base64.StdEncoding.EncodeToString(rawResponse)},
+	}
+
+	if res.HTTPResponse != nil {
+		item.Status = res.HTTPResponse.StatusCode
+		item.MIMEType = burpMIMEType(res.HTTPResponse.Header.Get("Content-Type"))
+	}
+
+	return item, nil
+}
+
+// burpMIMEType returns the name Burp uses for the content type.
+func burpMIMEType(contentType string) string {
+	ct := strings.ToLower(contentType)
+	switch {
+	case ct == "":
+		return ""
+	case strings.Contains(ct, "html"):
+		return "HTML"
+	case strings.Contains(ct, "json"):
+		return "JSON"
+	case strings.Contains(ct, "xml"):
+		return "XML"
+	case strings.Contains(ct, "javascript"):
+		return "script"
+	case strings.Contains(ct, "css"):
+		return "CSS"
+	case strings.HasPrefix(ct, "image/"):
+		return "image"
+	case strings.HasPrefix(ct, "text/"):
+		return "text"
+	default:
+		return "app"
+	}
+}
+
+// Burp writes all non-hidden responses to a file in the XML format used by
+// Burp Suite to import items.
+type Burp struct {
+	filename string
+	file     *os.File
+	wr       *bufio.Writer
+	enc      *xml.Encoder
+}
+
+// NewBurp creates the file and writes the XML header.
+func NewBurp(filename string) (*Burp, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Burp{
+		filename: filename,
+		file:     f,
+		wr:       bufio.NewWriter(f),
+	}
+	b.enc = xml.NewEncoder(b.wr)
+	b.enc.Indent("  ", "  ")
+
+	_, err = fmt.Fprintf(b.wr, "%s<items burpVersion=\"monsoon\" exportTime=\"%s\">", xml.Header, time.Now().Format(time.UnixDate))
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Run reads responses from in and forwards them to out, writing the
+// interesting (non-hidden) ones to the file. When in is closed or the context
+// is cancelled, the file is completed and closed, and out is closed.
+func (b *Burp) Run(ctx context.Context, in <-chan response.Response, out chan<- response.Response) (err error) {
+	defer close(out)
+	defer func() {
+		e := b.close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	for {
+		var res response.Response
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, ok = <-in:
+			if !ok {
+				return nil
+			}
+		}
+
+		if !res.Hide && res.Error == nil {
+			item, err := NewBurpItem(res)
+			if err != nil {
+				return err
+			}
+
+			err = b.enc.Encode(item)
+			if err != nil {
+				return fmt.Errorf("write %v: %v", b.filename, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- res:
+		}
+	}
+}
+
+// close writes the end of the XML document and closes the file.
+func (b *Burp) close() error {
+	_, err := b.wr.WriteString("\n</items>\n")
+	if err != nil {
+		_ = b.file.Close()
+		return err
+	}
+
+	err = b.wr.Flush()
+	if err != nil {
+		_ = b.file.Close()
+		return err
+	}
+
+	return b.file.Close()
+}
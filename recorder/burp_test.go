@@ -0,0 +1,312 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBurp(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/html; charset=utf-8")
+
+	responses := []response.Response{
+		{
+			URL:          "https://example.com:8443/admin/index.php?id=23",
+			RawRequest:   []byte("POST /admin/index.php?id=23 HTTP/1.1\r\nHost: example.com:8443\r\n\r\n"),
+			RawHeader:    []byte("HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\n\r\n"),
+			RawBody:      []byte("<html>"),
+			HTTPResponse: &http.Response{StatusCode: 200, Header: header},
+		},
+		{
+			URL:          "http://example.com/hidden",
+			RawRequest:   []byte("GET /hidden HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+			HTTPResponse: &http.Response{StatusCode: 404},
+			Hide:         true,
+		},
+		{
+			URL:          "http://example.com/foo",
+			RawRequest:   []byte("GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+			RawHeader:    []byte("HTTP/1.1 302 Found\r\n\r\n"),
+			HTTPResponse: &http.Response{StatusCode: 302},
+		},
+	}
+
+	filename := filepath.Join(tempdir, "burp.xml")
+	burp, err := NewBurp(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response)
+	out := make(chan response.Response)
+
+	go func() {
+		for _, res := range responses {
+			in <- res
+		}
+		close(in)
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- burp.Run(context.Background(), in, out)
+	}()
+
+	var forwarded int
+	for range out {
+		forwarded++
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forwarded != len(responses) {
+		t.Fatalf("wrong number of responses forwarded, want %d, got %d", len(responses), forwarded)
+	}
+
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items struct {
+		Items []BurpItem `xml:"item"`
+	}
+
+	err = xml.Unmarshal(buf, &items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type item struct {
+		URL, Host, Port, Protocol, Method, Path, Extension, MIMEType string
+		Status                                                       int
+		Request, Response                                            string
+	}
+
+	var got []item
+	for _, it := range items.Items {
+		req, err := base64.StdEncoding.DecodeString(it.Request.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := base64.StdEncoding.DecodeString(it.Response.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !it.Request.Base64 || !it.Response.Base64 {
+			t.Errorf("base64 attribute not set for %v", it.URL.Value)
+		}
+
+		if it.ResponseLength != len(res) {
+			t.Errorf("wrong response length for %v, want %d, got %d", it.URL.Value, len(res), it.ResponseLength)
+		}
+
+		got = append(got, item{
+			URL:       it.URL.Value,
+			Host:      it.Host.Name,
+			Port:      it.Port,
+			Protocol:  it.Protocol,
+			Method:    it.Method.Value,
+			Path:      it.Path.Value,
+			Extension: it.Extension,
+			MIMEType:  it.MIMEType,
+			Status:    it.Status,
+			Request:   string(req),
+			Response:  string(res),
+		})
+	}
+
+	want := []item{
+		{
+			URL:       "https://example.com:8443/admin/index.php?id=23",
+			Host:      "example.com",
+			Port:      "8443",
+			Protocol:  "https",
+			Method:    "POST",
+			Path:      "/admin/index.php?id=23",
+			Extension: "php",
+			MIMEType:  "HTML",
+			Status:    200,
+			Request:   "POST /admin/index.php?id=23 HTTP/1.1\r\nHost: example.com:8443\r\n\r\n",
+			Response:  "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\n\r\n<html>",
+		},
+		{
+			URL:       "http://example.com/foo",
+			Host:      "example.com",
+			Port:      "80",
+			Protocol:  "http",
+			Method:    "GET",
+			Path:      "/foo",
+			Extension: "null",
+			Status:    302,
+			Request:   "GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			Response:  "HTTP/1.1 302 Found\r\n\r\n",
+		},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+// gzipResponse returns the response of a runner for a server which sends a
+// gzip compressed body.
+func gzipResponse(t testing.TB, body string) response.Response {
+	var buf bytes.Buffer
+	wr := gzip.NewWriter(&buf)
+	_, err := wr.Write([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	template := request.New("")
+	template.URL = srv.URL + "/FUZZ"
+
+	tr, err := response.NewTransport(template, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := make(chan string, 1)
+	input <- "test"
+	close(input)
+
+	output := make(chan response.Response, 1)
+	response.NewRunner(tr, template, input, output).Run(context.Background())
+
+	res := <-output
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	if res.CompressedBodySize != buf.Len() {
+		t.Fatalf("body was not decompressed, compressed size %d", res.CompressedBodySize)
+	}
+
+	return res
+}
+
+// checkDecodedResponse parses the raw response in buf and makes sure the
+// header matches the decompressed body.
+func checkDecodedResponse(t testing.TB, buf []byte, body string) {
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enc := res.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding %q is still set", enc)
+	}
+
+	if res.ContentLength != int64(len(body)) {
+		t.Errorf("wrong Content-Length, want %d, got %d", len(body), res.ContentLength)
+	}
+
+	got, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != body {
+		t.Errorf("wrong body, want %q, got %q", body, got)
+	}
+}
+
+func TestBurpDecompressed(t *testing.T) {
+	const body = "foo bar baz"
+
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	filename := filepath.Join(tempdir, "burp.xml")
+	burp, err := NewBurp(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response, 1)
+	out := make(chan response.Response, 1)
+	in <- gzipResponse(t, body)
+	close(in)
+
+	err = burp.Run(context.Background(), in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items struct {
+		Items []BurpItem `xml:"item"`
+	}
+
+	err = xml.Unmarshal(buf, &items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items.Items) != 1 {
+		t.Fatalf("wrong number of items, want 1, got %d", len(items.Items))
+	}
+
+	res, err := base64.StdEncoding.DecodeString(items.Items[0].Response.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkDecodedResponse(t, res, body)
+}
@@ -0,0 +1,106 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// CurlFile writes a shell script with a curl command for each non-hidden
+// response, which sends the request again with the value inserted (see
+// Template.Curl).
+type CurlFile struct {
+	f           *os.File
+	wr          *bufio.Writer
+	template    Template
+	placeholder string
+}
+
+// NewCurlFile creates the file and writes the header of the script.
+func NewCurlFile(filename string, request *request.Request) (*CurlFile, error) {
+	t, err := NewTemplate(request)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CurlFile{
+		f:           f,
+		wr:          bufio.NewWriter(f),
+		template:    t,
+		placeholder: request.Replace,
+	}
+
+	_, err = fmt.Fprintf(c.wr, "#!/bin/sh\n")
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// command returns the curl command for the response. The method is taken from
+// the response if it differs from the template (e.g. for --head-first).
+func (c *CurlFile) command(res response.Response) string {
+	t := c.template
+	if res.Method != "" {
+		t.Method = res.Method
+	}
+
+	return t.Curl(c.placeholder, res.Item)
+}
+
+// Run reads responses from in and forwards them to out, writing a command for
+// the interesting (non-hidden) ones. When in is closed or the context is
+// cancelled, out is closed and the file is closed.
+func (c *CurlFile) Run(ctx context.Context, in <-chan response.Response, out chan<- response.Response) (err error) {
+	defer close(out)
+
+	defer func() {
+		flushErr := c.wr.Flush()
+		if err == nil {
+			err = flushErr
+		}
+
+		closeErr := c.f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	for {
+		var res response.Response
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, ok = <-in:
+			if !ok {
+				return nil
+			}
+		}
+
+		if !res.Hide && res.Error == nil {
+			_, err := fmt.Fprintln(c.wr, c.command(res))
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- res:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+func TestCurlFile(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	responses := []response.Response{
+		{Item: "admin"},
+		{Item: "hidden", Hide: true},
+		{Item: "failed", Error: errors.New("timeout")},
+		{Item: "it's;$(id)"},
+		{Item: "head", Method: http.MethodHead},
+	}
+
+	req := request.New("")
+	req.URL = "https://example.com/FUZZ"
+	req.Header = request.NewHeader(http.Header{"X-Value": []string{"FUZZ"}})
+
+	filename := filepath.Join(tempdir, "repro.sh")
+	c, err := NewCurlFile(filename, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response)
+	out := make(chan response.Response)
+
+	go func() {
+		for _, res := range responses {
+			in <- res
+		}
+		close(in)
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- c.Run(context.Background(), in, out)
+	}()
+
+	var n int
+	for range out {
+		n++
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != len(responses) {
+		t.Errorf("wrong number of responses passed on, want %d, got %d", len(responses), n)
+	}
+
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "#!/bin/sh\n" +
+		"curl -H 'X-Value: admin' https://example.com/admin\n" +
+		`curl -H 'X-Value: it'\''s;$(id)' 'https://example.com/it'\''s;$(id)'` + "\n" +
+		"curl --head -H 'X-Value: head' https://example.com/head\n"
+	if string(buf) != want {
+		t.Errorf("wrong data, want:\n%s\ngot:\n%s", want, buf)
+	}
+}
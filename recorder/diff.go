@@ -0,0 +1,68 @@
+package recorder
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Kinds of changes between two runs.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change describes how the response for a value differs between two runs.
+type Change struct {
+	Item string    `json:"item"`
+	Kind string    `json:"change"`
+	Old  *Response `json:"old,omitempty"`
+	New  *Response `json:"new,omitempty"`
+}
+
+// Diff compares the responses recorded in two runs. Only the responses which
+// were shown are recorded, so a value which was hidden in one of the runs is
+// reported as added or removed. A response is changed if the status code, the
+// error, the body size, words or lines, or the extracted data differ. The
+// header size is ignored since it changes for most responses (e.g. for the
+// Date header). If a value was requested more than once, the last response is
+// used. The changes are sorted by value.
+func Diff(oldRun, newRun Data) (changes []Change) {
+	oldResponses := responsesByItem(oldRun.Responses)
+	newResponses := responsesByItem(newRun.Responses)
+
+	for item, o := range oldResponses {
+		n, ok := newResponses[item]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Item: item, Kind: Removed, Old: o})
+		case changed(o, n):
+			changes = append(changes, Change{Item: item, Kind: Changed, Old: o, New: n})
+		}
+	}
+
+	for item, n := range newResponses {
+		if _, ok := oldResponses[item]; !ok {
+			changes = append(changes, Change{Item: item, Kind: Added, New: n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Item < changes[j].Item
+	})
+
+	return changes
+}
+
+func responsesByItem(list []Response) map[string]*Response {
+	res := make(map[string]*Response, len(list))
+	for i := range list {
+		res[list[i].Item] = &list[i]
+	}
+	return res
+}
+
+func changed(a, b *Response) bool {
+	return a.StatusCode != b.StatusCode || a.Error != b.Error || a.Body != b.Body ||
+		!reflect.DeepEqual(a.ExtractedData, b.ExtractedData)
+}
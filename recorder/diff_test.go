@@ -0,0 +1,65 @@
+package recorder
+
+import (
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	ok := Response{StatusCode: 200, Body: response.TextStats{Bytes: 100}}
+	notFound := Response{StatusCode: 404, Body: response.TextStats{Bytes: 10}}
+	larger := Response{StatusCode: 200, Body: response.TextStats{Bytes: 120}}
+	otherHeader := Response{StatusCode: 200, Header: response.TextStats{Bytes: 321}, Body: response.TextStats{Bytes: 100}}
+	extracted := Response{StatusCode: 200, Body: response.TextStats{Bytes: 100}, ExtractedData: []string{"foo"}}
+	failed := Response{Error: "timeout"}
+
+	item := func(r Response, item string) Response {
+		r.Item = item
+		return r
+	}
+
+	var tests = []struct {
+		old, new []Response
+		want     []string
+	}{
+		{nil, nil, nil},
+		{
+			[]Response{item(ok, "a"), item(notFound, "b")},
+			[]Response{item(ok, "a"), item(notFound, "b")},
+			nil,
+		},
+		{
+			[]Response{item(ok, "a"), item(ok, "b")},
+			[]Response{item(ok, "c"), item(ok, "a")},
+			[]string{"b removed", "c added"},
+		},
+		{
+			[]Response{item(ok, "a"), item(ok, "b"), item(ok, "c"), item(ok, "d"), item(ok, "e")},
+			[]Response{item(notFound, "a"), item(larger, "b"), item(otherHeader, "c"), item(extracted, "d"), item(failed, "e")},
+			[]string{"a changed", "b changed", "d changed", "e changed"},
+		},
+		// the last response for a value is used
+		{
+			[]Response{item(notFound, "a"), item(ok, "a")},
+			[]Response{item(ok, "a")},
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			changes := Diff(Data{Responses: test.old}, Data{Responses: test.new})
+
+			var got []string
+			for _, change := range changes {
+				got = append(got, change.Item+" "+change.Kind)
+			}
+
+			if !cmp.Equal(test.want, got) {
+				t.Error(cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
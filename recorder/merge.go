@@ -0,0 +1,125 @@
+package recorder
+
+import "sort"
+
+// Conflict describes a value for which the merged runs contain different
+// responses (as compared by Diff).
+type Conflict struct {
+	Target string
+	Item   string
+
+	// Responses are all distinct responses for the value, Runs contains the
+	// index of the run each response was taken from
+	Responses []Response
+	Runs      []int
+}
+
+// responseKey identifies the responses for a value sent to a target (which is
+// empty unless --targets-file was used).
+type responseKey struct {
+	Target string
+	Item   string
+}
+
+func (k responseKey) less(other responseKey) bool {
+	if k.Item != other.Item {
+		return k.Item < other.Item
+	}
+	return k.Target < other.Target
+}
+
+// Merge combines the data of several runs (e.g. of different shards) into
+// one. The responses are sorted by value and target, and responses for the
+// same value and target which are equal (as compared by Diff) are only kept
+// once. If the responses differ, the first one is kept and a Conflict is
+// returned. The
+// template and the input options are taken from the first run, the numbers of
+// requests are added up and the time covers all runs.
+func Merge(runs []Data) (merged Data, conflicts []Conflict) {
+	if len(runs) == 0 {
+		return Data{Version: FormatVersion, Responses: []Response{}}, nil
+	}
+
+	merged = runs[0]
+	merged.Version = FormatVersion
+	merged.Part = 0
+	merged.TotalRequests = 0
+	merged.SentRequests = 0
+	merged.HiddenResponses = 0
+	merged.Cancelled = false
+	merged.Responses = nil
+
+	// all distinct responses for each value and target and the runs they
+	// were taken from
+	responses := make(map[responseKey][]Response)
+	responseRuns := make(map[responseKey][]int)
+	conflictIndex := make(map[responseKey]int)
+	var keys []responseKey
+
+	for i, run := range runs {
+		// ignore missing times
+		if !run.Start.IsZero() && (merged.Start.IsZero() || run.Start.Before(merged.Start)) {
+			merged.Start = run.Start
+		}
+		if run.End.After(merged.End) {
+			merged.End = run.End
+		}
+
+		if run.TotalRequests < 0 || merged.TotalRequests < 0 {
+			merged.TotalRequests = -1
+		} else {
+			merged.TotalRequests += run.TotalRequests
+		}
+		merged.SentRequests += run.SentRequests
+		merged.HiddenResponses += run.HiddenResponses
+		merged.Cancelled = merged.Cancelled || run.Cancelled
+
+	responseLoop:
+		for _, res := range run.Responses {
+			key := responseKey{Target: res.Target, Item: res.Item}
+			list, ok := responses[key]
+			if !ok {
+				keys = append(keys, key)
+			}
+
+			for j := range list {
+				if !changed(&list[j], &res) {
+					continue responseLoop
+				}
+			}
+
+			responses[key] = append(list, res)
+			responseRuns[key] = append(responseRuns[key], i)
+
+			if len(list) == 0 {
+				continue
+			}
+
+			n, ok := conflictIndex[key]
+			if !ok {
+				n = len(conflicts)
+				conflictIndex[key] = n
+				conflicts = append(conflicts, Conflict{Target: res.Target, Item: res.Item})
+			}
+			conflicts[n].Responses = responses[key]
+			conflicts[n].Runs = responseRuns[key]
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].less(keys[j])
+	})
+	merged.Responses = make([]Response, 0, len(keys))
+	for _, key := range keys {
+		merged.Responses = append(merged.Responses, responses[key][0])
+	}
+	merged.ShownResponses = len(merged.Responses)
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		a := responseKey{Target: conflicts[i].Target, Item: conflicts[i].Item}
+		b := responseKey{Target: conflicts[j].Target, Item: conflicts[j].Item}
+		return a.less(b)
+	})
+
+	return merged, conflicts
+}
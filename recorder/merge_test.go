@@ -0,0 +1,144 @@
+package recorder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMerge(t *testing.T) {
+	ok := Response{StatusCode: 200, Body: response.TextStats{Bytes: 100}}
+	otherHeader := Response{StatusCode: 200, Header: response.TextStats{Bytes: 321}, Body: response.TextStats{Bytes: 100}}
+	notFound := Response{StatusCode: 404, Body: response.TextStats{Bytes: 10}}
+	failed := Response{Error: "timeout"}
+
+	item := func(r Response, item string) Response {
+		r.Item = item
+		return r
+	}
+
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	runs := []Data{
+		{
+			Start:           start.Add(time.Hour),
+			End:             start.Add(2 * time.Hour),
+			TotalRequests:   10,
+			SentRequests:    10,
+			HiddenResponses: 7,
+			ShownResponses:  3,
+			Part:            2,
+			InputFile:       "shard1.txt",
+			Responses:       []Response{item(ok, "c"), item(ok, "a"), item(notFound, "b")},
+		},
+		{
+			Start:           start,
+			End:             start.Add(time.Hour),
+			TotalRequests:   20,
+			SentRequests:    15,
+			HiddenResponses: 12,
+			ShownResponses:  3,
+			Cancelled:       true,
+			InputFile:       "shard2.txt",
+			Responses:       []Response{item(otherHeader, "a"), item(ok, "b"), item(ok, "d")},
+		},
+		{
+			TotalRequests: 5,
+			SentRequests:  5,
+			Responses:     []Response{item(failed, "b")},
+		},
+	}
+
+	merged, conflicts := Merge(runs)
+
+	want := Data{
+		Version:         FormatVersion,
+		Start:           start,
+		End:             start.Add(2 * time.Hour),
+		TotalRequests:   35,
+		SentRequests:    30,
+		HiddenResponses: 19,
+		ShownResponses:  4,
+		Cancelled:       true,
+		InputFile:       "shard1.txt",
+		// the header size is not compared, so the first response for "a" is kept
+		Responses: []Response{item(ok, "a"), item(notFound, "b"), item(ok, "c"), item(ok, "d")},
+	}
+
+	if !cmp.Equal(want, merged) {
+		t.Error(cmp.Diff(want, merged))
+	}
+
+	wantConflicts := []Conflict{
+		{
+			Item:      "b",
+			Responses: []Response{item(notFound, "b"), item(ok, "b"), item(failed, "b")},
+			Runs:      []int{0, 1, 2},
+		},
+	}
+
+	if !cmp.Equal(wantConflicts, conflicts) {
+		t.Error(cmp.Diff(wantConflicts, conflicts))
+	}
+}
+
+func TestMergeUnknownTotal(t *testing.T) {
+	merged, _ := Merge([]Data{{TotalRequests: 3}, {TotalRequests: -1}, {TotalRequests: 4}})
+	if merged.TotalRequests != -1 {
+		t.Errorf("wrong total, want -1, got %d", merged.TotalRequests)
+	}
+}
+
+func TestMergeTargets(t *testing.T) {
+	res := func(status int, target, item string) Response {
+		return Response{StatusCode: status, Target: target, Item: item}
+	}
+
+	targets := []string{"https://a.example.com/FUZZ", "https://b.example.com/FUZZ"}
+	runs := []Data{
+		{
+			Targets: targets,
+			Responses: []Response{
+				res(200, targets[0], "x"),
+				res(404, targets[1], "x"),
+				res(200, targets[0], "y"),
+				res(404, targets[1], "y"),
+			},
+		},
+		{
+			Targets: targets,
+			Responses: []Response{
+				res(200, targets[0], "x"),
+				res(500, targets[1], "x"),
+			},
+		},
+	}
+
+	merged, conflicts := Merge(runs)
+
+	want := []Response{
+		res(200, targets[0], "x"),
+		res(404, targets[1], "x"),
+		res(200, targets[0], "y"),
+		res(404, targets[1], "y"),
+	}
+
+	if !cmp.Equal(want, merged.Responses) {
+		t.Error(cmp.Diff(want, merged.Responses))
+	}
+
+	wantConflicts := []Conflict{
+		{
+			Target:    targets[1],
+			Item:      "x",
+			Responses: []Response{res(404, targets[1], "x"), res(500, targets[1], "x")},
+			Runs:      []int{0, 1},
+		},
+	}
+
+	if !cmp.Equal(wantConflicts, conflicts) {
+		t.Error(cmp.Diff(wantConflicts, conflicts))
+	}
+}
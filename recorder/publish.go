@@ -0,0 +1,226 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultNATSPort is used for NATS servers if the URL does not contain a port.
+const DefaultNATSPort = "4222"
+
+// publishTimeout is the time to wait for the server when connecting and to
+// confirm that all messages have been received at the end of a run.
+const publishTimeout = 10 * time.Second
+
+// Publisher publishes all interesting (non-hidden) responses as JSON messages
+// to a subject on a NATS server. Messages are queued so that a slow server
+// does not slow down the scan until the queue is full, then the scan is either
+// blocked or (with Drop set) the messages are dropped.
+type Publisher struct {
+	Subject string
+	Drop    bool
+
+	server  string
+	nc      *nats.Conn
+	queue   chan []byte
+	dropped int
+
+	errMu sync.Mutex
+	err   error
+}
+
+// contextDialer establishes connections to the NATS server, they are aborted
+// when the context is cancelled.
+type contextDialer struct {
+	ctx context.Context
+}
+
+func (d contextDialer) Dial(network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(d.ctx, network, addr)
+}
+
+// NewPublisher connects to the server in target, which has the form
+// nats://[user:password@]host[:port]/subject. With the scheme tls, the
+// connection is always secured with TLS, for nats only when the server
+// requires it. The queue holds at most queueSize messages.
+func NewPublisher(ctx context.Context, target string, queueSize int) (*Publisher, error) {
+	return newPublisher(ctx, target, queueSize)
+}
+
+// newPublisher works like NewPublisher, opts are passed on to the client
+// library.
+func newPublisher(ctx context.Context, target string, queueSize int, opts ...nats.Option) (*Publisher, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "nats", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q for publishing, use nats://host/subject", u.Scheme)
+	}
+
+	subject := strings.Trim(u.Path, "/")
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n/") {
+		return nil, fmt.Errorf("invalid subject %q for publishing, use nats://host/subject", subject)
+	}
+
+	if queueSize < 1 {
+		return nil, errors.New("invalid queue size for publishing, must be positive")
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), DefaultNATSPort)
+	}
+
+	p := &Publisher{
+		Subject: subject,
+		server:  host,
+		queue:   make(chan []byte, queueSize),
+	}
+
+	server := &url.URL{Scheme: u.Scheme, User: u.User, Host: host}
+	opts = append([]nats.Option{
+		nats.Name("monsoon"),
+		nats.Timeout(publishTimeout),
+		nats.SetCustomDialer(contextDialer{ctx}),
+		// the messages are queued by the publisher, so don't try to reconnect
+		nats.NoReconnect(),
+		nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, err error) {
+			p.setError(fmt.Errorf("publish to %v: server returned error: %v", host, err))
+		}),
+	}, opts...)
+
+	p.nc, err = nats.Connect(server.String(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %v: %v", host, err)
+	}
+
+	return p, nil
+}
+
+func (p *Publisher) setError(err error) {
+	p.errMu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.errMu.Unlock()
+}
+
+func (p *Publisher) error() error {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	return p.err
+}
+
+// sendLoop sends the messages from the queue to the server. After an error,
+// the remaining messages are discarded so that the scan is not blocked.
+func (p *Publisher) sendLoop(done chan<- struct{}) {
+	defer close(done)
+
+	for msg := range p.queue {
+		if p.error() != nil {
+			continue
+		}
+
+		err := p.nc.Publish(p.Subject, msg)
+		if err != nil {
+			p.setError(fmt.Errorf("publish to %v: %v", p.server, err))
+		}
+	}
+}
+
+// Dropped returns the number of messages which were dropped because the queue
+// was full.
+func (p *Publisher) Dropped() int {
+	return p.dropped
+}
+
+// Run reads responses from in and forwards them to out, publishing the
+// interesting (non-hidden) ones. When in is closed or the context is
+// cancelled, the queued messages are sent, the connection is closed, and out is
+// closed.
+func (p *Publisher) Run(ctx context.Context, in <-chan response.Response, out chan<- response.Response) (err error) {
+	defer close(out)
+
+	done := make(chan struct{})
+	go p.sendLoop(done)
+
+	defer func() {
+		close(p.queue)
+		<-done
+
+		e := p.close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	for {
+		var res response.Response
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, ok = <-in:
+			if !ok {
+				return nil
+			}
+		}
+
+		if !res.Hide {
+			buf, err := json.Marshal(NewResponse(res))
+			if err != nil {
+				return err
+			}
+
+			if p.Drop {
+				select {
+				case p.queue <- buf:
+				default:
+					p.dropped++
+				}
+			} else {
+				select {
+				case p.queue <- buf:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- res:
+		}
+	}
+}
+
+// close waits until the server has processed all messages and closes the
+// connection.
+func (p *Publisher) close() error {
+	if p.error() == nil {
+		err := p.nc.FlushTimeout(publishTimeout)
+		if err != nil {
+			p.setError(fmt.Errorf("publish to %v: %v", p.server, err))
+		}
+	}
+
+	p.nc.Close()
+	return p.error()
+}
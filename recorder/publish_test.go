@@ -0,0 +1,213 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats.go"
+)
+
+// natsServer starts a minimal NATS server which records the CONNECT options
+// and the published messages, which are sent to the returned channel when the
+// client closes the connection. If tlsConfig is not nil, the server requires
+// TLS.
+func natsServer(t testing.TB, tlsConfig *tls.Config) (addr string, messages <-chan []string, cleanup func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan []string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msgs []string
+		defer func() {
+			ch <- msgs
+		}()
+
+		_, _ = fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576,\"tls_required\":%v}\r\n", tlsConfig != nil)
+		if tlsConfig != nil {
+			conn = tls.Server(conn, tlsConfig)
+		}
+
+		rd := bufio.NewReader(conn)
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			switch fields[0] {
+			case "CONNECT":
+				var opts struct {
+					Name string `json:"name"`
+					User string `json:"user"`
+					Pass string `json:"pass"`
+				}
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &opts)
+				if err != nil {
+					t.Errorf("invalid CONNECT line %q: %v", line, err)
+					return
+				}
+				msgs = append(msgs, fmt.Sprintf("connect name=%v user=%v pass=%v", opts.Name, opts.User, opts.Pass))
+			case "PING":
+				_, _ = fmt.Fprintf(conn, "PONG\r\n")
+			case "PUB":
+				n, err := strconv.Atoi(fields[len(fields)-1])
+				if err != nil {
+					t.Errorf("invalid PUB line %q", line)
+					return
+				}
+
+				buf := make([]byte, n+2)
+				_, err = io.ReadFull(rd, buf)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				msgs = append(msgs, fields[1]+" "+string(buf[:n]))
+			}
+		}
+	}()
+
+	return l.Addr().String(), ch, func() { _ = l.Close() }
+}
+
+func TestPublisher(t *testing.T) {
+	// use the certificate of a test server for the NATS server
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	serverTLS := srv.TLS
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	srv.Close()
+
+	var tests = []struct {
+		name   string
+		scheme string
+		tls    bool
+	}{
+		{"plain", "nats", false},
+		// TLS is used when the server requires it
+		{"tls-required", "nats", true},
+		{"tls-scheme", "tls", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg *tls.Config
+			var opts []nats.Option
+			if test.tls {
+				cfg = serverTLS
+				// only set the roots, TLS must be enabled by the scheme or the server
+				opts = append(opts, func(o *nats.Options) error {
+					o.TLSConfig = &tls.Config{RootCAs: roots}
+					return nil
+				})
+			}
+			testPublisher(t, test.scheme, cfg, opts...)
+		})
+	}
+}
+
+func testPublisher(t *testing.T, scheme string, tlsConfig *tls.Config, opts ...nats.Option) {
+	addr, messages, cleanup := natsServer(t, tlsConfig)
+	defer cleanup()
+
+	responses := []response.Response{
+		{Item: "foo", HTTPResponse: &http.Response{StatusCode: 200, Status: "200 OK"}},
+		{Item: "hidden", HTTPResponse: &http.Response{StatusCode: 404, Status: "404 Not Found"}, Hide: true},
+		{Item: "bar", HTTPResponse: &http.Response{StatusCode: 302, Status: "302 Found"}},
+	}
+
+	pub, err := newPublisher(context.Background(), scheme+"://user:secret@"+addr+"/monsoon.results", 10, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response)
+	out := make(chan response.Response)
+
+	go func() {
+		for _, res := range responses {
+			in <- res
+		}
+		close(in)
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- pub.Run(context.Background(), in, out)
+	}()
+
+	var forwarded int
+	for range out {
+		forwarded++
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forwarded != len(responses) {
+		t.Errorf("wrong number of forwarded responses, want %d, got %d", len(responses), forwarded)
+	}
+
+	var want []string
+	want = append(want, "connect name=monsoon user=user pass=secret")
+	for _, res := range []response.Response{responses[0], responses[2]} {
+		buf, err := json.Marshal(NewResponse(res))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, "monsoon.results "+string(buf))
+	}
+
+	got := <-messages
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestNewPublisherInvalid(t *testing.T) {
+	var tests = []string{
+		"kafka://localhost:9092/topic",
+		"http://localhost/subject",
+		"nats://localhost",
+		"nats://localhost/foo/bar",
+	}
+
+	for _, target := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := NewPublisher(context.Background(), target, 10)
+			if err == nil {
+				t.Fatalf("NewPublisher(%q) did not return an error", target)
+			}
+		})
+	}
+}
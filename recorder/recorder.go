@@ -3,7 +3,11 @@ package recorder
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/textproto"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/RedTeamPentesting/monsoon/request"
@@ -15,38 +19,102 @@ type Recorder struct {
 	filename string
 	*request.Request
 	Data
+
+	// MaxSize is the maximum size of the file (estimated). When the responses
+	// would exceed it, the data is continued in a new file (e.g. foo.1.json
+	// for foo.json) which only contains the following responses. Zero means no
+	// limit.
+	MaxSize int64
 }
 
+// FormatVersion is the version of the data format written by a Recorder.
+// Files without a version were written before it was introduced and are
+// treated as version 0. New fields are only ever added (and omitted when
+// empty), so a newer file can still be read by an older version.
+const FormatVersion = 1
+
 // Data is the data structure written to the file by a Recorder.
 type Data struct {
+	Version int `json:"version"`
+
 	Start           time.Time `json:"start"`
 	End             time.Time `json:"end"`
-	TotalRequests   int       `json:"total_requests"`
+	TotalRequests   int       `json:"total_requests"` // -1 if the number is unknown
 	SentRequests    int       `json:"sent_requests"`
 	HiddenResponses int       `json:"hidden_responses"`
 	ShownResponses  int       `json:"shown_responses"`
 	Cancelled       bool      `json:"cancelled"`
+	Part            int       `json:"part,omitempty"`
 
 	Template    Template   `json:"template"`
 	InputFile   string     `json:"input_file,omitempty"`
+	Zip         []string   `json:"zip,omitempty"`
+	CSV         string     `json:"csv,omitempty"`
+	CSVColumn   string     `json:"csv_column,omitempty"`
 	Ranges      []string   `json:"ranges,omitempty"`
 	RangeFormat string     `json:"range_format,omitempty"`
+	RangeStep   int        `json:"range_step,omitempty"`
+	RangeFactor int        `json:"range_factor,omitempty"`
+	RangeRandom int        `json:"range_random,omitempty"`
+	RangeUnique bool       `json:"range_unique,omitempty"`
+	RangeSeed   int64      `json:"range_seed,omitempty"`
+	Mutate      string     `json:"mutate,omitempty"`
+	MutateCount int        `json:"mutate_count,omitempty"`
+	MutateSeed  int64      `json:"mutate_seed,omitempty"`
 	Responses   []Response `json:"responses"`
 	Extract     []string   `json:"extract,omitempty"`
 	ExtractPipe []string   `json:"extract_pipe,omitempty"`
+
+	// RecordHeaders are the names of the response headers which are
+	// recorded for each response
+	RecordHeaders []string `json:"record_headers,omitempty"`
+
+	// FileWeighted are the files with their weights for --file-weighted
+	FileWeighted     []string `json:"file_weighted,omitempty"`
+	FileWeightedSeed int64    `json:"file_weighted_seed,omitempty"`
+
+	// RawRequestDir is the directory with the requests for --raw-request-dir
+	RawRequestDir string `json:"raw_request_dir,omitempty"`
+
+	// Targets are the URLs read from --targets-file, the template is the one
+	// for the first target
+	Targets []string `json:"targets,omitempty"`
+
+	// Placeholder is the string in the template which is replaced by the
+	// value
+	Placeholder string `json:"placeholder,omitempty"`
+
+	// Fields are the names of the fields each value is split into (with
+	// --fields), separated by FieldSep
+	Fields   []string `json:"fields,omitempty"`
+	FieldSep string   `json:"fields_sep,omitempty"`
 }
 
 // Response is the result of a request sent to the target.
 type Response struct {
 	Item     string  `json:"item"`
+	Target   string  `json:"target,omitempty"`
 	Error    string  `json:"error,omitempty"`
 	Duration float64 `json:"duration"`
+	TTFB     float64 `json:"ttfb,omitempty"`
 
-	StatusCode    int                `json:"status_code"`
-	StatusText    string             `json:"status_text"`
-	Header        response.TextStats `json:"header"`
-	Body          response.TextStats `json:"body"`
-	ExtractedData []string           `json:"extracted_data,omitempty"`
+	Method             string              `json:"method,omitempty"`
+	StatusCode         int                 `json:"status_code"`
+	StatusText         string              `json:"status_text"`
+	Header             response.TextStats  `json:"header"`
+	Body               response.TextStats  `json:"body"`
+	CompressedBodySize int                 `json:"compressed_body_size,omitempty"`
+	CanonicalURL       string              `json:"canonical_url,omitempty"`
+	Reflected          int                 `json:"reflected,omitempty"`
+	WAF                string              `json:"waf,omitempty"`
+	Retries            int                 `json:"retries,omitempty"`
+	Trailers           map[string][]string `json:"trailers,omitempty"`
+	Simhash            string              `json:"simhash,omitempty"`
+	HeaderFingerprint  string              `json:"header_fingerprint,omitempty"`
+	TLSFingerprint     string              `json:"tls_fingerprint,omitempty"`
+	MetaRefresh        []string            `json:"meta_refresh,omitempty"`
+	ExtractedData      []string            `json:"extracted_data,omitempty"`
+	Headers            map[string][]string `json:"headers,omitempty"`
 }
 
 // New creates a new  recorder.
@@ -60,9 +128,15 @@ func New(filename string, request *request.Request) (*Recorder, error) {
 		filename: filename,
 		Request:  request,
 		Data: Data{
-			Template: t,
+			Version:     FormatVersion,
+			Template:    t,
+			Placeholder: request.Replace,
+			Fields:      request.Fields,
 		},
 	}
+	if len(request.Fields) > 0 {
+		rec.Data.FieldSep = request.FieldSep
+	}
 	return rec, nil
 }
 
@@ -79,15 +153,34 @@ func (r *Recorder) Run(ctx context.Context, in <-chan response.Response, out cha
 	data.Start = time.Now()
 	data.End = time.Now()
 
-	// omit range_format if range is unset
+	// omit range_format and range_step if range is unset
 	if len(data.Ranges) == 0 {
 		data.RangeFormat = ""
+		data.RangeStep = 0
+		data.RangeFactor = 0
+	}
+
+	// omit range_step if it's the default
+	if data.RangeStep == 1 {
+		data.RangeStep = 0
 	}
 
 	lastStatus := time.Now()
 
 	var countCh chan<- int // countCh is nil initially to disable sending
 
+	// estimated size of the current file, the size of the data without
+	// responses is used as the base
+	var base, size int64
+	if r.MaxSize > 0 {
+		buf, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		base = int64(len(buf))
+		size = base
+	}
+
 loop:
 	for {
 		var res response.Response
@@ -121,7 +214,30 @@ loop:
 		data.SentRequests++
 		if !res.Hide {
 			data.ShownResponses++
-			data.Responses = append(data.Responses, NewResponse(res))
+			rec := NewResponse(res)
+			rec.Headers = recordHeaders(res, data.RecordHeaders)
+
+			if r.MaxSize > 0 {
+				buf, err := json.MarshalIndent(rec, "    ", "  ")
+				if err != nil {
+					return err
+				}
+
+				// continue in the next file if this one would grow too large
+				if size > base && size+int64(len(buf)) > r.MaxSize {
+					err := r.dump(data)
+					if err != nil {
+						return err
+					}
+
+					data.Part++
+					data.Responses = nil
+					size = base
+				}
+				size += int64(len(buf))
+			}
+
+			data.Responses = append(data.Responses, rec)
 		} else {
 			data.HiddenResponses++
 		}
@@ -148,6 +264,23 @@ loop:
 	return r.dump(data)
 }
 
+// Load reads the data written by a Recorder from the file. Fields unknown to
+// this version are ignored, missing optional fields are left empty.
+func Load(filename string) (Data, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Data{}, err
+	}
+
+	var data Data
+	err = json.Unmarshal(buf, &data)
+	if err != nil {
+		return Data{}, fmt.Errorf("parse %v: %v", filename, err)
+	}
+
+	return data, nil
+}
+
 // dump writes the current status to the file.
 func (r *Recorder) dump(data Data) error {
 	buf, err := json.MarshalIndent(data, "", "  ")
@@ -156,15 +289,55 @@ func (r *Recorder) dump(data Data) error {
 	}
 	buf = append(buf, '\n')
 
-	return ioutil.WriteFile(r.filename, buf, 0644)
+	return ioutil.WriteFile(PartFilename(r.filename, data.Part), buf, 0644)
+}
+
+// PartFilename returns the name of the file for part n of filename, which is
+// split into several files: part 0 is filename itself, the following ones are
+// numbered (e.g. foo.1.json, foo.2.json for foo.json).
+func PartFilename(filename string, n int) string {
+	if n == 0 {
+		return filename
+	}
+
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(filename, ext), n, ext)
+}
+
+// recordHeaders returns the values of the headers names which are present in
+// res, with the canonical header name as the key.
+func recordHeaders(res response.Response, names []string) map[string][]string {
+	if res.HTTPResponse == nil || len(names) == 0 {
+		return nil
+	}
+
+	var headers map[string][]string
+	for _, name := range names {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		values, ok := res.HTTPResponse.Header[name]
+		if !ok {
+			continue
+		}
+
+		if headers == nil {
+			headers = make(map[string][]string)
+		}
+		headers[name] = values
+	}
+
+	return headers
 }
 
 // NewResponse builds a Response struct for serialization with JSON.
 func NewResponse(r response.Response) (res Response) {
 	res.Item = r.Item
+	res.Target = r.Target
 	if r.Duration != 0 {
 		res.Duration = float64(r.Duration) / float64(time.Second)
 	}
+	if r.TTFB != 0 {
+		res.TTFB = float64(r.TTFB) / float64(time.Second)
+	}
 	if r.Error != nil {
 		res.Error = r.Error.Error()
 	}
@@ -175,6 +348,21 @@ func NewResponse(r response.Response) (res Response) {
 	}
 	res.Header = r.Header
 	res.Body = r.Body
+	res.CompressedBodySize = r.CompressedBodySize
+	res.CanonicalURL = r.CanonicalURL
+	res.Reflected = r.Reflected
+	res.WAF = r.WAF
+	res.Retries = r.Retries
+	res.Trailers = r.Trailer
+	if r.Simhash != 0 {
+		res.Simhash = response.FormatSimhash(r.Simhash)
+	}
+	if r.HeaderFingerprint != 0 {
+		res.HeaderFingerprint = fmt.Sprintf("%016x", r.HeaderFingerprint)
+	}
+	res.TLSFingerprint = r.TLSFingerprint
+	res.Method = r.Method
+	res.MetaRefresh = r.MetaRefresh
 	res.ExtractedData = r.Extract
 
 	return res
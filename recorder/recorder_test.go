@@ -0,0 +1,272 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		data string
+		want Data
+	}{
+		{
+			// written before the version was introduced
+			data: `{
+  "start": "2020-01-02T03:04:05Z",
+  "end": "2020-01-02T03:04:05Z",
+  "total_requests": 2,
+  "sent_requests": 2,
+  "hidden_responses": 1,
+  "shown_responses": 1,
+  "cancelled": false,
+  "template": {"url": "https://example.com/FUZZ", "method": "GET", "header": null},
+  "input_file": "words.txt",
+  "responses": [
+    {
+      "item": "admin",
+      "duration": 0.5,
+      "status_code": 200,
+      "status_text": "200 OK",
+      "header": {"bytes": 10, "words": 2, "lines": 1},
+      "body": {"bytes": 0, "words": 0, "lines": 0}
+    }
+  ]
+}`,
+			want: Data{
+				Start:           start,
+				End:             start,
+				TotalRequests:   2,
+				SentRequests:    2,
+				HiddenResponses: 1,
+				ShownResponses:  1,
+				Template:        Template{URL: "https://example.com/FUZZ", Method: "GET"},
+				InputFile:       "words.txt",
+				Responses: []Response{
+					{
+						Item:       "admin",
+						Duration:   0.5,
+						StatusCode: 200,
+						StatusText: "200 OK",
+						Header:     response.TextStats{Bytes: 10, Words: 2, Lines: 1},
+					},
+				},
+			},
+		},
+		{
+			// written by a newer version with additional fields
+			data: `{
+  "version": 23,
+  "start": "2020-01-02T03:04:05Z",
+  "end": "2020-01-02T03:04:05Z",
+  "template": {"url": "https://example.com/FUZZ", "method": "GET", "header": null, "new_field": true},
+  "ranges": ["1-10"],
+  "new_summary": {"foo": "bar"},
+  "responses": [
+    {
+      "item": "1",
+      "duration": 0,
+      "status_code": 404,
+      "status_text": "404 Not Found",
+      "header": {"bytes": 10, "words": 2, "lines": 1},
+      "body": {"bytes": 0, "words": 0, "lines": 0},
+      "hash": "foobar"
+    }
+  ]
+}`,
+			want: Data{
+				Version:  23,
+				Start:    start,
+				End:      start,
+				Template: Template{URL: "https://example.com/FUZZ", Method: "GET"},
+				Ranges:   []string{"1-10"},
+				Responses: []Response{
+					{
+						Item:       "1",
+						StatusCode: 404,
+						StatusText: "404 Not Found",
+						Header:     response.TextStats{Bytes: 10, Words: 2, Lines: 1},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			filename := filepath.Join(tempdir, "data.json")
+			err := ioutil.WriteFile(filename, []byte(test.data), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := Load(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.want, data) {
+				t.Error(cmp.Diff(test.want, data))
+			}
+		})
+	}
+}
+
+func TestRecorderMaxSize(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	req := request.New("")
+	req.URL = "https://example.com/FUZZ"
+
+	filename := filepath.Join(tempdir, "run.json")
+	rec, err := New(filename, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// make room for two responses per file, the timestamps written by Run
+	// are longer than the zero values
+	base, err := json.MarshalIndent(rec.Data, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := json.MarshalIndent(NewResponse(response.Response{Item: "0"}), "    ", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.MaxSize = int64(len(base) + 2*len(buf) + 100)
+
+	in := make(chan response.Response)
+	out := make(chan response.Response)
+	inCount := make(chan int)
+	outCount := make(chan int)
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			in <- response.Response{Item: strconv.Itoa(i)}
+		}
+		in <- response.Response{Item: "hidden", Hide: true}
+		close(in)
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- rec.Run(context.Background(), in, out, inCount, outCount)
+	}()
+
+	for range out {
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		filename string
+		part     int
+		items    []string
+	}{
+		{"run.json", 0, []string{"0", "1"}},
+		{"run.1.json", 1, []string{"2", "3"}},
+		{"run.2.json", 2, []string{"4"}},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			data, err := Load(filepath.Join(tempdir, test.filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if data.Part != test.part {
+				t.Errorf("wrong part, want %d, got %d", test.part, data.Part)
+			}
+
+			var items []string
+			for _, res := range data.Responses {
+				items = append(items, res.Item)
+			}
+
+			if !cmp.Equal(test.items, items) {
+				t.Error(cmp.Diff(test.items, items))
+			}
+		})
+	}
+}
+
+func TestRecordHeaders(t *testing.T) {
+	res := response.Response{
+		HTTPResponse: &http.Response{
+			StatusCode: 200,
+			Header: http.Header{
+				"Etag":         []string{`"abc"`},
+				"X-Served-By":  []string{"cache-1", "cache-2"},
+				"Content-Type": []string{"text/html"},
+			},
+		},
+	}
+
+	var tests = []struct {
+		res   response.Response
+		names []string
+		want  map[string][]string
+	}{
+		{res, nil, nil},
+		{res, []string{"x-missing"}, nil},
+		{response.Response{Error: errors.New("failed")}, []string{"etag"}, nil},
+		{
+			res, []string{"etag", "X-SERVED-BY", "x-missing"},
+			map[string][]string{
+				"Etag":        {`"abc"`},
+				"X-Served-By": {"cache-1", "cache-2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got := recordHeaders(test.res, test.names)
+			if !cmp.Equal(test.want, got) {
+				t.Error(cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
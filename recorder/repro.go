@@ -0,0 +1,100 @@
+package recorder
+
+import (
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/RedTeamPentesting/monsoon/shell"
+)
+
+// Curl returns a curl command line which sends the request described by the
+// template with placeholder replaced by value in the URL, the method, the
+// headers and the body. The Content-Length header is left to curl.
+func (t Template) Curl(placeholder, value string) string {
+	return t.CurlInsert(func(s string) string {
+		if placeholder == "" {
+			return s
+		}
+		return strings.Replace(s, placeholder, value, -1)
+	})
+}
+
+// CurlInsert works like Curl, but the value is inserted by the function
+// insert (e.g. for values split into fields).
+func (t Template) CurlInsert(insert func(string) string) string {
+	method := insert(t.Method)
+	body := insert(t.Body)
+	targetURL := insert(t.URL)
+
+	// the URL is sent as parsed, e.g. with spaces in the path escaped
+	if u, err := url.Parse(targetURL); err == nil {
+		targetURL = u.String()
+	}
+
+	args := []string{"curl"}
+
+	switch {
+	case method == http.MethodHead && body == "":
+		args = append(args, "--head")
+	case method == "" || method == http.MethodGet && body == "":
+	case method == http.MethodPost && body != "":
+	default:
+		args = append(args, "-X", method)
+	}
+
+	names := make([]string, 0, len(t.Header))
+	for name := range t.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if textproto.CanonicalMIMEHeaderKey(name) == "Content-Length" {
+			continue
+		}
+
+		for _, v := range t.Header[name] {
+			args = append(args, "-H", insert(name)+": "+insert(v))
+		}
+	}
+
+	if body != "" {
+		args = append(args, "--data-binary", body)
+	}
+
+	// curl would otherwise expand [] and {} and remove dot segments
+	if strings.ContainsAny(targetURL, "[]{}") {
+		args = append(args, "--globoff")
+	}
+
+	if dotSegments(targetURL) {
+		args = append(args, "--path-as-is")
+	}
+
+	args = append(args, targetURL)
+
+	for i, arg := range args {
+		args[i] = shell.Quote(arg)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// dotSegments returns true if the path of rawURL contains the segments "." or
+// "..".
+func dotSegments(rawURL string) bool {
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+
+	for _, segment := range strings.Split(rawURL, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+
+	return false
+}
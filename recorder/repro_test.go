@@ -0,0 +1,78 @@
+package recorder
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTemplateCurl(t *testing.T) {
+	var tests = []struct {
+		template Template
+		value    string
+		want     string
+	}{
+		{
+			template: Template{
+				URL:    "https://example.com/FUZZ",
+				Method: "GET",
+				Header: http.Header{"User-Agent": []string{"monsoon"}},
+			},
+			value: "admin",
+			want:  "curl -H 'User-Agent: monsoon' https://example.com/admin",
+		},
+		{
+			template: Template{
+				URL:    "https://example.com/login",
+				Method: "POST",
+				Body:   "user=admin&pass=FUZZ",
+				Header: http.Header{
+					"Content-Type":   []string{"application/x-www-form-urlencoded"},
+					"Content-Length": []string{"20"},
+					"X-Pass":         []string{"FUZZ"},
+				},
+			},
+			value: "it's",
+			want:  `curl -H 'Content-Type: application/x-www-form-urlencoded' -H 'X-Pass: it'\''s' --data-binary 'user=admin&pass=it'\''s' https://example.com/login`,
+		},
+		{
+			template: Template{
+				URL:    "https://example.com/",
+				Method: "FUZZ",
+			},
+			value: "DELETE",
+			want:  "curl -X DELETE https://example.com/",
+		},
+		{
+			template: Template{
+				URL:    "https://example.com/",
+				Method: "HEAD",
+			},
+			want: "curl --head https://example.com/",
+		},
+		{
+			template: Template{
+				URL:    "https://example.com/static/FUZZ?x={a}",
+				Method: "GET",
+			},
+			value: "../etc/passwd",
+			want:  "curl --globoff --path-as-is 'https://example.com/static/../etc/passwd?x={a}'",
+		},
+		{
+			template: Template{
+				URL:    "https://example.com/FUZZ",
+				Method: "GET",
+			},
+			value: "a b",
+			want:  "curl https://example.com/a%20b",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			res := test.template.Curl("FUZZ", test.value)
+			if res != test.want {
+				t.Errorf("wrong command, want\n  %s\ngot\n  %s", test.want, res)
+			}
+		})
+	}
+}
@@ -1,9 +1,7 @@
 package recorder
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -57,20 +55,16 @@ func LoadRuns(dir string) (runs []Run, err error) {
 	}
 
 	for _, file := range files {
-		buf, err := ioutil.ReadFile(file)
+		data, err := Load(file)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to read file, skipping: %v\n", file)
+			fmt.Fprintf(os.Stderr, "unable to read JSON data from file %v, skipping: %v\n", file, err)
 			continue
 		}
 
 		run := Run{
 			JSONFile: file,
 			Logfile:  strings.TrimSuffix(file, filepath.Ext(file)) + ".log",
-		}
-		err = json.Unmarshal(buf, &run.Data)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to read JSON data from file %v, skipping: %v\n", file, err)
-			continue
+			Data:     data,
 		}
 
 		run.URL, err = url.Parse(run.Data.Template.URL)
@@ -0,0 +1,118 @@
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// maxFilenameLength is the maximum length of a file name derived from a value,
+// without the extension.
+const maxFilenameLength = 200
+
+// SaveResponses writes the header and body of all non-hidden responses to
+// files in a directory. The name of a file is derived from the value.
+type SaveResponses struct {
+	dir  string
+	used map[string]struct{}
+}
+
+// NewSaveResponses creates the directory (if necessary).
+func NewSaveResponses(dir string) (*SaveResponses, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SaveResponses{
+		dir:  dir,
+		used: make(map[string]struct{}),
+	}
+
+	return s, nil
+}
+
+// sanitizeFilename returns a name for a file which only consists of letters,
+// digits, dots, dashes and underscores and does not start with a dot. All
+// other characters are replaced with an underscore.
+func sanitizeFilename(value string) string {
+	name := []byte(value)
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.' || c == '-' || c == '_':
+		default:
+			name[i] = '_'
+		}
+	}
+
+	s := string(name)
+	if strings.HasPrefix(s, ".") {
+		s = "_" + s[1:]
+	}
+
+	if len(s) > maxFilenameLength {
+		s = s[:maxFilenameLength]
+	}
+
+	if s == "" {
+		s = "_"
+	}
+
+	return s
+}
+
+// filename returns an unused file name for the value.
+func (s *SaveResponses) filename(value string) string {
+	base := sanitizeFilename(value)
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := s.used[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	s.used[name] = struct{}{}
+	return filepath.Join(s.dir, name+".http")
+}
+
+// Run reads responses from in and forwards them to out, saving the
+// interesting (non-hidden) ones. When in is closed or the context is
+// cancelled, out is closed.
+func (s *SaveResponses) Run(ctx context.Context, in <-chan response.Response, out chan<- response.Response) error {
+	defer close(out)
+
+	for {
+		var res response.Response
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, ok = <-in:
+			if !ok {
+				return nil
+			}
+		}
+
+		if !res.Hide && res.Error == nil {
+			buf := res.RawResponse()
+			err := ioutil.WriteFile(s.filename(res.Item), buf, 0644)
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- res:
+		}
+	}
+}
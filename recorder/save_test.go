@@ -0,0 +1,148 @@
+package recorder
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	var tests = []struct {
+		value string
+		want  string
+	}{
+		{"admin", "admin"},
+		{"index.php", "index.php"},
+		{"../../etc/passwd", "_._.._etc_passwd"},
+		{".htaccess", "_htaccess"},
+		{"a b/c\\d\x00", "a_b_c_d_"},
+		{"", "_"},
+		{"ümlaut", "__mlaut"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			name := sanitizeFilename(test.value)
+			if name != test.want {
+				t.Errorf("wrong name for %q, want %q, got %q", test.value, test.want, name)
+			}
+		})
+	}
+}
+
+func TestSaveResponses(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	responses := []response.Response{
+		{Item: "admin", RawHeader: []byte("HTTP/1.1 200 OK\r\n\r\n"), RawBody: []byte("foo")},
+		{Item: "hidden", RawHeader: []byte("HTTP/1.1 404 Not Found\r\n\r\n"), Hide: true},
+		{Item: "a/b", RawHeader: []byte("HTTP/1.1 200 OK\r\n\r\n"), RawBody: []byte("bar")},
+		{Item: "a_b", RawHeader: []byte("HTTP/1.1 302 Found\r\n\r\n")},
+	}
+
+	dir := filepath.Join(tempdir, "responses")
+	s, err := NewSaveResponses(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response)
+	out := make(chan response.Response)
+
+	go func() {
+		for _, res := range responses {
+			in <- res
+		}
+		close(in)
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- s.Run(context.Background(), in, out)
+	}()
+
+	for range out {
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"admin.http": "HTTP/1.1 200 OK\r\n\r\nfoo",
+		"a_b.http":   "HTTP/1.1 200 OK\r\n\r\nbar",
+		"a_b-2.http": "HTTP/1.1 302 Found\r\n\r\n",
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, fi := range entries {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[fi.Name()] = string(buf)
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestSaveResponsesDecompressed(t *testing.T) {
+	const body = "foo bar baz"
+
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	s, err := NewSaveResponses(tempdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response, 1)
+	out := make(chan response.Response, 1)
+	in <- gzipResponse(t, body)
+	close(in)
+
+	err = s.Run(context.Background(), in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(tempdir, "test.http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkDecodedResponse(t, buf, body)
+}
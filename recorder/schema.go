@@ -0,0 +1,100 @@
+package recorder
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema returns a JSON schema (draft 7) of the data written by a Recorder.
+// It is generated from the Go types, so it always matches the fields which
+// are written. Fields which are omitted when empty are not required. The
+// version property must be FormatVersion.
+func Schema() map[string]interface{} {
+	schema := schemaFor(reflect.TypeOf(Data{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "monsoon JSON data"
+
+	props := schema["properties"].(map[string]interface{})
+	props["version"] = map[string]interface{}{
+		"type":  "integer",
+		"const": FormatVersion,
+	}
+
+	return schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the JSON schema for values of type t, as encoded by
+// encoding/json.
+func schemaFor(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": schemaFor(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": schemaFor(t.Elem()),
+		}
+	case reflect.Struct:
+		props := make(map[string]interface{})
+		required := []string{}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				// unexported fields are not encoded
+				continue
+			}
+
+			tag := strings.Split(field.Tag.Get("json"), ",")
+			name := tag[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			props[name] = schemaFor(field.Type)
+
+			omitempty := false
+			for _, opt := range tag[1:] {
+				if opt == "omitempty" {
+					omitempty = true
+				}
+			}
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": props,
+			"required":   required,
+		}
+	}
+
+	// other types are not used in the data
+	return map[string]interface{}{}
+}
@@ -0,0 +1,127 @@
+package recorder
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// validate checks that value (decoded from JSON) matches the subset of JSON
+// schema used by Schema.
+func validate(t testing.TB, path string, schema map[string]interface{}, value interface{}) {
+	if c, ok := schema["const"]; ok && value != float64(c.(int)) {
+		t.Errorf("%v: want constant %v, got %v", path, c, value)
+	}
+
+	var types []string
+	switch typ := schema["type"].(type) {
+	case string:
+		types = []string{typ}
+	case []string:
+		types = typ
+	}
+
+	var got string
+	switch value.(type) {
+	case nil:
+		got = "null"
+	case bool:
+		got = "boolean"
+	case float64:
+		got = "number"
+	case string:
+		got = "string"
+	case []interface{}:
+		got = "array"
+	case map[string]interface{}:
+		got = "object"
+	}
+
+	found := false
+	for _, typ := range types {
+		if typ == got || (typ == "integer" && got == "number") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("%v: wrong type, want %v, got %v", path, types, got)
+		return
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			validate(t, path+"[]", schema["items"].(map[string]interface{}), item)
+		}
+	case map[string]interface{}:
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			for name, item := range v {
+				validate(t, path+"."+name, additional, item)
+			}
+			return
+		}
+
+		props := schema["properties"].(map[string]interface{})
+		for name, item := range v {
+			prop, ok := props[name]
+			if !ok {
+				t.Errorf("%v: property %q is not in the schema", path, name)
+				continue
+			}
+			validate(t, path+"."+name, prop.(map[string]interface{}), item)
+		}
+
+		for _, name := range schema["required"].([]string) {
+			if _, ok := v[name]; !ok {
+				t.Errorf("%v: required property %q is missing", path, name)
+			}
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	res := NewResponse(response.Response{
+		Item:         "foo",
+		Duration:     time.Second,
+		TTFB:         time.Millisecond,
+		HTTPResponse: &http.Response{StatusCode: 200, Status: "200 OK"},
+		Header:       response.TextStats{Bytes: 100, Words: 10, Lines: 5},
+		Extract:      []string{"bar"},
+		Reflected:    2,
+		WAF:          "Cloudflare",
+	})
+	res.Headers = map[string][]string{"Server": {"nginx"}}
+
+	var tests = []Data{
+		{Version: FormatVersion},
+		{
+			Version:       FormatVersion,
+			Start:         time.Now(),
+			TotalRequests: -1,
+			Template:      Template{URL: "https://example.com/FUZZ", Method: "GET", Header: http.Header{"Accept": {"*/*"}}},
+			InputFile:     "values.txt",
+			Responses:     []Response{res, NewResponse(response.Response{Item: "bar", Error: http.ErrHandlerTimeout})},
+			RecordHeaders: []string{"Server"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			buf, err := json.Marshal(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var value interface{}
+			err = json.Unmarshal(buf, &value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validate(t, "data", Schema(), value)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package recorder
 import (
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/RedTeamPentesting/monsoon/request"
 )
@@ -15,14 +17,27 @@ type Template struct {
 	Header http.Header `json:"header"`
 }
 
-// NewTemplate builds a template to write to the JSON data file.
+// NewTemplate builds a template to write to the JSON data file. If the
+// placeholder is used in the port of the URL, the URL cannot be parsed with it,
+// so the URL is recorded as specified.
 func NewTemplate(request *request.Request) (t Template, err error) {
-	req, err := request.Apply(request.Replace)
+	rawURL := ""
+	if _, err := url.Parse(request.URL); err != nil && strings.Contains(request.URL, request.Replace) {
+		tmp := *request
+		tmp.URL = strings.Replace(request.URL, request.Replace, "0", -1)
+		rawURL = request.URL
+		request = &tmp
+	}
+
+	req, err := request.Apply(templateValue(request))
 	if err != nil {
 		return Template{}, err
 	}
 
 	t.URL = req.URL.String()
+	if rawURL != "" {
+		t.URL = rawURL
+	}
 	t.Method = req.Method
 	t.Header = req.Header
 
@@ -34,3 +49,31 @@ func NewTemplate(request *request.Request) (t Template, err error) {
 
 	return t, nil
 }
+
+// templateValue returns the value which inserts the placeholder as it is. For
+// values split into fields, the names of the fields are inserted as well, so
+// that they can be replaced later.
+func templateValue(r *request.Request) string {
+	if len(r.Fields) == 0 {
+		return r.Replace
+	}
+
+	sep := r.FieldSep
+	if sep == "" {
+		sep = "\t"
+	}
+
+	parts := make([]string, len(r.Fields))
+	for i, name := range r.Fields {
+		switch name {
+		case request.FieldValue:
+			parts[i] = r.Replace
+		case request.FieldTimeout:
+			parts[i] = ""
+		default:
+			parts[i] = name
+		}
+	}
+
+	return strings.Join(parts, sep)
+}
@@ -94,6 +94,49 @@ foobar`)
 				},
 			},
 		},
+		{
+			request: func() *request.Request {
+				req := request.New("")
+				req.URL = "http://localhost:FUZZ/FUZZ"
+				req.Body = "port=FUZZ"
+				return req
+			},
+			want: Template{
+				URL:    "http://localhost:FUZZ/FUZZ",
+				Method: "POST",
+				Body:   "port=FUZZ",
+				Header: request.DefaultHeader,
+			},
+		},
+		{
+			request: func() *request.Request {
+				req := request.New("")
+				req.URL = "FUZZ://localhost/"
+				return req
+			},
+			want: Template{
+				URL:    "fuzz://localhost/",
+				Method: "GET",
+				Header: request.DefaultHeader,
+			},
+		},
+		{
+			// the names of the fields are kept as placeholders
+			request: func() *request.Request {
+				req := request.New("")
+				req.URL = "http://localhost/FUZZ"
+				req.Body = "user=FUZZ&pass=PASS"
+				req.Fields = []string{"timeout", "value", "PASS"}
+				req.FieldSep = ";"
+				return req
+			},
+			want: Template{
+				URL:    "http://localhost/FUZZ",
+				Method: "POST",
+				Body:   "user=FUZZ&pass=PASS",
+				Header: request.DefaultHeader,
+			},
+		},
 	}
 
 	for _, test := range tests {
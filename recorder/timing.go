@@ -0,0 +1,107 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+// timingQueueSize is the number of durations buffered for TimingDump before
+// passing on responses blocks.
+const timingQueueSize = 10000
+
+// TimingDump writes the duration of each response (including hidden and
+// failed ones, but not cancelled requests) to a file, one number of seconds
+// per line. The file is written by a separate goroutine, so writing does not
+// delay the responses.
+type TimingDump struct {
+	f     *os.File
+	queue chan time.Duration
+}
+
+// NewTimingDump creates the file.
+func NewTimingDump(filename string) (*TimingDump, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &TimingDump{
+		f:     f,
+		queue: make(chan time.Duration, timingQueueSize),
+	}
+
+	return d, nil
+}
+
+// write writes the durations from the queue to the file until the queue is
+// closed, then closes the file.
+func (d *TimingDump) write() error {
+	wr := bufio.NewWriter(d.f)
+
+	var err error
+	for duration := range d.queue {
+		if err != nil {
+			// drain the queue so that Run is not blocked
+			continue
+		}
+
+		_, err = fmt.Fprintf(wr, "%.6f\n", duration.Seconds())
+	}
+
+	if err == nil {
+		err = wr.Flush()
+	}
+
+	closeErr := d.f.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Run reads responses from in and forwards them to out, recording the
+// duration of each one. When in is closed or the context is cancelled, out is
+// closed and the remaining durations are written to the file.
+func (d *TimingDump) Run(ctx context.Context, in <-chan response.Response, out chan<- response.Response) error {
+	defer close(out)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.write()
+	}()
+
+	finish := func() error {
+		close(d.queue)
+		return <-done
+	}
+
+	for {
+		var res response.Response
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return finish()
+		case res, ok = <-in:
+			if !ok {
+				return finish()
+			}
+		}
+
+		if !res.Cancelled() {
+			d.queue <- res.Duration
+		}
+
+		select {
+		case <-ctx.Done():
+			return finish()
+		case out <- res:
+		}
+	}
+}
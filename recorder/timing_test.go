@@ -0,0 +1,80 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+)
+
+func TestTimingDump(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-recorder-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	responses := []response.Response{
+		{Item: "a", Duration: 120 * time.Millisecond},
+		{Item: "hidden", Duration: 1500 * time.Millisecond, Hide: true},
+		{Item: "failed", Duration: 2 * time.Second, Error: errors.New("timeout")},
+		{Item: "cancelled", Duration: time.Second, Error: context.Canceled},
+		{Item: "b", Duration: 42 * time.Microsecond},
+	}
+
+	filename := filepath.Join(tempdir, "timing.txt")
+	d, err := NewTimingDump(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan response.Response)
+	out := make(chan response.Response)
+
+	go func() {
+		for _, res := range responses {
+			in <- res
+		}
+		close(in)
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- d.Run(context.Background(), in, out)
+	}()
+
+	var n int
+	for range out {
+		n++
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != len(responses) {
+		t.Errorf("wrong number of responses passed on, want %d, got %d", len(responses), n)
+	}
+
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "0.120000\n1.500000\n2.000000\n0.000042\n"
+	if string(buf) != want {
+		t.Errorf("wrong data, want:\n%s\ngot:\n%s", want, buf)
+	}
+}
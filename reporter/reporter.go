@@ -2,16 +2,56 @@ package reporter
 
 import (
 	"fmt"
+	"io"
 	"sort"
 	"time"
 
 	"github.com/RedTeamPentesting/monsoon/cli"
+	"github.com/RedTeamPentesting/monsoon/producer"
 	"github.com/RedTeamPentesting/monsoon/response"
 )
 
 // Reporter prints the Responses to a terminal.
 type Reporter struct {
 	term cli.Terminal
+
+	// Explain enables printing hidden responses together with the name of
+	// the filter which rejected them.
+	Explain bool
+
+	// NoBanner disables printing the table heading and the summary at the
+	// end, so that only the responses are printed.
+	NoBanner bool
+
+	// Collapse enables printing only the first of several consecutive
+	// responses with the same status code and sizes, followed by a line with
+	// the number of responses which were not printed.
+	Collapse bool
+
+	// Log receives checkpoint lines with the progress of the run every
+	// CheckpointInterval and whenever another CheckpointPercent of the
+	// requests are done (if the number is known). If it is nil, no
+	// checkpoints are written.
+	Log                io.Writer
+	CheckpointInterval time.Duration
+	CheckpointPercent  int
+
+	// ShowReflected enables printing the number of times the value was
+	// found in the response in an additional column.
+	ShowReflected bool
+
+	// ShowTTFB enables printing the time to first byte in an additional
+	// column.
+	ShowTTFB bool
+
+	// ShowWAF enables printing the name of the web application firewall for
+	// responses which look like block pages.
+	ShowWAF bool
+
+	// ShowTrailers enables printing the trailers received after the body.
+	ShowTrailers bool
+
+	shown int
 }
 
 // New returns a new reporter.
@@ -26,7 +66,7 @@ type HTTPStats struct {
 	Errors         int
 	Responses      int
 	ShownResponses int
-	Count          int
+	Count          int // producer.UnknownCount (or zero) if the total is not known
 
 	lastRPS time.Time
 	rps     float64
@@ -61,8 +101,9 @@ func (h *HTTPStats) Report(current string) (res []string) {
 		status += fmt.Sprintf(", %.0f req/s", h.rps)
 	}
 
+	// without the total number of requests only the rate can be displayed
 	todo := h.Count - h.Responses
-	if todo > 0 {
+	if h.Count != producer.UnknownCount && todo > 0 {
 		status += fmt.Sprintf(", %d todo", todo)
 
 		if h.rps > 0 {
@@ -78,6 +119,10 @@ func (h *HTTPStats) Report(current string) (res []string) {
 	res = append(res, status)
 
 	for code, count := range h.StatusCodes {
+		if text := response.StatusText(code); text != "" {
+			res = append(res, fmt.Sprintf("%v (%v): %v", code, text, count))
+			continue
+		}
 		res = append(res, fmt.Sprintf("%v: %v", code, count))
 	}
 
@@ -86,36 +131,222 @@ func (h *HTTPStats) Report(current string) (res []string) {
 	return res
 }
 
+// Checkpoint returns a line describing the progress of the run at time now,
+// which is meant to be written to a logfile.
+func (h *HTTPStats) Checkpoint(now time.Time) string {
+	line := fmt.Sprintf("checkpoint %v: %d", now.Format(time.RFC3339), h.Responses)
+	if h.Count > 0 {
+		line += fmt.Sprintf(" of %d requests done (%d%%)", h.Count, h.Responses*100/h.Count)
+	} else {
+		line += " requests done"
+	}
+
+	rate := 0.0
+	if secs := now.Sub(h.Start).Seconds(); secs > 0 {
+		rate = float64(h.Responses) / secs
+	}
+
+	return line + fmt.Sprintf(", %.0f req/s, %d shown, %d errors\n", rate, h.ShownResponses, h.Errors)
+}
+
+// checkpoints writes the progress of a run to a log at regular intervals and
+// whenever another percentage of the requests is done.
+type checkpoints struct {
+	log     io.Writer
+	percent int
+	next    int
+
+	ticker *time.Ticker
+	tick   <-chan time.Time
+}
+
+// newCheckpoints returns checkpoints for log, which may be nil. Stop must be
+// called when the run is done.
+func newCheckpoints(log io.Writer, interval time.Duration, percent int) *checkpoints {
+	c := &checkpoints{log: log, percent: percent, next: percent}
+	if log != nil && interval > 0 {
+		c.ticker = time.NewTicker(interval)
+		c.tick = c.ticker.C
+	}
+	return c
+}
+
+// write writes the current progress to the log.
+func (c *checkpoints) write(stats *HTTPStats) {
+	_, _ = io.WriteString(c.log, stats.Checkpoint(time.Now()))
+}
+
+// update writes the progress if another percentage of the requests is done.
+func (c *checkpoints) update(stats *HTTPStats) {
+	if c.log == nil || c.next <= 0 || stats.Count <= 0 {
+		return
+	}
+
+	if percent := stats.Responses * 100 / stats.Count; percent >= c.next {
+		c.write(stats)
+		c.next = (percent/c.percent + 1) * c.percent
+	}
+}
+
+// stop stops the ticker.
+func (c *checkpoints) stop() {
+	if c.ticker != nil {
+		c.ticker.Stop()
+	}
+}
+
+// Add records the response in the statistics, shown reports whether it was
+// displayed.
+func (h *HTTPStats) Add(res response.Response, shown bool) {
+	h.Responses++
+
+	if res.Error != nil {
+		h.Errors++
+	}
+	h.StatusCodes[res.Status()]++
+
+	if shown {
+		h.ShownResponses++
+	}
+}
+
+// Shown returns the number of responses which were shown (not hidden) by
+// Display.
+func (r *Reporter) Shown() int {
+	return r.shown
+}
+
+// signature contains the properties of a response which are compared for
+// collapsing consecutive responses. The extracted data is included so that
+// responses with different data are always printed.
+type signature struct {
+	status, header, body int
+	extract              string
+}
+
+// collapser keeps track of runs of responses with the same signature.
+type collapser struct {
+	term cli.Terminal
+	pad  int // width of the optional columns, which are left empty
+
+	last      signature
+	collapsed int
+	valid     bool
+}
+
+// add returns true if res is part of the current run and should not be
+// printed. Otherwise the line summarizing the previous run (if any) is
+// printed and a new run is started with res.
+func (c *collapser) add(res response.Response) bool {
+	if res.Error != nil || res.HTTPResponse == nil {
+		c.flush()
+		return false
+	}
+
+	sig := signature{
+		status: res.HTTPResponse.StatusCode,
+		header: res.Header.Bytes,
+		body:   res.Body.Bytes,
+		// quoted, so that the boundaries of the values are kept
+		extract: fmt.Sprintf("%q", res.Extract),
+	}
+
+	if c.valid && sig == c.last {
+		c.collapsed++
+		return true
+	}
+
+	c.flush()
+	c.last = sig
+	c.valid = true
+	return false
+}
+
+// flush prints the number of collapsed responses of the current run and ends
+// it.
+func (c *collapser) flush() {
+	if c.collapsed > 0 {
+		c.term.Printf("%7d %8d %8d%*s   ... ×%d\n", c.last.status, c.last.header, c.last.body, c.pad, "", c.collapsed)
+	}
+
+	c.collapsed = 0
+	c.valid = false
+}
+
 // Display shows incoming Responses.
 func (r *Reporter) Display(ch <-chan response.Response, countChannel <-chan int) error {
-	r.term.Printf("%7s %8s %8s   %-8s %s\n", "status", "header", "body", "value", "extract")
+	columns := response.Columns{Reflected: r.ShowReflected, TTFB: r.ShowTTFB, WAF: r.ShowWAF, Trailers: r.ShowTrailers}
+
+	if !r.NoBanner {
+		heading := fmt.Sprintf("%7s %8s %8s", "status", "header", "body")
+		if columns.Reflected {
+			heading += fmt.Sprintf(" %9s", "reflected")
+		}
+		if columns.TTFB {
+			heading += fmt.Sprintf(" %8s", "ttfb")
+		}
+		r.term.Printf("%s   %-8s %s\n", heading, "value", "extract")
+	}
 
 	stats := &HTTPStats{
 		Start:       time.Now(),
 		StatusCodes: make(map[int]int),
 	}
 
-	for response := range ch {
+	collapse := &collapser{term: r.term, pad: columns.Width()}
+
+	checkpoints := newCheckpoints(r.Log, r.CheckpointInterval, r.CheckpointPercent)
+	defer checkpoints.stop()
+
+	for {
+		var response response.Response
+		var ok bool
+
+		select {
+		case <-checkpoints.tick:
+			checkpoints.write(stats)
+			continue
+		case response, ok = <-ch:
+		}
+
+		if !ok {
+			break
+		}
+
 		select {
 		case c := <-countChannel:
 			stats.Count = c
 		default:
 		}
 
-		stats.Responses++
-
-		if response.Error != nil {
-			stats.Errors++
-		} else {
-			stats.StatusCodes[response.HTTPResponse.StatusCode]++
+		// requests which have been cancelled when the run was stopped are
+		// not counted
+		if response.Cancelled() {
+			continue
 		}
 
-		if !response.Hide {
-			r.term.Printf("%v\n", response)
-			stats.ShownResponses++
+		stats.Add(response, !response.Hide)
+
+		switch {
+		case !response.Hide:
+			if !r.Collapse || !collapse.add(response) {
+				r.term.Printf("%v\n", response.Format(columns))
+			}
+		case r.Explain:
+			collapse.flush()
+			r.term.Printf("%v (hidden by %v)\n", response.Format(columns), response.HiddenBy)
 		}
 
 		r.term.SetStatus(stats.Report(response.Item))
+		checkpoints.update(stats)
+	}
+
+	collapse.flush()
+
+	r.shown = stats.ShownResponses
+
+	if r.NoBanner {
+		return nil
 	}
 
 	r.term.Print("\n")
@@ -0,0 +1,293 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/producer"
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/google/go-cmp/cmp"
+)
+
+// testTerminal collects the lines printed.
+type testTerminal struct {
+	lines []string
+}
+
+func (t *testTerminal) Printf(msg string, data ...interface{}) {
+	t.lines = append(t.lines, fmt.Sprintf(msg, data...))
+}
+
+func (t *testTerminal) Print(msg string) {
+	t.lines = append(t.lines, msg)
+}
+
+func (t *testTerminal) SetStatus([]string)      {}
+func (t *testTerminal) Run(ctx context.Context) {}
+
+func TestReportCount(t *testing.T) {
+	var tests = []struct {
+		count int
+		todo  bool
+	}{
+		{10, true},
+		{5, false},
+		{0, false},
+		{producer.UnknownCount, false},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			stats := &HTTPStats{
+				Start:       time.Now().Add(-2 * time.Second),
+				StatusCodes: map[int]int{200: 5},
+				Responses:   5,
+				Count:       test.count,
+			}
+
+			status := stats.Report("")[1]
+			if !strings.Contains(status, "req/s") {
+				t.Errorf("rate missing in status %q", status)
+			}
+
+			if strings.Contains(status, "todo") != test.todo || strings.Contains(status, "-") {
+				t.Errorf("wrong status %q for count %d", status, test.count)
+			}
+		})
+	}
+}
+
+func TestReporterShown(t *testing.T) {
+	ok := response.Response{HTTPResponse: &http.Response{StatusCode: 200}}
+	hidden := response.Response{HTTPResponse: &http.Response{StatusCode: 404}, Hide: true}
+	cancelled := response.Response{Error: context.Canceled}
+
+	var tests = []struct {
+		responses []response.Response
+		shown     int
+	}{
+		{nil, 0},
+		{[]response.Response{hidden, hidden}, 0},
+		{[]response.Response{ok, hidden, ok}, 2},
+		{[]response.Response{cancelled, hidden}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			ch := make(chan response.Response, len(test.responses))
+			for _, res := range test.responses {
+				ch <- res
+			}
+			close(ch)
+
+			rep := New(&testTerminal{})
+			err := rep.Display(ch, make(chan int))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rep.Shown() != test.shown {
+				t.Errorf("wrong number of shown responses, want %d, got %d", test.shown, rep.Shown())
+			}
+		})
+	}
+}
+
+func TestReporterCollapse(t *testing.T) {
+	newResponse := func(item string, status, body int, hide bool) response.Response {
+		return response.Response{
+			Item:         item,
+			HTTPResponse: &http.Response{StatusCode: status},
+			Header:       response.TextStats{Bytes: 100},
+			Body:         response.TextStats{Bytes: body},
+			Hide:         hide,
+		}
+	}
+
+	extract := func(res response.Response, data ...string) response.Response {
+		res.Extract = data
+		return res
+	}
+
+	failed := response.Response{Item: "x", Error: errors.New("connection refused")}
+
+	var tests = []struct {
+		responses []response.Response
+		explain   bool
+		want      []string
+	}{
+		{
+			responses: []response.Response{
+				newResponse("a", 200, 10, false),
+				newResponse("b", 200, 10, false),
+				newResponse("c", 200, 10, false),
+				newResponse("d", 200, 20, false),
+			},
+			want: []string{
+				"    200      100       10   a       \n",
+				"    200      100       10   ... ×2\n",
+				"    200      100       20   d       \n",
+			},
+		},
+		{
+			// hidden responses are not printed, so they don't end a run
+			responses: []response.Response{
+				newResponse("a", 200, 10, false),
+				newResponse("b", 404, 10, true),
+				newResponse("c", 200, 10, false),
+			},
+			want: []string{
+				"    200      100       10   a       \n",
+				"    200      100       10   ... ×1\n",
+			},
+		},
+		{
+			responses: []response.Response{
+				newResponse("a", 200, 10, false),
+				newResponse("b", 404, 10, true),
+				newResponse("c", 200, 10, false),
+			},
+			explain: true,
+			want: []string{
+				"    200      100       10   a       \n",
+				"    404      100       10   b        (hidden by )\n",
+				"    200      100       10   c       \n",
+			},
+		},
+		{
+			responses: []response.Response{
+				newResponse("a", 200, 10, false),
+				newResponse("b", 200, 10, false),
+				failed,
+				failed,
+				newResponse("c", 200, 10, false),
+			},
+			want: []string{
+				"    200      100       10   a       \n",
+				"    200      100       10   ... ×1\n",
+				fmt.Sprintf("%v\n", failed),
+				fmt.Sprintf("%v\n", failed),
+				"    200      100       10   c       \n",
+			},
+		},
+		{
+			// responses with different extracted data are not collapsed
+			responses: []response.Response{
+				extract(newResponse("a", 200, 10, false), "token1"),
+				extract(newResponse("b", 200, 10, false), "token1"),
+				extract(newResponse("c", 200, 10, false), "token2"),
+			},
+			want: []string{
+				"    200      100       10   a        data: token1\n",
+				"    200      100       10   ... ×1\n",
+				"    200      100       10   c        data: token2\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			ch := make(chan response.Response, len(test.responses))
+			for _, res := range test.responses {
+				ch <- res
+			}
+			close(ch)
+
+			term := &testTerminal{}
+			rep := New(term)
+			rep.NoBanner = true
+			rep.Collapse = true
+			rep.Explain = test.explain
+			err := rep.Display(ch, make(chan int))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.want, term.lines) {
+				t.Error(cmp.Diff(test.want, term.lines))
+			}
+
+			if rep.Shown() != len(test.responses)-countHidden(test.responses) {
+				t.Errorf("wrong number of shown responses %d", rep.Shown())
+			}
+		})
+	}
+}
+
+func countHidden(responses []response.Response) (n int) {
+	for _, res := range responses {
+		if res.Hide {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCheckpoint(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		stats HTTPStats
+		want  string
+	}{
+		{
+			HTTPStats{Start: start, Responses: 250, Count: 1000, ShownResponses: 3, Errors: 1},
+			"checkpoint 2020-01-02T03:04:15Z: 250 of 1000 requests done (25%), 25 req/s, 3 shown, 1 errors\n",
+		},
+		{
+			HTTPStats{Start: start, Responses: 100, Count: producer.UnknownCount},
+			"checkpoint 2020-01-02T03:04:15Z: 100 requests done, 10 req/s, 0 shown, 0 errors\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got := test.stats.Checkpoint(start.Add(10 * time.Second))
+			if got != test.want {
+				t.Errorf("wrong checkpoint, want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReporterCheckpointPercent(t *testing.T) {
+	ok := response.Response{HTTPResponse: &http.Response{StatusCode: 200}}
+
+	ch := make(chan response.Response, 10)
+	for i := 0; i < 10; i++ {
+		ch <- ok
+	}
+	close(ch)
+
+	count := make(chan int, 1)
+	count <- 10
+
+	var log strings.Builder
+	rep := New(&testTerminal{})
+	rep.Log = &log
+	rep.CheckpointPercent = 25
+	err := rep.Display(ch, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var done []string
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			t.Fatalf("invalid checkpoint line %q", line)
+		}
+		done = append(done, fields[2])
+	}
+
+	// checkpoints at 30%, 50%, 80% and 100%
+	want := []string{"3", "5", "8", "10"}
+	if !cmp.Equal(want, done) {
+		t.Error(cmp.Diff(want, done))
+	}
+}
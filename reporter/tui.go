@@ -0,0 +1,584 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/gdamore/tcell"
+	"github.com/mattn/go-runewidth"
+)
+
+// maxMessages is the number of lines printed via the terminal interface which
+// are shown at the bottom of the screen.
+const maxMessages = 3
+
+// redrawInterval limits how often the screen is updated while responses are
+// received.
+const redrawInterval = 100 * time.Millisecond
+
+// TUI shows the responses in a full-screen interface: a scrollable list of
+// responses, toggles for the filters and a pane with the header and body of
+// the selected response. It also implements cli.Terminal, lines printed while
+// the interface is active are shown at the bottom of the screen and printed
+// again to stdout when it is closed.
+//
+// Filters are toggled by the name of the filter which hid a response, so a
+// response hidden by several filters is shown again when the first one is
+// disabled. The body is not kept for hidden responses.
+type TUI struct {
+	screen tcell.Screen
+
+	// Stop is called when the user quits the interface before all responses
+	// have been received, it should stop the run.
+	Stop func()
+
+	// Columns selects the optional columns of the list.
+	Columns response.Columns
+
+	// Log receives checkpoint lines like for Reporter.
+	Log                io.Writer
+	CheckpointInterval time.Duration
+	CheckpointPercent  int
+
+	msgMu    sync.Mutex
+	messages []string
+	closed   bool
+	out      io.Writer // receives the messages when the interface is closed
+
+	stats     *HTTPStats
+	responses []response.Response
+	visible   []int // indexes into responses
+	filters   []string
+	hidden    map[string]int  // number of responses hidden by the filter
+	showAll   bool            // show all hidden responses
+	show      map[string]bool // show the responses hidden by the filter
+	shown     int
+
+	selected int // index into visible
+	offset   int // first line of the list on the screen
+	follow   bool
+	detail   bool
+	scroll   int // first line of the detail pane
+	done     bool
+	quitting bool
+}
+
+// NewTUI initializes screen and returns an interface which uses it.
+func NewTUI(screen tcell.Screen) (*TUI, error) {
+	err := screen.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TUI{
+		screen: screen,
+		out:    os.Stdout,
+		hidden: make(map[string]int),
+		show:   make(map[string]bool),
+		follow: true,
+		stats: &HTTPStats{
+			Start:       time.Now(),
+			StatusCodes: make(map[int]int),
+		},
+	}
+
+	return t, nil
+}
+
+// Printf prints a message with formatting.
+func (t *TUI) Printf(msg string, data ...interface{}) {
+	t.Print(fmt.Sprintf(msg, data...))
+}
+
+// Print prints a message. While the interface is active, the last lines are
+// shown at the bottom of the screen.
+func (t *TUI) Print(msg string) {
+	t.msgMu.Lock()
+	defer t.msgMu.Unlock()
+
+	if t.closed {
+		if !strings.HasSuffix(msg, "\n") {
+			msg += "\n"
+		}
+		_, _ = io.WriteString(t.out, msg)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(msg, "\n"), "\n") {
+		t.messages = append(t.messages, line)
+	}
+}
+
+// SetStatus does nothing, the status is displayed by the interface.
+func (t *TUI) SetStatus([]string) {}
+
+// Run does nothing, the screen is updated by Display.
+func (t *TUI) Run(ctx context.Context) {}
+
+// Shown returns the number of responses which were not hidden.
+func (t *TUI) Shown() int {
+	return t.shown
+}
+
+// Close restores the terminal and prints all messages to stdout. It is called
+// by Display and may be called several times.
+func (t *TUI) Close() {
+	t.msgMu.Lock()
+	defer t.msgMu.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	t.screen.Fini()
+	t.closed = true
+	for _, msg := range t.messages {
+		_, _ = io.WriteString(t.out, msg+"\n")
+	}
+	t.messages = nil
+}
+
+// Display shows the responses from ch until the user quits. When the user
+// quits before ch is closed, Stop is called and the remaining responses are
+// read without showing them.
+func (t *TUI) Display(ch <-chan response.Response, countChannel <-chan int) error {
+	defer t.Close()
+
+	events := make(chan tcell.Event)
+	quit := make(chan struct{})
+	defer close(quit)
+
+	go func() {
+		for {
+			ev := t.screen.PollEvent()
+			if ev == nil {
+				return
+			}
+
+			select {
+			case events <- ev:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	checkpoints := newCheckpoints(t.Log, t.CheckpointInterval, t.CheckpointPercent)
+	defer checkpoints.stop()
+
+	redraw := time.NewTicker(redrawInterval)
+	defer redraw.Stop()
+
+	t.draw()
+	dirty := false
+
+	for {
+		select {
+		case <-checkpoints.tick:
+			checkpoints.write(t.stats)
+
+		case <-redraw.C:
+			if dirty {
+				t.draw()
+				dirty = false
+			}
+
+		case c := <-countChannel:
+			t.stats.Count = c
+
+		case res, ok := <-ch:
+			if !ok {
+				ch = nil
+				t.done = true
+				if t.quitting {
+					return nil
+				}
+				t.draw()
+				continue
+			}
+
+			t.add(res)
+			checkpoints.update(t.stats)
+			dirty = true
+
+		case ev := <-events:
+			if t.handle(ev) {
+				if t.done {
+					return nil
+				}
+
+				t.quitting = true
+				if t.Stop != nil {
+					t.Stop()
+				}
+			}
+			t.draw()
+		}
+	}
+}
+
+// add records a response.
+func (t *TUI) add(res response.Response) {
+	// requests which have been cancelled when the run was stopped are not
+	// counted
+	if res.Cancelled() {
+		return
+	}
+
+	t.stats.Add(res, !res.Hide)
+
+	if res.Hide {
+		if _, ok := t.hidden[res.HiddenBy]; !ok {
+			t.filters = append(t.filters, res.HiddenBy)
+		}
+		t.hidden[res.HiddenBy]++
+
+		// don't keep the bodies of all the uninteresting responses
+		res.RawBody = nil
+	} else {
+		t.shown++
+	}
+
+	t.responses = append(t.responses, res)
+	if t.isVisible(res) {
+		t.visible = append(t.visible, len(t.responses)-1)
+		if t.follow {
+			t.selected = len(t.visible) - 1
+		}
+	}
+}
+
+// isVisible returns true if res is displayed with the current filter toggles.
+func (t *TUI) isVisible(res response.Response) bool {
+	return !res.Hide || t.showAll || t.show[res.HiddenBy]
+}
+
+// toggle switches between hiding and showing the responses hidden by the
+// filter with index i.
+func (t *TUI) toggle(i int) {
+	if i < 0 || i >= len(t.filters) {
+		return
+	}
+
+	name := t.filters[i]
+	t.show[name] = !t.show[name]
+	t.rebuild()
+}
+
+// rebuild computes the list of visible responses and keeps the selection on
+// the same response (or the next visible one).
+func (t *TUI) rebuild() {
+	current := -1
+	if t.selected < len(t.visible) {
+		current = t.visible[t.selected]
+	}
+
+	t.visible = t.visible[:0]
+	t.selected = 0
+	for i, res := range t.responses {
+		if !t.isVisible(res) {
+			continue
+		}
+
+		if i <= current || t.follow {
+			t.selected = len(t.visible)
+		}
+		t.visible = append(t.visible, i)
+	}
+	t.scroll = 0
+}
+
+// move changes the selection by n lines.
+func (t *TUI) move(n int) {
+	t.selected += n
+	if t.selected >= len(t.visible) {
+		t.selected = len(t.visible) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+
+	t.follow = t.selected == len(t.visible)-1
+	t.scroll = 0
+}
+
+// handle processes an event, it returns true if the user wants to quit.
+func (t *TUI) handle(ev tcell.Event) bool {
+	key, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return false
+	}
+
+	_, height := t.screen.Size()
+	page := height / 2
+	if page < 1 {
+		page = 1
+	}
+
+	switch key.Key() {
+	case tcell.KeyCtrlC:
+		return true
+	case tcell.KeyUp:
+		t.move(-1)
+	case tcell.KeyDown:
+		t.move(1)
+	case tcell.KeyPgUp:
+		if t.detail {
+			t.scrollDetail(-page)
+		} else {
+			t.move(-page)
+		}
+	case tcell.KeyPgDn:
+		if t.detail {
+			t.scrollDetail(page)
+		} else {
+			t.move(page)
+		}
+	case tcell.KeyHome:
+		t.move(-len(t.visible))
+	case tcell.KeyEnd:
+		t.move(len(t.visible))
+	case tcell.KeyEnter:
+		t.detail = !t.detail
+		t.scroll = 0
+	case tcell.KeyEscape:
+		t.detail = false
+	case tcell.KeyRune:
+		switch r := key.Rune(); {
+		case r == 'q':
+			return true
+		case r == 'k':
+			t.move(-1)
+		case r == 'j':
+			t.move(1)
+		case r == 'g':
+			t.move(-len(t.visible))
+		case r == 'G':
+			t.move(len(t.visible))
+		case r == 'K':
+			t.scrollDetail(-1)
+		case r == 'J':
+			t.scrollDetail(1)
+		case r == 'a':
+			t.showAll = !t.showAll
+			t.rebuild()
+		case r >= '1' && r <= '9':
+			t.toggle(int(r - '1'))
+		}
+	}
+
+	return false
+}
+
+// scrollDetail scrolls the detail pane by n lines.
+func (t *TUI) scrollDetail(n int) {
+	t.scroll += n
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+}
+
+// detailLines returns the lines of the detail pane for res.
+func detailLines(res response.Response) []string {
+	lines := []string{
+		"url:   " + response.Sanitize(res.URL),
+		"value: " + response.Sanitize(res.Item),
+	}
+
+	if res.Hide {
+		lines = append(lines, "hidden by: "+res.HiddenBy)
+	}
+
+	if res.Error != nil {
+		return append(lines, "error: "+response.Sanitize(res.Error.Error()))
+	}
+
+	lines = append(lines, "")
+	header := string(response.SanitizeHeader(res.RawHeader))
+	for _, line := range strings.Split(header, "\n") {
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+
+	switch {
+	case len(res.RawBody) > 0:
+		body := string(response.FormatBody(res.RawBody, response.BinaryHex))
+		for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+			lines = append(lines, response.Sanitize(strings.TrimSuffix(line, "\r")))
+		}
+	case res.Body.Bytes == 0:
+	case res.Hide:
+		lines = append(lines, "[body of hidden response not kept]")
+	default:
+		lines = append(lines, "[body not kept, see --no-body]")
+	}
+
+	return lines
+}
+
+// drawText writes s at x, y and returns the column after the text. Tabs are
+// expanded, the text is cut at the edge of the screen.
+func (t *TUI) drawText(x, y int, s string, style tcell.Style) int {
+	width, _ := t.screen.Size()
+	for _, r := range s {
+		if r == '\t' {
+			for next := (x/8 + 1) * 8; x < next && x < width; x++ {
+				t.screen.SetContent(x, y, ' ', nil, style)
+			}
+			continue
+		}
+
+		w := runewidth.RuneWidth(r)
+		if x+w > width {
+			break
+		}
+		t.screen.SetContent(x, y, r, nil, style)
+		x += w
+	}
+	return x
+}
+
+// drawLine writes s at the start of line y and fills the rest of the line.
+func (t *TUI) drawLine(y int, s string, style tcell.Style) {
+	width, _ := t.screen.Size()
+	for x := t.drawText(0, y, s, style); x < width; x++ {
+		t.screen.SetContent(x, y, ' ', nil, style)
+	}
+}
+
+// statusLine returns the first line of the screen.
+func (t *TUI) statusLine() string {
+	current := ""
+	if len(t.responses) > 0 {
+		current = t.responses[len(t.responses)-1].Item
+	}
+
+	status := t.stats.Report(current)[1]
+	switch {
+	case t.done:
+		status += ", done"
+	case t.quitting:
+		status += ", stopping"
+	}
+	return status
+}
+
+// draw updates the screen.
+func (t *TUI) draw() {
+	t.screen.Clear()
+	_, height := t.screen.Size()
+
+	normal := tcell.StyleDefault
+	bold := normal.Bold(true)
+	reverse := normal.Reverse(true)
+	dim := normal.Dim(true)
+
+	t.drawLine(0, t.statusLine(), bold)
+
+	x := t.drawText(0, 1, "filters:", normal)
+	if len(t.filters) == 0 {
+		t.drawText(x, 1, " no responses hidden yet", dim)
+	}
+	for i, name := range t.filters {
+		style := reverse
+		if t.showAll || t.show[name] {
+			style = normal
+		}
+		x = t.drawText(x, 1, " ", normal)
+		label := fmt.Sprintf("%d:%s (%d)", i+1, name, t.hidden[name])
+		if i >= 9 {
+			label = fmt.Sprintf("%s (%d)", name, t.hidden[name])
+		}
+		x = t.drawText(x, 1, label, style)
+	}
+
+	heading := fmt.Sprintf("%7s %8s %8s", "status", "header", "body")
+	if t.Columns.Reflected {
+		heading += fmt.Sprintf(" %9s", "reflected")
+	}
+	if t.Columns.TTFB {
+		heading += fmt.Sprintf(" %8s", "ttfb")
+	}
+	t.drawLine(2, fmt.Sprintf("%s   %-8s %s", heading, "value", "extract"), bold)
+
+	t.msgMu.Lock()
+	messages := t.messages
+	if len(messages) > maxMessages {
+		messages = messages[len(messages)-maxMessages:]
+	}
+	t.msgMu.Unlock()
+
+	// the lines at the bottom: messages and the keys
+	bottom := height - 1 - len(messages)
+	for i, msg := range messages {
+		t.drawLine(bottom+i, msg, dim)
+	}
+	t.drawLine(height-1, "↑↓ select  enter details  PgUp/PgDn scroll  1-9 toggle filter  a show all  q quit", reverse)
+
+	listEnd := bottom
+	if t.detail && len(t.visible) > 0 {
+		listEnd = 3 + (bottom-3)/2
+		t.drawDetail(listEnd, bottom)
+	}
+
+	t.drawList(3, listEnd)
+	t.screen.Show()
+}
+
+// drawList draws the visible responses in the lines from start to end
+// (exclusive).
+func (t *TUI) drawList(start, end int) {
+	rows := end - start
+	if rows <= 0 {
+		return
+	}
+
+	// keep the selection on the screen
+	if t.selected < t.offset {
+		t.offset = t.selected
+	}
+	if t.selected >= t.offset+rows {
+		t.offset = t.selected - rows + 1
+	}
+
+	for i := 0; i < rows && t.offset+i < len(t.visible); i++ {
+		res := t.responses[t.visible[t.offset+i]]
+
+		line := res.Format(t.Columns)
+		style := tcell.StyleDefault
+		if res.Hide {
+			line += fmt.Sprintf(" (hidden by %v)", res.HiddenBy)
+			style = style.Dim(true)
+		}
+		if t.offset+i == t.selected {
+			style = style.Reverse(true)
+		}
+
+		t.drawLine(start+i, line, style)
+	}
+}
+
+// drawDetail draws the detail pane for the selected response in the lines
+// from start to end (exclusive), the first line is a separator.
+func (t *TUI) drawDetail(start, end int) {
+	res := t.responses[t.visible[t.selected]]
+	lines := detailLines(res)
+
+	rows := end - start - 1
+	if t.scroll > len(lines)-rows {
+		t.scroll = len(lines) - rows
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+
+	t.drawLine(start, fmt.Sprintf("── %v (line %d of %d, esc closes)", res.Item, t.scroll+1, len(lines)), tcell.StyleDefault.Bold(true))
+	for i := 0; i < rows && t.scroll+i < len(lines); i++ {
+		t.drawLine(start+1+i, lines[t.scroll+i], tcell.StyleDefault)
+	}
+}
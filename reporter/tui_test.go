@@ -0,0 +1,351 @@
+package reporter
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/response"
+	"github.com/gdamore/tcell"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestTUI returns an interface running on a simulated screen.
+func newTestTUI(t *testing.T) (*TUI, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	tui, err := NewTUI(screen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(100, 30)
+	tui.out = &bytes.Buffer{}
+	return tui, screen
+}
+
+// screenLines returns the lines shown on the screen without trailing spaces.
+func screenLines(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+
+	var lines []string
+	for y := 0; y < height; y++ {
+		var line []byte
+		for x := 0; x < width; x++ {
+			line = append(line, cells[y*width+x].Bytes...)
+		}
+		lines = append(lines, strings.TrimRight(string(line), " "))
+	}
+	return lines
+}
+
+// listLines returns the lines of the response list on the screen.
+func listLines(screen tcell.SimulationScreen) []string {
+	var lines []string
+	for _, line := range screenLines(screen)[3:] {
+		if line == "" {
+			break
+		}
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return lines
+}
+
+func testResponse(item string, status int, hiddenBy string) response.Response {
+	return response.Response{
+		Item:         item,
+		HTTPResponse: &http.Response{StatusCode: status},
+		RawHeader:    []byte("HTTP/1.1 200 OK\r\nX-Item: " + item + "\r\n\r\n"),
+		RawBody:      []byte("body " + item + "\n"),
+		Body:         response.TextStats{Bytes: len("body " + item + "\n")},
+		Hide:         hiddenBy != "",
+		HiddenBy:     hiddenBy,
+	}
+}
+
+func TestTUIFilters(t *testing.T) {
+	tui, screen := newTestTUI(t)
+	defer screen.Fini()
+
+	for _, res := range []response.Response{
+		testResponse("one", 200, ""),
+		testResponse("two", 404, "hide-status"),
+		testResponse("three", 200, "hide-pattern"),
+		testResponse("four", 404, "hide-status"),
+		testResponse("five", 500, ""),
+	} {
+		tui.add(res)
+	}
+
+	if tui.Shown() != 2 {
+		t.Errorf("wrong number of shown responses, want 2, got %d", tui.Shown())
+	}
+
+	key := func(r rune) {
+		tui.handle(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		tui.draw()
+	}
+
+	var tests = []struct {
+		key  rune
+		want []string
+	}{
+		{0, []string{
+			"200 0 9 one",
+			"500 0 10 five",
+		}},
+		{'1', []string{
+			"200 0 9 one",
+			"404 0 9 two (hidden by hide-status)",
+			"404 0 10 four (hidden by hide-status)",
+			"500 0 10 five",
+		}},
+		{'2', []string{
+			"200 0 9 one",
+			"404 0 9 two (hidden by hide-status)",
+			"200 0 11 three (hidden by hide-pattern)",
+			"404 0 10 four (hidden by hide-status)",
+			"500 0 10 five",
+		}},
+		{'1', []string{
+			"200 0 9 one",
+			"200 0 11 three (hidden by hide-pattern)",
+			"500 0 10 five",
+		}},
+		// there's no third filter
+		{'3', []string{
+			"200 0 9 one",
+			"200 0 11 three (hidden by hide-pattern)",
+			"500 0 10 five",
+		}},
+		{'2', []string{
+			"200 0 9 one",
+			"500 0 10 five",
+		}},
+		{'a', []string{
+			"200 0 9 one",
+			"404 0 9 two (hidden by hide-status)",
+			"200 0 11 three (hidden by hide-pattern)",
+			"404 0 10 four (hidden by hide-status)",
+			"500 0 10 five",
+		}},
+	}
+
+	for _, test := range tests {
+		if test.key == 0 {
+			tui.draw()
+		} else {
+			key(test.key)
+		}
+
+		if !cmp.Equal(test.want, listLines(screen)) {
+			t.Errorf("wrong list after key %q:\n%v", test.key, cmp.Diff(test.want, listLines(screen)))
+		}
+	}
+
+	filters := screenLines(screen)[1]
+	want := "filters: 1:hide-status (2) 2:hide-pattern (1)"
+	if filters != want {
+		t.Errorf("wrong filter line, want %q, got %q", want, filters)
+	}
+}
+
+func TestTUISelection(t *testing.T) {
+	tui, screen := newTestTUI(t)
+	defer screen.Fini()
+
+	for _, item := range []string{"one", "two", "three"} {
+		tui.add(testResponse(item, 200, ""))
+	}
+	tui.add(testResponse("four", 404, "hide-status"))
+
+	// new responses are selected until the selection is moved
+	if tui.selected != 2 {
+		t.Errorf("last response not selected, selected %d", tui.selected)
+	}
+
+	tui.handle(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone))
+	tui.add(testResponse("five", 200, ""))
+	if tui.selected != 1 {
+		t.Errorf("selection moved with new response, selected %d", tui.selected)
+	}
+
+	// showing the hidden responses keeps the selected response
+	tui.handle(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone))
+	if res := tui.responses[tui.visible[tui.selected]]; res.Item != "two" {
+		t.Errorf("wrong response selected: %v", res.Item)
+	}
+
+	tui.handle(tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone))
+	tui.handle(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone))
+	tui.add(testResponse("six", 200, ""))
+	if res := tui.responses[tui.visible[tui.selected]]; res.Item != "six" {
+		t.Errorf("wrong response selected: %v", res.Item)
+	}
+
+	tui.handle(tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone))
+	tui.handle(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	tui.draw()
+
+	var found bool
+	for _, line := range screenLines(screen) {
+		if line == "body one" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("body of the selected response not shown:\n%s", strings.Join(screenLines(screen), "\n"))
+	}
+}
+
+func TestDetailLines(t *testing.T) {
+	var tests = []struct {
+		res  response.Response
+		want []string
+	}{
+		{
+			res: testResponse("one", 200, ""),
+			want: []string{
+				"url:   http://localhost/one",
+				"value: one",
+				"",
+				"HTTP/1.1 200 OK",
+				"X-Item: one",
+				"",
+				"",
+				"body one",
+			},
+		},
+		{
+			res: func() response.Response {
+				res := testResponse("two", 404, "hide-status")
+				res.RawBody = nil
+				return res
+			}(),
+			want: []string{
+				"url:   http://localhost/two",
+				"value: two",
+				"hidden by: hide-status",
+				"",
+				"HTTP/1.1 200 OK",
+				"X-Item: two",
+				"",
+				"",
+				"[body of hidden response not kept]",
+			},
+		},
+		{
+			res: response.Response{
+				Item:  "three\x1b",
+				URL:   "http://localhost/three",
+				Error: errors.New("test error"),
+			},
+			want: []string{
+				"url:   http://localhost/three",
+				`value: three\x1b`,
+				"error: test error",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if test.res.URL == "" {
+				test.res.URL = "http://localhost/" + test.res.Item
+			}
+
+			lines := detailLines(test.res)
+			if !cmp.Equal(test.want, lines) {
+				t.Error(cmp.Diff(test.want, lines))
+			}
+		})
+	}
+}
+
+// runTUI runs Display in a goroutine and returns a channel which receives its
+// result.
+func runTUI(tui *TUI, ch <-chan response.Response) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- tui.Display(ch, make(chan int))
+	}()
+	return done
+}
+
+func waitTUI(t *testing.T, done <-chan error) {
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Display did not return")
+	}
+}
+
+func TestTUIQuit(t *testing.T) {
+	t.Run("after-run", func(t *testing.T) {
+		tui, screen := newTestTUI(t)
+		var stopped bool
+		tui.Stop = func() { stopped = true }
+
+		ch := make(chan response.Response)
+		done := runTUI(tui, ch)
+
+		ch <- testResponse("one", 200, "")
+		tui.Print("message\n")
+		close(ch)
+
+		screen.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+		waitTUI(t, done)
+
+		if stopped {
+			t.Errorf("Stop called after the run was done")
+		}
+
+		if tui.Shown() != 1 {
+			t.Errorf("wrong number of shown responses, want 1, got %d", tui.Shown())
+		}
+
+		// messages are printed when the interface is closed, and afterwards
+		tui.Print("after")
+		out := tui.out.(*bytes.Buffer).String()
+		if out != "message\nafter\n" {
+			t.Errorf("wrong output %q", out)
+		}
+	})
+
+	t.Run("during-run", func(t *testing.T) {
+		tui, screen := newTestTUI(t)
+		stop := make(chan struct{})
+		tui.Stop = func() { close(stop) }
+
+		ch := make(chan response.Response)
+		done := runTUI(tui, ch)
+
+		ch <- testResponse("one", 200, "")
+		screen.InjectKey(tcell.KeyCtrlC, 0, tcell.ModNone)
+
+		select {
+		case <-stop:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Stop not called")
+		}
+
+		// the remaining responses are read until the channel is closed
+		ch <- testResponse("two", 200, "")
+		select {
+		case err := <-done:
+			t.Fatalf("Display returned before the channel was closed: %v", err)
+		default:
+		}
+
+		close(ch)
+		waitTUI(t, done)
+
+		if tui.Shown() != 2 {
+			t.Errorf("wrong number of shown responses, want 2, got %d", tui.Shown())
+		}
+	})
+}
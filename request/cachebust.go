@@ -0,0 +1,30 @@
+package request
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+)
+
+// CacheBustParam is the name of the query parameter added for CacheBust.
+const CacheBustParam = "_cb"
+
+// addCacheBuster appends the parameter CacheBustParam with a random value to
+// the query string of u. The existing query string is kept as it is, so a
+// parameter of the same name is not replaced.
+func addCacheBuster(u *url.URL) error {
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return err
+	}
+
+	param := CacheBustParam + "=" + hex.EncodeToString(buf)
+	if u.RawQuery == "" {
+		u.RawQuery = param
+	} else {
+		u.RawQuery += "&" + param
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package request
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileCookie is a cookie read from a cookie file together with the URL it
+// belongs to.
+type FileCookie struct {
+	URL    *url.URL
+	Cookie *http.Cookie
+}
+
+// ParseCookieFile reads cookies in the Netscape cookie file format (as written
+// by curl and browser extensions). Each line contains the fields domain,
+// include subdomains (TRUE or FALSE), path, secure (TRUE or FALSE), expiry
+// (as a Unix timestamp, zero for session cookies), name and value separated by
+// tabs. Empty lines and lines starting with '#' are ignored, except for the
+// prefix "#HttpOnly_" for the domain.
+func ParseCookieFile(rd io.Reader) ([]FileCookie, error) {
+	var cookies []FileCookie
+
+	sc := bufio.NewScanner(rd)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimRight(sc.Text(), "\r")
+
+		httpOnly := false
+		if strings.HasPrefix(text, "#HttpOnly_") {
+			httpOnly = true
+			text = strings.TrimPrefix(text, "#HttpOnly_")
+		}
+
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		// the value may be empty, some programs omit the last tab then
+		if len(fields) == 6 {
+			fields = append(fields, "")
+		}
+
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: invalid cookie, expected 7 fields separated by tabs, found %d", line, len(fields))
+		}
+
+		domain, path, name, value := fields[0], fields[2], fields[5], fields[6]
+		if domain == "" || name == "" {
+			return nil, fmt.Errorf("line %d: invalid cookie, domain and name must not be empty", line)
+		}
+
+		subdomains, err := parseCookieBool(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value for include subdomains: %v", line, err)
+		}
+
+		secure, err := parseCookieBool(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value for secure: %v", line, err)
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", line, fields[4])
+		}
+
+		if path == "" {
+			path = "/"
+		}
+
+		host := strings.TrimPrefix(domain, ".")
+		u := &url.URL{Scheme: "http", Host: host, Path: path}
+		if secure {
+			u.Scheme = "https"
+		}
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     path,
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+
+		if subdomains {
+			cookie.Domain = host
+		}
+
+		if expiry != 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+
+		cookies = append(cookies, FileCookie{URL: u, Cookie: cookie})
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return cookies, nil
+}
+
+func parseCookieBool(s string) (bool, error) {
+	switch strings.ToUpper(s) {
+	case "TRUE":
+		return true, nil
+	case "FALSE":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%q is neither TRUE nor FALSE", s)
+	}
+}
+
+// validCookieValueByte returns true if net/http sends b in a cookie value.
+func validCookieValueByte(b byte) bool {
+	return 0x20 <= b && b < 0x7f && b != '"' && b != ';' && b != '\\'
+}
+
+// sanitizeCookieValue removes the bytes from v which net/http drops from a
+// cookie value (control characters, non-ASCII bytes, '"', ';' and '\').
+func sanitizeCookieValue(v string) string {
+	var buf strings.Builder
+	for i := 0; i < len(v); i++ {
+		if validCookieValueByte(v[i]) {
+			buf.WriteByte(v[i])
+		}
+	}
+	return buf.String()
+}
+
+// sentCookieValue returns v as it is sent by net/http, values containing a
+// space or a comma are quoted.
+func sentCookieValue(v string) string {
+	v = sanitizeCookieValue(v)
+	if strings.ContainsAny(v, " ,") {
+		return `"` + v + `"`
+	}
+	return v
+}
+
+// CookieJar returns a cookie jar with the cookies read from CookieFile, or nil
+// if it is not set. The cookies are sent by net/http, which drops invalid
+// bytes from the values and quotes values containing a space or a comma. For
+// each cookie which is not sent as it is in the file, a warning is returned.
+func (r *Request) CookieJar() (jar *cookiejar.Jar, warnings []string, err error) {
+	if r.CookieFile == "" {
+		return nil, nil, nil
+	}
+
+	f, err := os.Open(r.CookieFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cookies, err := ParseCookieFile(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("read cookies from %v: %v", r.CookieFile, err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jar, err = cookiejar.New(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range cookies {
+		if sent := sentCookieValue(c.Cookie.Value); sent != c.Cookie.Value {
+			warnings = append(warnings, fmt.Sprintf("cookie %v for %v from %v is sent with the value %q instead of %q, invalid bytes are dropped and values with spaces or commas are quoted",
+				c.Cookie.Name, c.URL.Host, r.CookieFile, sent, c.Cookie.Value))
+
+			// drop the invalid bytes here, otherwise net/http logs a
+			// message for each request
+			c.Cookie.Value = sanitizeCookieValue(c.Cookie.Value)
+		}
+
+		jar.SetCookies(c.URL, []*http.Cookie{c.Cookie})
+	}
+
+	return jar, warnings, nil
+}
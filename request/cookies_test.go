@@ -0,0 +1,170 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCookieFile(t *testing.T) {
+	const file = `# Netscape HTTP Cookie File
+# https://curl.se/docs/http-cookies.html
+
+.example.com	TRUE	/	TRUE	0	session	abc123
+#HttpOnly_www.example.com	FALSE	/app	FALSE	2000000000	token	x=y
+localhost	FALSE	/	FALSE	0	empty	
+localhost	FALSE		FALSE	0	nopath	1
+`
+
+	cookies, err := ParseCookieFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FileCookie{
+		{
+			URL:    &url.URL{Scheme: "https", Host: "example.com", Path: "/"},
+			Cookie: &http.Cookie{Name: "session", Value: "abc123", Path: "/", Domain: "example.com", Secure: true},
+		},
+		{
+			URL:    &url.URL{Scheme: "http", Host: "www.example.com", Path: "/app"},
+			Cookie: &http.Cookie{Name: "token", Value: "x=y", Path: "/app", HttpOnly: true, Expires: time.Unix(2000000000, 0)},
+		},
+		{
+			URL:    &url.URL{Scheme: "http", Host: "localhost", Path: "/"},
+			Cookie: &http.Cookie{Name: "empty", Value: "", Path: "/"},
+		},
+		{
+			URL:    &url.URL{Scheme: "http", Host: "localhost", Path: "/"},
+			Cookie: &http.Cookie{Name: "nopath", Value: "1", Path: "/"},
+		},
+	}
+
+	if !cmp.Equal(want, cookies) {
+		t.Error(cmp.Diff(want, cookies))
+	}
+}
+
+func TestParseCookieFileInvalid(t *testing.T) {
+	var tests = []struct {
+		file string
+		err  string
+	}{
+		{"# comment\nexample.com TRUE / FALSE 0 foo bar\n", "line 2: invalid cookie, expected 7 fields"},
+		{"example.com\tyes\t/\tFALSE\t0\tfoo\tbar\n", "line 1: invalid value for include subdomains"},
+		{"example.com\tTRUE\t/\tno\t0\tfoo\tbar\n", "line 1: invalid value for secure"},
+		{"\n\nexample.com\tTRUE\t/\tFALSE\tnever\tfoo\tbar\n", "line 3: invalid expiry"},
+		{"example.com\tTRUE\t/\tFALSE\t0\t\tbar\n", "line 1: invalid cookie, domain and name must not be empty"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseCookieFile(strings.NewReader(test.file))
+			if err == nil {
+				t.Fatal("expected error not returned")
+			}
+
+			if !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("wrong error, want prefix %q, got %q", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-cookies-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	filename := filepath.Join(tempdir, "cookies.txt")
+	err = ioutil.WriteFile(filename, []byte(".example.com\tTRUE\t/\tFALSE\t0\tsession\tabc\n"+
+		"www.example.com\tFALSE\t/admin\tTRUE\t0\tadmin\t1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := New("")
+	req.CookieFile = filename
+	jar, warnings, err := req.CookieJar()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	var tests = []struct {
+		url  string
+		want []string
+	}{
+		{"http://example.com/", []string{"session=abc"}},
+		{"http://www.example.com/foo", []string{"session=abc"}},
+		{"http://www.example.com/admin/", []string{"session=abc"}},
+		{"https://www.example.com/admin/", []string{"admin=1", "session=abc"}},
+		{"https://other.example.org/", nil},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			u, err := url.Parse(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, c := range jar.Cookies(u) {
+				got = append(got, c.String())
+			}
+
+			if !cmp.Equal(test.want, got) {
+				t.Error(cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func TestSentCookieValue(t *testing.T) {
+	var tests = []struct {
+		value string
+		want  string
+	}{
+		{"abc", "abc"},
+		{"", ""},
+		{"a=b/c+d", "a=b/c+d"},
+		{"a;b", "ab"},
+		{`"abc"`, "abc"},
+		{"a\\b\x01c\xffd", "abcd"},
+		{"a b", `"a b"`},
+		{"a,b;c", `"a,bc"`},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got := sentCookieValue(test.value)
+			if got != test.want {
+				t.Errorf("wrong value, want %q, got %q", test.want, got)
+			}
+
+			// the value must be sent like this by net/http
+			c := &http.Cookie{Name: "x", Value: sanitizeCookieValue(test.value)}
+			if c.String() != "x="+test.want {
+				t.Errorf("net/http sends %q, want %q", c.String(), "x="+test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of fields with a special meaning for Request.Fields.
+const (
+	FieldValue   = "value"   // replaces the placeholder
+	FieldTimeout = "timeout" // the timeout for the request, not inserted
+)
+
+// ParseFields parses the comma-separated list of field names spec. The names
+// must be unique and one of them must be FieldValue. All names apart from
+// FieldValue and FieldTimeout are used as additional placeholders, so they must
+// not contain the placeholder or another name, or be contained in it.
+func ParseFields(spec, placeholder string) ([]string, error) {
+	names := strings.Split(spec, ",")
+
+	seen := make(map[string]struct{})
+	var placeholders []string
+	for _, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("invalid fields %q: empty name", spec)
+		}
+
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("invalid fields %q: duplicate name %q", spec, name)
+		}
+		seen[name] = struct{}{}
+
+		if name == FieldValue || name == FieldTimeout {
+			continue
+		}
+
+		if strings.Contains(name, placeholder) || strings.Contains(placeholder, name) {
+			return nil, fmt.Errorf("invalid fields %q: name %q overlaps with the placeholder %q", spec, name, placeholder)
+		}
+
+		for _, other := range placeholders {
+			if strings.Contains(name, other) || strings.Contains(other, name) {
+				return nil, fmt.Errorf("invalid fields %q: names %q and %q overlap", spec, other, name)
+			}
+		}
+		placeholders = append(placeholders, name)
+	}
+
+	if _, ok := seen[FieldValue]; !ok {
+		return nil, fmt.Errorf("invalid fields %q: the field %q is missing", spec, FieldValue)
+	}
+
+	return names, nil
+}
+
+// SplitFields splits line into the fields named in r.Fields, separated by
+// r.FieldSep (a tab if it is empty). The last field contains the rest of the
+// line, missing fields are empty. It returns the field FieldValue and all
+// fields by name. If r.Fields is empty, line is returned as the value.
+func (r *Request) SplitFields(line string) (value string, fields map[string]string) {
+	if len(r.Fields) == 0 {
+		return line, nil
+	}
+
+	sep := r.FieldSep
+	if sep == "" {
+		sep = "\t"
+	}
+
+	parts := strings.SplitN(line, sep, len(r.Fields))
+	fields = make(map[string]string, len(r.Fields))
+	for i, name := range r.Fields {
+		if i < len(parts) {
+			fields[name] = parts[i]
+		} else {
+			fields[name] = ""
+		}
+	}
+
+	return fields[FieldValue], fields
+}
+
+// inserter returns a function which replaces the placeholder with value and
+// the names of the other fields (apart from FieldTimeout) with their values.
+// All strings are replaced in one pass, so inserted values are never replaced
+// again.
+func (r *Request) inserter(value string, fields map[string]string) func(string) string {
+	if len(fields) == 0 {
+		return func(s string) string {
+			return replaceTemplate(s, r.Replace, value)
+		}
+	}
+
+	pairs := []string{r.Replace, value}
+	for _, name := range r.Fields {
+		if name == FieldValue || name == FieldTimeout {
+			continue
+		}
+		pairs = append(pairs, name, fields[name])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	return replacer.Replace
+}
+
+// Inserter returns a function which inserts the value and the fields of line
+// (see SplitFields) like Apply does.
+func (r *Request) Inserter(line string) func(string) string {
+	value, fields := r.SplitFields(line)
+	return r.inserter(value, fields)
+}
+
+// Timeout returns the timeout for the request for line from the field
+// FieldTimeout, either a duration like "1.5s" or a number of seconds. It is
+// zero if there is no such field or it is empty.
+func (r *Request) Timeout(line string) (time.Duration, error) {
+	_, fields := r.SplitFields(line)
+	s := strings.TrimSpace(fields[FieldTimeout])
+	if s == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if seconds <= 0 {
+			return 0, fmt.Errorf("invalid timeout %q, must be positive", s)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q, use a duration like 1.5s or a number of seconds", s)
+	}
+
+	return d, nil
+}
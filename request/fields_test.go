@@ -0,0 +1,183 @@
+package request
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFields(t *testing.T) {
+	var tests = []struct {
+		spec string
+		want []string
+		err  bool
+	}{
+		{spec: "value", want: []string{"value"}},
+		{spec: "value,timeout", want: []string{"value", "timeout"}},
+		{spec: "USER,value,timeout", want: []string{"USER", "value", "timeout"}},
+		{spec: "timeout", err: true},
+		{spec: "value,", err: true},
+		{spec: "value,PASS,PASS", err: true},
+		{spec: "value,FUZZ", err: true},
+		{spec: "value,FUZZ2", err: true},
+		{spec: "value,UZ", err: true},
+		{spec: "USER,value,PASS", want: []string{"USER", "value", "PASS"}},
+		{spec: "USER,value,USERNAME", err: true},
+		{spec: "USERNAME,value,USER", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			fields, err := ParseFields(test.spec, "FUZZ")
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error not returned, got %v", fields)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.want, fields) {
+				t.Error(cmp.Diff(test.want, fields))
+			}
+		})
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	var tests = []struct {
+		fields []string
+		sep    string
+		line   string
+
+		wantValue  string
+		wantFields map[string]string
+	}{
+		{
+			line:      "foo\tbar",
+			wantValue: "foo\tbar",
+		},
+		{
+			fields:     []string{"value", "timeout"},
+			line:       "foo\t2s",
+			wantValue:  "foo",
+			wantFields: map[string]string{"value": "foo", "timeout": "2s"},
+		},
+		{
+			fields:     []string{"PASS", "value"},
+			line:       "secret\tadmin\textra",
+			wantValue:  "admin\textra",
+			wantFields: map[string]string{"PASS": "secret", "value": "admin\textra"},
+		},
+		{
+			fields:     []string{"value", "timeout"},
+			line:       "foo",
+			wantValue:  "foo",
+			wantFields: map[string]string{"value": "foo", "timeout": ""},
+		},
+		{
+			fields:     []string{"value", "PASS"},
+			sep:        ":",
+			line:       "admin:secret:more",
+			wantValue:  "admin",
+			wantFields: map[string]string{"value": "admin", "PASS": "secret:more"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.Fields = test.fields
+			req.FieldSep = test.sep
+
+			value, fields := req.SplitFields(test.line)
+			if value != test.wantValue {
+				t.Errorf("wrong value, want %q, got %q", test.wantValue, value)
+			}
+
+			if !cmp.Equal(test.wantFields, fields) {
+				t.Error(cmp.Diff(test.wantFields, fields))
+			}
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	var tests = []struct {
+		line string
+		want time.Duration
+		err  bool
+	}{
+		{line: "foo", want: 0},
+		{line: "foo\t", want: 0},
+		{line: "foo\t2", want: 2 * time.Second},
+		{line: "foo\t0.5", want: 500 * time.Millisecond},
+		{line: "foo\t1m30s", want: 90 * time.Second},
+		{line: "foo\t 250ms ", want: 250 * time.Millisecond},
+		{line: "foo\t0", err: true},
+		{line: "foo\t-1s", err: true},
+		{line: "foo\tsoon", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.Fields = []string{"value", "timeout"}
+
+			d, err := req.Timeout(test.line)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error not returned, got %v", d)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d != test.want {
+				t.Errorf("wrong timeout, want %v, got %v", test.want, d)
+			}
+		})
+	}
+}
+
+func TestApplyFields(t *testing.T) {
+	req := New("")
+	req.URL = "https://example.com/FUZZ?lang=LANG"
+	req.Body = "user=FUZZ&pass=PASS"
+	req.UserPass = "FUZZ:PASS"
+	req.Fields = []string{"value", "PASS", "LANG", "timeout"}
+
+	// inserted values are not replaced again
+	genReq, err := req.Apply("admin\tFUZZ-LANG\ten\t3s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantURL := "https://example.com/admin?lang=en"
+	if genReq.URL.String() != wantURL {
+		t.Errorf("wrong URL, want %q, got %q", wantURL, genReq.URL.String())
+	}
+
+	body, err := ioutil.ReadAll(genReq.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantBody := "user=admin&pass=FUZZ-LANG"
+	if string(body) != wantBody {
+		t.Errorf("wrong body, want %q, got %q", wantBody, body)
+	}
+
+	user, pass, _ := genReq.BasicAuth()
+	if user != "admin" || pass != "FUZZ-LANG" {
+		t.Errorf("wrong basic auth, want admin:FUZZ-LANG, got %v:%v", user, pass)
+	}
+}
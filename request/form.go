@@ -0,0 +1,50 @@
+package request
+
+import (
+	"net/url"
+	"strings"
+)
+
+// dataValue implements pflag.Value for --data, the data passed with several
+// instances of the flag is joined with '&' (like curl does).
+type dataValue struct {
+	s *string
+}
+
+func (v dataValue) String() string {
+	if v.s == nil {
+		return ""
+	}
+	return *v.s
+}
+
+func (v dataValue) Set(s string) error {
+	if *v.s != "" {
+		s = *v.s + "&" + s
+	}
+	*v.s = s
+	return nil
+}
+
+func (v dataValue) Type() string {
+	return "string"
+}
+
+// formBody returns the URL-encoded form data built from the fields in the
+// form "name=value", after insert has been called for the name and the value.
+// Fields without '=' are sent as the encoded data only.
+func (r *Request) formBody(insert func(string) string) string {
+	parts := make([]string, 0, len(r.FormFields))
+	for _, field := range r.FormFields {
+		i := strings.IndexByte(field, '=')
+		if i < 0 {
+			parts = append(parts, url.QueryEscape(insert(field)))
+			continue
+		}
+
+		name, value := insert(field[:i]), insert(field[i+1:])
+		parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(value))
+	}
+
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,42 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+)
+
+func TestDataFlags(t *testing.T) {
+	var tests = []struct {
+		args   []string
+		body   string
+		fields []string
+	}{
+		{nil, "", nil},
+		{[]string{"-d", `{"a": 1}`}, `{"a": 1}`, nil},
+		{[]string{"-d", "a=1", "--data", "b=2"}, "a=1&b=2", nil},
+		{[]string{"--data-urlencode", "a=x&y", "-d", "b=2", "--data-urlencode", "c"}, "b=2", []string{"a=x&y", "c"}},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			AddFlags(req, fs)
+
+			err := fs.Parse(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if req.Body != test.body {
+				t.Errorf("wrong body, want %q, got %q", test.body, req.Body)
+			}
+
+			if !cmp.Equal(test.fields, req.FormFields) {
+				t.Error(cmp.Diff(test.fields, req.FormFields))
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package request
 
-import "github.com/spf13/pflag"
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
 
 // LongHelp is a text which describes how constructing a request works. It is
 // typically used in the long help text.
@@ -12,6 +16,171 @@ from the file, this includes the HTTP headers, method and body.
 When a template file is used, the URL passed as an argument to the command must
 not have a path or query string set. It is just used to set the target host
 name, port and protocol.
+
+With --request-file, the request is read from a file in the format of the VS
+Code REST Client extension (.http or .rest files). The method, URL, headers and
+body from the file are used unless they are set with flags, the URL argument is
+optional and replaces the URL from the file. The placeholder can be used
+anywhere in the file. Supported are file variables ('@name = value' before the
+request, referenced as '{{name}}'), comments (lines starting with '#' or '//'),
+the request line ('METHOD URL [HTTP/1.1]' or just the URL), query string lines
+starting with '?' or '&' after the request line, the headers and the body after
+the first empty line. Only the first request is used, further requests after a
+line starting with '###' are ignored. Not supported are system and environment
+variables (like '{{$guid}}', which are sent as they are), request variables and
+reading the body from a file ('< file').
+
+With --raw-request-stdin, a raw HTTP request (e.g. copied from Burp or the
+developer tools of a browser) is read from stdin and used in the same way as
+--request-file, so flags have priority and the URL argument is optional. The
+request line, the headers up to the first empty line and the body are parsed,
+lines may end with CRLF or LF. The URL is built from the Host header and the
+path from the request line with the scheme https (pass the complete URL as an
+argument to use a different one). The headers Host and Content-Length are not
+sent as they are, the Content-Length is only used to remove data after the
+body (like a newline added when pasting). Insert the placeholder wherever a
+value should go before pasting the request.
+
+When --data is specified without --method, the request is sent with the
+method POST. For the methods GET, HEAD and TRACE set with --method, no body
+(and no Content-Length header) is sent at all, also when the body is read from
+a template file. This is useful when the method is fuzzed (e.g. with --method
+FUZZ). Use --force-body to send the body with these methods anyway. A
+template file without --method is sent with its method and body as written.
+
+With --method-override, the request is sent with the method POST and the
+method (after inserting the value) is sent in the X-HTTP-Method-Override
+header, unless the header is set or removed explicitly. This only works for
+applications and frameworks which evaluate the header (often to support
+clients which can only send GET and POST), and can be used to reach handlers
+for methods which are blocked by a proxy or firewall, e.g.
+'--method-override FUZZ' with a list of methods. It cannot be combined with
+--method, or with a request read by --request-file or --raw-request-stdin
+unless its method is POST.
+
+The data passed with --data is sent as it is, several instances are joined
+with '&'. Form fields can be passed with --data-urlencode as 'name=value'
+instead, possibly multiple times: the name and the value (after the
+placeholder has been replaced) are URL-encoded, so special characters like '&',
+'=', '+', '%' and spaces in the value are sent as '%26', '%3D', '%2B', '%25'
+and '+' and cannot break the form data. A field without '=' is encoded as a
+whole. The fields are joined with '&' and appended to the data from --data,
+and the Content-Type header is set to application/x-www-form-urlencoded unless
+it is set or removed explicitly. For example,
+'--data-urlencode user=admin --data-urlencode pass=FUZZ' sends
+'user=admin&pass=a%26b' for the value 'a&b'.
+
+With --cookie-file, cookies are read from a file in the Netscape cookie file
+format, as written by curl (--cookie-jar) and browser extensions for exporting
+cookies. The cookies are sent for all requests to matching URLs (depending on
+the domain, path and secure flag), unmodified and without inserting the value.
+Cookies set by the server are ignored, so all requests use the same session.
+Bytes which are not allowed in a cookie value (control characters, non-ASCII
+bytes, '"', ';' and '\') are dropped and values containing a space or a comma
+are sent in quotes, a warning is printed for each cookie which is changed.
+
+With --auto-content-type, the Content-Type header is set based on the body
+(after inserting the value) if it is not set otherwise: bodies starting with
+'{' or '[' are sent as application/json, bodies starting with '<' as
+application/xml, and bodies like 'a=b&c=d' as
+application/x-www-form-urlencoded. An explicit Content-Type header (also from a
+template file) is never changed.
+
+With --expand-templates, the headers and the body (set with --header and
+--data) are evaluated as Go templates for each request before the placeholder
+is replaced, so values computed from the value can be sent, e.g.
+'X-Request-ID: {{ sha256 .Value }}'. The value is available as .Value, the
+functions md5, sha1 and sha256 (hex encoded), base64, base64url (without
+padding), hex, urlenc (for query strings), lower and upper can be used and
+combined, e.g. '{{ .Value | upper | base64 }}'. The number of the thread which
+sends the request (1 to --threads) is available as .ThreadID, e.g. to spread
+the load with 'X-Client-ID: {{ .ThreadID }}'. Without --expand-templates,
+'{{' is sent as it is, which is useful for template injection payloads.
+
+With --aws-access-key, each request is signed with AWS Signature Version 4
+after the value has been inserted, so the signature covers the final request
+including the body. The region and service must be set with --aws-region and
+--aws-service. The signature is computed with the signer of the AWS SDK for Go,
+all headers except User-Agent are signed. For the service s3 the header
+X-Amz-Content-Sha256 is sent as well. Signing is not available for
+--raw-request, and headers modified by a proxy may invalidate the signature.
+
+With --connect host:port, every connection is established by sending a
+CONNECT request for host:port to the proxy configured in HTTPS_PROXY (for
+https URLs) or HTTP_PROXY, which must be an http proxy. The request (including
+the TLS handshake for https URLs) is then sent through the tunnel, with the
+host name from the URL in the Host header and for SNI. So the proxy can be
+used to reach a host (e.g. an internal one or the proxy's own loopback
+interface) under a different name, or to check whether a proxy allows
+tunneling to a port. Without --connect, the proxy is only sent a CONNECT
+request for https URLs, for the host in the URL. NO_PROXY is not evaluated for
+--connect, and raw requests are tunneled as well.
+
+With --insecure, TLS certificates are not verified for any host. With
+--insecure-hosts, verification is only disabled for the listed hosts (an entry
+like '*.example.com' matches all subdomains), certificates of all other hosts
+(e.g. when following redirects) are still verified. The hosts are compared to
+the host name (or IP address) in the URL of each request, without the port.
+
+The query string set with --raw-query replaces the query string from the URL or
+the template file. It is sent exactly as specified (after inserting the value),
+no characters are encoded or decoded, so it can be used for payloads which
+depend on a specific encoding, e.g. '%00' or double encoding.
+
+With --append-path, the value is appended to the path of the URL (like
+'https://example.com/app' + '/' + value), so the URL does not need to contain
+the placeholder. Slashes at the end of the path and the start of the value are
+merged into one, a slash at the end of the value is kept. A query string in the
+value (everything after '?') is added to the query string of the URL (or the
+one set with --raw-query) with '&'. The value is inserted as it is, so
+percent-encoded characters (e.g. '%2e') are not encoded again.
+
+With --cache-bust, the parameter '_cb' with a new random value is appended to
+the query string of each request (after --raw-query and --append-path), so
+that caches (e.g. of a CDN) do not return a stored response. Existing
+parameters are kept as they are, even one named '_cb'. The parameter is part
+of the URL displayed and recorded for the response.
+
+With --raw-request, the request is read from a file and sent exactly as it is,
+only the placeholder is replaced. This includes the request line (so any HTTP
+version string can be used), the order and spelling of all headers, the line
+endings and the Content-Length header. The file is not parsed and all other
+options to modify the request are ignored. The URL passed as an argument is
+only used to select the scheme, host and port to connect to. Each request is
+sent over a new connection, responses are parsed regardless of the HTTP
+version the server uses. Raw requests are not sent via the proxies configured
+in HTTP_PROXY and HTTPS_PROXY, only FORCE_SOCKS5_PROXY is used.
+
+The HTTP client of the Go standard library always sends the headers sorted by
+name, so the order cannot be controlled for normal requests. With
+--randomize-headers, the header lines are shuffled for each request. For
+--raw-request, the lines of the header read from the file are shuffled
+(the request line stays in place). Otherwise, the request is built from the
+other options and sent as an HTTP/1.1 raw request in the same way as for
+--raw-request, so it won't use HTTP/2 or an HTTP proxy. Full control over the
+order of the header lines is only possible with --raw-request.
+
+With --tls-fingerprint, TLS connections are established with the ClientHello
+message of a browser (chrome or firefox) instead of the one of the Go standard
+library, so that servers which identify clients by their TLS fingerprint (e.g.
+JA3) see a browser. For 'random', a randomized ClientHello is sent for each
+connection. HTTP/2 is always disabled (like with --disable-http2): only
+HTTP/1.1 is offered to the server via ALPN, because the transport of net/http
+cannot use HTTP/2 over connections established by another TLS library. Real
+browsers offer h2, so servers which also look at ALPN may still tell the
+difference. Connections via the proxies configured in HTTP_PROXY and
+HTTPS_PROXY are not affected. The JA3 fingerprint of the ClientHello sent is
+recorded for each response in the logfile. It cannot be combined with
+--wire-header-size or requests sent raw.
+
+With --content-length, the Content-Length header is sent exactly as specified
+instead of the length of the body, e.g. for testing HTTP request smuggling.
+The placeholder is replaced in the value, so any string can be sent (like
+'--content-length FUZZ' together with a list of numbers). Together with
+--force-chunked-encoding, both Content-Length and Transfer-Encoding headers
+are sent. Since net/http always sets the correct length, the requests are
+sent as raw HTTP/1.1 requests like for --randomize-headers. For GET, HEAD and
+TRACE requests the body is only sent with --force-body.
 `
 
 // AddFlags adds flags for all options of a request to fs.
@@ -20,17 +189,46 @@ func AddFlags(r *Request, fs *pflag.FlagSet) {
 	fs.StringVar(&r.Method, "request", "", "use HTTP request `method`")
 	_ = fs.MarkDeprecated("request", "use --method")
 	fs.StringVarP(&r.Method, "method", "X", "", "use HTTP request `method`")
+	fs.StringVar(&r.MethodOverride, "method-override", "", "send `method` in the X-HTTP-Method-Override header and POST as the method (see help)")
 	fs.VarP(r.Header, "header", "H", "add `\"name: value\"` as an HTTP request header, delete the header if only \"name\" is passed")
-	fs.StringVarP(&r.Body, "data", "d", "", "transmit `data` in the HTTP request body")
+	fs.VarP(dataValue{&r.Body}, "data", "d", "transmit `data` in the HTTP request body (can be specified multiple times, joined with '&')")
+	fs.StringArrayVar(&r.FormFields, "data-urlencode", nil, "send `name=value` URL-encoded as form data in the body (can be specified multiple times, see help)")
+	fs.StringVar(&r.RawQuery, "raw-query", "", "use `query` as the query string exactly as specified, without any encoding")
+	fs.BoolVar(&r.CacheBust, "cache-bust", false, "append a query parameter with a random value to each request to avoid cached responses (see help)")
+	fs.BoolVar(&r.AppendPath, "append-path", false, "append the value to the path of the URL instead of replacing the placeholder (see help)")
 	fs.StringVarP(&r.UserPass, "user", "u", "", "use `user:password` for HTTP basic auth")
+	fs.StringVar(&r.CookieFile, "cookie-file", "", "send the cookies read from `file` in the Netscape format (see help)")
 
 	fs.StringVar(&r.TemplateFile, "template-file", "", "read HTTP request from `file`")
+	fs.StringVar(&r.RequestFile, "request-file", "", "read method, URL, headers and body from `file` in the .http format (see help)")
+	fs.BoolVar(&r.RawStdin, "raw-request-stdin", false, "read method, URL, headers and body from a raw HTTP request pasted on stdin (see help)")
+	fs.StringVar(&r.RawFile, "raw-request", "", "send the request read from `file` without any modification (see help)")
 
 	// configure request
+	fs.BoolVar(&r.RandomizeHeaders, "randomize-headers", false, "send the header lines in random order for each request (see help)")
+	fs.BoolVar(&r.AutoContentType, "auto-content-type", false, "set the Content-Type header based on the body unless it is set explicitly (see help)")
+	fs.BoolVar(&r.ForceBody, "force-body", false, "send the body also for the methods GET, HEAD and TRACE")
+	fs.BoolVar(&r.ExpandTemplates, "expand-templates", false, "evaluate templates like '{{ sha256 .Value }}' in the headers and the body for each request (see help)")
+	fs.StringVar(&r.AWSAccessKey, "aws-access-key", "", "sign requests with AWS Signature Version 4 using the access key `id` (see help)")
+	fs.StringVar(&r.AWSSecretKey, "aws-secret-key", "", "use `key` as the AWS secret key (default: $AWS_SECRET_ACCESS_KEY)")
+	fs.StringVar(&r.AWSSessionToken, "aws-session-token", "", "send the AWS session `token` (default: $AWS_SESSION_TOKEN)")
+	fs.StringVar(&r.AWSRegion, "aws-region", "", "use `region` for the AWS signature (e.g. us-east-1)")
+	fs.StringVar(&r.AWSService, "aws-service", "", "use `service` for the AWS signature (e.g. execute-api or s3)")
+	fs.StringVar(&r.AcceptEncoding, "accept-encoding", "", "request the response body in the `encodings` (e.g. 'gzip, deflate, br') with the Accept-Encoding header (default: gzip)")
 	fs.BoolVar(&r.ForceChunkedEncoding, "force-chunked-encoding", false, `do not set the Content-Length HTTP header and use chunked encoding`)
+	fs.StringVar(&r.ContentLength, "content-length", "", "send `value` as the Content-Length header regardless of the body (see help)")
 
 	// Transport
 	fs.BoolVarP(&r.Insecure, "insecure", "k", false, "disable TLS certificate verification")
+	fs.StringSliceVar(&r.InsecureHosts, "insecure-hosts", nil, "disable TLS certificate verification only for `host,...` (see help)")
 	fs.StringVar(&r.TLSClientKeyCertFile, "client-cert", "", "read TLS client key and cert from `file`")
 	fs.BoolVar(&r.DisableHTTP2, "disable-http2", false, "do not try to negotiate an HTTP2 connection")
+	fs.StringVar(&r.Connect, "connect", "", "tunnel all connections via the proxy to `host:port` with CONNECT (see help)")
+	fs.BoolVar(&r.DisableKeepAlives, "no-keep-alive", false, "use a new connection for each request")
+	fs.IntVar(&r.MaxIdleConns, "max-idle-conns", 0, "keep at most `n` idle connections open for reuse (default: number of threads)")
+	fs.IntVar(&r.MaxConnsPerHost, "max-conns-per-host", 0, "open at most `n` connections to a host at the same time (default: no limit)")
+	fs.DurationVar(&r.ConnectTimeout, "connect-timeout", 30*time.Second, "abort establishing a connection (including DNS) after `duration`")
+	fs.DurationVar(&r.TLSHandshakeTimeout, "tls-handshake-timeout", 10*time.Second, "abort the TLS handshake after `duration`")
+	fs.BoolVar(&r.WireHeaderSize, "wire-header-size", false, "use the response header exactly as received for sizes and patterns (implies --disable-http2)")
+	fs.StringVar(&r.TLSFingerprint, "tls-fingerprint", "", "send the TLS ClientHello of `browser` (chrome, firefox or random) to imitate its fingerprint (implies --disable-http2, see help)")
 }
@@ -0,0 +1,208 @@
+package request
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// HTTPFile is a request read from a file in the format used by the VS Code
+// REST Client extension (usually with the extension .http or .rest).
+type HTTPFile struct {
+	Method string   // empty if the request line only contains the URL
+	URL    string   // including the query string
+	Header []string // header lines as "name: value"
+	Body   string
+}
+
+var httpFileVariable = regexp.MustCompile(`^@([A-Za-z0-9_-]+)\s*=\s*(.*)$`)
+
+// ParseHTTPFile reads the first request from rd. The supported subset of the
+// format is: file variables defined as '@name = value' before the request and
+// referenced as '{{name}}', comments (lines starting with '#' or '//') before
+// the request line and between the headers, the request line as 'METHOD URL
+// [HTTP/version]' or just 'URL', lines starting with '?' or '&' following the
+// request line which continue the query string, the headers up to the first
+// empty line and the body. Requests are separated by lines starting with '###',
+// all but the first request are ignored. References to unknown variables
+// (including system variables like '{{$guid}}') are kept as they are, reading
+// the body from a file ('< filename') is not supported.
+func ParseHTTPFile(rd io.Reader) (*HTTPFile, error) {
+	var lines []string
+	sc := bufio.NewScanner(rd)
+	for sc.Scan() {
+		lines = append(lines, strings.TrimRight(sc.Text(), "\r"))
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+	expand := func(s string) string {
+		for name, value := range vars {
+			s = strings.Replace(s, "{{"+name+"}}", value, -1)
+		}
+		return s
+	}
+
+	comment := func(line string) bool {
+		return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+	}
+
+	// find the request line, skipping variables and comments
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if m := httpFileVariable.FindStringSubmatch(line); m != nil {
+			vars[m[1]] = expand(m[2])
+			continue
+		}
+
+		if line == "" || comment(line) {
+			continue
+		}
+
+		break
+	}
+
+	if i == len(lines) {
+		return nil, errors.New("no request found")
+	}
+
+	f := &HTTPFile{}
+
+	fields := strings.Fields(expand(lines[i]))
+	if len(fields) > 1 && strings.HasPrefix(fields[len(fields)-1], "HTTP/") {
+		fields = fields[:len(fields)-1]
+	}
+
+	switch len(fields) {
+	case 1:
+		f.URL = fields[0]
+	case 2:
+		f.Method, f.URL = fields[0], fields[1]
+	default:
+		return nil, fmt.Errorf("line %d: invalid request line %q", i+1, lines[i])
+	}
+
+	// the query string may continue on the following lines
+	for i++; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		f.URL += expand(line)
+	}
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "###") {
+			break
+		}
+
+		if comment(line) {
+			continue
+		}
+
+		data := strings.SplitN(expand(line), ":", 2)
+		if len(data) != 2 || strings.TrimSpace(data[0]) == "" {
+			return nil, fmt.Errorf("line %d: invalid header %q, expected 'name: value'", i+1, lines[i])
+		}
+
+		f.Header = append(f.Header, strings.TrimSpace(data[0])+": "+strings.TrimSpace(data[1]))
+	}
+
+	// skip the empty line between the header and the body
+	if i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+
+	var body []string
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "###") {
+			break
+		}
+
+		if len(body) == 0 && strings.HasPrefix(lines[i], "<") {
+			return nil, fmt.Errorf("line %d: reading the body from a file is not supported", i+1)
+		}
+
+		body = append(body, lines[i])
+	}
+
+	// like the REST Client, ignore empty lines at the end of the body
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+
+	f.Body = expand(strings.Join(body, "\n"))
+
+	return f, nil
+}
+
+// LoadRequestFile reads a request in the format of the VS Code REST Client
+// from filename (see ParseHTTPFile). The method, URL, headers and body from the
+// file are used unless they have already been set (e.g. via flags). An error
+// is returned if no URL is set afterwards.
+func (r *Request) LoadRequestFile(filename string) error {
+	rd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	f, err := ParseHTTPFile(rd)
+	_ = rd.Close()
+	if err != nil {
+		return fmt.Errorf("read request from %v: %v", filename, err)
+	}
+
+	return r.useHTTPFile(f, filename)
+}
+
+// useHTTPFile sets the method, URL, headers and body from f unless they have
+// already been set. The source is used in error messages.
+func (r *Request) useHTTPFile(f *HTTPFile, source string) error {
+	if r.Method == "" {
+		r.Method = f.Method
+	}
+
+	if r.URL == "" {
+		r.URL = f.URL
+	}
+
+	if r.URL == "" {
+		return fmt.Errorf("read request from %v: no URL found", source)
+	}
+
+	if r.Body == "" && len(r.FormFields) == 0 {
+		r.Body = f.Body
+	}
+
+	// headers set before (apart from the default ones) have priority
+	set := make(map[string]struct{})
+	for name := range r.Header.Header {
+		if !headerDefaultValue(*r.Header, name) {
+			set[textproto.CanonicalMIMEHeaderKey(name)] = struct{}{}
+		}
+	}
+
+	for _, line := range f.Header {
+		name := textproto.CanonicalMIMEHeaderKey(strings.SplitN(line, ":", 2)[0])
+		if _, ok := set[name]; ok || r.headerRemoved(name) {
+			continue
+		}
+
+		err := r.Header.Set(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseHTTPFile(t *testing.T) {
+	var tests = []struct {
+		file string
+		want *HTTPFile
+	}{
+		{
+			file: "https://example.com/FUZZ\n",
+			want: &HTTPFile{URL: "https://example.com/FUZZ"},
+		},
+		{
+			file: "GET https://example.com/ HTTP/1.1\r\nAccept: text/html\r\n",
+			want: &HTTPFile{Method: "GET", URL: "https://example.com/", Header: []string{"Accept: text/html"}},
+		},
+		{
+			file: `# login request
+@host = https://example.com
+@user = admin
+
+// send the password
+POST {{host}}/login
+    ?user={{user}}
+    &debug=1
+Content-Type: application/json
+# X-Debug: 1
+X-Token  :   FUZZ
+
+{
+  "user": "{{user}}",
+  "pass": "FUZZ",
+  "id": "{{$guid}}"
+}
+
+
+### second request
+GET https://example.com/logout
+`,
+			want: &HTTPFile{
+				Method: "POST",
+				URL:    "https://example.com/login?user=admin&debug=1",
+				Header: []string{"Content-Type: application/json", "X-Token: FUZZ"},
+				Body:   "{\n  \"user\": \"admin\",\n  \"pass\": \"FUZZ\",\n  \"id\": \"{{$guid}}\"\n}",
+			},
+		},
+		{
+			file: "### first\nFUZZ https://example.com\n###\nGET https://example.com/other\n",
+			want: &HTTPFile{Method: "FUZZ", URL: "https://example.com"},
+		},
+		{
+			file: "PUT https://example.com/upload\n\nfoo\n\nbar\n###\n",
+			want: &HTTPFile{Method: "PUT", URL: "https://example.com/upload", Body: "foo\n\nbar"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f, err := ParseHTTPFile(strings.NewReader(test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.want, f) {
+				t.Error(cmp.Diff(test.want, f))
+			}
+		})
+	}
+}
+
+func TestParseHTTPFileInvalid(t *testing.T) {
+	var tests = []struct {
+		file string
+		err  string
+	}{
+		{"", "no request found"},
+		{"# only a comment\n@foo = bar\n", "no request found"},
+		{"GET https://example.com/ foo bar\n", "line 1: invalid request line"},
+		{"\nGET https://example.com/\nX-Foo\n", "line 3: invalid header"},
+		{"POST https://example.com/\n\n< ./body.json\n", "line 3: reading the body from a file is not supported"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseHTTPFile(strings.NewReader(test.file))
+			if err == nil {
+				t.Fatal("expected error not returned")
+			}
+
+			if !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("wrong error, want prefix %q, got %q", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadRequestFile(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-request-file-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	filename := filepath.Join(tempdir, "login.http")
+	err = ioutil.WriteFile(filename, []byte("POST https://example.com/login?name=FUZZ\n"+
+		"Accept: application/json\n"+
+		"X-Foo: file\n"+
+		"X-Bar: FUZZ\n"+
+		"\n"+
+		"user=FUZZ\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		header []string // set as --header
+		url    string
+		body   string
+
+		wantURL    string
+		wantHeader http.Header
+		wantBody   string
+	}{
+		{
+			wantURL: "https://example.com/login?name=foo",
+			wantHeader: http.Header{
+				"Accept":     []string{"application/json"},
+				"User-Agent": []string{"monsoon"},
+				"X-Foo":      []string{"file"},
+				"X-Bar":      []string{"foo"},
+			},
+			wantBody: "user=foo",
+		},
+		{
+			header:  []string{"x-foo: flag", "X-Bar", "User-Agent: test"},
+			url:     "http://localhost:8080/FUZZ",
+			body:    "x",
+			wantURL: "http://localhost:8080/foo",
+			wantHeader: http.Header{
+				"Accept":     []string{"application/json"},
+				"User-Agent": []string{"test"},
+				"X-Foo":      []string{"flag"},
+			},
+			wantBody: "x",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r := New("")
+			r.URL = test.url
+			r.Body = test.body
+			for _, hdr := range test.header {
+				err := r.Header.Set(hdr)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := r.LoadRequestFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := r.Apply("foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if req.Method != http.MethodPost {
+				t.Errorf("wrong method, want %v, got %v", http.MethodPost, req.Method)
+			}
+
+			if req.URL.String() != test.wantURL {
+				t.Errorf("wrong URL, want %v, got %v", test.wantURL, req.URL)
+			}
+
+			if !cmp.Equal(test.wantHeader, req.Header) {
+				t.Error(cmp.Diff(test.wantHeader, req.Header))
+			}
+
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != test.wantBody {
+				t.Errorf("wrong body, want %q, got %q", test.wantBody, body)
+			}
+		})
+	}
+}
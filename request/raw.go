@@ -0,0 +1,268 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync"
+)
+
+// Raw is a request which is sent exactly as specified, without parsing or
+// modifying it.
+type Raw struct {
+	// URL is the target, only the scheme, host and port are used
+	URL *url.URL
+
+	// Method is the method from the request line, it is only used to
+	// correctly parse the response to a HEAD request
+	Method string
+
+	Data []byte
+}
+
+// Target returns the host and port the raw request is to be sent to.
+func (r *Raw) Target() (host, port string, err error) {
+	return target(r.URL)
+}
+
+// RawMode returns true if the request needs to be sent with ApplyRaw instead
+// of Apply.
+func (r *Request) RawMode() bool {
+	return r.RawFile != "" || r.RawDir != "" || r.RandomizeHeaders || r.ContentLength != ""
+}
+
+// ApplyRaw returns the data to send for the request as a raw request. If
+// RawFile is set, the template is replaced with value in the raw request read
+// from the file and in the target URL. Apart from that, the data is not
+// modified in any way, including line endings. If RawDir is set, value is the
+// name of a file in RawDir which is sent verbatim to the target URL, nothing
+// is replaced. Otherwise the request is built with Apply and formatted as an
+// HTTP/1.1 request, using ContentLength (with the template replaced) as the
+// Content-Length header if it is set. If RandomizeHeaders is set, the header
+// lines are shuffled afterwards.
+func (r *Request) ApplyRaw(value string) (*Raw, error) {
+	var raw *Raw
+	var err error
+
+	switch {
+	case r.RawDir != "":
+		raw, err = r.readRawDir(value)
+	case r.RawFile != "":
+		raw, err = r.readRaw(value)
+	default:
+		var req *http.Request
+		req, err = r.Apply(value)
+		if err != nil {
+			return nil, err
+		}
+
+		v, fields := r.SplitFields(value)
+		raw, err = formatRaw(req, r.inserter(v, fields)(r.ContentLength))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if i := bytes.IndexAny(raw.Data, " \r\n"); i > 0 {
+		raw.Method = string(raw.Data[:i])
+	}
+
+	if r.RandomizeHeaders {
+		shuffleHeaders(raw.Data)
+	}
+
+	return raw, nil
+}
+
+func (r *Request) readRawDir(name string) (*Raw, error) {
+	if r.TemplateFile != "" || r.RawFile != "" {
+		return nil, errors.New("template file or raw request and raw request directory cannot be used together")
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(r.RawDir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Raw{URL: target, Data: buf}, nil
+}
+
+func (r *Request) readRaw(value string) (*Raw, error) {
+	if r.TemplateFile != "" {
+		return nil, errors.New("template file and raw request cannot be used together")
+	}
+
+	buf, err := r.readRawFile()
+	if err != nil {
+		return nil, err
+	}
+
+	value, fields := r.SplitFields(value)
+	insertValue := r.inserter(value, fields)
+
+	target, err := url.Parse(insertValue(r.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &Raw{
+		URL:  target,
+		Data: []byte(insertValue(string(buf))),
+	}
+
+	return raw, nil
+}
+
+// cachedFile holds the contents of a file which is read once.
+type cachedFile struct {
+	once     sync.Once
+	filename string
+	buf      []byte
+	err      error
+}
+
+// readRawFile returns the contents of RawFile. For requests created with New,
+// the file is read only once and the data is shared with all copies of the
+// request, it must not be modified.
+func (r *Request) readRawFile() ([]byte, error) {
+	if r.rawFile == nil {
+		return ioutil.ReadFile(r.RawFile)
+	}
+
+	r.rawFile.once.Do(func() {
+		r.rawFile.filename = r.RawFile
+		r.rawFile.buf, r.rawFile.err = ioutil.ReadFile(r.RawFile)
+	})
+
+	// the file name has been changed after the file was read
+	if r.rawFile.filename != r.RawFile {
+		return ioutil.ReadFile(r.RawFile)
+	}
+
+	return r.rawFile.buf, r.rawFile.err
+}
+
+// formatRaw returns req formatted as an HTTP/1.1 request. In contrast to
+// net/http, the headers are kept in the order of the header map (which is
+// random) and not sorted. If contentLength is not empty, it is sent verbatim
+// as the Content-Length header regardless of the body (and in addition to
+// Transfer-Encoding for chunked requests).
+func formatRaw(req *http.Request, contentLength string) (*Raw, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(buf, "Host: %s\r\n", host)
+
+	for name, values := range req.Header {
+		// an empty User-Agent means that the header is not sent, see Apply
+		if name == "User-Agent" && len(values) == 1 && values[0] == "" {
+			continue
+		}
+
+		if name == "Content-Length" && contentLength != "" {
+			continue
+		}
+
+		for _, v := range values {
+			fmt.Fprintf(buf, "%s: %s\r\n", name, v)
+		}
+	}
+
+	chunked := req.ContentLength < 0
+	if chunked {
+		buf.WriteString("Transfer-Encoding: chunked\r\n")
+	}
+
+	switch {
+	case contentLength != "":
+		fmt.Fprintf(buf, "Content-Length: %s\r\n", contentLength)
+	case chunked:
+		// the length is sent in the chunks
+	case len(body) > 0 || req.Method == http.MethodPost || req.Method == http.MethodPut:
+		fmt.Fprintf(buf, "Content-Length: %d\r\n", len(body))
+	}
+
+	buf.WriteString("\r\n")
+
+	if chunked {
+		if len(body) > 0 {
+			fmt.Fprintf(buf, "%x\r\n%s\r\n", len(body), body)
+		}
+		buf.WriteString("0\r\n\r\n")
+	} else {
+		buf.Write(body)
+	}
+
+	return &Raw{URL: req.URL, Data: buf.Bytes()}, nil
+}
+
+// shuffleHeaders randomizes the order of the header lines in the request
+// data. The request line stays in place, the header ends with the first empty
+// line.
+func shuffleHeaders(data []byte) {
+	// split into lines, including the line terminator
+	var lines [][]byte
+	rest := data
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			// the header is incomplete, nothing to do
+			return
+		}
+
+		line := rest[:i+1]
+		rest = rest[i+1:]
+
+		if len(lines) > 0 && len(bytes.TrimRight(line, "\r\n")) == 0 {
+			// end of the header
+			break
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) < 3 {
+		// request line and less than two header lines
+		return
+	}
+
+	headers := make([][]byte, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		headers = append(headers, append([]byte(nil), line...))
+	}
+
+	rand.Shuffle(len(headers), func(i, j int) {
+		headers[i], headers[j] = headers[j], headers[i]
+	})
+
+	// write the lines back to data
+	pos := len(lines[0])
+	for _, line := range headers {
+		pos += copy(data[pos:], line)
+	}
+}
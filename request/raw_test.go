@@ -0,0 +1,337 @@
+package request
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// splitRaw returns the request line, the sorted header lines and the rest of
+// the data.
+func splitRaw(t testing.TB, data []byte) (requestLine string, header []string, rest string) {
+	s := string(data)
+	end := strings.Index(s, "\r\n\r\n")
+	if end < 0 {
+		t.Fatalf("end of header not found in %q", data)
+	}
+
+	lines := strings.Split(s[:end], "\r\n")
+	header = lines[1:]
+	sort.Strings(header)
+
+	return lines[0], header, s[end+4:]
+}
+
+func TestApplyRawFormat(t *testing.T) {
+	var tests = []struct {
+		method        string
+		url           string
+		body          string
+		header        []string
+		chunked       bool
+		forceBody     bool
+		contentLength string
+
+		requestLine string
+		wantHeader  []string
+		rest        string
+	}{
+		{
+			url:         "http://example.com/FUZZ?x=1",
+			requestLine: "GET /foo?x=1 HTTP/1.1",
+			wantHeader:  []string{"Accept: */*", "Host: example.com", "User-Agent: monsoon"},
+		},
+		{
+			method:      "POST",
+			url:         "https://example.com:8443",
+			body:        "a=FUZZ",
+			header:      []string{"X-Foo: bar", "X-Foo: baz", "User-Agent"},
+			requestLine: "POST / HTTP/1.1",
+			wantHeader:  []string{"Accept: */*", "Content-Length: 5", "Host: example.com:8443", "X-Foo: bar", "X-Foo: baz"},
+			rest:        "a=foo",
+		},
+		{
+			method:      "POST",
+			url:         "http://example.com",
+			body:        "FUZZ",
+			chunked:     true,
+			requestLine: "POST / HTTP/1.1",
+			wantHeader:  []string{"Accept: */*", "Host: example.com", "Transfer-Encoding: chunked", "User-Agent: monsoon"},
+			rest:        "3\r\nfoo\r\n0\r\n\r\n",
+		},
+		{
+			method:        "POST",
+			url:           "http://example.com",
+			body:          "a=FUZZ",
+			header:        []string{"Content-Length: 1"},
+			contentLength: "100",
+			requestLine:   "POST / HTTP/1.1",
+			wantHeader:    []string{"Accept: */*", "Content-Length: 100", "Host: example.com", "User-Agent: monsoon"},
+			rest:          "a=foo",
+		},
+		{
+			url:           "http://example.com",
+			contentLength: "FUZZ",
+			requestLine:   "GET / HTTP/1.1",
+			wantHeader:    []string{"Accept: */*", "Content-Length: foo", "Host: example.com", "User-Agent: monsoon"},
+		},
+		{
+			method:        "GET",
+			url:           "http://example.com",
+			body:          "0\r\n\r\nG",
+			forceBody:     true,
+			contentLength: " 4",
+			requestLine:   "GET / HTTP/1.1",
+			wantHeader:    []string{"Accept: */*", "Content-Length:  4", "Host: example.com", "User-Agent: monsoon"},
+			rest:          "0\r\n\r\nG",
+		},
+		{
+			method:        "POST",
+			url:           "http://example.com",
+			body:          "FUZZ",
+			chunked:       true,
+			contentLength: "4",
+			requestLine:   "POST / HTTP/1.1",
+			wantHeader:    []string{"Accept: */*", "Content-Length: 4", "Host: example.com", "Transfer-Encoding: chunked", "User-Agent: monsoon"},
+			rest:          "3\r\nfoo\r\n0\r\n\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		for _, randomize := range []bool{false, true} {
+			t.Run("", func(t *testing.T) {
+				req := New("")
+				req.URL = test.url
+				req.Method = test.method
+				req.Body = test.body
+				req.ForceChunkedEncoding = test.chunked
+				req.ForceBody = test.forceBody
+				req.ContentLength = test.contentLength
+				req.RandomizeHeaders = randomize
+				for _, hdr := range test.header {
+					err := req.Header.Set(hdr)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				raw, err := req.ApplyRaw("foo")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				requestLine, header, rest := splitRaw(t, raw.Data)
+				if requestLine != test.requestLine {
+					t.Errorf("wrong request line, want %q, got %q", test.requestLine, requestLine)
+				}
+
+				if !cmp.Equal(test.wantHeader, header) {
+					t.Error(cmp.Diff(test.wantHeader, header))
+				}
+
+				if rest != test.rest {
+					t.Errorf("wrong body, want %q, got %q", test.rest, rest)
+				}
+			})
+		}
+	}
+}
+
+func TestApplyRawFile(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-raw-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	data := "GET /FUZZ HTTP/1.0\nA: 1\r\nB: 2\nC: FUZZ\nD: 4\n\nbody FUZZ\nE: 5\n"
+
+	filename := filepath.Join(tempdir, "request")
+	err = ioutil.WriteFile(filename, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := New("")
+	req.URL = "https://example.com:8443/FUZZ"
+	req.RawFile = filename
+
+	raw, err := req.ApplyRaw("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Replace(data, "FUZZ", "foo", -1)
+	if string(raw.Data) != want {
+		t.Fatalf("wrong data, want %q, got %q", want, raw.Data)
+	}
+
+	if raw.Method != "GET" {
+		t.Errorf("wrong method, want GET, got %q", raw.Method)
+	}
+
+	host, port, err := raw.Target()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if host != "example.com" || port != "8443" {
+		t.Errorf("wrong target, want example.com:8443, got %v:%v", host, port)
+	}
+
+	// shuffle the header lines and make sure everything else stays the same
+	req.RandomizeHeaders = true
+	lines := strings.SplitAfter(want, "\n")
+	orders := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		raw, err := req.ApplyRaw("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res := strings.SplitAfter(string(raw.Data), "\n")
+		if res[0] != lines[0] || !cmp.Equal(res[5:], lines[5:]) {
+			t.Fatalf("request line or body modified: %q", raw.Data)
+		}
+
+		header := append([]string(nil), res[1:5]...)
+		orders[strings.Join(header, "")] = struct{}{}
+
+		sort.Strings(header)
+		if !cmp.Equal(header, lines[1:5]) {
+			t.Fatalf("wrong header lines: %v", cmp.Diff(lines[1:5], header))
+		}
+	}
+
+	if len(orders) < 2 {
+		t.Errorf("header lines were not shuffled")
+	}
+}
+
+func TestApplyRawFileCached(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-raw-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	filename := filepath.Join(tempdir, "request")
+	err = ioutil.WriteFile(filename, []byte("GET /FUZZ HTTP/1.1\r\n\r\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := New("")
+	req.URL = "http://example.com/"
+	req.RawFile = filename
+
+	// copies are used by the runners
+	template := *req
+
+	raw, err := template.ApplyRaw("one")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(raw.Data) != "GET /one HTTP/1.1\r\n\r\n" {
+		t.Fatalf("wrong data %q", raw.Data)
+	}
+
+	// the file is not read again, also not for other copies
+	err = os.Remove(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []Request{template, *req} {
+		raw, err = r.ApplyRaw("two")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(raw.Data) != "GET /two HTTP/1.1\r\n\r\n" {
+			t.Fatalf("wrong data %q", raw.Data)
+		}
+	}
+
+	// a request which is not created with New reads the file each time
+	_, err = (&Request{URL: req.URL, Replace: "FUZZ", RawFile: filename}).ApplyRaw("three")
+	if err == nil {
+		t.Fatal("expected error for removed file not found")
+	}
+}
+
+func TestShuffleHeadersIncomplete(t *testing.T) {
+	for _, data := range []string{"", "GET / HTTP/1.1", "GET / HTTP/1.1\r\nA: 1\r\nB: 2"} {
+		buf := []byte(data)
+		shuffleHeaders(buf)
+		if !bytes.Equal(buf, []byte(data)) {
+			t.Errorf("data was modified: want %q, got %q", data, buf)
+		}
+	}
+}
+
+func TestApplyRawDir(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-raw-dir-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	files := map[string]string{
+		"get.txt":  "GET /FUZZ HTTP/1.0\nHost: example.com\n\n",
+		"post.txt": "POST /login HTTP/1.1\r\nHost: example.com\r\nContent-Length: 3\r\n\r\nx=1",
+	}
+
+	for name, data := range files {
+		err = ioutil.WriteFile(filepath.Join(tempdir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := New("")
+	req.URL = "https://example.com:8443"
+	req.RawDir = tempdir
+
+	if !req.RawMode() {
+		t.Fatal("RawMode() returned false for RawDir")
+	}
+
+	for name, data := range files {
+		raw, err := req.ApplyRaw(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the files are sent verbatim, the placeholder is not replaced
+		if string(raw.Data) != data {
+			t.Errorf("wrong data for %v, want %q, got %q", name, data, raw.Data)
+		}
+
+		wantMethod := strings.SplitN(data, " ", 2)[0]
+		if raw.Method != wantMethod {
+			t.Errorf("wrong method for %v, want %q, got %q", name, wantMethod, raw.Method)
+		}
+
+		host, port, err := raw.Target()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if host != "example.com" || port != "8443" {
+			t.Errorf("wrong target, want example.com:8443, got %v:%v", host, port)
+		}
+	}
+
+	_, err = req.ApplyRaw("missing.txt")
+	if err == nil {
+		t.Error("no error returned for a missing file")
+	}
+}
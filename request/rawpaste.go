@@ -0,0 +1,158 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ParseRawRequest parses a raw HTTP request as it is copied from an
+// intercepting proxy or the developer tools of a browser: the request line
+// ('METHOD TARGET [HTTP/version]'), the headers up to the first empty line and
+// the body. The lines may end with CRLF or LF. Unless the target is an
+// absolute URL, the URL is built from the Host header and the target, with the
+// scheme https. HTTP/2 pseudo headers (like ':authority') are used for the URL
+// and otherwise ignored. The headers Host and Content-Length are not returned,
+// they are set when the request is sent. If the body is longer than the
+// Content-Length, it is truncated, without a Content-Length header line endings
+// at the end of the body are removed.
+func ParseRawRequest(rd io.Reader) (*HTTPFile, error) {
+	buf, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	// nextLine returns the next line without the line ending
+	lineNumber := 0
+	nextLine := func() (string, bool) {
+		if len(buf) == 0 {
+			return "", false
+		}
+
+		lineNumber++
+		var line []byte
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line, buf = buf[:i], buf[i+1:]
+		} else {
+			line, buf = buf, nil
+		}
+
+		return strings.TrimSuffix(string(line), "\r"), true
+	}
+
+	// skip empty lines before the request line
+	var requestLine string
+	for {
+		line, ok := nextLine()
+		if !ok {
+			return nil, errors.New("no request found")
+		}
+
+		if strings.TrimSpace(line) != "" {
+			requestLine = line
+			break
+		}
+	}
+
+	fields := strings.Fields(requestLine)
+	if len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/") {
+		fields = fields[:2]
+	}
+
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("line %d: invalid request line %q", lineNumber, requestLine)
+	}
+
+	f := &HTTPFile{Method: fields[0]}
+	target := fields[1]
+
+	scheme, host := "https", ""
+	contentLength := -1
+
+	for {
+		line, ok := nextLine()
+		if !ok || line == "" {
+			break
+		}
+
+		// HTTP/2 pseudo headers, the name starts with a colon
+		if strings.HasPrefix(line, ":") {
+			data := strings.SplitN(line[1:], ":", 2)
+			if len(data) == 2 {
+				switch strings.ToLower(strings.TrimSpace(data[0])) {
+				case "authority":
+					host = strings.TrimSpace(data[1])
+				case "scheme":
+					scheme = strings.TrimSpace(data[1])
+				}
+			}
+			continue
+		}
+
+		data := strings.SplitN(line, ":", 2)
+		if len(data) != 2 || strings.TrimSpace(data[0]) == "" {
+			return nil, fmt.Errorf("line %d: invalid header %q, expected 'name: value'", lineNumber, line)
+		}
+
+		name, value := strings.TrimSpace(data[0]), strings.TrimSpace(data[1])
+
+		switch textproto.CanonicalMIMEHeaderKey(name) {
+		case "Host":
+			host = value
+			continue
+		case "Content-Length":
+			n, err := strconv.Atoi(value)
+			if err == nil && n >= 0 {
+				contentLength = n
+			}
+			continue
+		}
+
+		f.Header = append(f.Header, name+": "+value)
+	}
+
+	body := buf
+	switch {
+	case contentLength >= 0 && len(body) > contentLength:
+		body = body[:contentLength]
+	case contentLength < 0:
+		for bytes.HasSuffix(body, []byte("\n")) {
+			body = bytes.TrimSuffix(bytes.TrimSuffix(body, []byte("\n")), []byte("\r"))
+		}
+	}
+	f.Body = string(body)
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		f.URL = target
+		return f, nil
+	}
+
+	if host == "" {
+		return nil, errors.New("no Host header found")
+	}
+
+	if !strings.HasPrefix(target, "/") {
+		target = "/" + target
+	}
+
+	f.URL = scheme + "://" + host + target
+
+	return f, nil
+}
+
+// LoadRawRequest reads a raw HTTP request (e.g. pasted from an intercepting
+// proxy) from rd (see ParseRawRequest). Like for LoadRequestFile, the method,
+// URL, headers and body are used unless they have already been set.
+func (r *Request) LoadRawRequest(rd io.Reader, source string) error {
+	f, err := ParseRawRequest(rd)
+	if err != nil {
+		return fmt.Errorf("read request from %v: %v", source, err)
+	}
+
+	return r.useHTTPFile(f, source)
+}
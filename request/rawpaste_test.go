@@ -0,0 +1,150 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRawRequest(t *testing.T) {
+	var tests = []struct {
+		request string
+		want    *HTTPFile
+	}{
+		{
+			request: "GET /search?q=FUZZ HTTP/1.1\r\nHost: example.com\r\nAccept: text/html\r\n\r\n",
+			want: &HTTPFile{
+				Method: "GET",
+				URL:    "https://example.com/search?q=FUZZ",
+				Header: []string{"Accept: text/html"},
+			},
+		},
+		{
+			request: "\nPOST /login HTTP/1.1\nHost: example.com:8443\nContent-Type: application/x-www-form-urlencoded\nContent-Length: 23\n\nuser=admin&pass=secret\n\n",
+			want: &HTTPFile{
+				Method: "POST",
+				URL:    "https://example.com:8443/login",
+				Header: []string{"Content-Type: application/x-www-form-urlencoded"},
+				Body:   "user=admin&pass=secret\n",
+			},
+		},
+		{
+			request: "POST /api HTTP/2\r\nHost: example.com\r\n\r\n{\"a\":\r\n\"FUZZ\"}\r\n\r\n",
+			want: &HTTPFile{
+				Method: "POST",
+				URL:    "https://example.com/api",
+				Body:   "{\"a\":\r\n\"FUZZ\"}",
+			},
+		},
+		{
+			request: "GET http://example.com/proxy HTTP/1.1\nHost: other.example.com\n",
+			want: &HTTPFile{
+				Method: "GET",
+				URL:    "http://example.com/proxy",
+			},
+		},
+		{
+			request: "GET /h2\n:authority: example.com\n:scheme: http\n:path: /h2\nX-Foo:bar\n",
+			want: &HTTPFile{
+				Method: "GET",
+				URL:    "http://example.com/h2",
+				Header: []string{"X-Foo: bar"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f, err := ParseRawRequest(strings.NewReader(test.request))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.want, f) {
+				t.Error(cmp.Diff(test.want, f))
+			}
+		})
+	}
+}
+
+func TestParseRawRequestInvalid(t *testing.T) {
+	var tests = []struct {
+		request string
+		err     string
+	}{
+		{"", "no request found"},
+		{"\r\n\r\n", "no request found"},
+		{"GET\r\nHost: example.com\r\n", "line 1: invalid request line"},
+		{"GET / HTTP/1.1\r\nHost: example.com\r\nfoo\r\n", "line 3: invalid header"},
+		{"GET / HTTP/1.1\r\nAccept: */*\r\n", "no Host header found"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseRawRequest(strings.NewReader(test.request))
+			if err == nil {
+				t.Fatal("expected error not returned")
+			}
+
+			if !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("wrong error, want prefix %q, got %q", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadRawRequest(t *testing.T) {
+	raw := "POST /login?name=FUZZ HTTP/1.1\n" +
+		"Host: example.com\n" +
+		"X-Foo: pasted\n" +
+		"Content-Length: 8\n" +
+		"\n" +
+		"pw=FUZZ\n"
+
+	r := New("")
+	err := r.Header.Set("X-Foo: flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.LoadRawRequest(strings.NewReader(raw), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := r.Apply("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != http.MethodPost {
+		t.Errorf("wrong method, want %v, got %v", http.MethodPost, req.Method)
+	}
+
+	wantURL := "https://example.com/login?name=foo"
+	if req.URL.String() != wantURL {
+		t.Errorf("wrong URL, want %v, got %v", wantURL, req.URL)
+	}
+
+	wantHeader := http.Header{
+		"Accept":     []string{"*/*"},
+		"User-Agent": []string{"monsoon"},
+		"X-Foo":      []string{"flag"},
+	}
+	if !cmp.Equal(wantHeader, req.Header) {
+		t.Error(cmp.Diff(wantHeader, req.Header))
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantBody := "pw=foo\n"
+	if string(body) != wantBody {
+		t.Errorf("wrong body, want %q, got %q", wantBody, body)
+	}
+}
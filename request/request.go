@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Header is an HTTP header that implements the pflag.Value interface.
@@ -109,6 +110,22 @@ func (h Header) Apply(hdr http.Header, insertValue func(string) string) {
 	}
 }
 
+// Changed returns true if headers apart from the default ones have been set,
+// or if any header is to be removed.
+func (h Header) Changed() bool {
+	if len(h.Remove) > 0 {
+		return true
+	}
+
+	for name := range h.Header {
+		if !headerDefaultValue(h, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func headerDefaultValue(h Header, name string) bool {
 	key := textproto.CanonicalMIMEHeaderKey(name)
 
@@ -159,16 +176,61 @@ type Request struct {
 	Header *Header
 	Body   string
 
+	MethodOverride string // sent as X-HTTP-Method-Override, the request is sent with POST
+
+	FormFields []string // "name=value" pairs sent URL-encoded in the body after Body
+
+	RawQuery      string // used as the query string without any encoding
+	CacheBust     bool   // add a query parameter with a random value to each request
+	ContentLength string // sent verbatim as the Content-Length header, implies sending raw requests
+
+	AcceptEncoding string // sent as the Accept-Encoding header unless it is set explicitly
+
 	UserPass string // user:password for HTTP basic auth
 
 	TemplateFile string // used to read the request from a file
+	RequestFile  string // used to read the request in the format of the VS Code REST Client
+	RawStdin     bool   // read a raw request (e.g. copied from a proxy) from stdin
+	CookieFile   string // used to read cookies in the Netscape format
+	RawFile      string // used to read a request which is sent without modification
+	RawDir       string // used to read requests sent without modification, the value is the file name
 
-	Replace string // this string is being replaced by a value in a specific http request
+	RandomizeHeaders bool // send the header lines in random order
+	ForceBody        bool // send the body even for methods which don't have one
+	AutoContentType  bool // set the Content-Type header based on the body
+	ExpandTemplates  bool // evaluate templates in the header and body
+	ThreadID         int  // available as .ThreadID in templates, set for each thread
+
+	Replace    string // this string is being replaced by a value in a specific http request
+	AppendPath bool   // append the value to the path of the URL
+
+	Fields   []string // split each value into these fields, see SplitFields
+	FieldSep string   // separates the fields, a tab if empty
 
 	Insecure             bool
+	InsecureHosts        []string // disable TLS certificate verification only for these hosts
 	TLSClientKeyCertFile string
 	DisableHTTP2         bool
+	Connect              string // tunnel all connections with CONNECT via the proxy to this host:port
 	ForceChunkedEncoding bool
+	WireHeaderSize       bool // compute the header size from the data received on the wire
+	DisableKeepAlives    bool
+	MaxIdleConns         int // zero means one idle connection per concurrent request
+	MaxConnsPerHost      int // zero means no limit
+	ConnectTimeout       time.Duration
+	TLSHandshakeTimeout  time.Duration
+	TLSFingerprint       string // send the ClientHello of a browser ("chrome", "firefox") or a random one
+
+	// sign requests with AWS Signature Version 4 if the access key is set
+	AWSAccessKey    string
+	AWSSecretKey    string
+	AWSSessionToken string
+	AWSRegion       string
+	AWSService      string
+
+	// rawFile caches the contents of RawFile, the pointer is shared by all
+	// copies of a request created with New
+	rawFile *cachedFile
 }
 
 // New returns a new request. If replace is the empty string, "FUZZ" is used.
@@ -179,6 +241,7 @@ func New(replace string) *Request {
 	return &Request{
 		Header:  NewHeader(DefaultHeader),
 		Replace: replace,
+		rawFile: &cachedFile{},
 	}
 }
 
@@ -225,8 +288,7 @@ func readRequestFromFile(filename string, target *url.URL, replace func([]byte)
 	}
 
 	origBody = append(origBody, rest...)
-	req.Body = ioutil.NopCloser(bytes.NewReader(origBody))
-	req.ContentLength = int64(len(origBody))
+	setBody(req, origBody)
 
 	// fill some details from the URL
 
@@ -253,15 +315,83 @@ func readRequestFromFile(filename string, target *url.URL, replace func([]byte)
 	return req, nil
 }
 
+// bodylessMethod returns true if requests with the method should not have a
+// body.
+func bodylessMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// setBody uses buf as the body of req. GetBody is set so that the body can be
+// sent again, e.g. when a redirect is followed.
+func setBody(req *http.Request, buf []byte) {
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	req.ContentLength = int64(len(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+}
+
+// appendPath appends value (which may contain a query string) to the path of
+// u. Slashes between the path and the value are merged, a query string in the
+// value is added to the query string of u. Percent-encoded characters in the
+// value are sent as they are and not encoded again.
+func appendPath(u *url.URL, value string) {
+	p, query := value, ""
+	if i := strings.IndexByte(value, '?'); i >= 0 {
+		p, query = value[:i], value[i+1:]
+	}
+
+	escaped := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.TrimPrefix(p, "/")
+	unescaped, err := url.PathUnescape(escaped)
+	if err != nil {
+		// the value is not a valid escaped path, so encode it
+		unescaped = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(p, "/")
+		escaped = ""
+	}
+	u.Path = unescaped
+	u.RawPath = escaped
+
+	switch {
+	case query == "":
+	case u.RawQuery == "":
+		u.RawQuery = query
+	default:
+		u.RawQuery += "&" + query
+	}
+}
+
 // Apply replaces the template with value in all fields of the request and
-// returns a new http.Request.
+// returns a new http.Request. If Fields is set, value is split into fields
+// first (see SplitFields).
 func (r *Request) Apply(value string) (*http.Request, error) {
-	insertValue := func(s string) string {
-		return replaceTemplate(s, r.Replace, value)
+	value, fields := r.SplitFields(value)
+	insertValue := r.inserter(value, fields)
+
+	// evaluate the templates in the header and body before inserting the value
+	var templateErr error
+	insertTemplate := func(s string) string {
+		if r.ExpandTemplates {
+			var err error
+			s, err = expandTemplate(s, templateData{Value: value, Fields: fields, ThreadID: r.ThreadID})
+			if err != nil && templateErr == nil {
+				templateErr = err
+			}
+		}
+		return insertValue(s)
 	}
 
 	targetURL := insertValue(r.URL)
-	body := []byte(insertValue(r.Body))
+	body := []byte(insertTemplate(r.Body))
+	if len(r.FormFields) > 0 {
+		if len(body) > 0 {
+			body = append(body, '&')
+		}
+		body = append(body, r.formBody(insertTemplate)...)
+	}
 
 	var req *http.Request
 
@@ -273,7 +403,7 @@ func (r *Request) Apply(value string) (*http.Request, error) {
 		}
 
 		req, err = readRequestFromFile(r.TemplateFile, target, func(buf []byte) []byte {
-			return bytes.Replace(buf, []byte(r.Replace), []byte(value), -1)
+			return []byte(insertValue(string(buf)))
 		})
 		if err != nil {
 			return nil, err
@@ -281,8 +411,7 @@ func (r *Request) Apply(value string) (*http.Request, error) {
 
 		if len(body) > 0 {
 			// use new body and set content length
-			req.Body = ioutil.NopCloser(bytes.NewReader(body))
-			req.ContentLength = int64(len(body))
+			setBody(req, body)
 		}
 
 		if r.Method != "" {
@@ -292,17 +421,39 @@ func (r *Request) Apply(value string) (*http.Request, error) {
 	} else {
 		var err error
 
+		method := insertValue(r.Method)
+		if method == "" && len(body) > 0 {
+			// like curl, send data with POST unless another method is requested
+			method = http.MethodPost
+		}
+
 		// create new request from scratch
-		req, err = http.NewRequest(insertValue(r.Method), targetURL, bytes.NewReader(body))
+		req, err = http.NewRequest(method, targetURL, bytes.NewReader(body))
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// the server is expected to use the method from the header instead
+	if r.MethodOverride != "" {
+		req.Method = http.MethodPost
+	}
+
 	if r.ForceChunkedEncoding {
 		req.ContentLength = -1
 	}
 
+	// a method from a template file is used together with its body as written
+	fromTemplateFile := r.TemplateFile != "" && r.Method == ""
+
+	if !r.ForceBody && !fromTemplateFile && bodylessMethod(req.Method) {
+		// remove the body, so that neither the body nor Content-Length is sent
+		req.Body = http.NoBody
+		req.GetBody = nil
+		req.ContentLength = 0
+		req.Header.Del("Content-Length")
+	}
+
 	// if the URL has user and password, use that
 	if req.URL.User != nil {
 		u := req.URL.User.Username()
@@ -312,7 +463,7 @@ func (r *Request) Apply(value string) (*http.Request, error) {
 
 	// but if an explicit user and pass is specified, override them again
 	if r.UserPass != "" {
-		data := strings.SplitN(strings.Replace(r.UserPass, r.Replace, value, -1), ":", 2)
+		data := strings.SplitN(insertValue(r.UserPass), ":", 2)
 		u := data[0]
 		p := ""
 		if len(data) > 1 {
@@ -322,22 +473,47 @@ func (r *Request) Apply(value string) (*http.Request, error) {
 		req.SetBasicAuth(u, p)
 	}
 
+	// set the query string exactly as specified, so that the value is sent
+	// byte-for-byte
+	if r.RawQuery != "" {
+		req.URL.RawQuery = insertValue(r.RawQuery)
+	}
+
+	if r.AppendPath {
+		appendPath(req.URL, value)
+	}
+
+	if r.CacheBust {
+		err := addCacheBuster(req.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// make sure there's a valid path
 	if req.URL.Path == "" {
 		req.URL.Path = "/"
 	}
 
 	// apply template headers
-	r.Header.Apply(req.Header, insertValue)
+	r.Header.Apply(req.Header, insertTemplate)
+
+	if r.AcceptEncoding != "" && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", r.AcceptEncoding)
+	}
 
 	// special handling for the Host header, which needs to be set on the
 	// request field Host
 	for k, v := range r.Header.Header {
 		if textproto.CanonicalMIMEHeaderKey(k) == "Host" {
-			req.Host = insertValue(v[0])
+			req.Host = insertTemplate(v[0])
 		}
 	}
 
+	if templateErr != nil {
+		return nil, templateErr
+	}
+
 	for k := range r.Header.Remove {
 		name := textproto.CanonicalMIMEHeaderKey(k)
 
@@ -358,23 +534,128 @@ func (r *Request) Apply(value string) (*http.Request, error) {
 		}
 	}
 
+	if r.MethodOverride != "" && req.Header.Get("X-HTTP-Method-Override") == "" && !r.headerRemoved("X-Http-Method-Override") {
+		req.Header.Set("X-HTTP-Method-Override", insertValue(r.MethodOverride))
+	}
+
+	if len(r.FormFields) > 0 && req.Header.Get("Content-Type") == "" && !r.headerRemoved("Content-Type") {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if r.AutoContentType {
+		err := r.setContentType(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// sign the request last, so that the signature covers the final request
+	signer, err := r.AWSSigner()
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != nil {
+		err = signer.Sign(req, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return req, nil
 }
 
+// headerRemoved returns true if the header name is to be removed from all
+// requests.
+func (r *Request) headerRemoved(name string) bool {
+	for k := range r.Header.Remove {
+		if textproto.CanonicalMIMEHeaderKey(k) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setContentType sets the Content-Type header for req based on the body,
+// unless the header has been set or removed explicitly.
+func (r *Request) setContentType(req *http.Request) error {
+	if req.Header.Get("Content-Type") != "" || req.GetBody == nil || r.headerRemoved("Content-Type") {
+		return nil
+	}
+
+	rd, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+
+	if ct := detectContentType(body); ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+
+	return nil
+}
+
+// detectContentType returns the content type for JSON, XML and form data in
+// body. For all other data, the empty string is returned. Only the structure
+// is checked and not whether the data is valid, so that the content type stays
+// the same when a value inserted into the body breaks the syntax.
+func detectContentType(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+
+	switch {
+	case body[0] == '{' || body[0] == '[':
+		return "application/json"
+	case body[0] == '<':
+		return "application/xml"
+	case formData(body):
+		return "application/x-www-form-urlencoded"
+	}
+
+	return ""
+}
+
+// formData returns true if body looks like "name=value&name=value".
+func formData(body []byte) bool {
+	for _, pair := range bytes.Split(body, []byte("&")) {
+		i := bytes.IndexByte(pair, '=')
+		if i <= 0 {
+			return false
+		}
+
+		if bytes.ContainsAny(pair, "\r\n") {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Target returns the host and port for the request.
 func Target(req *http.Request) (host, port string, err error) {
-	port = req.URL.Port()
+	return target(req.URL)
+}
+
+func target(u *url.URL) (host, port string, err error) {
+	port = u.Port()
 	if port == "" {
 		// fill in default ports
-		switch req.URL.Scheme {
+		switch u.Scheme {
 		case "http":
 			port = "80"
 		case "https":
 			port = "443"
 		default:
-			return "", "", fmt.Errorf("unknown URL scheme %q", req.URL.Scheme)
+			return "", "", fmt.Errorf("unknown URL scheme %q", u.Scheme)
 		}
 	}
 
-	return req.URL.Hostname(), port, nil
+	return u.Hostname(), port, nil
 }
@@ -122,6 +122,14 @@ func checkURL(url string) CheckFunc {
 	}
 }
 
+func checkRequestURI(uri string) CheckFunc {
+	return func(t testing.TB, req *http.Request) {
+		if req.RequestURI != uri {
+			t.Errorf("wrong request URI, want %q, got %q", uri, req.RequestURI)
+		}
+	}
+}
+
 func checkMethod(method string) CheckFunc {
 	return func(t testing.TB, req *http.Request) {
 		if req.Method != method {
@@ -221,18 +229,49 @@ func checkBody(body string) CheckFunc {
 	}
 }
 
+func TestHeaderChanged(t *testing.T) {
+	var tests = []struct {
+		values []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"x-foo: bar"}, true},
+		{[]string{"accept"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			hdr := NewHeader(DefaultHeader)
+			for _, v := range test.values {
+				err := hdr.Set(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if hdr.Changed() != test.want {
+				t.Errorf("wrong result, want %v, got %v", test.want, hdr.Changed())
+			}
+		})
+	}
+}
+
 func TestRequestApply(t *testing.T) {
 	var tests = []struct {
 		URL  string
 		File string
 
-		Method string
-		Header []string // passed in as a sequence of "name: value" strings
-		Body   string
+		Method   string
+		Header   []string // passed in as a sequence of "name: value" strings
+		Body     string
+		RawQuery string
 
 		Template             string
 		Value                string
 		ForceChunkedEncoding bool
+		ForceBody            bool
+		AutoContentType      bool
+		FormFields           []string
 		Checks               []CheckFunc
 	}{
 		// basic URL tests
@@ -332,6 +371,36 @@ Host: other.com
 				checkMethod("GET"),
 			},
 		},
+		// raw query string
+		{
+			URL:      "http://www.example.com/download",
+			RawQuery: "file=FUZZ",
+			Value:    "%2e%2e/%2e%2e/etc/passwd%00.png",
+			Checks: []CheckFunc{
+				checkRequestURI("/download?file=%2e%2e/%2e%2e/etc/passwd%00.png"),
+				checkMethod("GET"),
+			},
+		},
+		{
+			// the query string from the URL is replaced
+			URL:      "http://www.example.com/?x=y",
+			RawQuery: "a=%252e%252e&b=FUZZ",
+			Value:    "%00",
+			Checks: []CheckFunc{
+				checkRequestURI("/?a=%252e%252e&b=%00"),
+			},
+		},
+		{
+			URL: "http://www.example.com",
+			File: `GET /download?x=y HTTP/1.1
+
+`,
+			RawQuery: "file=FUZZ%00",
+			Value:    "..%2f..%2f",
+			Checks: []CheckFunc{
+				checkRequestURI("/download?file=..%2f..%2f%00"),
+			},
+		},
 		// basic auth
 		{
 			// if supplied in the target URL, use that
@@ -664,6 +733,222 @@ foobarFUZZbaz`,
 				checkHeaderAbsent("Content-Length"),
 			},
 		},
+		// bodyless methods
+		{
+			URL:    "http://www.example.com",
+			Method: "FUZZ",
+			Body:   "foobar",
+			Value:  "GET",
+			Checks: []CheckFunc{
+				checkMethod("GET"),
+				checkBody(""),
+				checkHeaderAbsent("Content-Length"),
+			},
+		},
+		{
+			URL:    "http://www.example.com",
+			Method: "FUZZ",
+			Body:   "foobar",
+			Value:  "POST",
+			Checks: []CheckFunc{
+				checkMethod("POST"),
+				checkBody("foobar"),
+				checkHeader("Content-Length", "6"),
+			},
+		},
+		{
+			URL:    "http://www.example.com",
+			Method: "HEAD",
+			Body:   "foobar",
+			Checks: []CheckFunc{
+				checkMethod("HEAD"),
+				checkBody(""),
+				checkHeaderAbsent("Content-Length"),
+			},
+		},
+		{
+			URL:       "http://www.example.com",
+			Method:    "GET",
+			Body:      "foobar",
+			ForceBody: true,
+			Checks: []CheckFunc{
+				checkMethod("GET"),
+				checkBody("foobar"),
+				checkHeader("Content-Length", "6"),
+			},
+		},
+		{
+			// data without a method is sent with POST
+			URL:  "http://www.example.com",
+			Body: "foobar",
+			Checks: []CheckFunc{
+				checkMethod("POST"),
+				checkBody("foobar"),
+			},
+		},
+		{
+			// the method from --method replaces the one from the file
+			URL:    "http://www.example.com",
+			Method: "FUZZ",
+			File: `POST / HTTP/1.1
+Content-Length: 6
+
+foobar`,
+			Value: "GET",
+			Checks: []CheckFunc{
+				checkMethod("GET"),
+				checkBody(""),
+				checkHeaderAbsent("Content-Length"),
+			},
+		},
+		{
+			// a template file is sent as written
+			URL: "http://www.example.com",
+			File: `GET / HTTP/1.1
+Content-Length: 6
+
+foobar`,
+			Checks: []CheckFunc{
+				checkMethod("GET"),
+				checkBody("foobar"),
+				checkHeader("Content-Length", "6"),
+			},
+		},
+		{
+			URL: "http://www.example.com",
+			File: `FUZZ / HTTP/1.1
+Content-Length: 6
+
+foobar`,
+			Value: "PUT",
+			Checks: []CheckFunc{
+				checkMethod("PUT"),
+				checkBody("foobar"),
+				checkHeader("Content-Length", "6"),
+			},
+		},
+		// content type detection
+		{
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            `{"id": FUZZ}`,
+			Value:           "23",
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeader("Content-Type", "application/json"),
+			},
+		},
+		{
+			// the content type does not change when the value breaks the syntax
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            `[FUZZ]`,
+			Value:           `"`,
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeader("Content-Type", "application/json"),
+			},
+		},
+		{
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            `<?xml version="1.0"?><user>FUZZ</user>`,
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeader("Content-Type", "application/xml"),
+			},
+		},
+		{
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            "user=admin&password=FUZZ",
+			Value:           "secret",
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeader("Content-Type", "application/x-www-form-urlencoded"),
+			},
+		},
+		{
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            "foobar",
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeaderAbsent("Content-Type"),
+			},
+		},
+		{
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            "{}",
+			Header:          []string{"Content-Type: text/plain"},
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeader("Content-Type", "text/plain"),
+			},
+		},
+		{
+			URL:             "http://www.example.com",
+			Method:          "POST",
+			Body:            "{}",
+			Header:          []string{"Content-Type"},
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeaderAbsent("Content-Type"),
+			},
+		},
+		{
+			URL: "http://www.example.com",
+			File: `POST / HTTP/1.1
+Content-Type: text/x-foo
+
+{}`,
+			AutoContentType: true,
+			Checks: []CheckFunc{
+				checkHeader("Content-Type", "text/x-foo"),
+			},
+		},
+		{
+			URL:    "http://www.example.com",
+			Method: "POST",
+			Body:   "{}",
+			Checks: []CheckFunc{
+				checkHeaderAbsent("Content-Type"),
+			},
+		},
+		{
+			// form fields are encoded after inserting the value
+			URL:        "http://www.example.com",
+			FormFields: []string{"user=admin", "pass=FUZZ", "FUZZ=x y"},
+			Value:      "a&b=c+d%",
+			Checks: []CheckFunc{
+				checkMethod("POST"),
+				checkHeader("Content-Type", "application/x-www-form-urlencoded"),
+				checkBody("user=admin&pass=a%26b%3Dc%2Bd%25&a%26b%3Dc%2Bd%25=x+y"),
+			},
+		},
+		{
+			URL:        "http://www.example.com",
+			Body:       "a=1",
+			FormFields: []string{"no equals sign&"},
+			Header:     []string{"Content-Type: text/plain"},
+			Checks: []CheckFunc{
+				checkMethod("POST"),
+				checkHeader("Content-Type", "text/plain"),
+				checkBody("a=1&no+equals+sign%26"),
+			},
+		},
+		{
+			URL:        "http://www.example.com",
+			Method:     "PUT",
+			FormFields: []string{"a="},
+			Header:     []string{"Content-Type"},
+			Checks: []CheckFunc{
+				checkMethod("PUT"),
+				checkHeaderAbsent("Content-Type"),
+				checkBody("a="),
+			},
+		},
 		{
 			// ensure that the Host header is passed on directly and not taken from the target URL
 			URL: "http://www.example.com",
@@ -787,7 +1072,11 @@ Host: server:1234
 			}
 			req.Method = test.Method
 			req.Body = test.Body
+			req.RawQuery = test.RawQuery
 			req.ForceChunkedEncoding = test.ForceChunkedEncoding
+			req.ForceBody = test.ForceBody
+			req.AutoContentType = test.AutoContentType
+			req.FormFields = test.FormFields
 			for _, hdr := range test.Header {
 				err := req.Header.Set(hdr)
 				if err != nil {
@@ -843,3 +1132,230 @@ Host: server:1234
 		})
 	}
 }
+
+func TestApplySchemePort(t *testing.T) {
+	type testCase struct {
+		url    string
+		value  string
+		want   string // the URL of the request
+		target string // the address a connection is established to
+	}
+
+	var tests = []testCase{
+		{"http://www.example.com:FUZZ/", "8080", "http://www.example.com:8080/", "www.example.com:8080"},
+		{"FUZZ://www.example.com/", "https", "https://www.example.com/", "www.example.com:443"},
+		{"FUZZ://www.example.com:8443/", "http", "http://www.example.com:8443/", "www.example.com:8443"},
+		{"https://FUZZ.example.com/", "admin", "https://admin.example.com/", "admin.example.com:443"},
+	}
+
+	// --range 80-90 against http://host:FUZZ/
+	for port := 80; port <= 90; port++ {
+		tests = append(tests, testCase{
+			"http://host:FUZZ/", fmt.Sprintf("%d", port),
+			fmt.Sprintf("http://host:%d/", port), fmt.Sprintf("host:%d", port),
+		})
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.URL = test.url
+
+			genReq, err := req.Apply(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if genReq.URL.String() != test.want {
+				t.Errorf("wrong URL, want %q, got %q", test.want, genReq.URL.String())
+			}
+
+			host, port, err := Target(genReq)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if net.JoinHostPort(host, port) != test.target {
+				t.Errorf("wrong target, want %q, got %q", test.target, net.JoinHostPort(host, port))
+			}
+		})
+	}
+}
+
+func TestApplyAppendPath(t *testing.T) {
+	var tests = []struct {
+		url      string
+		rawQuery string
+		value    string
+		want     string
+	}{
+		{"https://example.com", "", "admin", "https://example.com/admin"},
+		{"https://example.com/", "", "admin", "https://example.com/admin"},
+		{"https://example.com/app", "", "admin", "https://example.com/app/admin"},
+		{"https://example.com/app/", "", "/admin/", "https://example.com/app/admin/"},
+		{"https://example.com/app", "", "admin/login.php", "https://example.com/app/admin/login.php"},
+		{"https://example.com/app", "", "search?q=1&x=y", "https://example.com/app/search?q=1&x=y"},
+		{"https://example.com/app?lang=en", "", "search?q=1", "https://example.com/app/search?lang=en&q=1"},
+		{"https://example.com/app?lang=en", "", "search", "https://example.com/app/search?lang=en"},
+		{"https://example.com/app", "debug=1", "search?q=1", "https://example.com/app/search?debug=1&q=1"},
+		{"https://example.com/app", "", "%2e%2e/etc/passwd", "https://example.com/app/%2e%2e/etc/passwd"},
+		{"https://example.com/a%2fb", "", "c", "https://example.com/a%2fb/c"},
+		{"https://example.com", "", "foo bar", "https://example.com/foo%20bar"},
+		{"https://example.com", "", "100%", "https://example.com/100%25"},
+		{"https://example.com/FUZZ", "", "admin", "https://example.com/admin/admin"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.URL = test.url
+			req.RawQuery = test.rawQuery
+			req.AppendPath = true
+
+			genReq, err := req.Apply(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if genReq.URL.String() != test.want {
+				t.Errorf("wrong URL, want %q, got %q", test.want, genReq.URL.String())
+			}
+		})
+	}
+}
+
+func TestApplyMethodOverride(t *testing.T) {
+	var tests = []struct {
+		override string
+		header   []string
+		body     string
+		value    string
+
+		wantHeader string
+		wantBody   string
+	}{
+		{override: "DELETE", wantHeader: "DELETE"},
+		{override: "FUZZ", value: "PUT", body: "x=FUZZ", wantHeader: "PUT", wantBody: "x=PUT"},
+		{override: "PATCH", header: []string{"X-HTTP-Method-Override: GET"}, wantHeader: "GET"},
+		{override: "PATCH", header: []string{"x-http-method-override"}, wantHeader: ""},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.URL = "https://example.com/"
+			req.MethodOverride = test.override
+			req.Body = test.body
+			for _, hdr := range test.header {
+				err := req.Header.Set(hdr)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			genReq, err := req.Apply(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if genReq.Method != http.MethodPost {
+				t.Errorf("wrong method, want %v, got %v", http.MethodPost, genReq.Method)
+			}
+
+			if v := genReq.Header.Get("X-HTTP-Method-Override"); v != test.wantHeader {
+				t.Errorf("wrong override header, want %q, got %q", test.wantHeader, v)
+			}
+
+			body, err := ioutil.ReadAll(genReq.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != test.wantBody {
+				t.Errorf("wrong body, want %q, got %q", test.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestApplyCacheBust(t *testing.T) {
+	var tests = []struct {
+		url      string
+		rawQuery string
+		value    string
+		want     string // the query string without the cache buster
+	}{
+		{"https://example.com/", "", "x", ""},
+		{"https://example.com/?a=FUZZ", "", "1", "a=1&"},
+		{"https://example.com/?_cb=FUZZ", "", "1", "_cb=1&"},
+		{"https://example.com/", "q=%00FUZZ", "x", "q=%00x&"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.URL = test.url
+			req.RawQuery = test.rawQuery
+			req.CacheBust = true
+
+			seen := make(map[string]struct{})
+			for i := 0; i < 3; i++ {
+				genReq, err := req.Apply(test.value)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				query := genReq.URL.RawQuery
+				if !strings.HasPrefix(query, test.want+CacheBustParam+"=") {
+					t.Fatalf("wrong query string, want prefix %q, got %q", test.want+CacheBustParam+"=", query)
+				}
+
+				cb := strings.TrimPrefix(query, test.want+CacheBustParam+"=")
+				if len(cb) != 16 {
+					t.Errorf("wrong value for the cache buster: %q", cb)
+				}
+
+				if _, ok := seen[cb]; ok {
+					t.Errorf("cache buster %q sent twice", cb)
+				}
+				seen[cb] = struct{}{}
+			}
+		})
+	}
+}
+
+func TestApplyAcceptEncoding(t *testing.T) {
+	var tests = []struct {
+		acceptEncoding string
+		header         []string
+		want           string
+	}{
+		{"", nil, ""},
+		{"gzip, deflate, br", nil, "gzip, deflate, br"},
+		// an explicit header is kept
+		{"br", []string{"Accept-Encoding: identity"}, "identity"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.URL = "https://example.com/"
+			req.AcceptEncoding = test.acceptEncoding
+			for _, hdr := range test.header {
+				err := req.Header.Set(hdr)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			genReq, err := req.Apply("x")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v := genReq.Header.Get("Accept-Encoding"); v != test.want {
+				t.Errorf("wrong Accept-Encoding, want %q, got %q", test.want, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test cases from the AWS Signature Version 4 test suite
+func TestAWSSign(t *testing.T) {
+	var tests = []struct {
+		method, url string
+		body        string
+		header      http.Header
+		want        string
+	}{
+		{
+			method: "GET",
+			url:    "https://example.amazonaws.com/",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			method: "GET",
+			url:    "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			method: "POST",
+			url:    "https://example.amazonaws.com/",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			method: "POST",
+			url:    "https://example.amazonaws.com/",
+			body:   "Param1=value1",
+			header: http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}},
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	signer := &AWSSigner{
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "service",
+	}
+	ts := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.url, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+
+			err = signer.Sign(req, ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+				t.Errorf("wrong date header %q", req.Header.Get("X-Amz-Date"))
+			}
+
+			if auth := req.Header.Get("Authorization"); auth != test.want {
+				t.Errorf("wrong Authorization header\nwant: %v\n got: %v", test.want, auth)
+			}
+		})
+	}
+}
+
+func TestAWSSignApply(t *testing.T) {
+	r := New("")
+	r.URL = "https://s3.amazonaws.com/bucket/FUZZ?x=FUZZ&a=b"
+	r.AWSAccessKey = "AKIDEXAMPLE"
+	r.AWSSecretKey = "secret"
+	r.AWSRegion = "us-east-1"
+	r.AWSService = "s3"
+
+	req, err := r.Apply("key'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the query string is not encoded again or sorted for signing
+	if req.URL.RawQuery != "x=key'&a=b" {
+		t.Errorf("wrong query string %q", req.URL.RawQuery)
+	}
+
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(auth, "SignedHeaders=accept;host;x-amz-content-sha256;x-amz-date,") {
+		t.Errorf("wrong Authorization header %q", auth)
+	}
+
+	// hash of the empty body
+	want := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if h := req.Header.Get("X-Amz-Content-Sha256"); h != want {
+		t.Errorf("wrong content hash, want %v, got %v", want, h)
+	}
+}
@@ -0,0 +1,106 @@
+package request
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions which can be used in templates.
+var templateFuncs = template.FuncMap{
+	"md5": func(s string) string {
+		return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+	},
+	"sha1": func(s string) string {
+		return fmt.Sprintf("%x", sha1.Sum([]byte(s)))
+	},
+	"sha256": func(s string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+	},
+	"base64": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"base64url": func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	},
+	"hex": func(s string) string {
+		return hex.EncodeToString([]byte(s))
+	},
+	"urlenc": url.QueryEscape,
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+}
+
+// templateData is passed to the templates.
+type templateData struct {
+	Value    string
+	Fields   map[string]string // all fields of the value if Request.Fields is set
+	ThreadID int
+}
+
+func parseTemplate(s string) (*template.Template, error) {
+	return template.New("").Funcs(templateFuncs).Parse(s)
+}
+
+// expandTemplate evaluates the template expressions (e.g. "{{ sha256 .Value }}")
+// in s for data.
+func expandTemplate(s string, data templateData) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+
+	t, err := parseTemplate(s)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	err = t.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// CheckTemplates returns an error if ExpandTemplates is set and the body or a
+// header contains an invalid template.
+func (r *Request) CheckTemplates() error {
+	if !r.ExpandTemplates {
+		return nil
+	}
+
+	check := func(s string) error {
+		_, err := expandTemplate(s, templateData{})
+		return err
+	}
+
+	err := check(r.Body)
+	if err != nil {
+		return fmt.Errorf("invalid template in body: %v", err)
+	}
+
+	for _, field := range r.FormFields {
+		err := check(field)
+		if err != nil {
+			return fmt.Errorf("invalid template in form field %q: %v", field, err)
+		}
+	}
+
+	for name, values := range r.Header.Header {
+		for _, v := range append([]string{name}, values...) {
+			err := check(v)
+			if err != nil {
+				return fmt.Errorf("invalid template in header %v: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package request
+
+import (
+	"io/ioutil"
+	"strconv"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	var tests = []struct {
+		template string
+		value    string
+		threadID int
+		want     string
+	}{
+		{"foo", "bar", 0, "foo"},
+		{"{{ .Value }}", "bar", 0, "bar"},
+		{"{{ md5 .Value }}", "test", 0, "098f6bcd4621d373cade4e832627b4f6"},
+		{"{{ sha1 .Value }}", "test", 0, "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"},
+		{"{{ sha256 .Value }}", "test", 0, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{"Basic {{ base64 .Value }}", "user:pass", 0, "Basic dXNlcjpwYXNz"},
+		{"{{ base64url .Value }}", "??>", 0, "Pz8-"},
+		{"{{ hex .Value }}", "ab", 0, "6162"},
+		{"q={{ urlenc .Value }}", "a b&c", 0, "q=a+b%26c"},
+		{"{{ .Value | upper | base64 }}", "test", 0, "VEVTVA=="},
+		{"{{ lower .Value }}", "TeSt", 0, "test"},
+		// the value is not evaluated as a template
+		{"{{ .Value }}", "{{ sha256 .Value }}", 0, "{{ sha256 .Value }}"},
+		{"client-{{ .ThreadID }}", "test", 3, "client-3"},
+		{"{{ .Value }}-{{ .ThreadID }}", "test", 0, "test-0"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := expandTemplate(test.template, templateData{Value: test.value, ThreadID: test.threadID})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != test.want {
+				t.Errorf("wrong result for %q, want %q, got %q", test.template, test.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckTemplates(t *testing.T) {
+	var tests = []struct {
+		header string
+		body   string
+		valid  bool
+	}{
+		{"X-Foo: {{ sha256 .Value }}", "{{ .Value }}", true},
+		{"X-Thread-ID: {{ .ThreadID }}", "", true},
+		{"X-Foo: {{ sha256 .Value", "", false},
+		{"X-Foo: {{ unknown .Value }}", "", false},
+		{"X-Foo: bar", "{{ .Foo }}", false},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.ExpandTemplates = true
+			req.Body = test.body
+			err := req.Header.Set(test.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = req.CheckTemplates()
+			if test.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("expected error not returned")
+			}
+		})
+	}
+}
+
+func TestApplyExpandTemplates(t *testing.T) {
+	var tests = []struct {
+		expand     bool
+		wantHeader string
+		wantBody   string
+	}{
+		{true, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 test", "dGVzdA== test"},
+		{false, "{{ sha256 .Value }} test", "{{ base64 .Value }} test"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			req := New("")
+			req.URL = "https://example.com/FUZZ"
+			req.ExpandTemplates = test.expand
+			req.Body = "{{ base64 .Value }} FUZZ"
+			err := req.Header.Set("X-Request-ID: {{ sha256 .Value }} FUZZ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			genReq, err := req.Apply("test")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if genReq.Header.Get("X-Request-ID") != test.wantHeader {
+				t.Errorf("wrong header, want %q, got %q", test.wantHeader, genReq.Header.Get("X-Request-ID"))
+			}
+
+			body, err := ioutil.ReadAll(genReq.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != test.wantBody {
+				t.Errorf("wrong body, want %q, got %q", test.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestApplyThreadID(t *testing.T) {
+	req := New("")
+	req.URL = "https://example.com/FUZZ"
+	req.ExpandTemplates = true
+	req.Body = "thread={{ .ThreadID }}"
+	err := req.Header.Set("X-Thread-ID: {{ .ThreadID }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int{1, 2, 23} {
+		tmpl := *req
+		tmpl.ThreadID = id
+
+		genReq, err := tmpl.Apply("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := strconv.Itoa(id)
+		if genReq.Header.Get("X-Thread-ID") != want {
+			t.Errorf("wrong header, want %q, got %q", want, genReq.Header.Get("X-Thread-ID"))
+		}
+
+		body, err := ioutil.ReadAll(genReq.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != "thread="+want {
+			t.Errorf("wrong body, want %q, got %q", "thread="+want, body)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Assertion evaluates an expression (see ParseExpression) for each response
+// and decides whether the run passed: if Any is set, at least one response
+// must match, otherwise all responses must match. Failed requests are
+// evaluated like other responses (with the synthetic status code), cancelled
+// requests are not counted.
+type Assertion struct {
+	Any bool
+
+	expr           func(Response) bool
+	passed, failed int
+	lastFailed     string
+}
+
+// NewAssertion parses the expression s and returns an assertion.
+func NewAssertion(s string, any bool) (*Assertion, error) {
+	expr, err := ParseExpression(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Assertion{Any: any, expr: expr}, nil
+}
+
+// Record evaluates the expression for res and counts the result.
+func (a *Assertion) Record(res Response) {
+	if res.Cancelled() {
+		return
+	}
+
+	if a.expr(res) {
+		a.passed++
+		return
+	}
+
+	a.failed++
+	a.lastFailed = res.Item
+}
+
+// Passed returns the number of responses for which the expression matched.
+func (a *Assertion) Passed() int {
+	return a.passed
+}
+
+// Failed returns the number of responses for which the expression did not
+// match.
+func (a *Assertion) Failed() int {
+	return a.failed
+}
+
+// Err returns an error describing why the assertion failed, or nil if it
+// passed. The assertion fails if no response has been recorded.
+func (a *Assertion) Err() error {
+	switch {
+	case a.passed+a.failed == 0:
+		return errors.New("no responses received")
+	case a.Any && a.passed == 0:
+		return fmt.Errorf("none of the %d responses matched", a.failed)
+	case !a.Any && a.failed > 0:
+		return fmt.Errorf("%d of %d responses did not match, last for value %q", a.failed, a.passed+a.failed, a.lastFailed)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAssertion(t *testing.T) {
+	ok := Response{Item: "a", HTTPResponse: &http.Response{StatusCode: 200}, RawBody: []byte("status: OK")}
+	notFound := Response{Item: "b", HTTPResponse: &http.Response{StatusCode: 404}, RawBody: []byte("not found")}
+	failed := Response{Item: "c", Error: wrapURLError(errors.New("connection refused"))}
+	cancelled := Response{Item: "d", Error: wrapURLError(context.Canceled)}
+
+	var tests = []struct {
+		any       bool
+		responses []Response
+		passed    int
+		failed    int
+		err       string
+	}{
+		{false, []Response{ok, ok}, 2, 0, ""},
+		{false, []Response{ok, notFound, ok}, 2, 1, `1 of 3 responses did not match, last for value "b"`},
+		{false, []Response{ok, failed}, 1, 1, `1 of 2 responses did not match, last for value "c"`},
+		{false, []Response{ok, cancelled}, 1, 0, ""},
+		{false, nil, 0, 0, "no responses received"},
+		{false, []Response{cancelled}, 0, 0, "no responses received"},
+		{true, []Response{notFound, ok, failed}, 1, 2, ""},
+		{true, []Response{notFound, failed}, 0, 2, "none of the 2 responses matched"},
+		{true, nil, 0, 0, "no responses received"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			a, err := NewAssertion(`status == 200 and body contains "OK"`, test.any)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, res := range test.responses {
+				a.Record(res)
+			}
+
+			if a.Passed() != test.passed || a.Failed() != test.failed {
+				t.Errorf("wrong counts, want %d passed and %d failed, got %d and %d", test.passed, test.failed, a.Passed(), a.Failed())
+			}
+
+			err = a.Err()
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("expected error %q not returned", test.err)
+			case test.err != "" && err.Error() != test.err:
+				t.Errorf("wrong error, want %q, got %q", test.err, err)
+			}
+		})
+	}
+}
+
+func TestAssertionInvalid(t *testing.T) {
+	_, err := NewAssertion("status ==", false)
+	if err == nil {
+		t.Fatal("expected error not returned")
+	}
+}
@@ -0,0 +1,115 @@
+package response
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Modes for printing binary bodies with FormatBody.
+const (
+	BinaryHex    = "hex"    // hex dump with offsets and the printable characters
+	BinaryBase64 = "base64" // base64 with lines of 76 characters
+	BinarySkip   = "skip"   // only the size
+	BinaryRaw    = "raw"    // the data as it is
+)
+
+// BinaryModes are the supported modes for FormatBody.
+var BinaryModes = []string{BinaryHex, BinaryBase64, BinarySkip, BinaryRaw}
+
+// printable returns true if r can be printed to a terminal without changing
+// its state.
+func printable(r rune) bool {
+	return !unicode.IsControl(r) || r == '\t' || r == '\n' || r == '\r'
+}
+
+// IsBinary returns true if the body is not text which can be printed, i.e. it
+// is not valid UTF-8 or contains control characters other than tabs and line
+// breaks. An incomplete character at the end (e.g. for a body cut at the
+// maximum size) is ignored.
+func IsBinary(body []byte) bool {
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRune(body[i:])
+		if r == utf8.RuneError && size == 1 {
+			return utf8.FullRune(body[i:])
+		}
+
+		if !printable(r) {
+			return true
+		}
+
+		i += size
+	}
+
+	return false
+}
+
+// FormatBody returns the body for printing to a terminal. Text is returned
+// unchanged, binary bodies (see IsBinary) are converted according to mode.
+func FormatBody(body []byte, mode string) []byte {
+	if mode == BinaryRaw || !IsBinary(body) {
+		return body
+	}
+
+	switch mode {
+	case BinaryBase64:
+		s := base64.StdEncoding.EncodeToString(body)
+		var buf bytes.Buffer
+		for len(s) > 76 {
+			buf.WriteString(s[:76] + "\n")
+			s = s[76:]
+		}
+		buf.WriteString(s + "\n")
+		return buf.Bytes()
+	case BinarySkip:
+		return []byte(fmt.Sprintf("[binary body, %d bytes]\n", len(body)))
+	default:
+		return []byte(hex.Dump(body))
+	}
+}
+
+// Sanitize replaces control characters (except tabs) and invalid UTF-8 in s
+// with escape sequences like \x1b, so that it can be printed to a terminal.
+func Sanitize(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, `\x%02x`, s[i])
+		case r == '\t' || !unicode.IsControl(r):
+			sb.WriteRune(r)
+		case r < 0x80:
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		default:
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		}
+		i += size
+	}
+
+	return sb.String()
+}
+
+// SanitizeHeader returns the raw header with each line sanitized (see
+// Sanitize), the line breaks are kept.
+func SanitizeHeader(header []byte) []byte {
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(header, []byte("\n")) {
+		var end []byte
+		switch {
+		case bytes.HasSuffix(line, []byte("\r\n")):
+			end = line[len(line)-2:]
+		case bytes.HasSuffix(line, []byte("\n")):
+			end = line[len(line)-1:]
+		}
+
+		buf.WriteString(Sanitize(string(line[:len(line)-len(end)])))
+		buf.Write(end)
+	}
+
+	return buf.Bytes()
+}
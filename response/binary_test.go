@@ -0,0 +1,90 @@
+package response
+
+import (
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	var tests = []struct {
+		body   string
+		binary bool
+	}{
+		{"", false},
+		{"<html>\r\n\t<body>hello</body>\n</html>", false},
+		{"ümlauts and emoji 🙂", false},
+		// cut in the middle of a character
+		{"ümlaut"[:1], false},
+		{"\x89PNG\r\n\x1a\n", true},
+		{"text with \x00 inside", true},
+		{"colored \x1b[31mtext", true},
+		{"invalid \xff\xfe utf-8", true},
+		{"c1 control \u0085", true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if b := IsBinary([]byte(test.body)); b != test.binary {
+				t.Errorf("wrong result for %q, want %v, got %v", test.body, test.binary, b)
+			}
+		})
+	}
+}
+
+func TestFormatBody(t *testing.T) {
+	var tests = []struct {
+		body string
+		mode string
+		want string
+	}{
+		{"just text\n", BinaryHex, "just text\n"},
+		{"just text\n", BinarySkip, "just text\n"},
+		{"\x00\x01AB", BinaryHex, "00000000  00 01 41 42                                       |..AB|\n"},
+		{"\x00\x01AB", BinaryBase64, "AAFBQg==\n"},
+		{"\x00\x01AB", BinarySkip, "[binary body, 4 bytes]\n"},
+		{"\x00\x01AB", BinaryRaw, "\x00\x01AB"},
+		{
+			string(make([]byte, 60)),
+			BinaryBase64,
+			"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA\nAAAA\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if s := string(FormatBody([]byte(test.body), test.mode)); s != test.want {
+				t.Errorf("wrong output, want %q, got %q", test.want, s)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	var tests = []struct {
+		s    string
+		want string
+	}{
+		{"text/html; charset=utf-8", "text/html; charset=utf-8"},
+		{"a\tb", "a\tb"},
+		{"\x1b]0;pwned\x07", `\x1b]0;pwned\x07`},
+		{"bad \xff byte", `bad \xff byte`},
+		{"next\u0085line", `next\u0085line`},
+		{"ümlaut", "ümlaut"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if s := Sanitize(test.s); s != test.want {
+				t.Errorf("wrong result for %q, want %q, got %q", test.s, test.want, s)
+			}
+		})
+	}
+}
+
+func TestSanitizeHeader(t *testing.T) {
+	header := "HTTP/1.1 200 OK\r\nX-Evil: \x1b[2J\r\nServer: test\n\r\n"
+	want := "HTTP/1.1 200 OK\r\nX-Evil: \\x1b[2J\r\nServer: test\n\r\n"
+
+	if s := string(SanitizeHeader([]byte(header))); s != want {
+		t.Errorf("wrong header, want %q, got %q", want, s)
+	}
+}
@@ -0,0 +1,76 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBudgetExhausted is returned for requests which were not sent because the
+// maximum number of requests has been reached.
+var ErrBudgetExhausted = errors.New("maximum number of requests reached")
+
+// Budget limits the number of HTTP requests sent by all runners, this includes
+// retries and redirects.
+type Budget struct {
+	Max int64
+
+	sent int64
+
+	once      sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (b *Budget) init() {
+	b.once.Do(func() {
+		b.done = make(chan struct{})
+	})
+}
+
+// Take reserves a request, ErrBudgetExhausted is returned if no request is
+// left.
+func (b *Budget) Take() error {
+	sent := atomic.AddInt64(&b.sent, 1)
+	if sent >= b.Max {
+		b.init()
+		b.closeOnce.Do(func() {
+			close(b.done)
+		})
+	}
+
+	if sent > b.Max {
+		return ErrBudgetExhausted
+	}
+
+	return nil
+}
+
+// Done returns a channel which is closed when no request is left, so that
+// runners waiting for the next value can stop.
+func (b *Budget) Done() <-chan struct{} {
+	b.init()
+	return b.done
+}
+
+// Exhausted returns true if no request is left.
+func (b *Budget) Exhausted() bool {
+	return atomic.LoadInt64(&b.sent) >= b.Max
+}
+
+// budgetTransport takes a request from the budget before each request is
+// sent, so redirects are counted as well.
+type budgetTransport struct {
+	http.RoundTripper
+	budget *Budget
+}
+
+func (t budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.budget.Take()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.RoundTripper.RoundTrip(req)
+}
@@ -0,0 +1,169 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+func TestBudget(t *testing.T) {
+	var tests = []struct {
+		values   int
+		max      int64
+		redirect bool
+		retries  int
+		want     int64 // number of requests received by the server
+	}{
+		{10, 20, false, 0, 10},
+		{100, 20, false, 0, 20},
+		{100, 20, false, 2, 20},
+		{5, 20, false, 2, 15},
+		{100, 21, true, 0, 21},
+		{5, 100, true, 0, 10},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var received int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt64(&received, 1)
+				if test.redirect && r.URL.Query().Get("redirected") == "" {
+					http.Redirect(w, r, r.URL.Path+"?redirected=1", http.StatusFound)
+					return
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the runners stop reading values when the budget is exhausted
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			values := test.values
+			input := make(chan string)
+			go func() {
+				defer close(input)
+				for i := 0; i < values; i++ {
+					select {
+					case input <- fmt.Sprintf("%d", i):
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+
+			output := make(chan Response)
+			budget := &Budget{Max: test.max}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				runner := NewRunner(tr, template, input, output)
+				runner.Budget = budget
+				if test.redirect {
+					runner.Client.CheckRedirect = nil
+				}
+				runner.MaxRetries = test.retries
+				runner.Retry = func(res Response) bool {
+					return res.Status() == http.StatusServiceUnavailable
+				}
+
+				wg.Add(1)
+				go func() {
+					runner.Run(ctx)
+					wg.Done()
+				}()
+			}
+
+			go func() {
+				wg.Wait()
+				close(output)
+			}()
+
+			for res := range output {
+				if res.Error != nil && !res.Cancelled() {
+					t.Errorf("unexpected error: %v", res.Error)
+				}
+			}
+
+			if received != test.want {
+				t.Errorf("wrong number of requests received, want %d, got %d", test.want, received)
+			}
+
+			if !budget.Exhausted() && test.want == test.max {
+				t.Errorf("budget is not exhausted")
+			}
+		})
+	}
+}
+
+func TestBudgetStopsWaiting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	template := request.New("")
+	template.URL = srv.URL + "/FUZZ"
+
+	tr, err := NewTransport(template, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the input is never closed, like for --follow
+	input := make(chan string, 2)
+	input <- "a"
+	input <- "b"
+
+	output := make(chan Response)
+	budget := &Budget{Max: 2}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		runner := NewRunner(tr, template, input, output)
+		runner.Budget = budget
+
+		wg.Add(1)
+		go func() {
+			runner.Run(context.Background())
+			wg.Done()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(output)
+	}()
+
+	timeout := time.After(5 * time.Second)
+	var responses int
+	for done := false; !done; {
+		select {
+		case _, ok := <-output:
+			if !ok {
+				done = true
+				break
+			}
+			responses++
+		case <-timeout:
+			t.Fatal("runners did not stop after the budget was exhausted")
+		}
+	}
+
+	if responses != 2 {
+		t.Errorf("wrong number of responses, want 2, got %d", responses)
+	}
+}
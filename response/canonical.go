@@ -0,0 +1,89 @@
+package response
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// indexFiles are the names of files which are commonly served for a
+// directory, "/dir/index.html" is therefore the same resource as "/dir".
+var indexFiles = []string{
+	"index.html",
+	"index.htm",
+	"index.php",
+	"index.asp",
+	"index.aspx",
+	"index.jsp",
+	"default.htm",
+	"default.html",
+	"default.asp",
+	"default.aspx",
+}
+
+// Canonicalize returns the canonical form of u: scheme and host are converted
+// to lower case, the default port is removed and the path is cleaned. Trailing
+// slashes and index files (e.g. index.html) are removed and the fragment is
+// dropped. If contentLocation is not empty, it is resolved relative to u and
+// used instead, since it names the resource which was actually returned.
+func Canonicalize(u *url.URL, contentLocation string) string {
+	if contentLocation != "" {
+		loc, err := u.Parse(strings.TrimSpace(contentLocation))
+		if err == nil {
+			u = loc
+		}
+	}
+
+	c := *u
+	c.Scheme = strings.ToLower(c.Scheme)
+	c.Host = strings.ToLower(c.Host)
+	c.Fragment = ""
+
+	if port := c.Port(); (c.Scheme == "http" && port == "80") || (c.Scheme == "https" && port == "443") {
+		c.Host = strings.TrimSuffix(c.Host, ":"+port)
+	}
+
+	// work on the escaped path so that encoded slashes are kept
+	p := c.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	p = path.Clean(p)
+
+	dir, file := path.Split(p)
+	for _, index := range indexFiles {
+		if strings.EqualFold(file, index) {
+			p = path.Clean(dir)
+			break
+		}
+	}
+
+	// path.Clean already removed the trailing slash for all paths except "/"
+	unescaped, err := url.PathUnescape(p)
+	if err != nil {
+		return c.String()
+	}
+	c.Path = unescaped
+	c.RawPath = p
+
+	return c.String()
+}
+
+// setCanonicalURL sets CanonicalURL for the response res which was received
+// for a request to the URL in r.URL. If the request was redirected, the URL of
+// the last request is used.
+func (r *Response) setCanonicalURL(res *http.Response) {
+	var u *url.URL
+	if res.Request != nil && res.Request.URL != nil {
+		u = res.Request.URL
+	} else {
+		var err error
+		u, err = url.Parse(r.URL)
+		if err != nil {
+			return
+		}
+	}
+
+	r.CanonicalURL = Canonicalize(u, res.Header.Get("Content-Location"))
+}
@@ -0,0 +1,47 @@
+package response
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	var tests = []struct {
+		url             string
+		contentLocation string
+		want            string
+	}{
+		{"https://example.com/admin", "", "https://example.com/admin"},
+		{"https://example.com/admin/", "", "https://example.com/admin"},
+		{"https://example.com", "", "https://example.com/"},
+		{"https://example.com/", "", "https://example.com/"},
+		{"HTTPS://Example.COM:443/admin/", "", "https://example.com/admin"},
+		{"http://example.com:80/admin", "", "http://example.com/admin"},
+		{"http://example.com:8080/admin", "", "http://example.com:8080/admin"},
+		{"https://example.com:80/admin", "", "https://example.com:80/admin"},
+		{"https://example.com/admin/index.html", "", "https://example.com/admin"},
+		{"https://example.com/admin/Index.PHP", "", "https://example.com/admin"},
+		{"https://example.com/index.html", "", "https://example.com/"},
+		{"https://example.com/admin/index.html.bak", "", "https://example.com/admin/index.html.bak"},
+		{"https://example.com/a//b/../c/./", "", "https://example.com/a/c"},
+		{"https://example.com/admin?x=1#foo", "", "https://example.com/admin?x=1"},
+		{"https://example.com/foo%2fbar/", "", "https://example.com/foo%2fbar"},
+		{"https://example.com/admin/", "index.php", "https://example.com/admin"},
+		{"https://example.com/admin/", "/other/", "https://example.com/other"},
+		{"https://example.com/a", "https://cdn.example.com/a/", "https://cdn.example.com/a"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			u, err := url.Parse(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := Canonicalize(u, test.contentLocation)
+			if got != test.want {
+				t.Errorf("Canonicalize(%q, %q): want %q, got %q", test.url, test.contentLocation, test.want, got)
+			}
+		})
+	}
+}
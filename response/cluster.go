@@ -0,0 +1,78 @@
+package response
+
+import "sort"
+
+// Cluster is a group of similar responses.
+type Cluster struct {
+	Status           int    // the status code (or synthetic status code) of all responses
+	Simhash          uint64 // the fingerprint of the first response
+	MinSize, MaxSize int    // the range of body sizes
+	Count            int
+	Example          string // the value of the first response
+}
+
+// Clusters groups the responses recorded with Add by shape: responses belong
+// to the same cluster if they have the same status code and the Hamming
+// distance between the fingerprint of the body (see Simhash) and the one of
+// the first response in the cluster is at most Distance. Bodies without any
+// words (e.g. empty ones) have the fingerprint zero and are only grouped with
+// each other. Cancelled responses are ignored.
+type Clusters struct {
+	Distance int
+
+	clusters []*Cluster
+}
+
+// Add records r in the first matching cluster, or in a new one.
+func (c *Clusters) Add(r Response) {
+	if r.Cancelled() {
+		return
+	}
+
+	status := r.Status()
+	for _, cl := range c.clusters {
+		if cl.Status != status {
+			continue
+		}
+
+		if (cl.Simhash == 0) != (r.Simhash == 0) || HammingDistance(cl.Simhash, r.Simhash) > c.Distance {
+			continue
+		}
+
+		cl.Count++
+		if r.Body.Bytes < cl.MinSize {
+			cl.MinSize = r.Body.Bytes
+		}
+		if r.Body.Bytes > cl.MaxSize {
+			cl.MaxSize = r.Body.Bytes
+		}
+		return
+	}
+
+	c.clusters = append(c.clusters, &Cluster{
+		Status:  status,
+		Simhash: r.Simhash,
+		MinSize: r.Body.Bytes,
+		MaxSize: r.Body.Bytes,
+		Count:   1,
+		Example: r.Item,
+	})
+}
+
+// List returns the clusters, the largest first. Clusters of the same size
+// are sorted by status code, then in the order they were created.
+func (c *Clusters) List() []Cluster {
+	list := make([]Cluster, 0, len(c.clusters))
+	for _, cl := range c.clusters {
+		list = append(list, *cl)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Status < list[j].Status
+	})
+
+	return list
+}
@@ -0,0 +1,48 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClusters(t *testing.T) {
+	res := func(item string, status int, size int, simhash uint64) Response {
+		return Response{
+			Item:         item,
+			HTTPResponse: &http.Response{StatusCode: status},
+			Body:         TextStats{Bytes: size},
+			Simhash:      simhash,
+		}
+	}
+
+	responses := []Response{
+		res("a", 404, 100, 0xff00),
+		res("b", 200, 50, 0xff00),
+		res("c", 404, 110, 0xff01),
+		res("d", 404, 90, 0xf00f),
+		res("e", 404, 0, 0),
+		res("f", 404, 0, 0),
+		res("g", 404, 105, 0xff03),
+		{Item: "h", Error: context.Canceled},
+	}
+
+	c := &Clusters{Distance: 2}
+	for _, r := range responses {
+		c.Add(r)
+	}
+
+	want := []Cluster{
+		{Status: 404, Simhash: 0xff00, MinSize: 100, MaxSize: 110, Count: 3, Example: "a"},
+		{Status: 404, Simhash: 0, MinSize: 0, MaxSize: 0, Count: 2, Example: "e"},
+		{Status: 200, Simhash: 0xff00, MinSize: 50, MaxSize: 50, Count: 1, Example: "b"},
+		{Status: 404, Simhash: 0xf00f, MinSize: 90, MaxSize: 90, Count: 1, Example: "d"},
+	}
+
+	list := c.List()
+	if !cmp.Equal(want, list) {
+		t.Error(cmp.Diff(want, list))
+	}
+}
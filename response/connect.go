@@ -0,0 +1,119 @@
+package response
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// connectProxy returns the proxy configured in the environment for requests
+// with the scheme. Unlike http.ProxyFromEnvironment, it does not depend on the
+// target, so NO_PROXY is not evaluated and loopback addresses can be reached
+// through the proxy.
+func connectProxy(scheme string) (*url.URL, error) {
+	names := []string{"HTTP_PROXY", "http_proxy"}
+	if scheme == "https" {
+		names = append([]string{"HTTPS_PROXY", "https_proxy"}, names...)
+	}
+
+	for _, name := range names {
+		s := os.Getenv(name)
+		if s == "" {
+			continue
+		}
+
+		if !strings.Contains(s, "://") {
+			s = "http://" + s
+		}
+
+		proxyURL, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy in %v: %v", name, err)
+		}
+
+		if proxyURL.Scheme != "http" {
+			return nil, fmt.Errorf("invalid proxy in %v: only http proxies support CONNECT", name)
+		}
+
+		if proxyURL.Port() == "" {
+			proxyURL.Host = net.JoinHostPort(proxyURL.Hostname(), "80")
+		}
+
+		return proxyURL, nil
+	}
+
+	return nil, errors.New("no proxy configured in HTTP_PROXY or HTTPS_PROXY")
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// connectDialer returns a function which connects to proxyURL with dial and
+// establishes a tunnel to target with a CONNECT request. The address passed
+// to the returned function is ignored, all connections go to target.
+func connectDialer(dial dialFunc, proxyURL *url.URL, target string) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, proxyURL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		err = connect(ctx, conn, proxyURL, target)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		return conn, nil
+	}
+}
+
+// connect sends a CONNECT request for target over conn and reads the
+// response, which must have a 2xx status code.
+func connect(ctx context.Context, conn net.Conn, proxyURL *url.URL, target string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() {
+			_ = conn.SetDeadline(time.Time{})
+		}()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		req.SetBasicAuth(proxyURL.User.Username(), password)
+		req.Header["Proxy-Authorization"] = req.Header["Authorization"]
+		delete(req.Header, "Authorization")
+	}
+
+	err := req.Write(conn)
+	if err != nil {
+		return fmt.Errorf("send CONNECT to %v: %v", proxyURL.Host, err)
+	}
+
+	// the proxy must not send any data after the response before the client
+	// does, so nothing of the tunneled connection is buffered here
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return fmt.Errorf("read response to CONNECT from %v: %v", proxyURL.Host, err)
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("CONNECT to %v via %v failed: %v", target, proxyURL.Host, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package response
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+// startConnectProxy starts a proxy which handles CONNECT requests, answering
+// with status and establishing the tunnel to the requested target for 200.
+// The targets are sent to the returned channel.
+func startConnectProxy(t testing.TB, status int) (addr string, targets <-chan string, stop func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan string, 10)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+				ch <- req.Host
+
+				res := &http.Response{StatusCode: status, ProtoMajor: 1, ProtoMinor: 1}
+				if res.Write(conn) != nil || status != http.StatusOK {
+					return
+				}
+
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+
+				go func() {
+					_, _ = io.Copy(target, conn)
+				}()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+
+	return l.Addr().String(), ch, func() { _ = l.Close() }
+}
+
+func TestConnect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "host "+r.Host)
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		status  int
+		raw     bool
+		wantErr string
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusOK, raw: true},
+		{status: http.StatusForbidden, wantErr: "403 Forbidden"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			proxyAddr, targets, stop := startConnectProxy(t, test.status)
+			defer stop()
+
+			oldProxy := os.Getenv("HTTP_PROXY")
+			_ = os.Setenv("HTTP_PROXY", proxyAddr)
+			defer func() {
+				_ = os.Setenv("HTTP_PROXY", oldProxy)
+			}()
+
+			// the host name does not exist, the connection is made to the
+			// address passed to --connect
+			template := request.New("")
+			template.URL = "http://internal.invalid/FUZZ"
+			template.Connect = strings.TrimPrefix(srv.URL, "http://")
+			template.RandomizeHeaders = test.raw
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.Run(context.Background())
+
+			res := <-output
+
+			if target := <-targets; target != template.Connect {
+				t.Errorf("wrong CONNECT target, want %v, got %v", template.Connect, target)
+			}
+
+			if test.wantErr != "" {
+				if res.Error == nil || !strings.Contains(res.Error.Error(), test.wantErr) {
+					t.Fatalf("wrong error, want %q, got %v", test.wantErr, res.Error)
+				}
+				return
+			}
+
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			want := "host internal.invalid"
+			if string(res.RawBody) != want {
+				t.Errorf("wrong body, want %q, got %q", want, res.RawBody)
+			}
+		})
+	}
+}
+
+func TestConnectProxy(t *testing.T) {
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+		old, ok := os.LookupEnv(name)
+		_ = os.Unsetenv(name)
+		if ok {
+			defer os.Setenv(name, old)
+		}
+	}
+
+	_, err := connectProxy("https")
+	if err == nil {
+		t.Fatal("expected error for missing proxy not returned")
+	}
+
+	_ = os.Setenv("HTTP_PROXY", "proxy.example.com")
+	defer os.Unsetenv("HTTP_PROXY")
+
+	proxyURL, err := connectProxy("https")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if proxyURL.Host != "proxy.example.com:80" {
+		t.Errorf("wrong proxy, want proxy.example.com:80, got %v", proxyURL.Host)
+	}
+
+	_ = os.Setenv("HTTPS_PROXY", "socks5://proxy.example.com:1080")
+	defer os.Unsetenv("HTTPS_PROXY")
+
+	_, err = connectProxy("https")
+	if err == nil {
+		t.Fatal("expected error for socks5 proxy not returned")
+	}
+}
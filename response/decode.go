@@ -0,0 +1,153 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decoder returns a reader which decodes the data read from rd with the
+// content encoding. The encodings gzip, deflate (with and without zlib
+// header) and br (Brotli) are supported. If the encoding is not supported or
+// the data is invalid, ok is false.
+func decoder(encoding string, rd io.Reader) (dec io.Reader, ok bool) {
+	var err error
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		dec, err = gzip.NewReader(rd)
+	case "deflate":
+		// most servers send the zlib format as specified, but some send raw
+		// deflate data, so look at the header before deciding
+		br := bufio.NewReader(rd)
+		if hdr, _ := br.Peek(2); len(hdr) == 2 && hdr[0]&0x0f == 8 && (uint(hdr[0])<<8|uint(hdr[1]))%31 == 0 {
+			dec, err = zlib.NewReader(br)
+		} else {
+			dec = flate.NewReader(br)
+		}
+	case "br":
+		dec = brotli.NewReader(rd)
+	default:
+		return nil, false
+	}
+
+	if err != nil {
+		return nil, false
+	}
+
+	return dec, true
+}
+
+// supportedEncodings returns true if decoder supports all encodings.
+func supportedEncodings(encodings []string) bool {
+	for _, enc := range encodings {
+		switch strings.ToLower(strings.TrimSpace(enc)) {
+		case "gzip", "x-gzip", "deflate", "br", "identity":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decompress returns the data decoded with the content encoding (see
+// decoder). If the encoding is not supported, ok is false.
+func decompress(encoding string, data []byte, maxBodySize int) (buf []byte, ok bool) {
+	rd, ok := decoder(encoding, bytes.NewReader(data))
+	if !ok {
+		return nil, false
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(rd, int64(maxBodySize)))
+	// the compressed data may have been truncated at maxBodySize, use what
+	// could be decoded in this case
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, false
+	}
+
+	return buf, true
+}
+
+// DecompressBody decodes the body read by ReadBody according to encoding,
+// which is the value of the Content-Encoding header. Several encodings can be
+// given separated by commas, they are removed in reverse order. The size of the
+// body as received is saved in CompressedBodySize and the statistics for the
+// body are computed from the decompressed data. At most maxBodySize bytes are
+// decompressed. If an encoding is not supported (e.g. zstd) or the data is
+// invalid, the body is left unchanged.
+func (r *Response) DecompressBody(encoding string, maxBodySize int) error {
+	if encoding == "" {
+		return nil
+	}
+
+	encodings := strings.Split(encoding, ",")
+	buf := r.RawBody
+	decoded := false
+	for i := len(encodings) - 1; i >= 0; i-- {
+		if strings.TrimSpace(encodings[i]) == "identity" {
+			continue
+		}
+
+		var ok bool
+		buf, ok = decompress(encodings[i], buf, maxBodySize)
+		if !ok {
+			return nil
+		}
+		decoded = true
+	}
+
+	if !decoded {
+		return nil
+	}
+
+	r.CompressedBodySize = len(r.RawBody)
+	r.RawBody = buf
+
+	var err error
+	r.Body, err = Count(bytes.NewReader(r.RawBody))
+	return err
+}
+
+// RawResponse returns the raw header followed by the body. If the body has
+// been decompressed (see DecompressBody), the Content-Encoding header is
+// removed and the Content-Length header is set to the size of the decoded
+// body, so that the header matches the body which follows it.
+func (r *Response) RawResponse() []byte {
+	if r.CompressedBodySize == 0 {
+		return append(append([]byte(nil), r.RawHeader...), r.RawBody...)
+	}
+
+	var buf bytes.Buffer
+	for i, line := range bytes.SplitAfter(r.RawHeader, []byte("\n")) {
+		name := ""
+		if i > 0 {
+			if j := bytes.IndexByte(line, ':'); j > 0 {
+				name = strings.ToLower(strings.TrimSpace(string(line[:j])))
+			}
+		}
+
+		switch name {
+		case "content-encoding":
+			continue
+		case "content-length":
+			end := "\n"
+			if bytes.HasSuffix(line, []byte("\r\n")) {
+				end = "\r\n"
+			}
+			fmt.Fprintf(&buf, "%s: %d%s", line[:bytes.IndexByte(line, ':')], len(r.RawBody), end)
+		default:
+			buf.Write(line)
+		}
+	}
+
+	buf.Write(r.RawBody)
+	return buf.Bytes()
+}
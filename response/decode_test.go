@@ -0,0 +1,211 @@
+package response
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/andybalholm/brotli"
+	"github.com/google/go-cmp/cmp"
+)
+
+func compress(t testing.TB, encoding string, data string) []byte {
+	var buf bytes.Buffer
+	var wr io.WriteCloser
+	var err error
+
+	switch encoding {
+	case "gzip":
+		wr = gzip.NewWriter(&buf)
+	case "zlib":
+		wr = zlib.NewWriter(&buf)
+	case "flate":
+		wr, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "br":
+		wr = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+
+	_, err = wr.Write([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	const body = "foo bar\nbaz\n"
+	gzipped := compress(t, "gzip", body)
+	zlibbed := compress(t, "zlib", body)
+	deflated := compress(t, "flate", body)
+	nested := compress(t, "gzip", string(zlibbed))
+	brotlied := compress(t, "br", body)
+
+	var tests = []struct {
+		encoding   string
+		body       []byte
+		maxSize    int
+		want       string
+		compressed int
+	}{
+		{"", []byte(body), 1024, body, 0},
+		{"identity", []byte(body), 1024, body, 0},
+		{"gzip", gzipped, 1024, body, len(gzipped)},
+		{"GZIP ", gzipped, 1024, body, len(gzipped)},
+		{"x-gzip", gzipped, 1024, body, len(gzipped)},
+		{"gzip", gzipped, 5, "foo b", len(gzipped)},
+		{"deflate", zlibbed, 1024, body, len(zlibbed)},
+		{"deflate", deflated, 1024, body, len(deflated)},
+		{"deflate, gzip", nested, 1024, body, len(nested)},
+		{"br", brotlied, 1024, body, len(brotlied)},
+		{"BR", brotlied, 5, "foo b", len(brotlied)},
+		// truncated data (here without the gzip trailer) is decoded as far as possible
+		{"gzip", gzipped[:len(gzipped)-8], 1024, body, len(gzipped) - 8},
+		// unsupported encodings and invalid data are left unchanged
+		{"zstd", []byte(body), 1024, body, 0},
+		{"gzip", []byte(body), 1024, body, 0},
+		{"br", []byte(body), 1024, body, 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var r Response
+			err := r.ReadBody(bytes.NewReader(test.body), 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.DecompressBody(test.encoding, test.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.want, string(r.RawBody)) {
+				t.Error(cmp.Diff(test.want, string(r.RawBody)))
+			}
+
+			if r.Body.Bytes != len(test.want) {
+				t.Errorf("wrong body size, want %d, got %d", len(test.want), r.Body.Bytes)
+			}
+
+			if r.CompressedBodySize != test.compressed {
+				t.Errorf("wrong compressed size, want %d, got %d", test.compressed, r.CompressedBodySize)
+			}
+		})
+	}
+}
+
+func TestRunnerDecompress(t *testing.T) {
+	const body = "foo bar baz"
+	gzipped := compress(t, "gzip", body)
+
+	var tests = []struct {
+		noDecompress bool
+		body         string
+		compressed   int
+	}{
+		{false, body, len(gzipped)},
+		{true, string(gzipped), 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != "gzip" {
+					t.Errorf("wrong Accept-Encoding header %q", r.Header.Get("Accept-Encoding"))
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				_, _ = w.Write(gzipped)
+			}))
+			defer srv.Close()
+
+			template := request.New("")
+			template.URL = srv.URL
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.NoDecompress = test.noDecompress
+			runner.Run(context.Background())
+
+			res := <-output
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			if string(res.RawBody) != test.body {
+				t.Errorf("wrong body, want %q, got %q", test.body, res.RawBody)
+			}
+
+			if res.CompressedBodySize != test.compressed {
+				t.Errorf("wrong compressed size, want %d, got %d", test.compressed, res.CompressedBodySize)
+			}
+		})
+	}
+}
+
+func TestRawResponse(t *testing.T) {
+	var tests = []struct {
+		res  Response
+		want string
+	}{
+		{
+			Response{
+				RawHeader: []byte("HTTP/1.1 200 OK\r\nContent-Length: 3\r\nContent-Type: text/plain\r\n\r\n"),
+				RawBody:   []byte("foo"),
+			},
+			"HTTP/1.1 200 OK\r\nContent-Length: 3\r\nContent-Type: text/plain\r\n\r\nfoo",
+		},
+		{
+			Response{
+				RawHeader:          []byte("HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\ncontent-length: 31\r\nContent-Type: text/plain\r\n\r\n"),
+				RawBody:            []byte("foo bar baz"),
+				CompressedBodySize: 31,
+			},
+			"HTTP/1.1 200 OK\r\ncontent-length: 11\r\nContent-Type: text/plain\r\n\r\nfoo bar baz",
+		},
+		{
+			// no Content-Length header and bare line feeds
+			Response{
+				RawHeader:          []byte("HTTP/1.1 200 OK\nContent-Encoding: br\nServer: test\n\n"),
+				RawBody:            []byte("foo"),
+				CompressedBodySize: 7,
+			},
+			"HTTP/1.1 200 OK\nServer: test\n\nfoo",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got := string(test.res.RawResponse())
+			if got != test.want {
+				t.Errorf("wrong response, want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
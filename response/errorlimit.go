@@ -0,0 +1,62 @@
+package response
+
+import "fmt"
+
+// ErrorLimit decides when a run should be aborted because too many requests
+// failed, e.g. because the target is not reachable any more. Only network
+// errors are counted (not cancelled or blocked requests), and if ServerErrors
+// is set also responses with a status code of at least 500. A limit of zero
+// disables the check.
+type ErrorLimit struct {
+	Max            int // maximum number of errors in total
+	MaxConsecutive int // maximum number of errors in a row
+	ServerErrors   bool
+
+	errors, consecutive int
+}
+
+// isError returns true if res should be counted as an error.
+func (l *ErrorLimit) isError(res Response) bool {
+	if res.Cancelled() {
+		return false
+	}
+
+	if _, blocked := res.BlockedHost(); blocked {
+		return false
+	}
+
+	if res.Error != nil {
+		return true
+	}
+
+	return l.ServerErrors && res.HTTPResponse != nil && res.HTTPResponse.StatusCode >= 500
+}
+
+// Record counts the response. If a limit is exceeded an error describing why
+// the run should be aborted is returned.
+func (l *ErrorLimit) Record(res Response) error {
+	if !l.isError(res) {
+		l.consecutive = 0
+		return nil
+	}
+
+	l.errors++
+	l.consecutive++
+
+	if l.MaxConsecutive > 0 && l.consecutive >= l.MaxConsecutive {
+		return fmt.Errorf("%d requests in a row failed, last error for value %q: %v", l.consecutive, res.Item, describeError(res))
+	}
+
+	if l.Max > 0 && l.errors >= l.Max {
+		return fmt.Errorf("%d requests failed, last error for value %q: %v", l.errors, res.Item, describeError(res))
+	}
+
+	return nil
+}
+
+func describeError(res Response) string {
+	if res.Error != nil {
+		return res.Error.Error()
+	}
+	return "status " + res.HTTPResponse.Status
+}
@@ -0,0 +1,50 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestErrorLimit(t *testing.T) {
+	ok := Response{HTTPResponse: &http.Response{StatusCode: 200, Status: "200 OK"}}
+	serverError := Response{HTTPResponse: &http.Response{StatusCode: 503, Status: "503 Service Unavailable"}}
+	failed := Response{Error: wrapURLError(errors.New("connection refused"))}
+	cancelled := Response{Error: wrapURLError(context.Canceled)}
+	blocked := Response{Error: &HostBlockedError{Host: "example.com"}}
+
+	var tests = []struct {
+		limit     ErrorLimit
+		responses []Response
+		abortAt   int // index of the response after which the run is aborted, -1 for none
+	}{
+		{ErrorLimit{}, []Response{failed, failed, failed}, -1},
+		{ErrorLimit{Max: 2}, []Response{failed, ok, failed, ok}, 2},
+		{ErrorLimit{Max: 3}, []Response{failed, ok, failed, ok}, -1},
+		{ErrorLimit{MaxConsecutive: 2}, []Response{failed, ok, failed, ok}, -1},
+		{ErrorLimit{MaxConsecutive: 2}, []Response{failed, ok, failed, failed}, 3},
+		{ErrorLimit{Max: 1}, []Response{cancelled, blocked, ok}, -1},
+		{ErrorLimit{Max: 1}, []Response{serverError, ok}, -1},
+		{ErrorLimit{Max: 1, ServerErrors: true}, []Response{ok, serverError}, 1},
+		{ErrorLimit{MaxConsecutive: 2, ServerErrors: true}, []Response{serverError, failed}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			limit := test.limit
+			abortAt := -1
+			for i, res := range test.responses {
+				err := limit.Record(res)
+				if err != nil {
+					abortAt = i
+					break
+				}
+			}
+
+			if abortAt != test.abortAt {
+				t.Errorf("wrong abort position, want %d, got %d", test.abortAt, abortAt)
+			}
+		})
+	}
+}
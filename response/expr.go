@@ -0,0 +1,404 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FilterExpression hides all responses for which the expression does not
+// match.
+type FilterExpression struct {
+	expr func(Response) bool
+}
+
+// NewFilterExpression parses the expression s and returns a filter.
+func NewFilterExpression(s string) (FilterExpression, error) {
+	expr, err := ParseExpression(s)
+	if err != nil {
+		return FilterExpression{}, err
+	}
+
+	return FilterExpression{expr: expr}, nil
+}
+
+// Reject decides if r is to be printed.
+func (f FilterExpression) Reject(r Response) bool {
+	return !f.expr(r)
+}
+
+// Name returns a short description of the filter.
+func (f FilterExpression) Name() string {
+	return "expression (--match-expr)"
+}
+
+// ExpressionHelp describes the syntax for expressions.
+const ExpressionHelp = `
+An expression compares fields of the response with values, and combines
+comparisons with "and", "or", "not" and parentheses. Numeric fields are
+status, size (body bytes), words, lines, header_size, duration and ttfb (time
+to first byte), durations are written like 1.5s or 200ms. They support
+== != < <= > >=. String fields are body, header (all raw headers),
+header["Name"] (the value of a single header) and trailer["Name"] (the value of
+a trailer sent after the body, e.g. trailer["Grpc-Status"]), which support ==
+!= contains and matches (regular expression). Strings are quoted with single or
+double quotes. Example:
+
+    status == 200 and (body contains "admin" or header["Server"] matches "(?i)nginx")
+`
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenLeftBracket
+	tokenRightBracket
+)
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.val)
+}
+
+func tokenize(s string) (tokens []token, err error) {
+	for pos := 0; pos < len(s); {
+		c := s[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c == '(':
+			tokens = append(tokens, token{tokenLeftParen, "(", pos})
+			pos++
+		case c == ')':
+			tokens = append(tokens, token{tokenRightParen, ")", pos})
+			pos++
+		case c == '[':
+			tokens = append(tokens, token{tokenLeftBracket, "[", pos})
+			pos++
+		case c == ']':
+			tokens = append(tokens, token{tokenRightBracket, "]", pos})
+			pos++
+		case c == '"' || c == '\'':
+			start := pos
+			pos++
+			var val strings.Builder
+			for pos < len(s) && s[pos] != c {
+				if s[pos] == '\\' && pos+1 < len(s) {
+					pos++
+				}
+				val.WriteByte(s[pos])
+				pos++
+			}
+			if pos >= len(s) {
+				return nil, fmt.Errorf("parse error at position %d: unterminated string", start)
+			}
+			pos++
+			tokens = append(tokens, token{tokenString, val.String(), start})
+		case strings.ContainsRune("=!<>&|", rune(c)):
+			start := pos
+			for pos < len(s) && strings.ContainsRune("=!<>&|", rune(s[pos])) {
+				pos++
+			}
+			tokens = append(tokens, token{tokenOperator, s[start:pos], start})
+		case c >= '0' && c <= '9' || c == '.':
+			start := pos
+			for pos < len(s) && (s[pos] == '.' || unicode.IsLetter(rune(s[pos])) || unicode.IsDigit(rune(s[pos]))) {
+				pos++
+			}
+			tokens = append(tokens, token{tokenNumber, s[start:pos], start})
+		case unicode.IsLetter(rune(c)) || c == '_':
+			start := pos
+			for pos < len(s) && (s[pos] == '_' || unicode.IsLetter(rune(s[pos])) || unicode.IsDigit(rune(s[pos]))) {
+				pos++
+			}
+			tokens = append(tokens, token{tokenIdent, s[start:pos], start})
+		default:
+			return nil, fmt.Errorf("parse error at position %d: unexpected character %q", pos, c)
+		}
+	}
+
+	tokens = append(tokens, token{tokenEOF, "", len(s)})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, msg string, args ...interface{}) error {
+	return fmt.Errorf("parse error at position %d: %s", t.pos, fmt.Sprintf(msg, args...))
+}
+
+func isKeyword(t token, words ...string) bool {
+	for _, w := range words {
+		if (t.typ == tokenIdent || t.typ == tokenOperator) && strings.ToLower(t.val) == w {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExpression parses an expression and returns a function which
+// evaluates it for a response.
+func ParseExpression(s string) (func(Response) bool, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().typ == tokenEOF {
+		return nil, p.errorf(p.peek(), "empty expression")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, p.errorf(t, "unexpected %v", t)
+	}
+
+	return expr, nil
+}
+
+func (p *parser) parseOr() (func(Response) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for isKeyword(p.peek(), "or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(r Response) bool { return l(r) || right(r) }
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (func(Response) bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for isKeyword(p.peek(), "and", "&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(r Response) bool { return l(r) && right(r) }
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (func(Response) bool, error) {
+	t := p.peek()
+
+	if isKeyword(t, "not", "!") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(r Response) bool { return !expr(r) }, nil
+	}
+
+	if t.typ == tokenLeftParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if t := p.next(); t.typ != tokenRightParen {
+			return nil, p.errorf(t, "expected \")\", got %v", t)
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+var numericFields = map[string]func(Response) float64{
+	"status":      func(r Response) float64 { return float64(r.Status()) },
+	"size":        func(r Response) float64 { return float64(r.Body.Bytes) },
+	"words":       func(r Response) float64 { return float64(r.Body.Words) },
+	"lines":       func(r Response) float64 { return float64(r.Body.Lines) },
+	"header_size": func(r Response) float64 { return float64(r.Header.Bytes) },
+	"duration":    func(r Response) float64 { return r.Duration.Seconds() },
+	"ttfb":        func(r Response) float64 { return r.TTFB.Seconds() },
+}
+
+var stringFields = map[string]func(Response) string{
+	"body":   func(r Response) string { return string(r.RawBody) },
+	"header": func(r Response) string { return string(r.RawHeader) },
+}
+
+func (p *parser) parseComparison() (func(Response) bool, error) {
+	t := p.next()
+	if t.typ != tokenIdent {
+		return nil, p.errorf(t, "expected field name, got %v", t)
+	}
+
+	name := strings.ToLower(t.val)
+
+	if (name == "header" && p.peek().typ == tokenLeftBracket) || name == "trailer" {
+		if t := p.next(); t.typ != tokenLeftBracket {
+			return nil, p.errorf(t, "expected \"[\", got %v", t)
+		}
+		hdr := p.next()
+		if hdr.typ != tokenString {
+			return nil, p.errorf(hdr, "expected quoted %v name, got %v", name, hdr)
+		}
+		if t := p.next(); t.typ != tokenRightBracket {
+			return nil, p.errorf(t, "expected \"]\", got %v", t)
+		}
+
+		key := http.CanonicalHeaderKey(hdr.val)
+		field := func(r Response) string {
+			if r.HTTPResponse == nil {
+				return ""
+			}
+			return strings.Join(r.HTTPResponse.Header[key], ", ")
+		}
+		if name == "trailer" {
+			field = func(r Response) string {
+				return strings.Join(r.Trailer[key], ", ")
+			}
+		}
+		return p.parseStringComparison(field)
+	}
+
+	if field, ok := numericFields[name]; ok {
+		return p.parseNumericComparison(name, field)
+	}
+
+	if field, ok := stringFields[name]; ok {
+		return p.parseStringComparison(field)
+	}
+
+	return nil, p.errorf(t, "unknown field %v", t)
+}
+
+func (p *parser) parseNumericComparison(name string, field func(Response) float64) (func(Response) bool, error) {
+	op := p.next()
+	if op.typ != tokenOperator {
+		return nil, p.errorf(op, "expected comparison operator for numeric field %q, got %v", name, op)
+	}
+
+	t := p.next()
+	if t.typ != tokenNumber {
+		return nil, p.errorf(t, "expected number for field %q, got %v", name, t)
+	}
+
+	var value float64
+	var err error
+	if name == "duration" || name == "ttfb" {
+		var d time.Duration
+		d, err = time.ParseDuration(t.val)
+		if err != nil {
+			// a plain number is interpreted as seconds
+			value, err = strconv.ParseFloat(t.val, 64)
+		} else {
+			value = d.Seconds()
+		}
+	} else {
+		value, err = strconv.ParseFloat(t.val, 64)
+	}
+	if err != nil {
+		return nil, p.errorf(t, "invalid number %v", t)
+	}
+
+	var cmp func(a, b float64) bool
+	switch op.val {
+	case "==":
+		cmp = func(a, b float64) bool { return a == b }
+	case "!=":
+		cmp = func(a, b float64) bool { return a != b }
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	default:
+		return nil, p.errorf(op, "unknown operator %v for numeric field %q", op, name)
+	}
+
+	return func(r Response) bool { return cmp(field(r), value) }, nil
+}
+
+func (p *parser) parseStringComparison(field func(Response) string) (func(Response) bool, error) {
+	op := p.next()
+	if op.typ != tokenOperator && op.typ != tokenIdent {
+		return nil, p.errorf(op, "expected operator, got %v", op)
+	}
+
+	t := p.next()
+	if t.typ != tokenString {
+		return nil, p.errorf(t, "expected quoted string, got %v", t)
+	}
+	value := t.val
+
+	switch strings.ToLower(op.val) {
+	case "==":
+		return func(r Response) bool { return field(r) == value }, nil
+	case "!=":
+		return func(r Response) bool { return field(r) != value }, nil
+	case "contains":
+		return func(r Response) bool { return strings.Contains(field(r), value) }, nil
+	case "matches":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, p.errorf(t, "regexp %q failed to compile: %v", value, err)
+		}
+		return func(r Response) bool { return re.MatchString(field(r)) }, nil
+	default:
+		return nil, p.errorf(op, "unknown operator %v for string field", op)
+	}
+}
@@ -0,0 +1,107 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpression(t *testing.T) {
+	res := Response{
+		HTTPResponse: &http.Response{
+			StatusCode: 200,
+			Header: http.Header{
+				"Server": []string{"nginx/1.2.3"},
+			},
+		},
+		RawHeader: []byte("HTTP/1.1 200 OK\r\nServer: nginx/1.2.3\r\n\r\n"),
+		RawBody:   []byte("welcome admin\n"),
+		Header:    TextStats{Bytes: 41},
+		Body:      TextStats{Bytes: 14, Words: 2, Lines: 1},
+		Duration:  1500 * time.Millisecond,
+		TTFB:      1200 * time.Millisecond,
+		Trailer:   http.Header{"Grpc-Status": []string{"5"}},
+	}
+
+	var tests = []struct {
+		expr   string
+		result bool
+	}{
+		{`status == 200`, true},
+		{`status != 200`, false},
+		{`status >= 200 and status < 300`, true},
+		{`status == 404 or size == 14`, true},
+		{`status == 404 || size == 13`, false},
+		{`not status == 404`, true},
+		{`!(status == 200 && words == 2)`, false},
+		{`lines == 1 and header_size == 41`, true},
+		{`duration > 1s`, true},
+		{`duration > 1.5`, false},
+		{`duration <= 1500ms`, true},
+		{`ttfb > 1s`, true},
+		{`ttfb <= 1500ms`, true},
+		{`body contains "admin"`, true},
+		{`body contains 'root'`, false},
+		{`body matches "^wel.*n$"`, false},
+		{`body matches "(?m)^wel.*n$"`, true},
+		{`header contains "nginx"`, true},
+		{`header["server"] == "nginx/1.2.3"`, true},
+		{`header["X-Foo"] == ""`, true},
+		{`header["Server"] matches "(?i)NGINX" and (status == 500 or body contains "admin")`, true},
+		{`STATUS == 200 AND Body CONTAINS "welcome"`, true},
+		{`trailer["grpc-status"] == "5"`, true},
+		{`trailer["Grpc-Status"] != "0" and trailer["Grpc-Message"] == ""`, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			expr, err := ParseExpression(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := expr(res)
+			if result != test.result {
+				t.Fatalf("wrong result for %q: want %v, got %v", test.expr, test.result, result)
+			}
+		})
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	var tests = []struct {
+		expr string
+		err  string
+	}{
+		{``, "position 0: empty expression"},
+		{`status`, "position 6: expected comparison operator"},
+		{`status == "foo"`, "position 10: expected number"},
+		{`status contains 200`, "position 7: expected comparison operator"},
+		{`status => 200`, "unknown operator"},
+		{`foo == 1`, "position 0: unknown field"},
+		{`body > "x"`, "unknown operator"},
+		{`body contains foo`, "expected quoted string"},
+		{`body matches "("`, "failed to compile"},
+		{`(status == 200`, "expected \")\""},
+		{`status == 200 status == 300`, "position 14: unexpected"},
+		{`body contains "foo`, "position 14: unterminated string"},
+		{`header[Server] == "x"`, "expected quoted header name"},
+		{`trailer == "x"`, "position 8: expected \"[\""},
+		{`trailer[status] == "0"`, "expected quoted trailer name"},
+		{`status == 200 # comment`, "unexpected character"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseExpression(test.expr)
+			if err == nil {
+				t.Fatalf("expected error for %q not found", test.expr)
+			}
+
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("wrong error for %q: want %q, got %q", test.expr, test.err, err)
+			}
+		})
+	}
+}
@@ -9,6 +9,10 @@ import (
 // Filter decides whether to reject a Response.
 type Filter interface {
 	Reject(Response) bool
+
+	// Name returns a short description of the filter, used to explain why a
+	// response has been hidden.
+	Name() string
 }
 
 // FilterStatusCode hides responses based on the HTTP status code.
@@ -41,20 +45,19 @@ func NewFilterStatusCode(rejects, accepts []string) (FilterStatusCode, error) {
 	return filter, nil
 }
 
-// Reject decides if r is to be printed.
+// Reject decides if r is to be printed. Failed requests are checked with
+// their synthetic status code.
 func (f FilterStatusCode) Reject(r Response) bool {
-	if r.HTTPResponse == nil {
-		return false
-	}
+	status := r.Status()
 
 	for _, f := range f.rejects {
-		if f(r.HTTPResponse.StatusCode) {
+		if f(status) {
 			return true
 		}
 	}
 
 	for _, f := range f.accepts {
-		if !f(r.HTTPResponse.StatusCode) {
+		if !f(status) {
 			return true
 		}
 	}
@@ -62,6 +65,11 @@ func (f FilterStatusCode) Reject(r Response) bool {
 	return false
 }
 
+// Name returns a short description of the filter.
+func (f FilterStatusCode) Name() string {
+	return "status code (--hide-status, --show-status)"
+}
+
 // parseRangeFilterSpec returns a function that returns true if the size matches with the spec.
 //
 // possible matches:
@@ -172,6 +180,11 @@ func (f FilterSize) Reject(r Response) bool {
 	return false
 }
 
+// Name returns a short description of the filter.
+func (f FilterSize) Name() string {
+	return "size (--hide-header-size, --hide-body-size)"
+}
+
 // FilterRejectPattern filters responses based on patterns (header and body are matched).
 type FilterRejectPattern struct {
 	Pattern []*regexp.Regexp
@@ -198,6 +211,11 @@ func (f FilterRejectPattern) Reject(res Response) bool {
 	return false
 }
 
+// Name returns a short description of the filter.
+func (f FilterRejectPattern) Name() string {
+	return "pattern (--hide-pattern)"
+}
+
 // FilterAcceptPattern filters responses based on patterns (header and body are matched).
 type FilterAcceptPattern struct {
 	Pattern []*regexp.Regexp
@@ -223,3 +241,8 @@ func (f FilterAcceptPattern) Reject(res Response) bool {
 
 	return true
 }
+
+// Name returns a short description of the filter.
+func (f FilterAcceptPattern) Name() string {
+	return "pattern (--show-pattern)"
+}
@@ -0,0 +1,97 @@
+package response
+
+import (
+	"hash/fnv"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// VolatileHeaders are the headers whose values usually differ for each
+// response, they are only included in a header fingerprint with their names.
+var VolatileHeaders = []string{
+	"Age",
+	"Cf-Ray",
+	"Content-Length",
+	"Date",
+	"Etag",
+	"Expires",
+	"Last-Modified",
+	"Set-Cookie",
+	"X-Correlation-Id",
+	"X-Request-Id",
+}
+
+// HeaderFingerprint returns a hash of the header names, which are normalized
+// (canonicalized and sorted). If values is true, the values of all headers
+// except VolatileHeaders are included as well, in the order they were sent.
+func HeaderFingerprint(header http.Header, values bool) uint64 {
+	// the keys are usually canonical already, except for raw requests
+	normalized := make(map[string][]string, len(header))
+	names := make([]string, 0, len(header))
+	for name, vs := range header {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if _, ok := normalized[name]; !ok {
+			names = append(names, name)
+		}
+		normalized[name] = append(normalized[name], vs...)
+	}
+	sort.Strings(names)
+
+	volatile := make(map[string]struct{}, len(VolatileHeaders))
+	for _, name := range VolatileHeaders {
+		volatile[name] = struct{}{}
+	}
+
+	h := fnv.New64a()
+	for _, name := range names {
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write([]byte{0})
+
+		if !values {
+			continue
+		}
+
+		if _, ok := volatile[name]; ok {
+			continue
+		}
+
+		_, _ = h.Write([]byte(strings.Join(normalized[name], "\x00")))
+		_, _ = h.Write([]byte{0})
+	}
+
+	return h.Sum64()
+}
+
+// FilterDuplicateHeaders hides responses with the same header fingerprint (see
+// HeaderFingerprint) as a response which has been shown before, so that only
+// the first response for each distinct set of headers is displayed. It must be
+// the last filter, so that only the fingerprints of the responses which are
+// not hidden otherwise are remembered. Failed requests are never hidden.
+type FilterDuplicateHeaders struct {
+	seen map[uint64]struct{}
+}
+
+// Reject decides if r is to be printed.
+func (f *FilterDuplicateHeaders) Reject(r Response) bool {
+	if r.Error != nil || r.HTTPResponse == nil {
+		return false
+	}
+
+	if f.seen == nil {
+		f.seen = make(map[uint64]struct{})
+	}
+
+	if _, ok := f.seen[r.HeaderFingerprint]; ok {
+		return true
+	}
+
+	f.seen[r.HeaderFingerprint] = struct{}{}
+	return false
+}
+
+// Name returns a short description of the filter.
+func (f *FilterDuplicateHeaders) Name() string {
+	return "duplicate headers (--hide-duplicate-headers)"
+}
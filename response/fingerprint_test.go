@@ -0,0 +1,61 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestHeaderFingerprint(t *testing.T) {
+	base := http.Header{
+		"Server":       {"nginx"},
+		"Content-Type": {"text/html"},
+		"Date":         {"Mon, 01 Jan 2024 00:00:00 GMT"},
+	}
+
+	var tests = []struct {
+		header http.Header
+		values bool
+		same   bool
+	}{
+		// same names in a different order and with different values
+		{http.Header{"Date": {"Tue, 02 Jan 2024 00:00:00 GMT"}, "content-type": {"application/json"}, "Server": {"apache"}}, false, true},
+		{http.Header{"Date": {"Tue, 02 Jan 2024 00:00:00 GMT"}, "Content-Type": {"application/json"}, "Server": {"apache"}}, true, false},
+		// volatile headers are compared by name only
+		{http.Header{"Date": {"Tue, 02 Jan 2024 00:00:00 GMT"}, "Content-Type": {"text/html"}, "Server": {"nginx"}}, true, true},
+		{http.Header{"Content-Type": {"text/html"}, "Server": {"nginx"}}, false, false},
+		{http.Header{"Content-Type": {"text/html"}, "Server": {"nginx"}, "Date": {""}, "X-Backend": {"2"}}, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			want := HeaderFingerprint(base, test.values)
+			got := HeaderFingerprint(test.header, test.values)
+			if (want == got) != test.same {
+				t.Errorf("wrong fingerprint for %v (values %v): base %016x, got %016x", test.header, test.values, want, got)
+			}
+		})
+	}
+}
+
+func TestFilterDuplicateHeaders(t *testing.T) {
+	var tests = []struct {
+		res  Response
+		hide bool
+	}{
+		{Response{HTTPResponse: &http.Response{}, HeaderFingerprint: 1}, false},
+		{Response{HTTPResponse: &http.Response{}, HeaderFingerprint: 2}, false},
+		{Response{HTTPResponse: &http.Response{}, HeaderFingerprint: 1}, true},
+		{Response{Error: errors.New("timeout")}, false},
+		{Response{Error: errors.New("timeout")}, false},
+		{Response{HTTPResponse: &http.Response{}, HeaderFingerprint: 2}, true},
+		{Response{HTTPResponse: &http.Response{}, HeaderFingerprint: 3}, false},
+	}
+
+	f := &FilterDuplicateHeaders{}
+	for i, test := range tests {
+		if hide := f.Reject(test.res); hide != test.hide {
+			t.Errorf("response %d: wrong result, want %v, got %v", i, test.hide, hide)
+		}
+	}
+}
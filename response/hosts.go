@@ -0,0 +1,156 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+// HostList is a list of host names (or IP addresses). An entry "*.example.com"
+// matches all subdomains of example.com.
+type HostList []string
+
+// Contains returns true if host (without the port) matches an entry in the
+// list. Host names are compared case-insensitive.
+func (l HostList) Contains(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range l {
+		entry = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry), "."))
+
+		if strings.HasPrefix(entry, "*.") {
+			if strings.HasSuffix(host, entry[1:]) {
+				return true
+			}
+			continue
+		}
+
+		if host == entry {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HostBlockedError is returned for requests which were not sent because the
+// target host is not allowed.
+type HostBlockedError struct {
+	Host string
+}
+
+func (e *HostBlockedError) Error() string {
+	return fmt.Sprintf("host %v is not in the list of allowed hosts, request blocked", e.Host)
+}
+
+// BlockedHost returns the host if the request was blocked because the host is
+// not allowed.
+func (r Response) BlockedHost() (host string, blocked bool) {
+	var err *HostBlockedError
+	if errors.As(r.Error, &err) {
+		return err.Host, true
+	}
+	return "", false
+}
+
+// Check returns an error if the host for u is not in the list. A nil list
+// allows all hosts.
+func (l HostList) Check(u *url.URL) error {
+	if l == nil || l.Contains(u.Hostname()) {
+		return nil
+	}
+
+	return &HostBlockedError{Host: u.Hostname()}
+}
+
+// CheckRedirect wraps the function which decides whether a redirect is
+// followed, so that redirects to hosts which are not in the list are blocked.
+func (l HostList) CheckRedirect(next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if next != nil {
+			// if the redirect is not followed anyway, the host does not matter
+			err := next(req, via)
+			if err != nil {
+				return err
+			}
+		} else if len(via) >= 10 {
+			// default policy of the http client
+			return errors.New("stopped after 10 redirects")
+		}
+
+		return l.Check(req.URL)
+	}
+}
+
+// checkHost returns an error if the host for u is not allowed.
+func (r *Runner) checkHost(u *url.URL) error {
+	return r.AllowedHosts.Check(u)
+}
+
+// checkRedirectHost wraps the function which decides whether a redirect is
+// followed, so that redirects to hosts which are not allowed are blocked.
+func (r *Runner) checkRedirectHost(next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return r.AllowedHosts.CheckRedirect(next)
+}
+
+// NewInsecureTransport returns a transport like NewTransport which does not
+// verify TLS certificates. It is used for requests to the hosts in
+// template.InsecureHosts.
+func NewInsecureTransport(template *request.Request, concurrentRequests int) (*http.Transport, error) {
+	insecure := *template
+	insecure.Insecure = true
+	insecure.InsecureHosts = nil
+
+	return NewTransport(&insecure, concurrentRequests)
+}
+
+// NewClientTransport returns a transport for requests sent outside of a
+// Runner (e.g. a single request before the scan), which skips verifying TLS
+// certificates for template.InsecureHosts like a Runner.
+func NewClientTransport(template *request.Request) (http.RoundTripper, error) {
+	tr, err := NewTransport(template, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(template.InsecureHosts) == 0 {
+		return tr, nil
+	}
+
+	insecure, err := NewInsecureTransport(template, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return insecureHostsTransport{RoundTripper: tr, insecure: insecure, hosts: HostList(template.InsecureHosts)}, nil
+}
+
+// insecureHostsTransport sends requests to the hosts in the list via a
+// transport which does not verify TLS certificates, and all other requests
+// via the regular transport. Redirects are sent via the transport for the new
+// host.
+type insecureHostsTransport struct {
+	http.RoundTripper
+	insecure http.RoundTripper
+	hosts    HostList
+}
+
+func (t insecureHostsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.hosts.Contains(req.URL.Hostname()) {
+		return t.insecure.RoundTrip(req)
+	}
+
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t insecureHostsTransport) CloseIdleConnections() {
+	for _, tr := range []http.RoundTripper{t.RoundTripper, t.insecure} {
+		if c, ok := tr.(interface{ CloseIdleConnections() }); ok {
+			c.CloseIdleConnections()
+		}
+	}
+}
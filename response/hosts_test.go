@@ -0,0 +1,171 @@
+package response
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+func TestHostListContains(t *testing.T) {
+	var tests = []struct {
+		list HostList
+		host string
+		want bool
+	}{
+		{HostList{"example.com"}, "example.com", true},
+		{HostList{"example.com"}, "EXAMPLE.com.", true},
+		{HostList{"example.com"}, "www.example.com", false},
+		{HostList{"example.com"}, "example.com.evil.com", false},
+		{HostList{"*.example.com"}, "www.example.com", true},
+		{HostList{"*.example.com"}, "a.b.example.com", true},
+		{HostList{"*.example.com"}, "example.com", false},
+		{HostList{"*.example.com"}, "wwwexample.com", false},
+		{HostList{"foo", "192.168.1.1"}, "192.168.1.1", true},
+		{HostList{}, "example.com", false},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			got := test.list.Contains(test.host)
+			if got != test.want {
+				t.Fatalf("wrong result for %q in %v, want %v, got %v", test.host, test.list, test.want, got)
+			}
+		})
+	}
+}
+
+func TestAllowedHosts(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		path    string
+		allowed HostList
+		blocked string
+	}{
+		{"/", nil, ""},
+		{"/", HostList{"127.0.0.1"}, ""},
+		{"/", HostList{"example.com"}, "127.0.0.1"},
+		{"/redirect", HostList{"127.0.0.1"}, "example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			requests = 0
+
+			template := request.New("")
+			template.URL = srv.URL + test.path
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.Client.CheckRedirect = nil
+			runner.AllowedHosts = test.allowed
+			runner.Run(context.Background())
+
+			res := <-output
+			host, blocked := res.BlockedHost()
+			if test.blocked == "" {
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				return
+			}
+
+			if !blocked || host != test.blocked {
+				t.Fatalf("request not blocked for host %v, error %v", test.blocked, res.Error)
+			}
+
+			if res.Status() != StatusBlocked {
+				t.Errorf("wrong status, want %d, got %d", StatusBlocked, res.Status())
+			}
+
+			// the redirect must not be followed
+			if test.path == "/redirect" && requests != 1 {
+				t.Errorf("wrong number of requests, want 1, got %d", requests)
+			}
+		})
+	}
+}
+
+func TestInsecureHosts(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// don't log the failed handshakes
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	var tests = []struct {
+		hosts     []string
+		raw       bool
+		transport bool // set the insecure transport
+		valid     bool
+	}{
+		{nil, false, true, false},
+		{[]string{"127.0.0.1"}, false, true, true},
+		{[]string{"127.0.0.1"}, false, false, false},
+		{[]string{"localhost", "*.example.com"}, false, true, false},
+		{[]string{"127.0.0.1"}, true, true, true},
+		{[]string{"example.com"}, true, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL
+			template.InsecureHosts = test.hosts
+			template.RandomizeHeaders = test.raw
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			if test.transport {
+				runner.InsecureTransport, err = NewInsecureTransport(template, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			runner.Run(context.Background())
+
+			res := <-output
+			if test.valid && res.Error != nil {
+				t.Fatalf("request failed: %v", res.Error)
+			}
+
+			if !test.valid && res.Error == nil {
+				t.Fatalf("request with invalid certificate succeeded")
+			}
+
+			if !test.valid && res.Status() != StatusTLSError {
+				t.Errorf("wrong status for error %v, want %d, got %d", res.Error, StatusTLSError, res.Status())
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package response
 
 // Mark runs all responses through filters and sets the Hide attribute if a
-// filter matches. Filtering is done in a separate goroutine, which terminates
+// filter matches. The name of the filter which rejected the response is
+// saved in HiddenBy. Filtering is done in a separate goroutine, which terminates
 // when the input channel is closed.
 func Mark(in <-chan Response, filters []Filter) <-chan Response {
 	ch := make(chan Response)
@@ -14,6 +15,7 @@ func Mark(in <-chan Response, filters []Filter) <-chan Response {
 			for _, f := range filters {
 				if f.Reject(res) {
 					hide = true
+					res.HiddenBy = f.Name()
 					break
 				}
 			}
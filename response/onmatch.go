@@ -0,0 +1,139 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OnMatch runs a command for each interesting (non-hidden) response.
+type OnMatch struct {
+	Command     []string
+	Concurrency int // number of commands run at the same time, at least one
+	Error       func(error)
+}
+
+// Env returns the environment variables which describe the response for
+// the command.
+func (r Response) Env() []string {
+	env := []string{
+		"MONSOON_VALUE=" + r.Item,
+		"MONSOON_URL=" + r.URL,
+		"MONSOON_STATUS=" + strconv.Itoa(r.Status()),
+		"MONSOON_HEADER_SIZE=" + strconv.Itoa(r.Header.Bytes),
+		"MONSOON_BODY_SIZE=" + strconv.Itoa(r.Body.Bytes),
+		"MONSOON_BODY_WORDS=" + strconv.Itoa(r.Body.Words),
+		"MONSOON_BODY_LINES=" + strconv.Itoa(r.Body.Lines),
+		"MONSOON_EXTRACT=" + strings.Join(r.Extract, "\n"),
+	}
+
+	if r.Error != nil {
+		env = append(env, "MONSOON_ERROR="+r.Error.Error())
+	}
+
+	return env
+}
+
+// HeaderEnv returns environment variables with the headers of the response:
+// each header is available as MONSOON_HTTP_NAME, with the name in upper case
+// and all characters apart from letters and digits replaced by '_' (e.g.
+// MONSOON_HTTP_CONTENT_TYPE). Several values are separated by newlines.
+func (r Response) HeaderEnv() []string {
+	if r.HTTPResponse == nil {
+		return nil
+	}
+
+	values := make(map[string][]string)
+	for name, vs := range r.HTTPResponse.Header {
+		key := "MONSOON_HTTP_" + strings.Map(func(c rune) rune {
+			switch {
+			case c >= 'a' && c <= 'z':
+				return c - 'a' + 'A'
+			case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+				return c
+			default:
+				return '_'
+			}
+		}, name)
+		values[key] = append(values[key], vs...)
+	}
+
+	env := make([]string, 0, len(values))
+	for key, vs := range values {
+		env = append(env, key+"="+strings.Join(vs, "\n"))
+	}
+	sort.Strings(env)
+
+	return env
+}
+
+// Run forwards all responses from in to out. For each response which is not
+// hidden, the command is started with the environment variables from Env.
+// When Concurrency commands are running, processing waits until one of them
+// has finished. The commands are killed when the context is cancelled. Run
+// returns when in is closed and all commands have finished.
+func (m *OnMatch) Run(ctx context.Context, in <-chan Response, out chan<- Response) error {
+	defer close(out)
+
+	concurrency := m.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, concurrency)
+
+	for res := range in {
+		if !res.Hide && !res.Cancelled() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil
+			}
+
+			wg.Add(1)
+			go func(res Response) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				err := m.run(ctx, res)
+				if err != nil && m.Error != nil {
+					m.Error(err)
+				}
+			}(res)
+		}
+
+		select {
+		case out <- res:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (m *OnMatch) run(ctx context.Context, res Response) error {
+	cmd := exec.CommandContext(ctx, m.Command[0], m.Command[1:]...)
+	cmd.Env = append(os.Environ(), res.Env()...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil && ctx.Err() == nil {
+		msg := strings.TrimSpace(string(output))
+		if msg != "" {
+			return fmt.Errorf("command %s for value %q failed: %v: %s", m.Command, res.Item, err, msg)
+		}
+		return fmt.Errorf("command %s for value %q failed: %v", m.Command, res.Item, err)
+	}
+
+	return nil
+}
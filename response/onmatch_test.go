@@ -0,0 +1,135 @@
+package response
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOnMatch(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "monsoon-test-onmatch-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	responses := []Response{
+		{Item: "a", HTTPResponse: &http.Response{StatusCode: 200}, Body: TextStats{Bytes: 23}},
+		{Item: "b", HTTPResponse: &http.Response{StatusCode: 404}, Hide: true},
+		{Item: "c", HTTPResponse: &http.Response{StatusCode: 302}, Extract: []string{"foo", "bar"}},
+	}
+
+	in := make(chan Response, len(responses))
+	for _, res := range responses {
+		in <- res
+	}
+	close(in)
+
+	var (
+		mu     sync.Mutex
+		failed []error
+	)
+	m := &OnMatch{
+		Command: []string{"sh", "-c", `printf '%s %s %s' "$MONSOON_STATUS" "$MONSOON_BODY_SIZE" "$MONSOON_EXTRACT" > "$0/$MONSOON_VALUE"`, tempdir},
+		Error: func(err error) {
+			mu.Lock()
+			failed = append(failed, err)
+			mu.Unlock()
+		},
+	}
+
+	out := make(chan Response, len(responses))
+	err = m.Run(context.Background(), in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items []string
+	for res := range out {
+		items = append(items, res.Item)
+	}
+
+	if !cmp.Equal([]string{"a", "b", "c"}, items) {
+		t.Error(cmp.Diff([]string{"a", "b", "c"}, items))
+	}
+
+	if len(failed) > 0 {
+		t.Fatalf("unexpected errors: %v", failed)
+	}
+
+	var tests = []struct {
+		item string
+		want string
+	}{
+		{"a", "200 23 "},
+		{"b", ""},
+		{"c", "302 0 foo\nbar"},
+	}
+
+	for _, test := range tests {
+		buf, err := ioutil.ReadFile(filepath.Join(tempdir, test.item))
+		if os.IsNotExist(err) && test.want == "" {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(buf) != test.want {
+			t.Errorf("wrong output for %v, want %q, got %q", test.item, test.want, buf)
+		}
+	}
+}
+
+func TestHeaderEnv(t *testing.T) {
+	res := Response{HTTPResponse: &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Content-Type": []string{"text/html"},
+			"Set-Cookie":   []string{"a=1", "b=2"},
+			"X-Foo.Bar":    []string{"baz"},
+		},
+	}}
+
+	want := []string{
+		"MONSOON_HTTP_CONTENT_TYPE=text/html",
+		"MONSOON_HTTP_SET_COOKIE=a=1\nb=2",
+		"MONSOON_HTTP_X_FOO_BAR=baz",
+	}
+
+	env := res.HeaderEnv()
+	if !cmp.Equal(want, env) {
+		t.Error(cmp.Diff(want, env))
+	}
+
+	if env := (Response{}).HeaderEnv(); len(env) != 0 {
+		t.Errorf("unexpected environment for response without header: %v", env)
+	}
+}
+
+func TestExtractBodyCommandEnv(t *testing.T) {
+	res := Response{
+		Item:         "foo",
+		URL:          "https://example.com/foo",
+		HTTPResponse: &http.Response{StatusCode: 302, Header: http.Header{"Location": []string{"/login"}}},
+		RawBody:      []byte("body"),
+	}
+
+	err := res.ExtractBodyCommand([][]string{
+		{"sh", "-c", `printf '%s %s %s %s' "$MONSOON_STATUS" "$MONSOON_URL" "$MONSOON_HTTP_LOCATION" "$(cat)"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"302 https://example.com/foo /login body"}
+	if !cmp.Equal(want, res.Extract) {
+		t.Error(cmp.Diff(want, res.Extract))
+	}
+}
@@ -0,0 +1,153 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StatusAction is what happens when a response with a specific status code
+// is received.
+type StatusAction int
+
+// All actions which can be configured for a status code.
+const (
+	ActionContinue StatusAction = iota // process the next values as usual
+	ActionBackoff                      // count the response for slowing down
+	ActionStop                         // stop the run
+	ActionRetry                        // send the request again
+)
+
+var actionNames = map[string]StatusAction{
+	"continue": ActionContinue,
+	"backoff":  ActionBackoff,
+	"stop":     ActionStop,
+	"retry":    ActionRetry,
+}
+
+func (a StatusAction) String() string {
+	for name, action := range actionNames {
+		if action == a {
+			return name
+		}
+	}
+	return fmt.Sprintf("StatusAction(%d)", int(a))
+}
+
+type statusRule struct {
+	match  func(int) bool
+	action StatusAction
+}
+
+// StatusPolicy selects the action for the status code of a response.
+type StatusPolicy struct {
+	rules []statusRule
+}
+
+// ParseStatusPolicy parses a list of rules "code=action", where code can also
+// be a range like for --hide-status.
+func ParseStatusPolicy(specs []string) (StatusPolicy, error) {
+	var policy StatusPolicy
+	for _, spec := range specs {
+		data := strings.SplitN(spec, "=", 2)
+		if len(data) != 2 {
+			return StatusPolicy{}, fmt.Errorf("invalid status policy %q, must be code=action", spec)
+		}
+
+		match, err := parseRangeFilterSpec(data[0])
+		if err != nil {
+			return StatusPolicy{}, fmt.Errorf("invalid status policy %q: %v", spec, err)
+		}
+
+		action, ok := actionNames[data[1]]
+		if !ok {
+			return StatusPolicy{}, fmt.Errorf("invalid status policy %q: unknown action %q (continue, backoff, stop, retry)", spec, data[1])
+		}
+
+		policy.rules = append(policy.rules, statusRule{match: match, action: action})
+	}
+
+	return policy, nil
+}
+
+// Action returns the action for the status code. The first rule that
+// matches is used, if none matches the action is ActionContinue.
+func (p StatusPolicy) Action(status int) StatusAction {
+	for _, rule := range p.rules {
+		if rule.match(status) {
+			return rule.action
+		}
+	}
+	return ActionContinue
+}
+
+// Has returns true if any rule uses action.
+func (p StatusPolicy) Has(action StatusAction) bool {
+	for _, rule := range p.rules {
+		if rule.action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Retry returns true if the policy requests to send the request for res
+// again, it can be used for Runner.Retry.
+func (p StatusPolicy) Retry(res Response) bool {
+	return !res.Cancelled() && p.Action(res.Status()) == ActionRetry
+}
+
+// StatusActions runs the backoff and stop actions of a policy for the
+// responses, retries are handled by the Runner (see StatusPolicy.Retry).
+type StatusActions struct {
+	Policy StatusPolicy
+
+	// Backoff is called for each response which is counted for slowing
+	// down, failed is true for the ones with ActionBackoff and, if
+	// BackoffOn5xx is set, for server errors. Responses for failed requests
+	// are only counted if their status has ActionBackoff. It may be nil.
+	Backoff      func(failed bool)
+	BackoffOn5xx bool
+
+	// Stop is called after the first response with ActionStop has been
+	// forwarded, it should stop the run. It may be nil.
+	Stop func(Response)
+}
+
+// Run forwards all responses from in to out and runs the actions for them.
+// It returns when in is closed or the context is cancelled.
+func (a *StatusActions) Run(ctx context.Context, in <-chan Response, out chan<- Response) error {
+	defer close(out)
+
+	stopped := false
+	for res := range in {
+		var action StatusAction
+		if !res.Cancelled() {
+			action = a.Policy.Action(res.Status())
+		}
+
+		if a.Backoff != nil && !res.Cancelled() {
+			switch {
+			case action == ActionBackoff:
+				a.Backoff(true)
+			case res.Error == nil:
+				a.Backoff(a.BackoffOn5xx && res.HTTPResponse.StatusCode >= 500)
+			}
+		}
+
+		select {
+		case out <- res:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if action == ActionStop && !stopped {
+			stopped = true
+			if a.Stop != nil {
+				a.Stop(res)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusPolicy(t *testing.T) {
+	policy, err := ParseStatusPolicy([]string{"429=backoff", "403=stop", "401=retry", "500-=backoff", "0-9=retry", "404=continue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		status int
+		want   StatusAction
+	}{
+		{200, ActionContinue},
+		{404, ActionContinue},
+		{429, ActionBackoff},
+		{403, ActionStop},
+		{401, ActionRetry},
+		{503, ActionBackoff},
+		{StatusTimeout, ActionRetry},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			action := policy.Action(test.status)
+			if action != test.want {
+				t.Errorf("wrong action for status %d, want %v, got %v", test.status, test.want, action)
+			}
+		})
+	}
+
+	if !policy.Has(ActionStop) {
+		t.Errorf("Has(ActionStop) returned false")
+	}
+}
+
+func TestStatusPolicyInvalid(t *testing.T) {
+	for _, spec := range []string{"429", "429=foo", "x=stop", "=stop"} {
+		_, err := ParseStatusPolicy([]string{spec})
+		if err == nil {
+			t.Errorf("expected error for %q not returned", spec)
+		}
+	}
+}
+
+// statusServer answers requests for /name with the status codes listed for
+// name, one after the other, and repeats the last one. The function requests
+// returns the number of requests received for a name.
+func statusServer(statuses map[string][]int) (srv *httptest.Server, requests func(string) int) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimPrefix(req.URL.Path, "/")
+
+		mu.Lock()
+		n := counts[name]
+		counts[name]++
+		mu.Unlock()
+
+		codes, ok := statuses[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if n >= len(codes) {
+			n = len(codes) - 1
+		}
+		w.WriteHeader(codes[n])
+	}))
+
+	return srv, func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[name]
+	}
+}
+
+func TestStatusPolicyRetry(t *testing.T) {
+	srv, requests := statusServer(map[string][]int{
+		"ok":       {200},
+		"flaky":    {401, 401, 200},
+		"denied":   {401},
+		"notfound": {404, 200},
+	})
+	defer srv.Close()
+
+	policy, err := ParseStatusPolicy([]string{"401=retry", "403=stop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := request.New("")
+	template.URL = srv.URL + "/FUZZ"
+
+	tr, err := NewTransport(template, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		item     string
+		status   int
+		requests int
+	}{
+		{"ok", 200, 1},
+		{"flaky", 200, 3},
+		{"denied", 401, 3},
+		{"notfound", 404, 1},
+	}
+
+	input := make(chan string, len(tests))
+	for _, test := range tests {
+		input <- test.item
+	}
+	close(input)
+
+	output := make(chan Response, len(tests))
+	runner := NewRunner(tr, template, input, output)
+	runner.Retry = policy.Retry
+	runner.MaxRetries = 2
+	runner.Run(context.Background())
+	close(output)
+
+	statuses := make(map[string]int)
+	for res := range output {
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		statuses[res.Item] = res.HTTPResponse.StatusCode
+	}
+
+	for _, test := range tests {
+		t.Run(test.item, func(t *testing.T) {
+			if statuses[test.item] != test.status {
+				t.Errorf("wrong status, want %d, got %d", test.status, statuses[test.item])
+			}
+
+			if n := requests(test.item); n != test.requests {
+				t.Errorf("wrong number of requests, want %d, got %d", test.requests, n)
+			}
+		})
+	}
+}
+
+func TestStatusActions(t *testing.T) {
+	srv, requests := statusServer(map[string][]int{
+		"ok":      {200},
+		"busy":    {429},
+		"broken":  {500},
+		"blocked": {403},
+		"after":   {200},
+	})
+	defer srv.Close()
+
+	var tests = []struct {
+		name  string
+		specs []string
+		on5xx bool
+		items []string
+
+		// the values recorded for the backoff
+		backoff []bool
+		// the value for which Stop was called
+		stopped string
+		// the values for which no request must be sent
+		notSent []string
+	}{
+		{
+			name:    "backoff",
+			specs:   []string{"429=backoff"},
+			items:   []string{"ok", "busy", "broken", "notfound"},
+			backoff: []bool{false, true, false, false},
+		},
+		{
+			name:    "backoff-5xx",
+			specs:   []string{"429=backoff"},
+			on5xx:   true,
+			items:   []string{"ok", "busy", "broken"},
+			backoff: []bool{false, true, true},
+		},
+		{
+			name:    "backoff-continue",
+			specs:   []string{"500=continue"},
+			on5xx:   true,
+			items:   []string{"broken", "ok"},
+			backoff: []bool{true, false},
+		},
+		{
+			name:    "stop",
+			specs:   []string{"403=stop"},
+			items:   []string{"ok", "blocked", "busy", "blocked", "after", "after"},
+			stopped: "blocked",
+			// one more request may have been started before the run is
+			// stopped, but not two
+			notSent: []string{"after"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := ParseStatusPolicy(test.specs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			input := make(chan string, len(test.items))
+			for _, item := range test.items {
+				input <- item
+			}
+			close(input)
+
+			before := make(map[string]int)
+			for _, item := range test.notSent {
+				before[item] = requests(item)
+			}
+
+			responses := make(chan Response)
+			runner := NewRunner(tr, template, input, responses)
+			go func() {
+				runner.Run(ctx)
+				close(responses)
+			}()
+
+			var backoff []bool
+			var stopped []string
+			actions := &StatusActions{
+				Policy:       policy,
+				BackoffOn5xx: test.on5xx,
+				Stop: func(res Response) {
+					stopped = append(stopped, res.Item)
+					cancel()
+				},
+			}
+			if test.backoff != nil {
+				actions.Backoff = func(failed bool) {
+					backoff = append(backoff, failed)
+				}
+			}
+
+			out := make(chan Response)
+			done := make(chan error, 1)
+			go func() {
+				done <- actions.Run(context.Background(), responses, out)
+			}()
+
+			for range out {
+			}
+
+			err = <-done
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(test.backoff, backoff) {
+				t.Errorf("wrong values recorded for backoff: %v", cmp.Diff(test.backoff, backoff))
+			}
+
+			switch {
+			case test.stopped == "" && len(stopped) > 0:
+				t.Errorf("run stopped for %v", stopped)
+			case test.stopped != "" && !cmp.Equal([]string{test.stopped}, stopped):
+				t.Errorf("Stop not called once for %v: %v", test.stopped, stopped)
+			}
+
+			for _, item := range test.notSent {
+				if n := requests(item) - before[item]; n > 0 {
+					t.Errorf("%d requests sent for %v after the run was stopped", n, item)
+				}
+			}
+		})
+	}
+}
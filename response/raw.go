@@ -0,0 +1,215 @@
+package response
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+// rawTimeout returns the maximum time a raw request may take, including
+// establishing the connection and reading the response: the timeouts for
+// connecting and (for https) the TLS handshake from the template, plus the
+// time the transport waits for the response header.
+func rawTimeout(template *request.Request, https bool) time.Duration {
+	connect, handshake := timeouts(template)
+	if !https {
+		handshake = 0
+	}
+
+	return connect + handshake + responseHeaderTimeout
+}
+
+// rawRequest sends the raw request built from the template and item over a
+// new connection, which is closed afterwards.
+func (r *Runner) rawRequest(ctx context.Context, item string) (response Response) {
+	raw, err := r.Template.ApplyRaw(item)
+	if err != nil {
+		response.Error = err
+		return
+	}
+
+	response = Response{
+		URL:  raw.URL.String(),
+		Item: item,
+	}
+
+	err = r.checkHost(raw.URL)
+	if err != nil {
+		response.Error = err
+		return
+	}
+
+	if r.RecordRequest {
+		response.RawRequest = raw.Data
+	}
+
+	if r.Budget != nil {
+		err = r.Budget.Take()
+		if err != nil {
+			response.Error = err
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rawTimeout(r.Template, raw.URL.Scheme == "https"))
+	defer cancel()
+
+	start := time.Now()
+	res, conn, ttfb, err := r.sendRaw(ctx, raw)
+	response.Duration = time.Since(start)
+	response.TTFB = ttfb
+	if conn != nil {
+		defer conn.Close()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		response.Error = &url.Error{Op: "raw " + raw.Method, URL: response.URL, Err: err}
+		return
+	}
+
+	err = r.readBody(&response, res)
+	if err != nil {
+		response.Error = err
+		return
+	}
+
+	err = response.ExtractHeader(res, wireHeader(conn.stop(), res.StatusCode), r.Extract)
+	if err != nil {
+		response.Error = err
+		return
+	}
+
+	response.HTTPResponse = res
+	response.Trailer = readTrailer(res)
+	response.setCanonicalURL(res)
+
+	if r.NormalizeReflection {
+		err = response.NormalizeReflection(r.Template.Replace)
+		if err != nil {
+			response.Error = err
+			return
+		}
+	}
+
+	if r.FindReflected {
+		response.CountReflected()
+	}
+
+	if r.DetectWAF {
+		response.DetectWAF()
+	}
+
+	return
+}
+
+// sendRaw establishes a connection to the target of raw, sends the data and
+// reads the response header, returning the time to first byte. The connection
+// is closed when the context is cancelled. If the returned connection is not
+// nil, the caller must close it.
+func (r *Runner) sendRaw(ctx context.Context, raw *request.Raw) (*http.Response, *captureConn, time.Duration, error) {
+	host, port, err := raw.Target()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	c, err := r.Transport.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = c.Close()
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = c.SetDeadline(deadline)
+		if err != nil {
+			_ = c.Close()
+			return nil, nil, 0, err
+		}
+	}
+
+	if raw.URL.Scheme == "https" {
+		cfg := r.Transport.TLSClientConfig.Clone()
+		cfg.ServerName = host
+		if HostList(r.Template.InsecureHosts).Contains(host) {
+			cfg.InsecureSkipVerify = true
+		}
+		// the data may be anything, so don't negotiate a protocol via ALPN
+		cfg.NextProtos = nil
+
+		tlsConn := tls.Client(c, cfg)
+		err = handshake(ctx, tlsConn, r.Transport.TLSHandshakeTimeout)
+		if err != nil {
+			_ = c.Close()
+			return nil, nil, 0, err
+		}
+		c = tlsConn
+	}
+
+	conn := &captureConn{Conn: c}
+	conn.start()
+
+	_, err = conn.Write(raw.Data)
+	if err != nil {
+		return nil, conn, 0, err
+	}
+	wrote := time.Now()
+
+	rd := bufio.NewReader(conn)
+	_, err = rd.Peek(1)
+	if err != nil {
+		return nil, conn, 0, err
+	}
+	ttfb := time.Since(wrote)
+
+	res, err := readRawResponse(rd, raw.Method)
+	if err != nil {
+		return nil, conn, 0, err
+	}
+
+	return res, conn, ttfb, nil
+}
+
+// readRawResponse reads a response to a request with method from rd. The
+// HTTP version in the status line may be anything, responses with a version
+// unknown to net/http are parsed like HTTP/1.1 and res.Proto contains the
+// version as sent by the server.
+func readRawResponse(rd *bufio.Reader, method string) (*http.Response, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	proto := line
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		proto = line[:i]
+	}
+
+	statusLine := line
+	if _, _, ok := http.ParseHTTPVersion(proto); !ok {
+		statusLine = "HTTP/1.1" + line[len(proto):]
+	}
+
+	src := io.MultiReader(strings.NewReader(statusLine), rd)
+	res, err := http.ReadResponse(bufio.NewReader(src), &http.Request{Method: method})
+	if err != nil {
+		return nil, err
+	}
+
+	res.Proto = proto
+
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package response
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+func TestRawTimeout(t *testing.T) {
+	var tests = []struct {
+		connect, handshake time.Duration
+		https              bool
+		want               time.Duration
+	}{
+		{0, 0, false, DefaultConnectTimeout + responseHeaderTimeout},
+		{0, 0, true, DefaultConnectTimeout + DefaultTLSHandshakeTimeout + responseHeaderTimeout},
+		{2 * time.Second, 0, false, 2*time.Second + responseHeaderTimeout},
+		{2 * time.Second, 5 * time.Second, false, 2*time.Second + responseHeaderTimeout},
+		{2 * time.Second, 5 * time.Second, true, 7*time.Second + responseHeaderTimeout},
+		{0, time.Second, true, DefaultConnectTimeout + time.Second + responseHeaderTimeout},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.ConnectTimeout = test.connect
+			template.TLSHandshakeTimeout = test.handshake
+
+			timeout := rawTimeout(template, test.https)
+			if timeout != test.want {
+				t.Errorf("wrong timeout, want %v, got %v", test.want, timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"sort"
+)
+
+// reflectionVariants returns the forms of value which are searched for in the
+// response: the value itself and the most common encodings applied by web
+// applications. Each variant is contained only once.
+func reflectionVariants(value string) []string {
+	var variants []string
+	seen := make(map[string]struct{})
+
+	for _, v := range []string{
+		value,
+		url.QueryEscape(value),
+		url.PathEscape(value),
+		html.EscapeString(value),
+	} {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		variants = append(variants, v)
+	}
+
+	return variants
+}
+
+// CountReflected sets r.Reflected to the number of times the value r.Item
+// (unencoded, URL-encoded or HTML-encoded) is contained in the response header
+// and body.
+func (r *Response) CountReflected() {
+	r.Reflected = 0
+	if r.Item == "" {
+		return
+	}
+
+	for _, v := range reflectionVariants(r.Item) {
+		r.Reflected += bytes.Count(r.RawHeader, []byte(v))
+		r.Reflected += bytes.Count(r.RawBody, []byte(v))
+	}
+}
+
+// NormalizeReflection computes the statistics for the header and the body
+// (used by the size filters) with all occurrences of the value r.Item
+// (unencoded, URL-encoded or HTML-encoded) replaced by placeholder, so that
+// they don't depend on the value if it is reflected. The header and body
+// themselves are not modified.
+func (r *Response) NormalizeReflection(placeholder string) error {
+	if r.Item == "" {
+		return nil
+	}
+
+	// replace longer variants first, e.g. "%25" before "%"
+	variants := reflectionVariants(r.Item)
+	sort.SliceStable(variants, func(i, j int) bool {
+		return len(variants[i]) > len(variants[j])
+	})
+
+	normalize := func(buf []byte) []byte {
+		for _, v := range variants {
+			buf = bytes.Replace(buf, []byte(v), []byte(placeholder), -1)
+		}
+		return buf
+	}
+
+	var err error
+	r.Header, err = Count(bytes.NewReader(normalize(r.RawHeader)))
+	if err != nil {
+		return err
+	}
+
+	r.Body, err = Count(bytes.NewReader(normalize(r.RawBody)))
+	return err
+}
+
+// FilterReflected hides responses which do not contain the value.
+type FilterReflected struct{}
+
+// Reject decides if r is to be printed.
+func (f FilterReflected) Reject(r Response) bool {
+	return r.Reflected == 0
+}
+
+// Name returns a short description of the filter.
+func (f FilterReflected) Name() string {
+	return "reflected (--only-reflected)"
+}
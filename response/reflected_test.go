@@ -0,0 +1,105 @@
+package response
+
+import (
+	"testing"
+)
+
+func TestCountReflected(t *testing.T) {
+	var tests = []struct {
+		item   string
+		header string
+		body   string
+		want   int
+	}{
+		{"foo", "HTTP/1.1 200 OK\r\n\r\n", "no match", 0},
+		{"foo", "HTTP/1.1 302 Found\r\nLocation: /foo\r\n\r\n", "<a href=\"/foo\">foo</a>", 3},
+		{"a b", "", "a b a+b a%20b", 3},
+		{"<script>", "", "&lt;script&gt; <script>", 2},
+		{"x&y", "", "x&y x%26y x&amp;y", 3},
+		{"", "", "foo", 0},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			res := Response{
+				Item:      test.item,
+				RawHeader: []byte(test.header),
+				RawBody:   []byte(test.body),
+			}
+
+			res.CountReflected()
+			if res.Reflected != test.want {
+				t.Errorf("wrong number of reflections, want %d, got %d", test.want, res.Reflected)
+			}
+
+			if (FilterReflected{}).Reject(res) != (test.want == 0) {
+				t.Errorf("wrong filter result for %d reflections", res.Reflected)
+			}
+		})
+	}
+}
+
+func TestNormalizeReflection(t *testing.T) {
+	var tests = []struct {
+		item       string
+		header     string
+		body       string
+		wantHeader TextStats
+		wantBody   TextStats
+	}{
+		{
+			item:       "foo",
+			header:     "Location: /foo\r\n\r\n",
+			body:       "<a href=\"/foo\">foo</a>\n",
+			wantHeader: TextStats{Bytes: 19, Words: 3, Lines: 2},
+			wantBody:   TextStats{Bytes: 25, Words: 2, Lines: 1},
+		},
+		{
+			item:       "a longer value",
+			header:     "Location: /a%20longer%20value\r\n\r\n",
+			body:       "<a href=\"/a+longer+value\">a longer value</a>\n",
+			wantHeader: TextStats{Bytes: 19, Words: 3, Lines: 2},
+			wantBody:   TextStats{Bytes: 25, Words: 2, Lines: 1},
+		},
+		{
+			item:       "%",
+			body:       "% %25",
+			wantHeader: TextStats{},
+			wantBody:   TextStats{Bytes: 9, Words: 2, Lines: 0},
+		},
+		{
+			item:     "",
+			body:     "foo bar",
+			wantBody: TextStats{Bytes: 7, Words: 2, Lines: 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			res := Response{
+				Item:      test.item,
+				RawHeader: []byte(test.header),
+				RawBody:   []byte(test.body),
+				Header:    TextStats{Bytes: len(test.header)},
+				Body:      TextStats{Bytes: len(test.body), Words: 2},
+			}
+
+			err := res.NormalizeReflection("FUZZ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Header != test.wantHeader {
+				t.Errorf("wrong header stats, want %+v, got %+v", test.wantHeader, res.Header)
+			}
+
+			if res.Body != test.wantBody {
+				t.Errorf("wrong body stats, want %+v, got %+v", test.wantBody, res.Body)
+			}
+
+			if string(res.RawBody) != test.body {
+				t.Errorf("body was modified, want %q, got %q", test.body, res.RawBody)
+			}
+		})
+	}
+}
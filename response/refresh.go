@@ -0,0 +1,154 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	metaTag          = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	metaHTTPEquiv    = regexp.MustCompile(`(?is)\bhttp-equiv\s*=\s*["']?\s*refresh\b`)
+	metaContent      = regexp.MustCompile(`(?is)\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	refreshURLPrefix = regexp.MustCompile(`(?i)^url\s*=\s*`)
+
+	// assignments like "window.location.href = '/login'" and calls like
+	// "location.replace('/login')"
+	jsRedirect = regexp.MustCompile(`(?:\blocation(?:\.href)?\s*=\s*|\blocation\.(?:replace|assign)\(\s*)(?:"([^"]+)"|'([^']+)')`)
+)
+
+// parseRefresh returns the URL in the value of a Refresh header or meta
+// refresh tag like "0; url=/login". It is empty if there is none, e.g. for
+// "5" which reloads the page.
+func parseRefresh(s string) string {
+	i := strings.IndexAny(s, ";,")
+	if i < 0 {
+		return ""
+	}
+
+	target := strings.TrimSpace(s[i+1:])
+	target = refreshURLPrefix.ReplaceAllString(target, "")
+	target = strings.Trim(target, `"' `)
+	return target
+}
+
+// RefreshTarget returns the target of a redirect which is not done with a 3xx
+// status code, found heuristically in the Refresh header, a meta refresh tag
+// in the body or JavaScript setting the location. It is the empty string if
+// none is found.
+func RefreshTarget(header http.Header, body []byte) string {
+	if target := parseRefresh(header.Get("Refresh")); target != "" {
+		return target
+	}
+
+	for _, tag := range metaTag.FindAll(body, -1) {
+		if !metaHTTPEquiv.Match(tag) {
+			continue
+		}
+
+		m := metaContent.FindSubmatch(tag)
+		if m == nil {
+			continue
+		}
+
+		content := string(m[1]) + string(m[2]) + string(m[3])
+		if target := parseRefresh(content); target != "" {
+			return target
+		}
+	}
+
+	for _, m := range jsRedirect.FindAllSubmatch(body, -1) {
+		target := string(m[1]) + string(m[2])
+		if strings.HasPrefix(target, "#") || strings.HasPrefix(strings.ToLower(target), "javascript:") {
+			continue
+		}
+		return target
+	}
+
+	return ""
+}
+
+// refreshURL returns the resolved target of a meta refresh or JavaScript
+// redirect for a successful response, or nil.
+func (r *Response) refreshURL() *url.URL {
+	res := r.HTTPResponse
+	if res == nil || res.StatusCode < 200 || res.StatusCode > 299 || res.Request == nil {
+		return nil
+	}
+
+	target := RefreshTarget(res.Header, r.RawBody)
+	if target == "" {
+		return nil
+	}
+
+	u, err := res.Request.URL.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+
+	return u
+}
+
+// followRefresh follows at most r.FollowMetaRefresh redirects found with
+// RefreshTarget, starting at response (the response for orig). All targets
+// found are recorded in MetaRefresh, the response for the last one followed
+// replaces response.
+func (r *Runner) followRefresh(ctx context.Context, orig *http.Request, response *Response) {
+	for hops := 0; ; hops++ {
+		target := response.refreshURL()
+		if target == nil {
+			return
+		}
+
+		response.MetaRefresh = append(response.MetaRefresh, target.String())
+
+		// stop at the limit and for pages which refresh themselves
+		current := response.HTTPResponse.Request.URL
+		if hops >= r.FollowMetaRefresh || (target.String() == current.String()) {
+			return
+		}
+
+		next := Response{
+			URL:         response.URL,
+			Item:        response.Item,
+			RawRequest:  response.RawRequest,
+			MetaRefresh: response.MetaRefresh,
+		}
+
+		req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+		if err != nil {
+			next.Error = err
+			*response = next
+			return
+		}
+
+		// send the headers like for a redirect, without the body and
+		// credentials for other hosts
+		for name, values := range orig.Header {
+			req.Header[name] = values
+		}
+		req.Header.Del("Content-Type")
+		req.Header.Del("Content-Length")
+		if target.Host != orig.URL.Host {
+			req.Header.Del("Authorization")
+			req.Header.Del("Cookie")
+		}
+
+		err = r.checkHost(req.URL)
+		if err != nil {
+			next.Error = err
+			*response = next
+			return
+		}
+
+		r.do(ctx, req, &next)
+		next.Duration += response.Duration
+		*response = next
+
+		if next.Error != nil {
+			return
+		}
+	}
+}
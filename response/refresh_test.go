@@ -0,0 +1,136 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRefreshTarget(t *testing.T) {
+	var tests = []struct {
+		header string
+		body   string
+		want   string
+	}{
+		{body: `<html><body>nothing here</body></html>`},
+		{body: `<meta http-equiv="refresh" content="0; url=/login">`, want: "/login"},
+		{body: `<META HTTP-EQUIV="Refresh" CONTENT="5;URL='https://example.com/'">`, want: "https://example.com/"},
+		{body: `<meta content="0;url=/a" http-equiv=refresh />`, want: "/a"},
+		{body: `<meta http-equiv='refresh' content='3, /b'>`, want: "/b"},
+		// reload without a target
+		{body: `<meta http-equiv="refresh" content="30">`},
+		{body: `<meta name="description" content="0; url=/nope">`},
+		{body: `<script>window.location.href = "/js";</script>`, want: "/js"},
+		{body: `<script>location.replace('/replaced')</script>`, want: "/replaced"},
+		{body: `<script>document.location='#top'; location = '/next'</script>`, want: "/next"},
+		{body: `<a href="javascript:void(0)" onclick="location.href='javascript:x()'">`},
+		{header: "0; url=/header", body: `<meta http-equiv="refresh" content="0; url=/body">`, want: "/header"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := make(http.Header)
+			if test.header != "" {
+				header.Set("Refresh", test.header)
+			}
+
+			target := RefreshTarget(header, []byte(test.body))
+			if target != test.want {
+				t.Errorf("wrong target, want %q, got %q", test.want, target)
+			}
+		})
+	}
+}
+
+func TestFollowMetaRefresh(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			_, _ = w.Write([]byte(`<meta http-equiv="refresh" content="0; url=step">`))
+		case "/step":
+			_, _ = w.Write([]byte(`<script>window.location = "/login?from=step";</script>`))
+		case "/login":
+			_, _ = w.Write([]byte(`<meta http-equiv="refresh" content="0; url=/end">please log in`))
+		case "/self":
+			w.Header().Set("Refresh", "1; url=/self")
+			_, _ = w.Write([]byte(`waiting`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		path  string
+		limit int
+
+		wantPath    string
+		wantTargets []string
+	}{
+		{
+			path:     "/end",
+			limit:    5,
+			wantPath: "/end",
+		},
+		{
+			path:        "/start",
+			limit:       5,
+			wantPath:    "/end",
+			wantTargets: []string{srv.URL + "/step", srv.URL + "/login?from=step", srv.URL + "/end"},
+		},
+		{
+			path:        "/start",
+			limit:       2,
+			wantPath:    "/login",
+			wantTargets: []string{srv.URL + "/step", srv.URL + "/login?from=step", srv.URL + "/end"},
+		},
+		{
+			path:        "/self",
+			limit:       5,
+			wantPath:    "/self",
+			wantTargets: []string{srv.URL + "/self"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "FUZZ"
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- test.path
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.FollowMetaRefresh = test.limit
+			runner.Run(context.Background())
+			res := <-output
+
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			if res.URL != srv.URL+test.path {
+				t.Errorf("wrong URL, want %v, got %v", srv.URL+test.path, res.URL)
+			}
+
+			if p := res.HTTPResponse.Request.URL.Path; p != test.wantPath {
+				t.Errorf("wrong final path, want %v, got %v", test.wantPath, p)
+			}
+
+			if !cmp.Equal(test.wantTargets, res.MetaRefresh) {
+				t.Error(cmp.Diff(test.wantTargets, res.MetaRefresh))
+			}
+		})
+	}
+}
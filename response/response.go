@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,14 +27,72 @@ type Response struct {
 	Error    error
 	Duration time.Duration
 
+	// TTFB is the time to first byte, the time between sending the request
+	// (after the connection has been established) and receiving the first
+	// byte of the response
+	TTFB time.Duration
+
 	Header, Body TextStats
 	Extract      []string
 
 	HTTPResponse *http.Response
 	RawBody      []byte
 	RawHeader    []byte
+	RawRequest   []byte // the request as sent, only set if requested from the runner
+
+	// CompressedBodySize is the size of the body as received if it has been
+	// decompressed, RawBody and Body then contain the decompressed data
+	CompressedBodySize int
+
+	// CanonicalURL is the canonical form of the URL of the resource returned
+	// (after redirects and taking Content-Location into account), so that
+	// responses for equivalent paths can be recognized
+	CanonicalURL string
+
+	// Reflected is the number of times the value was found in the header and
+	// body of the response, it is only set if requested from the runner
+	Reflected int
+
+	// WAF is the name of the web application firewall if the response looks
+	// like a block page, it is only set if requested from the runner
+	WAF string
+
+	// Trailer contains the trailers received after the body (e.g.
+	// Grpc-Status), it is nil if there were none
+	Trailer http.Header
+
+	// Retries is the number of times the request was sent again because of
+	// the status code (see Runner.RetryStatus)
+	Retries int
 
-	Hide bool // can be set by a filter, response should not be displayed
+	// Method is the method used for the request if it was not taken from
+	// the template (see Runner.HeadFirst)
+	Method string
+
+	// Target identifies the target the request was sent to (e.g. the URL of
+	// the template) if several targets are scanned at once
+	Target string
+
+	// MetaRefresh contains the targets of the redirects found in the body
+	// or the Refresh header (see RefreshTarget), in the order they were
+	// found, only set if requested from the runner
+	MetaRefresh []string
+
+	// Simhash is the fingerprint of the (decompressed) body, it is only set
+	// if requested from the runner
+	Simhash uint64
+
+	// HeaderFingerprint is the hash of the header names (and values) of the
+	// response (see HeaderFingerprint), it is only set if requested from the
+	// runner
+	HeaderFingerprint uint64
+
+	// TLSFingerprint is the JA3 fingerprint of the ClientHello sent for the
+	// connection, it is only set if the template has a TLS fingerprint
+	TLSFingerprint string
+
+	Hide     bool   // can be set by a filter, response should not be displayed
+	HiddenBy string // name of the filter which hid the response
 }
 
 func quote(strs []string) []string {
@@ -46,33 +105,120 @@ func quote(strs []string) []string {
 	return res
 }
 
+// Cancelled returns true if the request has been cancelled, either directly,
+// because the deadline for the run has been reached or because the maximum
+// number of requests has been sent.
+func (r Response) Cancelled() bool {
+	err := r.Error
+	if e, ok := err.(*url.Error); ok {
+		err = e.Err
+	}
+
+	return err == context.Canceled || err == context.DeadlineExceeded || err == ErrBudgetExhausted
+}
+
 func (r Response) String() string {
+	return r.Format(Columns{})
+}
+
+// Columns selects the optional columns printed by Format after the body size.
+type Columns struct {
+	Reflected bool // the number of reflections of the value
+	TTFB      bool // the time to first byte
+	WAF       bool // the name of the web application firewall (at the end of the line)
+	Trailers  bool // the trailers (at the end of the line)
+}
+
+// Width returns the number of characters used by the optional columns,
+// including the separating spaces.
+func (c Columns) Width() (width int) {
+	if c.Reflected {
+		width += 10
+	}
+	if c.TTFB {
+		width += 9
+	}
+	return width
+}
+
+// Format returns a string representation of r with the optional columns
+// selected in c.
+func (r Response) Format(c Columns) string {
 	if r.Error != nil {
 		// don't print anything if the request has been cancelled
-		if r.Error == context.Canceled {
-			return ""
-		}
-		if e, ok := r.Error.(*url.Error); ok && e.Err == context.Canceled {
+		if r.Cancelled() {
 			return ""
 		}
 
-		return fmt.Sprintf("%7s %18s   %v", "error", r.Error, r.Item)
+		status := fmt.Sprintf("%7s %*s   %v", "error", 18+c.Width(), r.Error, r.Item)
+		if r.Target != "" {
+			status += " target: " + r.Target
+		}
+		return status
 	}
 
 	res := r.HTTPResponse
-	status := fmt.Sprintf("%7d %8d %8d   %-8v", res.StatusCode, r.Header.Bytes, r.Body.Bytes, r.Item)
+	status := fmt.Sprintf("%7d %8d %8d", res.StatusCode, r.Header.Bytes, r.Body.Bytes)
+	if c.Reflected {
+		status += fmt.Sprintf(" %9d", r.Reflected)
+	}
+	if c.TTFB {
+		status += fmt.Sprintf(" %8v", r.TTFB.Round(100*time.Microsecond))
+	}
+	status += fmt.Sprintf("   %-8v", r.Item)
+	if r.Target != "" {
+		status += " target: " + r.Target
+	}
+
 	if res.StatusCode >= 300 && res.StatusCode < 400 {
 		loc, ok := res.Header["Location"]
 		if ok {
-			status += ", Location: " + loc[0]
+			status += ", Location: " + Sanitize(loc[0])
 		}
 	}
+	if c.WAF && r.WAF != "" {
+		status += " waf: " + r.WAF
+	}
+	if c.Trailers && len(r.Trailer) > 0 {
+		status += " trailers: " + formatTrailer(r.Trailer)
+	}
 	if len(r.Extract) > 0 {
 		status += " data: " + strings.Join(quote(r.Extract), ", ")
 	}
 	return status
 }
 
+// formatTrailer returns the trailers as "Name=value" pairs sorted by name.
+func formatTrailer(trailer http.Header) string {
+	names := make([]string, 0, len(trailer))
+	for name := range trailer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(strings.Join(trailer[name], ", ")))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// readTrailer returns the trailers of res which have a value, they are only
+// available after the body has been read completely.
+func readTrailer(res *http.Response) http.Header {
+	var trailer http.Header
+	for name, values := range res.Trailer {
+		if len(values) == 0 {
+			continue
+		}
+		if trailer == nil {
+			trailer = make(http.Header)
+		}
+		trailer[name] = values
+	}
+	return trailer
+}
+
 func extractRegexp(buf []byte, targets []*regexp.Regexp) (data []string) {
 	for _, reg := range targets {
 		if !reg.Match(buf) {
@@ -96,7 +242,7 @@ func extractRegexp(buf []byte, targets []*regexp.Regexp) (data []string) {
 	return data
 }
 
-func extractCommand(buf []byte, cmds [][]string) (data []string, err error) {
+func extractCommand(buf []byte, env []string, cmds [][]string) (data []string, err error) {
 	for _, command := range cmds {
 		if len(command) < 1 {
 			panic("command is invalid")
@@ -104,6 +250,7 @@ func extractCommand(buf []byte, cmds [][]string) (data []string, err error) {
 		cmd := exec.Command(command[0], command[1:]...)
 		cmd.Stdin = bytes.NewReader(buf)
 		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), env...)
 
 		buf, err := cmd.Output()
 		if err != nil {
@@ -131,14 +278,27 @@ func (r *Response) ReadBody(body io.Reader, maxBodySize int) (err error) {
 	return err
 }
 
+// CountBody computes the statistics for at most maxBodySize bytes of the body
+// like ReadBody, but without keeping the body in memory.
+func (r *Response) CountBody(body io.Reader, maxBodySize int) (err error) {
+	r.Body, err = Count(io.LimitReader(body, int64(maxBodySize)))
+	return err
+}
+
 // ExtractBody extracts data from the HTTP response body.
 func (r *Response) ExtractBody(targets []*regexp.Regexp) {
 	r.Extract = append(r.Extract, extractRegexp(r.RawBody, targets)...)
 }
 
-// ExtractBodyCommand extracts data from the HTTP response body by running an external command.
+// ExtractBodyCommand extracts data from the HTTP response body by running an
+// external command. The body is passed on stdin, the status, URL and headers
+// in the environment variables from Env and HeaderEnv.
 func (r *Response) ExtractBodyCommand(cmds [][]string) (err error) {
-	data, err := extractCommand(r.RawBody, cmds)
+	if len(cmds) == 0 {
+		return nil
+	}
+
+	data, err := extractCommand(r.RawBody, append(r.Env(), r.HeaderEnv()...), cmds)
 	if err != nil {
 		return err
 	}
@@ -146,14 +306,27 @@ func (r *Response) ExtractBodyCommand(cmds [][]string) (err error) {
 	return err
 }
 
-// ExtractHeader extracts data from an HTTP header. This fills r.Header.
-func (r *Response) ExtractHeader(res *http.Response, targets []*regexp.Regexp) error {
-	buf, err := httputil.DumpResponse(res, false)
-	if err != nil {
-		return err
+// ExtractHeader extracts data from an HTTP header. This fills r.Header and
+// r.RawHeader.
+//
+// If raw is not nil, it is used as the header exactly as it was received from
+// the server. Otherwise, the header is reconstructed from res: it consists of
+// the status line, each header line formatted as "Name: value\r\n" with the
+// canonical header name, and the terminating empty line "\r\n". The size of
+// the reconstructed header may differ from the data sent by the server, e.g.
+// in whitespace, line terminators or for HTTP/2.
+func (r *Response) ExtractHeader(res *http.Response, raw []byte, targets []*regexp.Regexp) error {
+	buf := raw
+	if buf == nil {
+		var err error
+		buf, err = httputil.DumpResponse(res, false)
+		if err != nil {
+			return err
+		}
 	}
 
 	r.RawHeader = buf
+	var err error
 	r.Header, err = Count(bytes.NewReader(buf))
 	r.Extract = append(r.Extract, extractRegexp(buf, targets)...)
 
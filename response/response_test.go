@@ -1,6 +1,8 @@
 package response
 
 import (
+	"errors"
+	"net/http"
 	"reflect"
 	"regexp"
 	"strings"
@@ -123,3 +125,33 @@ func TestExtractBody(t *testing.T) {
 		})
 	}
 }
+
+func TestFormat(t *testing.T) {
+	res := Response{
+		Item:         "foo",
+		HTTPResponse: &http.Response{StatusCode: 200},
+		Header:       TextStats{Bytes: 100},
+		Body:         TextStats{Bytes: 20},
+	}
+
+	var tests = []struct {
+		columns Columns
+		res     Response
+		want    string
+	}{
+		{Columns{}, res, "    200      100       20   foo     "},
+		{Columns{Trailers: true}, res, "    200      100       20   foo     "},
+		{Columns{Trailers: true}, Response{Item: "bar", HTTPResponse: &http.Response{StatusCode: 200}, Trailer: http.Header{"Grpc-Status": {"0"}, "Grpc-Message": {"a b"}}}, `    200        0        0   bar      trailers: Grpc-Message="a b" Grpc-Status="0"`},
+		{Columns{}, Response{Item: "bar", HTTPResponse: &http.Response{StatusCode: 302, Header: http.Header{"Location": {"/\x1b[2Jx"}}}}, `    302        0        0   bar     , Location: /\x1b[2Jx`},
+		{Columns{}, Response{Item: "bar", HTTPResponse: &http.Response{StatusCode: 200}, Target: "https://a.example.com/FUZZ"}, "    200        0        0   bar      target: https://a.example.com/FUZZ"},
+		{Columns{}, Response{Item: "bar", Error: errors.New("failed"), Target: "https://b.example.com/FUZZ"}, "  error             failed   bar target: https://b.example.com/FUZZ"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if s := test.res.Format(test.columns); s != test.want {
+				t.Errorf("wrong string, want %q, got %q", test.want, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{" 5 ", 5 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Thu, 02 Jan 2020 03:04:35 GMT", 30 * time.Second, true},
+		{"Thu, 02 Jan 2020 03:00:00 GMT", 0, true},
+		// must not overflow to a negative duration
+		{"9223372036854775807", time.Duration(maxRetryAfterSeconds) * time.Second, true},
+		{"10000000000000", time.Duration(maxRetryAfterSeconds) * time.Second, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			delay, ok := parseRetryAfter(test.value, now)
+			if ok != test.ok || delay != test.delay {
+				t.Errorf("wrong result for %q, want %v %v, got %v %v", test.value, test.delay, test.ok, delay, ok)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	var tests = []struct {
+		retryAfter string
+		retries    int
+		delay      time.Duration
+	}{
+		{"", 0, 100 * time.Millisecond},
+		{"", 1, 200 * time.Millisecond},
+		{"", 3, 800 * time.Millisecond},
+		{"", 100, MaxRetryAfter},
+		{"3", 2, 3 * time.Second},
+		{"86400", 0, MaxRetryAfter},
+		{"9223372036854775807", 0, MaxRetryAfter},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r := &Runner{RetryStatusDelay: 100 * time.Millisecond}
+			res := Response{
+				HTTPResponse: &http.Response{StatusCode: 503, Header: http.Header{}},
+				Retries:      test.retries,
+			}
+			if test.retryAfter != "" {
+				res.HTTPResponse.Header.Set("Retry-After", test.retryAfter)
+			}
+
+			delay := r.retryDelay(res)
+			if delay != test.delay {
+				t.Errorf("wrong delay, want %v, got %v", test.delay, delay)
+			}
+		})
+	}
+}
+
+func TestRetryStatus(t *testing.T) {
+	var tests = []struct {
+		failures int32 // number of 503 responses before 200 is returned
+		max      int
+		codes    []int
+
+		status   int
+		retries  int
+		requests int32
+	}{
+		{failures: 0, max: 3, codes: []int{503}, status: 200, retries: 0, requests: 1},
+		{failures: 2, max: 3, codes: []int{429, 503}, status: 200, retries: 2, requests: 3},
+		{failures: 5, max: 3, codes: []int{503}, status: 503, retries: 3, requests: 4},
+		{failures: 2, max: 3, codes: []int{429}, status: 503, retries: 0, requests: 1},
+		{failures: 2, max: 0, codes: []int{503}, status: 503, retries: 0, requests: 1},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= test.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.RetryStatus = test.codes
+			runner.RetryStatusMax = test.max
+			runner.RetryStatusDelay = time.Hour // not used, Retry-After is sent
+
+			runner.Run(context.Background())
+			res := <-output
+
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			if res.Status() != test.status {
+				t.Errorf("wrong status, want %d, got %d", test.status, res.Status())
+			}
+
+			if res.Retries != test.retries {
+				t.Errorf("wrong number of retries, want %d, got %d", test.retries, res.Retries)
+			}
+
+			if requests != test.requests {
+				t.Errorf("wrong number of requests, want %d, got %d", test.requests, requests)
+			}
+		})
+	}
+}
+
+func TestRetryStatusCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	template := request.New("")
+	template.URL = srv.URL + "/FUZZ"
+
+	tr, err := NewTransport(template, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := make(chan string, 1)
+	input <- "test"
+	close(input)
+
+	output := make(chan Response, 1)
+	runner := NewRunner(tr, template, input, output)
+	runner.RetryStatus = []int{503}
+	runner.RetryStatusMax = 3
+	runner.RetryStatusDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	runner.Run(ctx)
+
+	// waiting for the retry is aborted when the context is cancelled
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Run did not return after the context was cancelled")
+	}
+}
@@ -1,16 +1,23 @@
 package response
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,37 +30,144 @@ import (
 type Runner struct {
 	Template *request.Request
 
-	MaxBodySize int
-	Extract     []*regexp.Regexp
+	// Target is set in each response (see Response.Target), it is used when
+	// several targets are scanned at once.
+	Target string
+
+	MaxBodySize   int
+	Extract       []*regexp.Regexp
+	RecordRequest bool // keep a copy of the request in each response
+	NoDecompress  bool // keep compressed response bodies as they were received
+	NoBody        bool // only compute the statistics for the body, don't keep it
+	FindReflected bool // count how often the value is contained in each response
+	DetectWAF     bool // recognize block pages of web application firewalls
+
+	// NormalizeReflection computes the statistics for the header and body
+	// with the value replaced by the placeholder from the template.
+	NormalizeReflection bool
+
+	// Simhash computes the fingerprint of each body, also with NoBody.
+	Simhash bool
+
+	// HeaderFingerprint computes the fingerprint of the header of each
+	// response, including the values if HeaderFingerprintValues is set.
+	HeaderFingerprint       bool
+	HeaderFingerprintValues bool
+
+	// HeadFirst sends each request with HEAD first. The request is sent
+	// again with GET if the server does not support HEAD (status 405 or 501)
+	// or HeadFirstGet returns true for the response, e.g. because the body
+	// is needed.
+	HeadFirst    bool
+	HeadFirstGet func(Response) bool
+
+	// FollowMetaRefresh is the maximum number of redirects with a meta
+	// refresh tag, a Refresh header or JavaScript which are followed (see
+	// RefreshTarget), the body must not be dropped with NoBody.
+	FollowMetaRefresh int
+
+	// StreamCommands are run for each response and receive the body on
+	// stdin while it is read, so it does not need to be kept in memory. The
+	// output is added to the extracted data, errors are passed to
+	// StreamError.
+	StreamCommands [][]string
+	StreamError    func(error)
+
+	// AllowedHosts is the list of hosts requests may be sent to, including
+	// redirects. If it is nil, all hosts are allowed.
+	AllowedHosts HostList
+
+	// Retry is called for each response, if it returns true the request is
+	// sent again, at most MaxRetries times.
+	Retry      func(Response) bool
+	MaxRetries int
+
+	// RetryStatus are the status codes for which a request is sent again
+	// after a delay, at most RetryStatusMax times. The delay is taken from
+	// the Retry-After header (at most MaxRetryAfter), otherwise it starts at
+	// RetryStatusDelay and doubles for each retry (up to MaxRetryAfter).
+	RetryStatus      []int
+	RetryStatusMax   int
+	RetryStatusDelay time.Duration
+
+	// Budget limits the number of requests sent (if set), it can be shared
+	// between runners.
+	Budget *Budget
 
 	Client    *http.Client
 	Transport *http.Transport
 
+	// InsecureTransport (see NewInsecureTransport) is used for requests to
+	// the hosts in Template.InsecureHosts, it can be shared between runners.
+	// If it is nil, certificates are verified for all hosts.
+	InsecureTransport *http.Transport
+
 	input  <-chan string
 	output chan<- Response
 }
 
+// MaxRetryAfter is the maximum delay taken from the Retry-After header of a
+// response before a request is sent again.
+const MaxRetryAfter = time.Minute
+
 // DefaultMaxBodySize is the default size for peeking at the body to extract strings via regexp.
 const DefaultMaxBodySize = 5 * 1024 * 1024
 
-// NewTransport creates a new shared transport for clients to use.
-func NewTransport(insecure bool, TLSClientCertKeyFilename string,
-	disableHTTP2 bool, concurrentRequests int) (*http.Transport, error) {
+// Default timeouts for establishing connections, used when the request
+// template does not set them.
+const (
+	DefaultConnectTimeout      = 30 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// responseHeaderTimeout is the time to wait for the header of a response
+// after the request has been sent.
+const responseHeaderTimeout = 10 * time.Second
+
+// timeouts returns the timeouts for establishing a connection and for the TLS
+// handshake from the template, or the defaults.
+func timeouts(template *request.Request) (connect, handshake time.Duration) {
+	connect = DefaultConnectTimeout
+	if template.ConnectTimeout > 0 {
+		connect = template.ConnectTimeout
+	}
+
+	handshake = DefaultTLSHandshakeTimeout
+	if template.TLSHandshakeTimeout > 0 {
+		handshake = template.TLSHandshakeTimeout
+	}
+
+	return connect, handshake
+}
+
+// NewTransport creates a new shared transport for clients to use. The
+// transport related options are taken from the request template.
+func NewTransport(template *request.Request, concurrentRequests int) (*http.Transport, error) {
+	maxIdleConns := concurrentRequests
+	if template.MaxIdleConns > 0 {
+		maxIdleConns = template.MaxIdleConns
+	}
+
+	connectTimeout, handshakeTimeout := timeouts(template)
+
 	// for timeouts, see
 	// https://blog.cloudflare.com/the-complete-guide-to-golang-net-http-timeouts/
 	tr := &http.Transport{
 		Proxy:                 http.ProxyFromEnvironment,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ResponseHeaderTimeout: 10 * time.Second,
+		TLSHandshakeTimeout:   handshakeTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
 		ExpectContinueTimeout: 1 * time.Second,
 		IdleConnTimeout:       15 * time.Second,
+		DisableCompression:    true, // bodies are decompressed by the runner
 		TLSClientConfig:       &tls.Config{},
-		MaxIdleConns:          concurrentRequests,
-		MaxIdleConnsPerHost:   concurrentRequests,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConns,
+		MaxConnsPerHost:       template.MaxConnsPerHost,
+		DisableKeepAlives:     template.DisableKeepAlives,
 	}
 
 	dialer := &net.Dialer{
-		Timeout:   30 * time.Second,
+		Timeout:   connectTimeout,
 		KeepAlive: 30 * time.Second,
 	}
 
@@ -73,11 +187,48 @@ func NewTransport(insecure bool, TLSClientCertKeyFilename string,
 		tr.DialContext = socks5Dialer.DialContext
 	}
 
-	if insecure {
+	if template.Connect != "" {
+		// all connections are tunneled via the proxy to the same target
+		scheme := "http"
+		if strings.HasPrefix(strings.ToLower(template.URL), "https://") {
+			scheme = "https"
+		}
+
+		proxyURL, err := connectProxy(scheme)
+		if err != nil {
+			return nil, fmt.Errorf("--connect: %v", err)
+		}
+
+		tr.Proxy = nil
+		tr.DialContext = connectDialer(tr.DialContext, proxyURL, template.Connect)
+	}
+
+	if template.Insecure {
 		tr.TLSClientConfig.InsecureSkipVerify = true
 	}
 
-	if !disableHTTP2 {
+	if template.WireHeaderSize {
+		// record all data received so that the header size can be computed
+		// exactly, this requires establishing TLS connections ourselves
+		tr.DialContext, tr.DialTLSContext = captureDialer(tr.DialContext, tr.TLSClientConfig, tr.TLSHandshakeTimeout)
+	}
+
+	if template.TLSFingerprint != "" {
+		if template.WireHeaderSize || template.RawMode() {
+			return nil, errors.New("--tls-fingerprint cannot be used with --wire-header-size and requests sent raw (e.g. --raw-request)")
+		}
+
+		id, err := clientHelloID(template.TLSFingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		// the ClientHello is sent by uTLS, so TLS connections are established
+		// by us and only use HTTP/1.1
+		tr.DialTLSContext = fingerprintDialer(tr.DialContext, tr.TLSClientConfig, id, tr.TLSHandshakeTimeout)
+	}
+
+	if !template.DisableHTTP2 && !template.WireHeaderSize && template.TLSFingerprint == "" {
 		// enable http2
 		err := http2.ConfigureTransport(tr)
 		if err != nil {
@@ -85,8 +236,8 @@ func NewTransport(insecure bool, TLSClientCertKeyFilename string,
 		}
 	}
 
-	if TLSClientCertKeyFilename != "" {
-		certs, key, err := readPEMCertKey(TLSClientCertKeyFilename)
+	if template.TLSClientKeyCertFile != "" {
+		certs, key, err := readPEMCertKey(template.TLSClientKeyCertFile)
 		if err != nil {
 			return nil, err
 		}
@@ -173,7 +324,32 @@ func NewRunner(tr *http.Transport, template *request.Request, input <-chan strin
 	}
 }
 
-func (r *Runner) request(ctx context.Context, item string) (response Response) {
+func (r *Runner) request(ctx context.Context, item string) Response {
+	if r.Template.RawMode() {
+		return r.rawRequest(ctx, item)
+	}
+
+	if !r.HeadFirst {
+		return r.requestMethod(ctx, item, "")
+	}
+
+	res := r.requestMethod(ctx, item, http.MethodHead)
+	if res.Error != nil {
+		return res
+	}
+
+	// servers which do not support HEAD
+	notSupported := res.HTTPResponse.StatusCode == http.StatusMethodNotAllowed || res.HTTPResponse.StatusCode == http.StatusNotImplemented
+	if !notSupported && (r.HeadFirstGet == nil || !r.HeadFirstGet(res)) {
+		return res
+	}
+
+	return r.requestMethod(ctx, item, http.MethodGet)
+}
+
+// requestMethod sends the request for item, using method instead of the one
+// from the template if it is not empty.
+func (r *Runner) requestMethod(ctx context.Context, item, method string) (response Response) {
 	req, err := r.Template.Apply(item)
 	if err != nil {
 		response.Error = err
@@ -185,6 +361,67 @@ func (r *Runner) request(ctx context.Context, item string) (response Response) {
 		Item: item,
 	}
 
+	if method != "" {
+		req.Method = method
+		response.Method = method
+	}
+
+	err = r.checkHost(req.URL)
+	if err != nil {
+		response.Error = err
+		return
+	}
+
+	// request compressed data like the transport does by default, so that the
+	// size of the compressed body can be recorded
+	if req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" && req.Method != http.MethodHead {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	if r.RecordRequest {
+		response.RawRequest, err = httputil.DumpRequestOut(req, true)
+		if err != nil {
+			response.Error = err
+			return
+		}
+	}
+
+	r.do(ctx, req, &response)
+
+	if r.FollowMetaRefresh > 0 && response.Error == nil {
+		r.followRefresh(ctx, req, &response)
+	}
+
+	return
+}
+
+// do sends req and records the result in response.
+func (r *Runner) do(ctx context.Context, req *http.Request, response *Response) {
+	// if the transport records the data received on the wire, enable
+	// capturing for the connection used for the request
+	var conn *captureConn
+	var wrote time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if c, ok := info.Conn.(*captureConn); ok {
+				conn = c
+				c.start()
+			}
+			if c, ok := info.Conn.(*fingerprintConn); ok {
+				response.TLSFingerprint = c.ja3
+			}
+		},
+		// measure the time to first byte for each request (when redirects
+		// are followed, the last one is kept)
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			wrote = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			response.TTFB = time.Since(wrote)
+		},
+	}
+	ctx = httptrace.WithClientTrace(ctx, trace)
+
 	start := time.Now()
 	res, err := r.Client.Do(req.WithContext(ctx))
 	response.Duration = time.Since(start)
@@ -193,7 +430,12 @@ func (r *Runner) request(ctx context.Context, item string) (response Response) {
 		return
 	}
 
-	err = response.ReadBody(res.Body, r.MaxBodySize)
+	var rawHeader []byte
+	if conn != nil {
+		rawHeader = wireHeader(conn.stop(), res.StatusCode)
+	}
+
+	err = r.readBody(response, res)
 	if err != nil {
 		response.Error = err
 		return
@@ -202,7 +444,7 @@ func (r *Runner) request(ctx context.Context, item string) (response Response) {
 	// dump the header and extract data now so the stats about the header are
 	// present when the filter runs in the next step. We need to dump the header
 	// for that, so we can easily run data extraction in the same step.
-	err = response.ExtractHeader(res, r.Extract)
+	err = response.ExtractHeader(res, rawHeader, r.Extract)
 	if err != nil {
 		response.Error = err
 		return
@@ -215,14 +457,336 @@ func (r *Runner) request(ctx context.Context, item string) (response Response) {
 	}
 
 	response.HTTPResponse = res
+	response.Trailer = readTrailer(res)
+	response.setCanonicalURL(res)
 
-	return
+	if r.NormalizeReflection {
+		err = response.NormalizeReflection(r.Template.Replace)
+		if err != nil {
+			response.Error = err
+			return
+		}
+	}
+
+	if r.FindReflected {
+		response.CountReflected()
+	}
+
+	if r.DetectWAF {
+		response.DetectWAF()
+	}
+}
+
+// readBody reads the body of res (or only computes the statistics if NoBody
+// is set) and decompresses it unless NoDecompress is set.
+func (r *Runner) readBody(response *Response, res *http.Response) error {
+	encoding := res.Header.Get("Content-Encoding")
+	if r.NoDecompress {
+		encoding = ""
+	}
+
+	if len(r.StreamCommands) > 0 {
+		return r.streamBody(response, res, encoding)
+	}
+
+	// compressed bodies are decompressed in memory
+	if r.NoBody && encoding == "" {
+		if !r.Simhash {
+			return response.CountBody(res.Body, r.MaxBodySize)
+		}
+
+		var h Simhasher
+		err := response.CountBody(io.TeeReader(res.Body, &h), r.MaxBodySize)
+		response.Simhash = h.Sum()
+		return err
+	}
+
+	err := response.ReadBody(res.Body, r.MaxBodySize)
+	if err != nil {
+		return err
+	}
+
+	err = response.DecompressBody(encoding, r.MaxBodySize)
+	if err != nil {
+		return err
+	}
+
+	if r.Simhash {
+		response.Simhash = Simhash(response.RawBody)
+	}
+
+	if r.NoBody {
+		response.RawBody = nil
+	}
+
+	return nil
+}
+
+// send executes the request for item, retrying it if necessary. All requests
+// use a context derived from ctx, so cancelling ctx aborts a request in
+// flight immediately (including reading the body), and the resources bound
+// to the context are released when the request is done.
+func (r *Runner) send(ctx context.Context, item string) Response {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	res := r.attempt(ctx, item)
+	for i := 0; i < r.MaxRetries && r.Retry != nil && ctx.Err() == nil && r.Retry(res); i++ {
+		next := r.attempt(ctx, item)
+		if errors.Is(next.Error, ErrBudgetExhausted) {
+			// keep the last response which was received
+			break
+		}
+		res = next
+	}
+
+	for res.Retries < r.RetryStatusMax && r.retryStatus(res) && ctx.Err() == nil {
+		select {
+		case <-time.After(r.retryDelay(res)):
+		case <-ctx.Done():
+			return res
+		}
+
+		next := r.attempt(ctx, item)
+		if errors.Is(next.Error, ErrBudgetExhausted) {
+			break
+		}
+		next.Retries = res.Retries + 1
+		res = next
+	}
+
+	return res
+}
+
+// retryStatus returns true if res has one of the status codes in RetryStatus.
+func (r *Runner) retryStatus(res Response) bool {
+	if res.HTTPResponse == nil {
+		return false
+	}
+
+	for _, code := range r.RetryStatus {
+		if res.HTTPResponse.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryDelay returns the time to wait before res is retried: the delay
+// requested in the Retry-After header (in seconds or as a date) if present,
+// otherwise RetryStatusDelay doubled for each previous retry.
+func (r *Runner) retryDelay(res Response) time.Duration {
+	if delay, ok := parseRetryAfter(res.HTTPResponse.Header.Get("Retry-After"), time.Now()); ok {
+		if delay > MaxRetryAfter {
+			delay = MaxRetryAfter
+		}
+		return delay
+	}
+
+	delay := r.RetryStatusDelay
+	for i := 0; i < res.Retries && delay < MaxRetryAfter; i++ {
+		delay *= 2
+	}
+	if delay > MaxRetryAfter {
+		delay = MaxRetryAfter
+	}
+	return delay
 }
 
-// Run processes items read from ch and executes HTTP requests.
+// maxRetryAfterSeconds is the largest number of seconds which can be
+// represented as a time.Duration.
+const maxRetryAfterSeconds = int64(math.MaxInt64 / time.Second)
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date, relative to now.
+func parseRetryAfter(s string, now time.Time) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		// the multiplication below would overflow for large values
+		if secs > maxRetryAfterSeconds {
+			secs = maxRetryAfterSeconds
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := http.ParseTime(s)
+	if err != nil {
+		return 0, false
+	}
+
+	delay := t.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// countingReader counts the bytes read from rd.
+type countingReader struct {
+	rd io.Reader
+	n  int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.rd.Read(p)
+	c.n += n
+	return n, err
+}
+
+// streamBody reads at most MaxBodySize bytes of the body of res, decompresses
+// it on the fly according to encoding and passes it to the StreamCommands
+// while it is read. The body is only kept in memory if NoBody is not set.
+func (r *Runner) streamBody(response *Response, res *http.Response, encoding string) error {
+	compressed := &countingReader{rd: io.LimitReader(res.Body, int64(r.MaxBodySize))}
+	var rd io.Reader = compressed
+
+	// the encodings are removed in reverse order, all of them must be
+	// supported, otherwise the body is passed on as it was received
+	decoded := false
+	if encodings := strings.Split(encoding, ","); encoding != "" && supportedEncodings(encodings) {
+		for i := len(encodings) - 1; i >= 0; i-- {
+			if strings.TrimSpace(encodings[i]) == "identity" {
+				continue
+			}
+
+			dec, ok := decoder(encodings[i], rd)
+			if !ok {
+				return fmt.Errorf("invalid %v data in body", strings.TrimSpace(encodings[i]))
+			}
+			rd = dec
+			decoded = true
+		}
+		rd = io.LimitReader(rd, int64(r.MaxBodySize))
+	}
+
+	var buf *bytes.Buffer
+	if !r.NoBody {
+		buf = &bytes.Buffer{}
+		rd = io.TeeReader(rd, buf)
+	}
+
+	var h *Simhasher
+	if r.Simhash {
+		h = &Simhasher{}
+		rd = io.TeeReader(rd, h)
+	}
+
+	tee, wait, err := streamCommands(rd, streamEnv(response, res), r.StreamCommands)
+	if err != nil {
+		r.streamError(err)
+		tee = rd
+	}
+
+	response.Body, err = Count(tee)
+	// the compressed data may have been truncated at MaxBodySize
+	if err == io.ErrUnexpectedEOF && decoded {
+		err = nil
+	}
+
+	if wait != nil {
+		data, werr := wait()
+		if werr != nil {
+			r.streamError(werr)
+		}
+		response.Extract = append(response.Extract, data...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if decoded {
+		response.CompressedBodySize = compressed.n
+	}
+
+	if h != nil {
+		response.Simhash = h.Sum()
+	}
+
+	if buf != nil {
+		response.RawBody = buf.Bytes()
+	}
+
+	return nil
+}
+
+func (r *Runner) streamError(err error) {
+	if r.StreamError != nil {
+		r.StreamError(err)
+	}
+}
+
+// Run processes items read from ch and executes HTTP requests. When ctx is
+// cancelled, requests in flight are aborted and Run returns.
 func (r *Runner) Run(ctx context.Context) {
-	for item := range r.input {
-		res := r.request(ctx, item)
+	if r.AllowedHosts != nil {
+		r.Client.CheckRedirect = r.checkRedirectHost(r.Client.CheckRedirect)
+	}
+
+	if len(r.Template.InsecureHosts) > 0 && r.InsecureTransport != nil {
+		tr := r.Client.Transport
+		if tr == nil {
+			tr = http.DefaultTransport
+		}
+		r.Client.Transport = insecureHostsTransport{
+			RoundTripper: tr,
+			insecure:     r.InsecureTransport,
+			hosts:        HostList(r.Template.InsecureHosts),
+		}
+	}
+
+	if r.Budget != nil {
+		tr := r.Client.Transport
+		if tr == nil {
+			tr = http.DefaultTransport
+		}
+		r.Client.Transport = budgetTransport{RoundTripper: tr, budget: r.Budget}
+	}
+
+	// stop when the maximum number of requests has been sent, also while
+	// waiting for the next value (which may never come, e.g. for --follow)
+	var exhausted <-chan struct{}
+	if r.Budget != nil {
+		exhausted = r.Budget.Done()
+	}
+
+	for {
+		var item string
+		select {
+		case <-exhausted:
+			return
+		case <-ctx.Done():
+			return
+		case v, ok := <-r.input:
+			if !ok {
+				return
+			}
+			item = v
+		}
+
+		if r.Budget != nil && r.Budget.Exhausted() {
+			return
+		}
+
+		// don't start new requests for buffered items when the run has
+		// been cancelled
+		if ctx.Err() != nil {
+			return
+		}
+
+		res := r.send(ctx, item)
+		res.Target = r.Target
+		if r.HeaderFingerprint && res.HTTPResponse != nil {
+			res.HeaderFingerprint = HeaderFingerprint(res.HTTPResponse.Header, r.HeaderFingerprintValues)
+		}
 
 		select {
 		case <-ctx.Done():
@@ -0,0 +1,1042 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"github.com/google/go-cmp/cmp"
+)
+
+// rawServer starts a server which answers all requests with the given raw
+// response. The function connections returns the number of connections
+// accepted so far.
+func rawServer(t testing.TB, response string) (url string, connections func() int, cleanup func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var conns int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&conns, 1)
+
+			go func() {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for {
+					req, err := http.ReadRequest(rd)
+					if err != nil {
+						return
+					}
+					_ = req.Body.Close()
+
+					_, err = conn.Write([]byte(response))
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	connections = func() int { return int(atomic.LoadInt32(&conns)) }
+	return "http://" + l.Addr().String() + "/", connections, func() { _ = l.Close() }
+}
+
+func runRequest(t testing.TB, template *request.Request) Response {
+	return runRequests(t, template, "test")[0]
+}
+
+// runRequests sends one request for each value, one after the other, with the
+// same transport and runner.
+func runRequests(t testing.TB, template *request.Request, values ...string) []Response {
+	tr, err := NewTransport(template, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := make(chan string, len(values))
+	for _, value := range values {
+		input <- value
+	}
+	close(input)
+
+	output := make(chan Response, len(values))
+	runner := NewRunner(tr, template, input, output)
+	runner.Run(context.Background())
+	close(output)
+
+	var responses []Response
+	for res := range output {
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		responses = append(responses, res)
+	}
+
+	return responses
+}
+
+func TestHeaderSize(t *testing.T) {
+	const header = "HTTP/1.1 200 OK\r\n" +
+		"x-foo:bar\r\n" +
+		"Content-Length: 3\n" +
+		"\r\n"
+
+	url, connections, cleanup := rawServer(t, header+"foo")
+	defer cleanup()
+
+	var tests = []struct {
+		wire      bool
+		want      int
+		rawHeader string
+	}{
+		{
+			// reconstructed header: status line, canonical header names,
+			// "Name: value" and CRLF line terminators
+			wire:      false,
+			want:      50,
+			rawHeader: "HTTP/1.1 200 OK\r\nContent-Length: 3\r\nX-Foo: bar\r\n\r\n",
+		},
+		{
+			// exactly as received on the wire
+			wire:      true,
+			want:      48,
+			rawHeader: header,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = url
+			template.WireHeaderSize = test.wire
+
+			// send two requests over the same transport to make sure the
+			// data is recorded correctly when connections are reused
+			before := connections()
+			for _, res := range runRequests(t, template, "one", "two") {
+				if res.Header.Bytes != test.want {
+					t.Errorf("wrong header size, want %d, got %d", test.want, res.Header.Bytes)
+				}
+
+				if string(res.RawHeader) != test.rawHeader {
+					t.Errorf("wrong raw header, want %q, got %q", test.rawHeader, res.RawHeader)
+				}
+
+				if res.Body.Bytes != 3 {
+					t.Errorf("wrong body size, want 3, got %d", res.Body.Bytes)
+				}
+			}
+
+			if n := connections() - before; n != 1 {
+				t.Errorf("connection not reused, %d connections established", n)
+			}
+		})
+	}
+}
+
+func TestWireHeader(t *testing.T) {
+	var tests = []struct {
+		data   string
+		status int
+		want   string
+	}{
+		{"HTTP/1.1 200 OK\r\n\r\nbody", 200, "HTTP/1.1 200 OK\r\n\r\n"},
+		{"HTTP/1.1 200 OK\nFoo: bar\n\nbody", 200, "HTTP/1.1 200 OK\nFoo: bar\n\n"},
+		{"HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 404 Not Found\r\nX: y\r\n\r\n", 404, "HTTP/1.1 404 Not Found\r\nX: y\r\n\r\n"},
+		{"HTTP/1.1 200 OK\r\nFoo: bar\r\n", 200, ""},
+		{"\x16\x03\x01garbage", 200, ""},
+		{"HTTP/1.1\r\n\r\n", 200, "HTTP/1.1\r\n\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			res := wireHeader([]byte(test.data), test.status)
+			if string(res) != test.want {
+				t.Fatalf("wrong header returned, want %q, got %q", test.want, res)
+			}
+		})
+	}
+}
+
+func TestHeaderSizeTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["x-foo"] = []string{"bar"}
+		w.Header()["Date"] = nil
+		w.Header()["Content-Type"] = nil
+		_, _ = w.Write([]byte("foo"))
+	}))
+	defer srv.Close()
+
+	template := request.New("")
+	template.URL = srv.URL
+	template.Insecure = true
+	template.WireHeaderSize = true
+
+	res := runRequest(t, template)
+
+	want := "HTTP/1.1 200 OK\r\nx-foo: bar\r\nContent-Length: 3\r\n\r\n"
+	if string(res.RawHeader) != want {
+		t.Errorf("wrong raw header, want %q, got %q", want, res.RawHeader)
+	}
+
+	if res.Header.Bytes != len(want) {
+		t.Errorf("wrong header size, want %d, got %d", len(want), res.Header.Bytes)
+	}
+}
+
+// recordServer starts a server which records the data received on each
+// connection until the client stops sending, then answers with response and
+// closes the connection. The received data is sent to the returned channel.
+func recordServer(t testing.TB, response string) (url string, received <-chan []byte, cleanup func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan []byte, 10)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				var data []byte
+				buf := make([]byte, 4096)
+				for {
+					_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+					n, err := conn.Read(buf)
+					data = append(data, buf[:n]...)
+					if err != nil {
+						break
+					}
+				}
+				ch <- data
+
+				_, _ = conn.Write([]byte(response))
+			}()
+		}
+	}()
+
+	return "http://" + l.Addr().String(), ch, func() { _ = l.Close() }
+}
+
+func TestRawRequest(t *testing.T) {
+	var tests = []struct {
+		request  string
+		response string
+		value    string
+
+		sent      string
+		status    int
+		proto     string
+		rawHeader string
+		body      string
+	}{
+		{
+			request:   "GET /FUZZ HTTP/1.0\r\nHost: example.com\r\n\r\n",
+			value:     "foo",
+			response:  "HTTP/1.0 200 OK\r\nX-Foo: bar\r\n\r\nbody",
+			sent:      "GET /foo HTTP/1.0\r\nHost: example.com\r\n\r\n",
+			status:    200,
+			proto:     "HTTP/1.0",
+			rawHeader: "HTTP/1.0 200 OK\r\nX-Foo: bar\r\n\r\n",
+			body:      "body",
+		},
+		{
+			// non-standard version strings and line endings are sent verbatim
+			request:   "GET / HTTP/9.FUZZ\nhost:  x\n\n",
+			value:     "9x",
+			response:  "HTTP/2 404 Not Found\r\nContent-Length: 3\r\n\r\nfoo",
+			sent:      "GET / HTTP/9.9x\nhost:  x\n\n",
+			status:    404,
+			proto:     "HTTP/2",
+			rawHeader: "HTTP/2 404 Not Found\r\nContent-Length: 3\r\n\r\n",
+			body:      "foo",
+		},
+		{
+			// the response to a HEAD request does not have a body
+			request:   "HEAD / HTTP/1.1\r\nHost: x\r\n\r\n",
+			response:  "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n",
+			sent:      "HEAD / HTTP/1.1\r\nHost: x\r\n\r\n",
+			status:    200,
+			proto:     "HTTP/1.1",
+			rawHeader: "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n",
+			body:      "",
+		},
+		{
+			request:   "POST / HTTP/1.1\r\nContent-Length: 1\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\nFUZZ",
+			value:     "G",
+			response:  "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n",
+			sent:      "POST / HTTP/1.1\r\nContent-Length: 1\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\nG",
+			status:    400,
+			proto:     "HTTP/1.1",
+			rawHeader: "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n",
+			body:      "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			url, received, cleanup := recordServer(t, test.response)
+			defer cleanup()
+
+			tempdir, err := ioutil.TempDir("", "monsoon-test-raw-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tempdir)
+
+			filename := filepath.Join(tempdir, "request")
+			err = ioutil.WriteFile(filename, []byte(test.request), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := request.New("")
+			template.URL = url
+			template.RawFile = filename
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- test.value
+			close(input)
+
+			output := make(chan Response, 1)
+			NewRunner(tr, template, input, output).Run(context.Background())
+
+			res := <-output
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			sent := <-received
+			if string(sent) != test.sent {
+				t.Errorf("wrong data sent, want %q, got %q", test.sent, sent)
+			}
+
+			if res.HTTPResponse.StatusCode != test.status {
+				t.Errorf("wrong status, want %d, got %d", test.status, res.HTTPResponse.StatusCode)
+			}
+
+			if res.HTTPResponse.Proto != test.proto {
+				t.Errorf("wrong protocol, want %q, got %q", test.proto, res.HTTPResponse.Proto)
+			}
+
+			if string(res.RawHeader) != test.rawHeader {
+				t.Errorf("wrong raw header, want %q, got %q", test.rawHeader, res.RawHeader)
+			}
+
+			if string(res.RawBody) != test.body {
+				t.Errorf("wrong body, want %q, got %q", test.body, res.RawBody)
+			}
+		})
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	var tests = []struct {
+		method        string
+		body          string
+		chunked       bool
+		contentLength string
+		value         string
+
+		sent string
+	}{
+		{
+			method:        "POST",
+			body:          "a=FUZZ",
+			contentLength: "100",
+			value:         "foo",
+			sent:          "POST / HTTP/1.1\r\nHost: HOST\r\nContent-Length: 100\r\n\r\na=foo",
+		},
+		{
+			method:        "POST",
+			body:          "0\r\n\r\nGET /admin HTTP/1.1\r\n\r\n",
+			contentLength: "FUZZ",
+			value:         "-1",
+			sent:          "POST / HTTP/1.1\r\nHost: HOST\r\nContent-Length: -1\r\n\r\n0\r\n\r\nGET /admin HTTP/1.1\r\n\r\n",
+		},
+		{
+			method:        "POST",
+			body:          "xFUZZ",
+			chunked:       true,
+			contentLength: "3",
+			value:         "G",
+			sent:          "POST / HTTP/1.1\r\nHost: HOST\r\nTransfer-Encoding: chunked\r\nContent-Length: 3\r\n\r\n2\r\nxG\r\n0\r\n\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			url, received, cleanup := recordServer(t, "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n")
+			defer cleanup()
+
+			template := request.New("")
+			template.URL = url
+			template.Method = test.method
+			template.Body = test.body
+			template.ForceChunkedEncoding = test.chunked
+			template.ContentLength = test.contentLength
+
+			// remove the default headers so that the data sent is deterministic
+			for _, name := range []string{"Accept", "User-Agent"} {
+				err := template.Header.Set(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- test.value
+			close(input)
+
+			output := make(chan Response, 1)
+			NewRunner(tr, template, input, output).Run(context.Background())
+
+			res := <-output
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			want := strings.Replace(test.sent, "HOST", strings.TrimPrefix(url, "http://"), 1)
+			sent := <-received
+			if string(sent) != want {
+				t.Errorf("wrong data sent, want %q, got %q", want, sent)
+			}
+		})
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	var tests = []struct {
+		disableKeepAlives bool
+		want              int32
+	}{
+		{false, 1},
+		{true, 5},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var conns int32
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&conns, 1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+
+			template := request.New("")
+			template.URL = srv.URL
+			template.DisableKeepAlives = test.disableKeepAlives
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 5)
+			for i := 0; i < 5; i++ {
+				input <- "test"
+			}
+			close(input)
+
+			output := make(chan Response, 5)
+			runner := NewRunner(tr, template, input, output)
+			runner.Run(context.Background())
+			close(output)
+
+			for res := range output {
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+			}
+
+			if n := atomic.LoadInt32(&conns); n != test.want {
+				t.Errorf("wrong number of connections, want %d, got %d", test.want, n)
+			}
+		})
+	}
+}
+
+func TestNoBody(t *testing.T) {
+	const body = "foo bar\nbaz\n"
+
+	var tests = []struct {
+		noBody    bool
+		gzip      bool
+		wantBody  string
+		wantStats TextStats
+	}{
+		{false, false, body, TextStats{Bytes: 12, Words: 2, Lines: 2}},
+		{true, false, "", TextStats{Bytes: 12, Words: 2, Lines: 2}},
+		{true, true, "", TextStats{Bytes: 12, Words: 2, Lines: 2}},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.gzip {
+					w.Header().Set("Content-Encoding", "gzip")
+					_, _ = w.Write(compress(t, "gzip", body))
+					return
+				}
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			template := request.New("")
+			template.URL = srv.URL
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.NoBody = test.noBody
+			runner.Run(context.Background())
+
+			res := <-output
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			if string(res.RawBody) != test.wantBody {
+				t.Errorf("wrong body, want %q, got %q", test.wantBody, res.RawBody)
+			}
+
+			if res.Body != test.wantStats {
+				t.Errorf("wrong stats, want %+v, got %+v", test.wantStats, res.Body)
+			}
+		})
+	}
+}
+
+func TestTLSHandshakeTimeout(t *testing.T) {
+	// accept connections, but never send anything
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	for _, wire := range []bool{false, true} {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = "https://" + l.Addr().String() + "/"
+			template.TLSHandshakeTimeout = 100 * time.Millisecond
+			template.WireHeaderSize = wire
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+
+			start := time.Now()
+			runner.Run(context.Background())
+			res := <-output
+
+			if res.Status() != StatusConnTimeout {
+				t.Errorf("wrong status, want %d, got %d (%v)", StatusConnTimeout, res.Status(), res.Error)
+			}
+
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("timeout not applied, request took %v", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestRunnerPort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a free port on which connections are refused
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, closedPort, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+
+	template := request.New("")
+	template.URL = "http://127.0.0.1:FUZZ/"
+
+	tr, err := NewTransport(template, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := make(chan string, 2)
+	input <- port
+	input <- closedPort
+	close(input)
+
+	output := make(chan Response, 2)
+	runner := NewRunner(tr, template, input, output)
+	runner.Run(context.Background())
+	close(output)
+
+	status := make(map[string]int)
+	for res := range output {
+		status[res.Item] = res.Status()
+	}
+
+	if status[port] != http.StatusOK {
+		t.Errorf("wrong status for port %v, want %v, got %v", port, http.StatusOK, status[port])
+	}
+
+	if status[closedPort] != StatusConnRefused {
+		t.Errorf("wrong status for port %v, want %v, got %v", closedPort, StatusConnRefused, status[closedPort])
+	}
+}
+
+func TestRunnerCancel(t *testing.T) {
+	// the server receives the request, but only sends the header (or
+	// nothing at all) and then stalls until the test is done
+	done := make(chan struct{})
+	received := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		if r.URL.Path == "/body" {
+			w.Header().Set("Content-Length", "100")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+		}
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	tempdir, err := ioutil.TempDir("", "monsoon-test-cancel-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	rawFile := filepath.Join(tempdir, "request.txt")
+	err = ioutil.WriteFile(rawFile, []byte("GET /FUZZ HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		path string
+		wire bool
+		raw  bool
+	}{
+		{path: "header"},
+		{path: "body"},
+		{path: "header", wire: true},
+		{path: "body", wire: true},
+		{path: "header", raw: true},
+		{path: "body", raw: true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+			template.WireHeaderSize = test.wire
+			if test.raw {
+				template.RawFile = rawFile
+			}
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the second item must not be requested after cancellation
+			input := make(chan string, 2)
+			input <- test.path
+			input <- test.path
+			close(input)
+
+			output := make(chan Response, 2)
+			runner := NewRunner(tr, template, input, output)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			finished := make(chan struct{})
+			go func() {
+				runner.Run(ctx)
+				close(finished)
+			}()
+
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not receive the request")
+			}
+
+			// wait until the header has been received for a stalled body
+			time.Sleep(50 * time.Millisecond)
+
+			start := time.Now()
+			cancel()
+
+			select {
+			case <-finished:
+			case <-time.After(2 * time.Second):
+				t.Fatal("Run did not return after the context was cancelled")
+			}
+
+			if time.Since(start) > time.Second {
+				t.Errorf("request was not aborted promptly, Run returned after %v", time.Since(start))
+			}
+
+			select {
+			case <-received:
+				t.Errorf("request for the second item was sent after cancellation")
+			default:
+			}
+		})
+	}
+}
+
+func TestStreamCommands(t *testing.T) {
+	body := strings.Repeat("foo bar\n", 1024*1024)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gz.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "foo")
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(compressed.Bytes())
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		path        string
+		maxBodySize int
+		noBody      bool
+		commands    [][]string
+
+		extract      []string
+		bodySize     int
+		keepBody     bool
+		streamErrors int
+	}{
+		{
+			path:        "plain",
+			maxBodySize: 2 * len(body),
+			noBody:      true,
+			// head exits before the body has been read completely
+			commands: [][]string{{"wc", "-l"}, {"head", "-c", "7"}, {"sh", "-c", "echo $MONSOON_VALUE $MONSOON_STATUS $MONSOON_HTTP_X_TEST"}},
+			extract:  []string{"1048576", "foo bar", "plain 200 foo"},
+			bodySize: len(body),
+		},
+		{
+			path:        "gzip",
+			maxBodySize: 2 * len(body),
+			commands:    [][]string{{"wc", "-c"}},
+			extract:     []string{strconv.Itoa(len(body))},
+			bodySize:    len(body),
+			keepBody:    true,
+		},
+		{
+			path:        "plain",
+			maxBodySize: 1000,
+			noBody:      true,
+			commands:    [][]string{{"wc", "-c"}},
+			extract:     []string{"1000"},
+			bodySize:    1000,
+		},
+		{
+			path:         "plain",
+			maxBodySize:  2 * len(body),
+			noBody:       true,
+			commands:     [][]string{{"sh", "-c", "exit 1"}, {"wc", "-l"}},
+			bodySize:     len(body),
+			streamErrors: 1,
+		},
+		{
+			path:         "plain",
+			maxBodySize:  2 * len(body),
+			noBody:       true,
+			commands:     [][]string{{"/invalid/command/does/not/exist"}},
+			bodySize:     len(body),
+			streamErrors: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- test.path
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.MaxBodySize = test.maxBodySize
+			runner.NoBody = test.noBody
+			runner.StreamCommands = test.commands
+
+			var streamErrors int32
+			runner.StreamError = func(err error) {
+				atomic.AddInt32(&streamErrors, 1)
+			}
+
+			runner.Run(context.Background())
+			res := <-output
+
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			var extract []string
+			for _, s := range res.Extract {
+				extract = append(extract, strings.TrimSpace(s))
+			}
+
+			if !cmp.Equal(test.extract, extract) {
+				t.Error(cmp.Diff(test.extract, extract))
+			}
+
+			if res.Body.Bytes != test.bodySize {
+				t.Errorf("wrong body size, want %d, got %d", test.bodySize, res.Body.Bytes)
+			}
+
+			if test.keepBody && string(res.RawBody) != body {
+				t.Errorf("body was not kept")
+			}
+
+			if !test.keepBody && res.RawBody != nil {
+				t.Errorf("body was kept in memory although NoBody is set")
+			}
+
+			if int(streamErrors) != test.streamErrors {
+				t.Errorf("wrong number of errors, want %d, got %d", test.streamErrors, streamErrors)
+			}
+		})
+	}
+}
+
+func TestTrailer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message, X-Empty")
+		_, _ = w.Write([]byte("body"))
+		w.Header().Set("Grpc-Status", "13")
+		w.Header().Set("Grpc-Message", "internal error")
+	}))
+	defer srv.Close()
+
+	tempdir, err := ioutil.TempDir("", "monsoon-test-trailer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := os.RemoveAll(tempdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	rawFile := filepath.Join(tempdir, "request.txt")
+	err = ioutil.WriteFile(rawFile, []byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := http.Header{
+		"Grpc-Status":  []string{"13"},
+		"Grpc-Message": []string{"internal error"},
+	}
+
+	for _, mode := range []string{"default", "wire", "raw", "nobody"} {
+		t.Run(mode, func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+			template.WireHeaderSize = mode == "wire"
+			if mode == "raw" {
+				template.RawFile = rawFile
+			}
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- "test"
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.NoBody = mode == "nobody"
+			runner.Run(context.Background())
+
+			res := <-output
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			if !cmp.Equal(want, res.Trailer) {
+				t.Error(cmp.Diff(want, res.Trailer))
+			}
+		})
+	}
+}
+
+func TestHeadFirst(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nohead" && r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte("body for " + r.Method))
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		path   string
+		getFor func(Response) bool
+
+		wantMethod string
+		wantBody   string
+	}{
+		{"/", nil, http.MethodHead, ""},
+		{"/nohead", nil, http.MethodGet, "body for GET"},
+		{"/", func(Response) bool { return true }, http.MethodGet, "body for GET"},
+		{"/missing", func(res Response) bool { return res.Status() != http.StatusNotFound }, http.MethodHead, ""},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "FUZZ"
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- test.path
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+			runner.HeadFirst = true
+			runner.HeadFirstGet = test.getFor
+			runner.Run(context.Background())
+
+			res := <-output
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			if res.Method != test.wantMethod {
+				t.Errorf("wrong method, want %v, got %v", test.wantMethod, res.Method)
+			}
+
+			if string(res.RawBody) != test.wantBody {
+				t.Errorf("wrong body, want %q, got %q", test.wantBody, res.RawBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package response
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Simhasher computes a simhash of the data written to it, a 64 bit
+// fingerprint for which similar documents have a small Hamming distance. The
+// features are the words (runs of letters and digits, ASCII letters are
+// compared case-insensitive), weighted by their number of occurrences. The
+// data is processed while it is written, so it does not need to be kept in
+// memory.
+type Simhasher struct {
+	weights [64]int
+	hash    uint64 // FNV-1a hash of the current word
+	inWord  bool
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Write processes the data in buf, it never returns an error.
+func (s *Simhasher) Write(buf []byte) (int, error) {
+	for _, c := range buf {
+		if !wordChar(c) {
+			s.endWord()
+			continue
+		}
+
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+
+		if !s.inWord {
+			s.inWord = true
+			s.hash = fnvOffset64
+		}
+
+		s.hash ^= uint64(c)
+		s.hash *= fnvPrime64
+	}
+
+	return len(buf), nil
+}
+
+// wordChar returns true for ASCII letters and digits and all bytes of
+// multi-byte UTF-8 characters.
+func wordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func (s *Simhasher) endWord() {
+	if !s.inWord {
+		return
+	}
+	s.inWord = false
+
+	h := mix64(s.hash)
+	for i := range s.weights {
+		if h&(1<<uint(i)) != 0 {
+			s.weights[i]++
+		} else {
+			s.weights[i]--
+		}
+	}
+}
+
+// mix64 is the finalizer of SplitMix64, so that all bits of the FNV hash
+// depend on all input bytes.
+func mix64(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// Sum returns the simhash of the data written so far. It is zero if the data
+// does not contain any words.
+func (s *Simhasher) Sum() uint64 {
+	weights := s.weights
+	if s.inWord {
+		// include the last word without modifying the state
+		tmp := *s
+		tmp.endWord()
+		weights = tmp.weights
+	}
+
+	var sum uint64
+	for i, w := range weights {
+		if w > 0 {
+			sum |= 1 << uint(i)
+		}
+	}
+	return sum
+}
+
+// Simhash returns the simhash of buf (see Simhasher).
+func Simhash(buf []byte) uint64 {
+	var s Simhasher
+	_, _ = s.Write(buf)
+	return s.Sum()
+}
+
+// HammingDistance returns the number of bits in which a and b differ.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatSimhash returns the fingerprint as a hex string with 16 digits.
+func FormatSimhash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
+
+// FilterSimilar hides responses with a body similar to one of the baseline
+// fingerprints: the Hamming distance between the simhash of the body and the
+// fingerprint is at most Distance. Responses without a body (e.g. failed
+// requests) are never hidden.
+type FilterSimilar struct {
+	Baselines []uint64
+	Distance  int
+}
+
+// Reject decides if r is to be printed.
+func (f FilterSimilar) Reject(r Response) bool {
+	if r.Error != nil || r.Body.Bytes == 0 {
+		return false
+	}
+
+	for _, baseline := range f.Baselines {
+		if HammingDistance(r.Simhash, baseline) <= f.Distance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Name returns a short description of the filter.
+func (f FilterSimilar) Name() string {
+	return "similar body (--hide-similar-to)"
+}
@@ -0,0 +1,90 @@
+package response
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const simhashPage = `<html><head><title>Not Found</title></head>
+<body><h1>Page not found</h1><p>The requested page %s could not be found on
+this server. Please check the address or go back to the start page, the
+navigation contains links to all sections of the website.</p>
+<footer>Copyright example.com, all rights reserved</footer></body></html>`
+
+func TestSimhash(t *testing.T) {
+	page := func(value string) string {
+		return strings.Replace(simhashPage, "%s", value, 1)
+	}
+
+	var tests = []struct {
+		a, b        string
+		maxDistance int
+		minDistance int
+	}{
+		{"", "", 0, 0},
+		{"foo bar", "FOO   bar\n", 0, 0},
+		{"foo, bar!", "<foo>bar", 0, 0},
+		{page("/admin"), page("/admin"), 0, 0},
+		// short pages change more bits for the same difference
+		{page("/admin"), page("/backup.zip"), 6, 1},
+		{page("/admin"), "<html><body>Welcome to the admin interface, please log in</body></html>", 64, 10},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			d := HammingDistance(Simhash([]byte(test.a)), Simhash([]byte(test.b)))
+			if d > test.maxDistance || d < test.minDistance {
+				t.Errorf("wrong distance %d, want %d to %d", d, test.minDistance, test.maxDistance)
+			}
+		})
+	}
+}
+
+func TestSimhasherWrite(t *testing.T) {
+	buf := []byte(simhashPage)
+	want := Simhash(buf)
+	if want == 0 {
+		t.Fatal("simhash is zero")
+	}
+
+	// the result must not depend on how the data is split
+	for _, size := range []int{1, 2, 7, 100} {
+		var h Simhasher
+		for i := 0; i < len(buf); i += size {
+			end := i + size
+			if end > len(buf) {
+				end = len(buf)
+			}
+			_, _ = h.Write(buf[i:end])
+		}
+
+		if h.Sum() != want {
+			t.Errorf("wrong simhash for chunk size %d, want %v, got %v", size, FormatSimhash(want), FormatSimhash(h.Sum()))
+		}
+	}
+}
+
+func TestFilterSimilar(t *testing.T) {
+	var tests = []struct {
+		res    Response
+		reject bool
+	}{
+		{Response{Simhash: 0xff00, Body: TextStats{Bytes: 10}}, true},
+		{Response{Simhash: 0xff07, Body: TextStats{Bytes: 10}}, true},
+		{Response{Simhash: 0xff0f, Body: TextStats{Bytes: 10}}, false},
+		{Response{Simhash: 0x1234, Body: TextStats{Bytes: 10}}, true},
+		{Response{Simhash: 0, Body: TextStats{Bytes: 0}}, false},
+		{Response{Simhash: 0xff00, Error: errors.New("test")}, false},
+	}
+
+	f := FilterSimilar{Baselines: []uint64{0xff00, 0x1234}, Distance: 3}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if f.Reject(test.res) != test.reject {
+				t.Errorf("wrong result for %v, want %v", FormatSimhash(test.res.Simhash), test.reject)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package response
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Synthetic status codes for requests which failed without an HTTP response.
+// They are below 100 so they never collide with real HTTP status codes and
+// can be used in status code filters like any other code.
+const (
+	StatusError       = 0 // any error not covered below
+	StatusTimeout     = 1
+	StatusConnRefused = 2
+	StatusTLSError    = 3
+	StatusDNSError    = 4
+	StatusBlocked     = 5 // the host is not allowed
+	StatusConnTimeout = 6 // timeout while connecting or in the TLS handshake
+)
+
+var statusText = map[int]string{
+	StatusError:       "error",
+	StatusTimeout:     "timeout",
+	StatusConnRefused: "connection refused",
+	StatusTLSError:    "TLS error",
+	StatusDNSError:    "DNS error",
+	StatusBlocked:     "blocked host",
+	StatusConnTimeout: "connect timeout",
+}
+
+// StatusText returns a description for the synthetic status codes, and the
+// empty string for all other codes.
+func StatusText(code int) string {
+	return statusText[code]
+}
+
+// Status returns the HTTP status code of the response. If the request failed,
+// the synthetic status code for the error is returned instead.
+func (r Response) Status() int {
+	if r.Error != nil || r.HTTPResponse == nil {
+		return errorStatus(r.Error)
+	}
+
+	return r.HTTPResponse.StatusCode
+}
+
+// errorStatus categorizes err and returns the synthetic status code.
+func errorStatus(err error) int {
+	if err == nil {
+		return StatusError
+	}
+
+	var blockedErr *HostBlockedError
+	if errors.As(err, &blockedErr) {
+		return StatusBlocked
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		// dial errors for timeouts while resolving the name are DNS errors
+		var dnsErr *net.DNSError
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" && !errors.As(err, &dnsErr) {
+			return StatusConnTimeout
+		}
+
+		if strings.Contains(err.Error(), "TLS handshake timeout") {
+			return StatusConnTimeout
+		}
+
+		return StatusTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return StatusDNSError
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		// the message on Windows is "[...] actively refused it"
+		if errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(opErr.Err.Error(), "refused") {
+			return StatusConnRefused
+		}
+	}
+
+	var (
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return StatusTLSError
+	}
+
+	// alerts sent by the server are not exported by crypto/tls
+	if strings.Contains(err.Error(), "tls: ") {
+		return StatusTLSError
+	}
+
+	return StatusError
+}
@@ -0,0 +1,103 @@
+package response
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func wrapURLError(err error) error {
+	return &url.Error{Op: "Get", URL: "http://example.com", Err: err}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+func (timeoutError) Error() string   { return "i/o timeout" }
+
+func TestStatus(t *testing.T) {
+	var tests = []struct {
+		res  Response
+		want int
+	}{
+		{Response{HTTPResponse: &http.Response{StatusCode: 404}}, 404},
+		{Response{Error: errors.New("foo")}, StatusError},
+		{Response{Error: wrapURLError(&net.DNSError{Err: "no such host", Name: "example.com"})}, StatusDNSError},
+		{Response{Error: wrapURLError(&net.DNSError{Err: "timeout", IsTimeout: true})}, StatusTimeout},
+		{Response{Error: wrapURLError(&net.OpError{
+			Op:  "dial",
+			Net: "tcp",
+			Err: os.NewSyscallError("connect", syscall.ECONNREFUSED),
+		})}, StatusConnRefused},
+		{Response{Error: wrapURLError(&net.OpError{
+			Op:  "read",
+			Net: "tcp",
+			Err: os.NewSyscallError("read", syscall.ECONNRESET),
+		})}, StatusError},
+		{Response{Error: wrapURLError(x509.UnknownAuthorityError{})}, StatusTLSError},
+		{Response{Error: wrapURLError(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"})}, StatusTLSError},
+		{Response{Error: wrapURLError(errors.New("remote error: tls: handshake failure"))}, StatusTLSError},
+		{Response{Error: wrapURLError(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}})}, StatusConnTimeout},
+		{Response{Error: wrapURLError(&net.OpError{
+			Op:  "dial",
+			Net: "tcp",
+			Err: &net.DNSError{Err: "timeout", IsTimeout: true},
+		})}, StatusTimeout},
+		{Response{Error: wrapURLError(&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}})}, StatusTimeout},
+		{Response{Error: wrapURLError(tlsHandshakeTimeoutError{})}, StatusConnTimeout},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			status := test.res.Status()
+			if status != test.want {
+				t.Fatalf("wrong status for %v, want %d, got %d", test.res.Error, test.want, status)
+			}
+		})
+	}
+}
+
+func TestStatusFilter(t *testing.T) {
+	refused := Response{Error: wrapURLError(&net.OpError{
+		Op:  "dial",
+		Net: "tcp",
+		Err: os.NewSyscallError("connect", syscall.ECONNREFUSED),
+	})}
+	ok := Response{HTTPResponse: &http.Response{StatusCode: 200}}
+
+	var tests = []struct {
+		hide, show []string
+		res        Response
+		reject     bool
+	}{
+		{nil, nil, refused, false},
+		{[]string{"2"}, nil, refused, true},
+		{[]string{"0-9"}, nil, refused, true},
+		{[]string{"0-9"}, nil, ok, false},
+		{[]string{"0"}, nil, refused, false},
+		{nil, []string{"200"}, refused, true},
+		{nil, []string{"0-199"}, refused, false},
+		{nil, []string{"2"}, ok, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f, err := NewFilterStatusCode(test.hide, test.show)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reject := f.Reject(test.res)
+			if reject != test.reject {
+				t.Fatalf("wrong result, want %v, got %v", test.reject, reject)
+			}
+		})
+	}
+}
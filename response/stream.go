@@ -0,0 +1,124 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// stdinWriter writes data to the standard input of several commands. If a
+// command exits before reading all data (e.g. "head"), writing to it fails
+// with a broken pipe, so its input is closed and the data is just written to
+// the other commands.
+type stdinWriter struct {
+	pipes []io.WriteCloser
+}
+
+func (w *stdinWriter) Write(p []byte) (int, error) {
+	for i, pipe := range w.pipes {
+		if pipe == nil {
+			continue
+		}
+
+		_, err := pipe.Write(p)
+		if err != nil {
+			_ = pipe.Close()
+			w.pipes[i] = nil
+		}
+	}
+
+	// never fail, so that the body is still read completely
+	return len(p), nil
+}
+
+// Close closes the input of the commands which are still running.
+func (w *stdinWriter) Close() {
+	for i, pipe := range w.pipes {
+		if pipe != nil {
+			_ = pipe.Close()
+			w.pipes[i] = nil
+		}
+	}
+}
+
+// streamCommands starts all commands and copies the data read from rd to their
+// standard input while it is read, so it is never kept in memory. The returned
+// reader must be read until EOF (or an error), then wait must be called, which
+// returns the output of the commands.
+func streamCommands(rd io.Reader, env []string, cmds [][]string) (tee io.Reader, wait func() ([]string, error), err error) {
+	w := &stdinWriter{}
+	var started []*exec.Cmd
+	outputs := make([]*bytes.Buffer, len(cmds))
+
+	// stop the commands already started if one cannot be started
+	cleanup := func() {
+		w.Close()
+		for _, cmd := range started {
+			_ = cmd.Wait()
+		}
+	}
+
+	for i, command := range cmds {
+		if len(command) < 1 {
+			panic("command is invalid")
+		}
+
+		cmd := exec.Command(command[0], command[1:]...)
+		outputs[i] = &bytes.Buffer{}
+		cmd.Stdout = outputs[i]
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), env...)
+
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+
+		err = cmd.Start()
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("command %s failed: %v", command, err)
+		}
+
+		w.pipes = append(w.pipes, stdin)
+		started = append(started, cmd)
+	}
+
+	wait = func() (data []string, err error) {
+		w.Close()
+		for i, cmd := range started {
+			werr := cmd.Wait()
+			if werr != nil && err == nil {
+				err = fmt.Errorf("command %s failed: %v", cmds[i], werr)
+			}
+			data = append(data, outputs[i].String())
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return data, nil
+	}
+
+	return io.TeeReader(rd, w), wait, nil
+}
+
+// streamEnv returns the environment variables for commands which receive the
+// body while it is read: the value, URL, status and headers are known, the
+// statistics about the header and body are not.
+func streamEnv(r *Response, res *http.Response) []string {
+	tmp := Response{HTTPResponse: res}
+	env := []string{
+		"MONSOON_VALUE=" + r.Item,
+		"MONSOON_URL=" + r.URL,
+		"MONSOON_STATUS=" + strconv.Itoa(res.StatusCode),
+	}
+
+	return append(env, tmp.HeaderEnv()...)
+}
@@ -0,0 +1,50 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ValueTimeoutError is returned for requests which took longer than the
+// timeout given for the value (see request.Request.Timeout). It is a timeout
+// error, so the response has the status StatusTimeout.
+type ValueTimeoutError struct {
+	Duration time.Duration
+}
+
+func (e *ValueTimeoutError) Error() string {
+	return fmt.Sprintf("timeout of %v for the value exceeded", e.Duration)
+}
+
+// Timeout returns true, it is part of the net.Error interface.
+func (e *ValueTimeoutError) Timeout() bool { return true }
+
+// Temporary returns false, it is part of the net.Error interface.
+func (e *ValueTimeoutError) Temporary() bool { return false }
+
+// attempt sends the request for item once. If the value has a timeout of its
+// own, the request is aborted when it is reached.
+func (r *Runner) attempt(ctx context.Context, item string) Response {
+	timeout, err := r.Template.Timeout(item)
+	if err != nil {
+		return Response{Item: item, Error: err}
+	}
+
+	if timeout <= 0 {
+		return r.request(ctx, item)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := r.request(reqCtx, item)
+
+	// report the timeout as an error for this value instead of a cancelled
+	// request, unless the whole run is being cancelled
+	if res.Error != nil && reqCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		res.Error = &ValueTimeoutError{Duration: timeout}
+	}
+
+	return res
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+func TestValueTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var tests = []struct {
+		line   string
+		status int
+	}{
+		{"fast", 200},
+		{"fast\t1s", 200},
+		{"slow\t100ms", StatusTimeout},
+		{"fast\tsoon", StatusError},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL + "/FUZZ"
+			template.Fields = []string{"value", "timeout"}
+
+			tr, err := NewTransport(template, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 1)
+			input <- test.line
+			close(input)
+
+			output := make(chan Response, 1)
+			runner := NewRunner(tr, template, input, output)
+
+			start := time.Now()
+			runner.Run(context.Background())
+			res := <-output
+
+			if res.Status() != test.status {
+				t.Errorf("wrong status, want %d, got %d (%v)", test.status, res.Status(), res.Error)
+			}
+
+			if res.Item != test.line {
+				t.Errorf("wrong item, want %q, got %q", test.line, res.Item)
+			}
+
+			if res.Cancelled() {
+				t.Errorf("response is marked as cancelled: %v", res.Error)
+			}
+
+			if time.Since(start) > 3*time.Second {
+				t.Errorf("timeout not applied, request took %v", time.Since(start))
+			}
+		})
+	}
+}
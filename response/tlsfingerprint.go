@@ -0,0 +1,246 @@
+package response
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// TLSFingerprints are the supported values for the TLS fingerprint of the
+// template: the ClientHello of a browser or a random one.
+var TLSFingerprints = []string{"chrome", "firefox", "random"}
+
+// clientHelloID returns the ClientHello for the TLS fingerprint name.
+func clientHelloID(name string) (utls.ClientHelloID, error) {
+	switch name {
+	case "chrome":
+		return utls.HelloChrome_Auto, nil
+	case "firefox":
+		return utls.HelloFirefox_Auto, nil
+	case "random":
+		return utls.HelloRandomized, nil
+	}
+
+	return utls.ClientHelloID{}, fmt.Errorf("unknown TLS fingerprint %q, supported: %v", name, strings.Join(TLSFingerprints, ", "))
+}
+
+// fingerprintConn is a TLS connection established with a spoofed ClientHello.
+type fingerprintConn struct {
+	net.Conn
+
+	// ja3 is the JA3 fingerprint (MD5 hash) of the ClientHello sent
+	ja3 string
+}
+
+// fingerprintDialer returns a function which establishes TLS connections via
+// dial, sending the ClientHello for id. The settings (e.g. the server name,
+// certificate verification and client certificates) are taken from tlsConfig
+// when a connection is established, the handshake is aborted after
+// handshakeTimeout. Only HTTP/1.1 is offered via ALPN, which is not part of the
+// JA3 fingerprint.
+func fingerprintDialer(dial dialContextFunc, tlsConfig *tls.Config, id utls.ClientHelloID, handshakeTimeout time.Duration) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := &utls.Config{
+			ServerName:         tlsConfig.ServerName,
+			InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+			RootCAs:            tlsConfig.RootCAs,
+			NextProtos:         []string{"http/1.1"},
+		}
+		for _, crt := range tlsConfig.Certificates {
+			cfg.Certificates = append(cfg.Certificates, utls.Certificate{
+				Certificate: crt.Certificate,
+				PrivateKey:  crt.PrivateKey,
+			})
+		}
+
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			cfg.ServerName = host
+		}
+
+		tlsConn := utls.UClient(conn, cfg, id)
+		err = tlsConn.BuildHandshakeState()
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		// the presets offer HTTP/2, which the transport cannot use for
+		// connections it did not establish itself
+		for _, ext := range tlsConn.Extensions {
+			if alpn, ok := ext.(*utls.ALPNExtension); ok {
+				alpn.AlpnProtocols = []string{"http/1.1"}
+			}
+		}
+
+		err = tlsConn.BuildHandshakeState()
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		ja3, err := JA3(tlsConn.HandshakeState.Hello.Raw)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		err = handshake(ctx, tlsConn, handshakeTimeout)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		return &fingerprintConn{Conn: tlsConn, ja3: ja3}, nil
+	}
+}
+
+// isGREASE returns true for the reserved values which are sent by browsers to
+// make sure servers ignore unknown values (RFC 8701), they are not part of
+// the JA3 fingerprint.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// helloReader reads the fields of a ClientHello message.
+type helloReader struct {
+	buf []byte
+	err error
+}
+
+var errShortHello = errors.New("ClientHello too short")
+
+func (r *helloReader) bytes(n int) []byte {
+	if r.err != nil || len(r.buf) < n {
+		r.err = errShortHello
+		return nil
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *helloReader) uint8() int {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return int(b[0])
+}
+
+func (r *helloReader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+// list returns the 16 bit values in data as decimal numbers, without GREASE
+// values, joined with dashes.
+func list(data []byte) string {
+	var values []string
+	for i := 0; i+1 < len(data); i += 2 {
+		v := binary.BigEndian.Uint16(data[i:])
+		if !isGREASE(v) {
+			values = append(values, strconv.Itoa(int(v)))
+		}
+	}
+	return strings.Join(values, "-")
+}
+
+// JA3String returns the JA3 description of the ClientHello message hello
+// (including the handshake header): the version, the cipher suites, the
+// extensions, the elliptic curves and the point formats.
+func JA3String(hello []byte) (string, error) {
+	r := &helloReader{buf: hello}
+
+	if typ := r.uint8(); r.err == nil && typ != 1 {
+		return "", fmt.Errorf("message type %d is not a ClientHello", typ)
+	}
+	r.bytes(3) // length
+
+	version := r.uint16()
+	r.bytes(32)        // random
+	r.bytes(r.uint8()) // session ID
+	ciphers := r.bytes(int(r.uint16()))
+	r.bytes(r.uint8()) // compression methods
+
+	var extensions []string
+	var curves, points string
+	if len(r.buf) > 0 {
+		ext := &helloReader{buf: r.bytes(int(r.uint16()))}
+		for ext.err == nil && len(ext.buf) > 0 {
+			typ := ext.uint16()
+			data := &helloReader{buf: ext.bytes(int(ext.uint16()))}
+			if ext.err != nil {
+				break
+			}
+
+			if isGREASE(typ) {
+				continue
+			}
+			extensions = append(extensions, strconv.Itoa(int(typ)))
+
+			switch typ {
+			case 10: // supported groups
+				curves = list(data.bytes(int(data.uint16())))
+			case 11: // EC point formats
+				var formats []string
+				for _, f := range data.bytes(data.uint8()) {
+					formats = append(formats, strconv.Itoa(int(f)))
+				}
+				points = strings.Join(formats, "-")
+			}
+		}
+
+		if ext.err != nil {
+			return "", ext.err
+		}
+	}
+
+	if r.err != nil {
+		return "", r.err
+	}
+
+	fields := []string{
+		strconv.Itoa(int(version)),
+		list(ciphers),
+		strings.Join(extensions, "-"),
+		curves,
+		points,
+	}
+
+	return strings.Join(fields, ","), nil
+}
+
+// JA3 returns the JA3 fingerprint of the ClientHello message hello, the MD5
+// hash of JA3String.
+func JA3(hello []byte) (string, error) {
+	s, err := JA3String(hello)
+	if err != nil {
+		return "", err
+	}
+
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,201 @@
+package response
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+	"golang.org/x/net/http2"
+)
+
+// clientHello returns a ClientHello message with the handshake header for body.
+func clientHello(body string) []byte {
+	n := len(body)
+	return []byte("\x01" + string([]byte{byte(n >> 16), byte(n >> 8), byte(n)}) + body)
+}
+
+func TestJA3String(t *testing.T) {
+	random := string(make([]byte, 32))
+	ciphers := "\x00\x06\x0a\x0a\x13\x01\xc0\x2f"
+	extensions := "\x0a\x0a\x00\x00" + // GREASE
+		"\x00\x0a\x00\x06\x00\x04\x00\x1d\x00\x17" + // supported groups
+		"\x00\x0b\x00\x02\x01\x00" + // point formats
+		"\x00\x00\x00\x00" // server name
+
+	var tests = []struct {
+		hello []byte
+		want  string
+		err   bool
+	}{
+		{
+			hello: clientHello("\x03\x03" + random + "\x00" + ciphers + "\x01\x00" + "\x00\x18" + extensions),
+			want:  "771,4865-49199,10-11-0,29-23,0",
+		},
+		{
+			// no extensions at all
+			hello: clientHello("\x03\x01" + random + "\x00" + ciphers + "\x01\x00"),
+			want:  "769,4865-49199,,,",
+		},
+		{
+			// cut in the middle of the extensions
+			hello: clientHello("\x03\x03" + random + "\x00" + ciphers + "\x01\x00" + "\x00\x18" + extensions[:10]),
+			err:   true,
+		},
+		{
+			hello: []byte("\x01\x00\x00"),
+			err:   true,
+		},
+		{
+			// ServerHello
+			hello: []byte("\x02\x00\x00\x26\x03\x03"),
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			s, err := JA3String(test.hello)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error not found, got %q", s)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s != test.want {
+				t.Errorf("wrong JA3 string, want %q, got %q", test.want, s)
+			}
+		})
+	}
+}
+
+func TestTLSFingerprint(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	ja3 := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	var tests = []struct {
+		fingerprint string
+		err         bool
+	}{
+		{"chrome", false},
+		{"firefox", false},
+		{"random", false},
+		{"netscape", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fingerprint, func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL
+			template.Insecure = true
+			template.DisableKeepAlives = true
+			template.TLSFingerprint = test.fingerprint
+
+			tr, err := NewTransport(template, 1)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error not found")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := make(chan string, 2)
+			input <- "one"
+			input <- "two"
+			close(input)
+
+			output := make(chan Response, 2)
+			NewRunner(tr, template, input, output).Run(context.Background())
+			close(output)
+
+			var fingerprints []string
+			for res := range output {
+				if res.Error != nil {
+					t.Fatalf("request failed: %v", res.Error)
+				}
+
+				if res.HTTPResponse.StatusCode != http.StatusOK {
+					t.Errorf("wrong status code, want %d, got %d", http.StatusOK, res.HTTPResponse.StatusCode)
+				}
+
+				if !ja3.MatchString(res.TLSFingerprint) {
+					t.Errorf("invalid JA3 fingerprint %q", res.TLSFingerprint)
+				}
+
+				fingerprints = append(fingerprints, res.TLSFingerprint)
+			}
+
+			// the browser presets send the same ClientHello for each connection
+			if test.fingerprint != "random" && fingerprints[0] != fingerprints[1] {
+				t.Errorf("fingerprints differ: %v", fingerprints)
+			}
+		})
+	}
+}
+
+func TestTLSFingerprintHTTP2(t *testing.T) {
+	// the server offers HTTP/2 via ALPN
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	err := http2.ConfigureServer(srv.Config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.TLS = &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	var tests = []struct {
+		fingerprint string
+		proto       string
+	}{
+		{"", "HTTP/2.0"},
+		// HTTP/2 is disabled with a TLS fingerprint
+		{"chrome", "HTTP/1.1"},
+		{"firefox", "HTTP/1.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fingerprint, func(t *testing.T) {
+			template := request.New("")
+			template.URL = srv.URL
+			template.Insecure = true
+			template.TLSFingerprint = test.fingerprint
+
+			res := runRequest(t, template)
+			if res.HTTPResponse.Proto != test.proto {
+				t.Errorf("wrong protocol, want %v, got %v", test.proto, res.HTTPResponse.Proto)
+			}
+		})
+	}
+}
+
+func TestTLSFingerprintInvalidCombination(t *testing.T) {
+	template := request.New("")
+	template.URL = "https://localhost"
+	template.TLSFingerprint = "chrome"
+	template.WireHeaderSize = true
+
+	_, err := NewTransport(template, 1)
+	if err == nil {
+		t.Fatal("expected error not found")
+	}
+}
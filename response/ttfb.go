@@ -0,0 +1,108 @@
+package response
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseDurationRangeSpec returns a function that returns true if a duration is
+// within the range in spec, which is either from-to, from- or -to (e.g.
+// 500ms-2s).
+func parseDurationRangeSpec(spec string) (func(time.Duration) bool, error) {
+	pos := strings.IndexByte(spec, '-')
+	if pos < 0 {
+		return nil, fmt.Errorf("invalid time range %q, use from-to, from- or -to", spec)
+	}
+
+	var from, to time.Duration
+	var err error
+
+	if s := spec[:pos]; s != "" {
+		from, err = time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	to = -1
+	if s := spec[pos+1:]; s != "" {
+		to, err = time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if spec == "-" || (to >= 0 && to < from) {
+		return nil, fmt.Errorf("invalid time range %q", spec)
+	}
+
+	f := func(d time.Duration) bool {
+		return d >= from && (to < 0 || d <= to)
+	}
+	return f, nil
+}
+
+// FilterTTFB hides responses based on the time to first byte. Responses are
+// rejected if the time is within one of the rejected ranges or not within any
+// of the accepted ranges. Failed requests have no time to first byte, they are
+// only rejected if accepted ranges are set.
+type FilterTTFB struct {
+	rejects []func(time.Duration) bool
+	accepts []func(time.Duration) bool
+}
+
+// NewFilterTTFB returns a filter based on the time to first byte.
+func NewFilterTTFB(rejects, accepts []string) (FilterTTFB, error) {
+	filter := FilterTTFB{}
+	for _, s := range rejects {
+		f, err := parseDurationRangeSpec(s)
+		if err != nil {
+			return FilterTTFB{}, err
+		}
+
+		filter.rejects = append(filter.rejects, f)
+	}
+
+	for _, s := range accepts {
+		f, err := parseDurationRangeSpec(s)
+		if err != nil {
+			return FilterTTFB{}, err
+		}
+
+		filter.accepts = append(filter.accepts, f)
+	}
+
+	return filter, nil
+}
+
+// Reject decides if r is to be printed.
+func (f FilterTTFB) Reject(r Response) bool {
+	if r.Error != nil || r.HTTPResponse == nil {
+		return len(f.accepts) > 0
+	}
+
+	for _, f := range f.rejects {
+		if f(r.TTFB) {
+			return true
+		}
+	}
+
+	if len(f.accepts) == 0 {
+		return false
+	}
+
+	// show the response if it matches at least one range
+	for _, f := range f.accepts {
+		if f(r.TTFB) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Name returns a short description of the filter.
+func (f FilterTTFB) Name() string {
+	return "time to first byte (--hide-ttfb, --show-ttfb)"
+}
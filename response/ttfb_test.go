@@ -0,0 +1,137 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedTeamPentesting/monsoon/request"
+)
+
+func TestFilterTTFB(t *testing.T) {
+	fast := Response{HTTPResponse: &http.Response{StatusCode: 200}, TTFB: 50 * time.Millisecond}
+	slow := Response{HTTPResponse: &http.Response{StatusCode: 200}, TTFB: 3 * time.Second}
+	failed := Response{Error: errors.New("connection refused")}
+
+	var tests = []struct {
+		rejects, accepts []string
+		res              Response
+		reject           bool
+	}{
+		{nil, nil, fast, false},
+		{[]string{"-100ms"}, nil, fast, true},
+		{[]string{"-100ms"}, nil, slow, false},
+		{[]string{"-100ms"}, nil, failed, false},
+		{[]string{"1s-5s"}, nil, slow, true},
+		{[]string{"1s-2s"}, nil, slow, false},
+		{nil, []string{"2s-"}, slow, false},
+		{nil, []string{"2s-"}, fast, true},
+		{nil, []string{"2s-"}, failed, true},
+		{nil, []string{"10ms-20ms", "40ms-60ms"}, fast, false},
+		{[]string{"50ms-"}, []string{"-1m"}, fast, true},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f, err := NewFilterTTFB(test.rejects, test.accepts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f.Reject(test.res) != test.reject {
+				t.Errorf("wrong result for TTFB %v, want %v", test.res.TTFB, test.reject)
+			}
+		})
+	}
+}
+
+func TestFilterTTFBInvalid(t *testing.T) {
+	var tests = []string{
+		"100ms",
+		"-",
+		"2s-1s",
+		"1x-",
+		"-100",
+	}
+
+	for _, spec := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := NewFilterTTFB([]string{spec}, nil)
+			if err == nil {
+				t.Fatalf("NewFilterTTFB(%q) did not return an error", spec)
+			}
+		})
+	}
+}
+
+func TestRunnerTTFB(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	for _, raw := range []bool{false, true} {
+		template := request.New("")
+		template.URL = srv.URL
+		template.RandomizeHeaders = raw
+
+		tr, err := NewTransport(template, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := make(chan string, 1)
+		input <- "test"
+		close(input)
+
+		output := make(chan Response, 1)
+		NewRunner(tr, template, input, output).Run(context.Background())
+
+		res := <-output
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+
+		if res.TTFB < delay || res.TTFB > res.Duration {
+			t.Errorf("raw %v: wrong TTFB %v, want at least %v and at most the duration %v", raw, res.TTFB, delay, res.Duration)
+		}
+	}
+}
+
+func TestFormatColumns(t *testing.T) {
+	res := Response{
+		Item:         "foo",
+		HTTPResponse: &http.Response{StatusCode: 200},
+		Header:       TextStats{Bytes: 100},
+		Body:         TextStats{Bytes: 20},
+		Reflected:    2,
+		TTFB:         123456 * time.Microsecond,
+	}
+
+	var tests = []struct {
+		columns Columns
+		res     Response
+		want    string
+	}{
+		{Columns{}, res, "    200      100       20   foo     "},
+		{Columns{TTFB: true}, res, "    200      100       20  123.5ms   foo     "},
+		{Columns{Reflected: true, TTFB: true}, res, "    200      100       20         2  123.5ms   foo     "},
+		{Columns{WAF: true}, res, "    200      100       20   foo     "},
+		{Columns{WAF: true}, Response{Item: "bar", HTTPResponse: &http.Response{StatusCode: 403}, WAF: "Cloudflare"}, "    403        0        0   bar      waf: Cloudflare"},
+		{Columns{Reflected: true, TTFB: true}, Response{Item: "bar", Error: errors.New("failed")}, "  error                                failed   bar"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if s := test.res.Format(test.columns); s != test.want {
+				t.Errorf("wrong string, want %q, got %q", test.want, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package response
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// wafSignature describes how the block pages of a web application firewall
+// can be recognized. The body pattern is specific for the block page and
+// matched regardless of the status code. The header patterns are usually
+// present in all responses (e.g. a header set by a CDN), so they are only
+// matched for responses with a status code typically used for blocking.
+type wafSignature struct {
+	name    string
+	headers map[string]*regexp.Regexp
+	body    *regexp.Regexp
+}
+
+var wafSignatures = []wafSignature{
+	{
+		name: "Cloudflare",
+		headers: map[string]*regexp.Regexp{
+			"Server": regexp.MustCompile(`(?i)^cloudflare`),
+			"Cf-Ray": regexp.MustCompile(`.`),
+		},
+		body: regexp.MustCompile(`(?i)Attention Required! \| Cloudflare|cf-error-details|Cloudflare Ray ID`),
+	},
+	{
+		name: "Akamai",
+		headers: map[string]*regexp.Regexp{
+			"Server": regexp.MustCompile(`(?i)^AkamaiGHost`),
+		},
+		body: regexp.MustCompile(`(?is)Access Denied.*errors\.edgesuite\.net|You don't have permission to access .* on this server\.<P>\s*Reference #`),
+	},
+	{
+		name: "AWS WAF",
+		headers: map[string]*regexp.Regexp{
+			"X-Amz-Cf-Id": regexp.MustCompile(`.`),
+			"Server":      regexp.MustCompile(`(?i)^awselb`),
+		},
+		body: regexp.MustCompile(`(?i)Request blocked\. We can't connect to the server for this app or website at this time`),
+	},
+	{
+		name: "Imperva",
+		headers: map[string]*regexp.Regexp{
+			"X-Iinfo":    regexp.MustCompile(`.`),
+			"Set-Cookie": regexp.MustCompile(`^(incap_ses|visid_incap)_`),
+		},
+		body: regexp.MustCompile(`(?i)Incapsula incident ID|_Incapsula_Resource`),
+	},
+	{
+		name: "Sucuri",
+		headers: map[string]*regexp.Regexp{
+			"Server":      regexp.MustCompile(`(?i)^Sucuri`),
+			"X-Sucuri-Id": regexp.MustCompile(`.`),
+		},
+		body: regexp.MustCompile(`(?i)Sucuri WebSite Firewall - Access Denied`),
+	},
+	{
+		name: "F5 BIG-IP ASM",
+		body: regexp.MustCompile(`(?i)The requested URL was rejected\. Please consult with your administrator\.`),
+	},
+	{
+		name: "ModSecurity",
+		headers: map[string]*regexp.Regexp{
+			"Server": regexp.MustCompile(`(?i)mod_security`),
+		},
+		body: regexp.MustCompile(`(?i)This error was generated by Mod_Security|ModSecurity Action`),
+	},
+	{
+		name: "Barracuda",
+		headers: map[string]*regexp.Regexp{
+			"Set-Cookie": regexp.MustCompile(`^barra_counter_session=`),
+		},
+		body: regexp.MustCompile(`(?i)Barracuda Networks|You have been blocked`),
+	},
+	{
+		name: "FortiWeb",
+		headers: map[string]*regexp.Regexp{
+			"Set-Cookie": regexp.MustCompile(`^FORTIWAFSID=`),
+		},
+		body: regexp.MustCompile(`(?i)FortiWeb|\.fgd_icon`),
+	},
+}
+
+// blockingStatus returns true if the status code is typically returned by
+// web application firewalls for blocked requests.
+func blockingStatus(status int) bool {
+	switch status {
+	case http.StatusForbidden, http.StatusNotAcceptable, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	return false
+}
+
+// DetectWAF sets r.WAF to the name of the web application firewall if the
+// response looks like one of its block pages. This is a heuristic based on
+// the status code, the header and the body (which must have been read).
+func (r *Response) DetectWAF() {
+	if r.HTTPResponse == nil {
+		return
+	}
+
+	for _, sig := range wafSignatures {
+		if sig.body != nil && sig.body.Match(r.RawBody) {
+			r.WAF = sig.name
+			return
+		}
+
+		if !blockingStatus(r.HTTPResponse.StatusCode) {
+			continue
+		}
+
+		for name, pattern := range sig.headers {
+			for _, v := range r.HTTPResponse.Header[name] {
+				if pattern.MatchString(v) {
+					r.WAF = sig.name
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package response
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDetectWAF(t *testing.T) {
+	var tests = []struct {
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{200, nil, "<html>hello</html>", ""},
+		{404, nil, "not found", ""},
+		{403, nil, "forbidden", ""},
+		{
+			status: 403,
+			header: http.Header{"Server": []string{"cloudflare"}, "Cf-Ray": []string{"1234-FRA"}},
+			body:   "<title>Attention Required! | Cloudflare</title>",
+			want:   "Cloudflare",
+		},
+		{
+			// all responses via Cloudflare have these headers
+			status: 200,
+			header: http.Header{"Server": []string{"cloudflare"}, "Cf-Ray": []string{"1234-FRA"}},
+			body:   "<html>hello</html>",
+			want:   "",
+		},
+		{
+			status: 503,
+			header: http.Header{"Cf-Ray": []string{"1234-FRA"}},
+			want:   "Cloudflare",
+		},
+		{
+			status: 403,
+			header: http.Header{"Server": []string{"AkamaiGHost"}},
+			body:   "<H1>Access Denied</H1>\nYou don't have permission to access \"http://www.example.com/\" on this server.<P>\nReference #18.1234",
+			want:   "Akamai",
+		},
+		{
+			status: 403,
+			header: http.Header{"Set-Cookie": []string{"foo=bar", "visid_incap_123=abc; path=/"}},
+			want:   "Imperva",
+		},
+		{
+			// F5 ASM returns the block page with status 200
+			status: 200,
+			body:   "<html><head><title>Request Rejected</title></head><body>The requested URL was rejected. Please consult with your administrator.<br><br>Your support ID is: 123</body></html>",
+			want:   "F5 BIG-IP ASM",
+		},
+		{
+			status: 406,
+			body:   "This error was generated by Mod_Security.",
+			want:   "ModSecurity",
+		},
+		{
+			status: 429,
+			header: http.Header{"X-Sucuri-Id": []string{"11005"}},
+			want:   "Sucuri",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			res := Response{
+				HTTPResponse: &http.Response{StatusCode: test.status, Header: test.header},
+				RawBody:      []byte(test.body),
+			}
+
+			res.DetectWAF()
+			if res.WAF != test.want {
+				t.Errorf("wrong WAF detected, want %q, got %q", test.want, res.WAF)
+			}
+		})
+	}
+}
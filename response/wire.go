@@ -0,0 +1,176 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxCaptureSize is the maximum number of bytes recorded for a response
+// header received on the wire.
+const maxCaptureSize = 1024 * 1024
+
+// captureConn records the data read from the underlying connection while
+// capturing is enabled.
+type captureConn struct {
+	net.Conn
+
+	mu      sync.Mutex
+	capture bool
+	buf     []byte
+}
+
+func (c *captureConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+
+	c.mu.Lock()
+	if c.capture && len(c.buf) < maxCaptureSize {
+		c.buf = append(c.buf, p[:n]...)
+	}
+	c.mu.Unlock()
+
+	return n, err
+}
+
+// start discards all previously recorded data and enables capturing.
+func (c *captureConn) start() {
+	c.mu.Lock()
+	c.buf = c.buf[:0]
+	c.capture = true
+	c.mu.Unlock()
+}
+
+// stop disables capturing and returns the recorded data.
+func (c *captureConn) stop() []byte {
+	c.mu.Lock()
+	buf := c.buf
+	c.buf = nil
+	c.capture = false
+	c.mu.Unlock()
+
+	return buf
+}
+
+// wireHeader returns the response header as it was received on the wire
+// from the data captured on a connection, including the status line and all
+// line terminators. Interim responses (1xx) are skipped if the final status
+// isn't 1xx itself. If no complete header is found, nil is returned.
+func wireHeader(buf []byte, statusCode int) []byte {
+	for {
+		if !bytes.HasPrefix(buf, []byte("HTTP/")) {
+			return nil
+		}
+
+		end := headerEnd(buf)
+		if end < 0 {
+			return nil
+		}
+
+		hdr, rest := buf[:end], buf[end:]
+
+		// skip interim responses
+		fields := bytes.SplitN(hdr, []byte(" "), 3)
+		if statusCode >= 200 && len(fields) > 1 && bytes.HasPrefix(fields[1], []byte("1")) {
+			buf = rest
+			continue
+		}
+
+		return hdr
+	}
+}
+
+// headerEnd returns the offset of the first byte after the empty line
+// terminating a header, which is either CRLF or LF.
+func headerEnd(buf []byte) int {
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != '\n' {
+			continue
+		}
+
+		switch {
+		case i+1 < len(buf) && buf[i+1] == '\n':
+			return i + 2
+		case i+2 < len(buf) && buf[i+1] == '\r' && buf[i+2] == '\n':
+			return i + 3
+		}
+	}
+
+	return -1
+}
+
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// tlsHandshakeTimeoutError is returned when the TLS handshake takes too long.
+type tlsHandshakeTimeoutError struct{}
+
+func (tlsHandshakeTimeoutError) Timeout() bool   { return true }
+func (tlsHandshakeTimeoutError) Temporary() bool { return true }
+func (tlsHandshakeTimeoutError) Error() string   { return "TLS handshake timeout" }
+
+// handshake runs the TLS handshake for conn, it is aborted when the context
+// is cancelled or after timeout. The caller must close the connection when an
+// error is returned.
+func handshake(ctx context.Context, conn interface{ Handshake() error }, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- conn.Handshake()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		return tlsHandshakeTimeoutError{}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// captureDialer wraps dial so that all connections record the received data.
+// TLS connections are established by the returned function so that the
+// plaintext is recorded, the handshake is aborted after handshakeTimeout.
+// Since the ALPN extension is not used, connections will only ever use
+// HTTP/1.1.
+func captureDialer(dial dialContextFunc, tlsConfig *tls.Config, handshakeTimeout time.Duration) (plain, secure dialContextFunc) {
+	plain = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &captureConn{Conn: conn}, nil
+	}
+
+	secure = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := tlsConfig.Clone()
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			cfg.ServerName = host
+		}
+
+		tlsConn := tls.Client(conn, cfg)
+		err = handshake(ctx, tlsConn, handshakeTimeout)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		return &captureConn{Conn: tlsConn}, nil
+	}
+
+	return plain, secure
+}
@@ -25,3 +25,14 @@ func Join(args []string) (s string) {
 	}
 	return s
 }
+
+// Quote returns s quoted for a POSIX shell. Strings which only contain
+// characters without a special meaning are returned as they are, all others
+// are enclosed in single quotes, so nothing is expanded by the shell.
+func Quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,+@%") == "" {
+		return s
+	}
+
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
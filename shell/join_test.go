@@ -36,3 +36,29 @@ func TestJoin(t *testing.T) {
 		})
 	}
 }
+
+func TestQuote(t *testing.T) {
+	var tests = []struct {
+		s    string
+		want string
+	}{
+		{"curl", "curl"},
+		{"https://example.com/foo", "https://example.com/foo"},
+		{"https://example.com/?a=b", "'https://example.com/?a=b'"},
+		{"", "''"},
+		{"x-foo: bar", "'x-foo: bar'"},
+		{"a&b", "'a&b'"},
+		{"$HOME", "'$HOME'"},
+		{"it's", `'it'\''s'`},
+		{"a\nb", "'a\nb'"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			res := Quote(test.s)
+			if res != test.want {
+				t.Fatalf("wrong result, want %s, got %s", test.want, res)
+			}
+		})
+	}
+}